@@ -18,17 +18,20 @@ Example:
   ./coscli abort cos://examplebucket/test/`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		include, _ := cmd.Flags().GetString("include")
-		exclude, _ := cmd.Flags().GetString("exclude")
 		failOutput, _ := cmd.Flags().GetBool("fail-output")
 		failOutputPath, _ := cmd.Flags().GetString("fail-output-path")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
 
-		_, filters := util.GetFilter(include, exclude)
+		filters, err := getFilters(cmd)
+		if err != nil {
+			return err
+		}
 
 		fo := &util.FileOperations{
 			Operation: util.Operation{
 				FailOutput:     failOutput,
 				FailOutputPath: failOutputPath,
+				DryRun:         dryRun,
 				Filters:        filters,
 			},
 			Config:    &config,
@@ -36,7 +39,15 @@ Example:
 			ErrOutput: &util.ErrOutput{},
 		}
 
-		err := util.AbortUploads(args, fo)
+		err = initDryRun(fo)
+		if err != nil {
+			return err
+		}
+
+		err = util.AbortUploads(args, fo)
+		if err == nil && fo.Operation.DryRun {
+			fo.Plan.Finish()
+		}
 		return err
 	},
 }
@@ -44,8 +55,8 @@ Example:
 func init() {
 	rootCmd.AddCommand(abortCmd)
 
-	abortCmd.Flags().String("include", "", "List files that meet the specified criteria")
-	abortCmd.Flags().String("exclude", "", "Exclude files that meet the specified criteria")
+	addFilterFlags(abortCmd)
 	abortCmd.Flags().Bool("fail-output", true, "This option determines whether the error output for failed file uploads or downloads is enabled. If enabled, the error messages for any failed file transfers will be recorded in a file within the specified directory (if not specified, the default is coscli_output). If disabled, only the number of error files will be output to the console.")
 	abortCmd.Flags().String("fail-output-path", "coscli_output", "This option specifies the designated error output folder where the error messages for failed file uploads or downloads will be recorded. By providing a custom folder path, you can control the location and name of the error output folder. If this option is not set, the default error log folder (coscli_output) will be used.")
+	abortCmd.Flags().Bool("dry-run", false, "Print the multipart uploads that would be aborted without aborting them. Use --output to print them as json, jsonl or csv")
 }
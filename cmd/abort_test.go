@@ -32,6 +32,21 @@ func TestAbortCmd(t *testing.T) {
 				e := cmd.Execute()
 				So(e, ShouldBeNil)
 			})
+			Convey("dry-run", func() {
+				c, _ := util.NewClient(&config, &param, testAlias)
+				_, _, err := c.Object.InitiateMultipartUpload(context.Background(), "dry-run", nil)
+				So(err, ShouldBeNil)
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"abort", fmt.Sprintf("cos://%s", testAlias), "--dry-run", "--output", "json"}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				So(e, ShouldBeNil)
+				// dry-run 不中止分块上传
+				res, _, err := c.Bucket.ListMultipartUploads(context.Background(), nil)
+				So(err, ShouldBeNil)
+				So(len(res.Uploads), ShouldEqual, 1)
+			})
 			Convey("1 success", func() {
 				clearCmd()
 				cmd := rootCmd
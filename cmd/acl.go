@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var aclCmd = &cobra.Command{
+	Use:   "acl",
+	Short: "Get or put the ACL of objects or buckets",
+	Long: `Get or put the ACL of objects or buckets
+
+Format:
+	./coscli acl --method [method] cos://<bucket-name>[/<object>] [flags]
+
+Example:
+	./coscli acl --method get cos://examplebucket
+	./coscli acl --method get cos://examplebucket/test.txt
+	./coscli acl --method put cos://examplebucket/test.txt --acl public-read
+	./coscli acl --method put cos://examplebucket --acl private --grant-read 100000000002,100000000003`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		method, _ := cmd.Flags().GetString("method")
+		versionId, _ := cmd.Flags().GetString("version-id")
+
+		cosUrl, err := util.FormatUrl(args[0])
+		if err != nil {
+			return fmt.Errorf("cos url format error:%v", err)
+		}
+		if !cosUrl.IsCosUrl() {
+			return fmt.Errorf("cospath needs to contain cos://")
+		}
+		object := cosUrl.(*util.CosUrl).Object
+		if versionId != "" && object == "" {
+			return fmt.Errorf("--version-id only works with objects")
+		}
+		var id []string
+		if versionId != "" {
+			id = append(id, versionId)
+		}
+
+		bucketName := cosUrl.(*util.CosUrl).Bucket
+		c, err := util.NewClient(&config, &param, bucketName)
+		if err != nil {
+			return err
+		}
+
+		switch method {
+		case "get":
+			res, err := util.GetACL(c, cosUrl, id...)
+			if err != nil {
+				return err
+			}
+			if res.Owner != nil {
+				fmt.Printf("Owner: %s\n", res.Owner.ID)
+			}
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"Grantee", "Type", "Permission"})
+			for _, grant := range res.AccessControlList {
+				if grant.Grantee == nil {
+					continue
+				}
+				grantee := grant.Grantee.ID
+				if grantee == "" {
+					grantee = grant.Grantee.URI
+				}
+				table.Append([]string{grantee, grant.Grantee.Type, grant.Permission})
+			}
+			table.SetBorder(false)
+			table.SetAlignment(tablewriter.ALIGN_RIGHT)
+			table.Render()
+		case "put":
+			opt, err := getACLOptions(cmd, object == "")
+			if err != nil {
+				return err
+			}
+			if opt.IsEmpty() {
+				return fmt.Errorf("--acl, --grant-read or --grant-full-control is required to put acl")
+			}
+			if err = util.PutACL(c, cosUrl, opt, id...); err != nil {
+				return err
+			}
+			logger.Infof("Put acl of %s successfully", args[0])
+		default:
+			return fmt.Errorf("--method can only be get or put")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(aclCmd)
+	aclCmd.Flags().String("method", "", "get/put")
+	aclCmd.Flags().String("version-id", "", "Version id of the object")
+	addACLFlags(aclCmd)
+}
+
+// 注册访问权限相关参数
+func addACLFlags(cmd *cobra.Command) {
+	cmd.Flags().String("acl", "", "Canned ACL, private, public-read or default for objects, private, public-read, public-read-write or authenticated-read for buckets")
+	cmd.Flags().String("grant-read", "", "Grant read permission, a comma-separated list of account ids (100000000002 is sent as id=\"qcs::cam::uin/100000000002:uin/100000000002\"), or grantees such as id=\"qcs::cam::uin/100000000001:uin/100000000002\"")
+	cmd.Flags().String("grant-full-control", "", "Grant full control permission, in the same format as --grant-read")
+}
+
+// 获取访问权限设置，bucket 为 true 时用于存储桶
+func getACLOptions(cmd *cobra.Command, bucket bool) (util.ACLOptions, error) {
+	acl, _ := cmd.Flags().GetString("acl")
+	grantRead, _ := cmd.Flags().GetString("grant-read")
+	grantFullControl, _ := cmd.Flags().GetString("grant-full-control")
+	return util.NewACLOptions(acl, grantRead, grantFullControl, bucket)
+}
+
+// 按传输方向校验并获取访问权限设置，仅上传和拷贝时设置目标对象的访问权限
+func getTransferACLOptions(cmd *cobra.Command, srcUrl, destUrl util.StorageUrl) (util.ACLOptions, error) {
+	opt, err := getACLOptions(cmd, false)
+	if err != nil {
+		return opt, err
+	}
+	if !opt.IsEmpty() && !destUrl.IsCosUrl() {
+		return opt, fmt.Errorf("--acl and --grant-* only work with upload or copy")
+	}
+	return opt, nil
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"coscli/util"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// 返回授权列表中的被授权者及权限
+func aclGrants(res *cos.ACLXml) map[string]string {
+	grants := make(map[string]string)
+	for _, grant := range res.AccessControlList {
+		grantee := grant.Grantee.ID
+		if grantee == "" {
+			grantee = grant.Grantee.URI
+		}
+		grants[grantee+" "+grant.Permission] = grant.Grantee.Type
+	}
+	return grants
+}
+
+func TestACL(t *testing.T) {
+	fmt.Println("TestACL")
+	if testServer == nil {
+		t.Skip("grants to other accounts are only checked in the offline cos service")
+	}
+	dir, err := ioutil.TempDir("", "coscli-acl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testBucket = randStr(8)
+	testAlias = testBucket + "-alias"
+	setUp(testBucket, testAlias, testEndpoint, false, false)
+	defer tearDown(testBucket, testAlias, testEndpoint, false)
+	c, _ := util.NewClient(&config, &param, testAlias)
+
+	smallFile := filepath.Join(dir, "small")
+	genFile(smallFile, 1024)
+	bigFile := filepath.Join(dir, "big")
+	genFile(bigFile, 2*1024*1024+100)
+	const allUsers = "http://cam.qcloud.com/groups/global/AllUsers"
+
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	Convey("Test acl", t, func() {
+		Convey("upload and copy", func() {
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"cp", smallFile, fmt.Sprintf("cos://%s/public", testAlias), "--acl", "public-read"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			res, err := util.GetACL(c, &util.CosUrl{Bucket: testAlias, Object: "public"})
+			So(err, ShouldBeNil)
+			So(aclGrants(res), ShouldContainKey, allUsers+" READ")
+
+			// 分块上传
+			clearCmd()
+			args = []string{"sync", bigFile, fmt.Sprintf("cos://%s/granted", testAlias), "--part-size", "1",
+				"--grant-read", "100000000002,id=\"qcs::cam::uin/100000000001:uin/100000000003\"", "--grant-full-control", "100000000004"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			res, err = util.GetACL(c, &util.CosUrl{Bucket: testAlias, Object: "granted"})
+			So(err, ShouldBeNil)
+			grants := aclGrants(res)
+			So(grants, ShouldContainKey, "qcs::cam::uin/100000000002:uin/100000000002 READ")
+			So(grants, ShouldContainKey, "qcs::cam::uin/100000000001:uin/100000000003 READ")
+			So(grants, ShouldContainKey, "qcs::cam::uin/100000000004:uin/100000000004 FULL_CONTROL")
+			So(grants, ShouldNotContainKey, allUsers+" READ")
+
+			// 拷贝不保留来源对象的访问权限
+			clearCmd()
+			args = []string{"cp", fmt.Sprintf("cos://%s/public", testAlias), fmt.Sprintf("cos://%s/public-copy", testAlias)}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			res, err = util.GetACL(c, &util.CosUrl{Bucket: testAlias, Object: "public-copy"})
+			So(err, ShouldBeNil)
+			So(aclGrants(res), ShouldNotContainKey, allUsers+" READ")
+
+			clearCmd()
+			args = []string{"cp", fmt.Sprintf("cos://%s/granted", testAlias), fmt.Sprintf("cos://%s/granted-copy", testAlias), "--acl", "public-read"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			res, err = util.GetACL(c, &util.CosUrl{Bucket: testAlias, Object: "granted-copy"})
+			So(err, ShouldBeNil)
+			So(aclGrants(res), ShouldContainKey, allUsers+" READ")
+			So(aclGrants(res), ShouldNotContainKey, "qcs::cam::uin/100000000002:uin/100000000002 READ")
+		})
+		Convey("acl command", func() {
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"acl", "--method", "put", fmt.Sprintf("cos://%s/public", testAlias), "--acl", "private", "--grant-read", "100000000002"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			res, err := util.GetACL(c, &util.CosUrl{Bucket: testAlias, Object: "public"})
+			So(err, ShouldBeNil)
+			So(aclGrants(res), ShouldNotContainKey, allUsers+" READ")
+			So(aclGrants(res), ShouldContainKey, "qcs::cam::uin/100000000002:uin/100000000002 READ")
+
+			clearCmd()
+			args = []string{"acl", "--method", "put", fmt.Sprintf("cos://%s", testAlias), "--acl", "public-read-write"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			res, err = util.GetACL(c, &util.CosUrl{Bucket: testAlias})
+			So(err, ShouldBeNil)
+			So(aclGrants(res), ShouldContainKey, allUsers+" WRITE")
+
+			for _, cosPath := range []string{fmt.Sprintf("cos://%s", testAlias), fmt.Sprintf("cos://%s/public", testAlias)} {
+				clearCmd()
+				args = []string{"acl", "--method", "get", cosPath}
+				cmd.SetArgs(args)
+				So(cmd.Execute(), ShouldBeNil)
+			}
+		})
+		Convey("mb", func() {
+			bucketIDName := fmt.Sprintf("%s-%s", randStr(8), appID)
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"mb", fmt.Sprintf("cos://%s", bucketIDName), "-e", testEndpoint, "--acl", "public-read"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			bc, err := util.CreateClient(&config, &param, bucketIDName)
+			So(err, ShouldBeNil)
+			defer bc.Bucket.Delete(context.Background())
+			res, _, err := bc.Bucket.GetACL(context.Background())
+			So(err, ShouldBeNil)
+			So(aclGrants(res), ShouldContainKey, allUsers+" READ")
+		})
+		Convey("fail", func() {
+			cosPath := fmt.Sprintf("cos://%s/public", testAlias)
+			// 服务端同样校验被授权者的格式
+			_, err := c.Object.PutACL(context.Background(), "public", &cos.ObjectPutACLOptions{
+				Header: &cos.ACLHeaderOptions{XCosGrantRead: "id=\"100000000002\""},
+			})
+			So(err, ShouldBeError)
+			for _, args := range [][]string{
+				{"cp", smallFile, cosPath, "--acl", "public"},
+				{"cp", smallFile, cosPath, "--acl", "public-read-write"},
+				{"cp", smallFile, cosPath, "--grant-read", "uin=100000000002"},
+				{"cp", smallFile, cosPath, "--grant-read", "admin"},
+				{"cp", smallFile, cosPath, "--grant-full-control", "id=\"100000000002\""},
+				{"cp", cosPath, filepath.Join(dir, "download"), "--acl", "private"},
+				{"acl", "--method", "put", cosPath},
+				{"acl", "--method", "put", fmt.Sprintf("cos://%s", testAlias), "--acl", "default"},
+				{"acl", "--method", "get", fmt.Sprintf("cos://%s", testAlias), "--version-id", "MTg0NDUxNz"},
+				{"acl", "--method", "delete", cosPath},
+				{"acl", "--method", "get", fmt.Sprintf("cos://%s/not-exist", testAlias)},
+				{"mb", fmt.Sprintf("cos://%s-%s", randStr(8), appID), "-e", testEndpoint, "--acl", "default"},
+			} {
+				clearCmd()
+				cmd := rootCmd
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			}
+		})
+	})
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	clilog "coscli/logger"
+	"coscli/util"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// 从文件读取并通过 --method put/get/delete 修改的存储桶配置，如生命周期、跨域访问等
+type bucketConfigCommand struct {
+	name string
+	// 是否支持 YAML 格式，不支持时配置文件及输出只能是 JSON
+	yaml   bool
+	load   func(path string) (util.BucketConfig, error)
+	get    func(c *cos.Client) (util.BucketConfig, error)
+	put    func(c *cos.Client, v util.BucketConfig) error
+	delete func(c *cos.Client) error
+	// 可选，设置后支持 --method list 获取存储桶的全部配置，输出方式与 get 相同
+	list func(c *cos.Client) (util.BucketConfig, error)
+	// 以表格输出配置
+	print func(v util.BucketConfig)
+}
+
+// 注册存储桶配置命令的参数
+func addBucketConfigFlags(cmd *cobra.Command, fileUsage string) {
+	cmd.Flags().String("method", "", "put/get/delete")
+	cmd.Flags().String("file", "", fileUsage)
+}
+
+func (bc bucketConfigCommand) run(cmd *cobra.Command, args []string) error {
+	method, _ := cmd.Flags().GetString("method")
+	file, _ := cmd.Flags().GetString("file")
+
+	cosUrl, err := util.FormatUrl(args[0])
+	if err != nil {
+		return fmt.Errorf("cos url format error:%v", err)
+	}
+	if !cosUrl.IsCosUrl() {
+		return fmt.Errorf("cospath needs to contain cos://")
+	}
+	if cosUrl.(*util.CosUrl).Object != "" {
+		return fmt.Errorf("%s only works with buckets", bc.name)
+	}
+
+	var format string
+	var v util.BucketConfig
+	switch method {
+	case "put":
+		if file == "" {
+			return fmt.Errorf("--file is required to put bucket %s", bc.name)
+		}
+		if v, err = bc.load(file); err != nil {
+			return err
+		}
+	case "get":
+		if format, err = bc.outputFormat(file); err != nil {
+			return err
+		}
+	case "list":
+		if bc.list == nil {
+			return fmt.Errorf("--method can only be put, get or delete")
+		}
+		if format, err = bc.outputFormat(file); err != nil {
+			return err
+		}
+	case "delete":
+		if file != "" {
+			if bc.list != nil {
+				return fmt.Errorf("--file only works with --method put, get or list")
+			}
+			return fmt.Errorf("--file only works with --method put or get")
+		}
+	default:
+		if bc.list != nil {
+			return fmt.Errorf("--method can only be put, get, list or delete")
+		}
+		return fmt.Errorf("--method can only be put, get or delete")
+	}
+
+	c, err := util.NewClient(&config, &param, cosUrl.(*util.CosUrl).Bucket)
+	if err != nil {
+		return err
+	}
+	switch method {
+	case "put":
+		if err = bc.put(c, v); err != nil {
+			return err
+		}
+		logger.Infof("put %s of %s successfully", bc.name, args[0])
+	case "get", "list":
+		if method == "list" {
+			v, err = bc.list(c)
+		} else {
+			v, err = bc.get(c)
+		}
+		if err != nil {
+			return err
+		}
+		if format == util.OutputTable {
+			bc.print(v)
+			return nil
+		}
+		data, err := util.MarshalConfig(v, format)
+		if err != nil {
+			return err
+		}
+		if file == "" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		if err = ioutil.WriteFile(file, data, 0644); err != nil {
+			return err
+		}
+		logger.Infof("%s of %s is saved to %s", bc.name, args[0], file)
+	case "delete":
+		if err = bc.delete(c); err != nil {
+			return err
+		}
+		logger.Infof("delete %s of %s successfully", bc.name, args[0])
+	}
+	return nil
+}
+
+// 获取 get 的输出格式，指定 --file 时按文件扩展名，否则按 --output（table、json 或 yaml）
+func (bc bucketConfigCommand) outputFormat(file string) (string, error) {
+	if file != "" {
+		format, err := util.ConfigFileFormat(file)
+		if err != nil {
+			return "", err
+		}
+		if format == util.ConfigFormatYaml && !bc.yaml {
+			return "", fmt.Errorf("the %s can only be saved as a .json file", bc.name)
+		}
+		return format, nil
+	}
+
+	format := strings.ToLower(outputFormat)
+	switch {
+	case format == "" || format == util.OutputTable:
+		return util.OutputTable, nil
+	case format == util.ConfigFormatJson || format == util.ConfigFormatYaml && bc.yaml:
+		// 配置输出到 stdout 时日志改为输出到 stderr，保证输出可直接作为配置文件
+		clilog.SetConsoleOutput(os.Stderr)
+		return format, nil
+	}
+	if bc.yaml {
+		return "", fmt.Errorf("--output of %s can only be selected between table, json and yaml", bc.name)
+	}
+	return "", fmt.Errorf("--output of %s can only be selected between table and json", bc.name)
+}
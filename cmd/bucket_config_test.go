@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// 通过命令 put 配置文件后，校验 get 的表格、JSON、YAML 输出及 --file 保存的文件可再次读取为相同配置
+func testBucketConfigRoundTrip(dir, command, content, ext string, formats []string,
+	load func(path string) (util.BucketConfig, error), want util.BucketConfig) {
+	cosPath := fmt.Sprintf("cos://%s", testAlias)
+	configFile := filepath.Join(dir, command+ext)
+	ioutil.WriteFile(configFile, []byte(content), 0644)
+
+	clearCmd()
+	cmd := rootCmd
+	cmd.SetArgs([]string{command, "--method", "put", cosPath, "--file", configFile})
+	So(cmd.Execute(), ShouldBeNil)
+
+	clearCmd()
+	cmd.SetArgs([]string{command, "--method", "get", cosPath})
+	So(cmd.Execute(), ShouldBeNil)
+
+	for _, format := range formats {
+		clearCmd()
+		cmd.SetArgs([]string{command, "--method", "get", cosPath, "--output", format})
+		output, err := captureStdout(cmd.Execute)
+		So(err, ShouldBeNil)
+		outputFile := filepath.Join(dir, command+"-output."+format)
+		ioutil.WriteFile(outputFile, []byte(output), 0644)
+		v, err := load(outputFile)
+		So(err, ShouldBeNil)
+		So(v, ShouldResemble, want)
+
+		savedFile := filepath.Join(dir, command+"-saved."+format)
+		clearCmd()
+		cmd.SetArgs([]string{command, "--method", "get", cosPath, "--file", savedFile})
+		So(cmd.Execute(), ShouldBeNil)
+		v, err = load(savedFile)
+		So(err, ShouldBeNil)
+		So(v, ShouldResemble, want)
+	}
+
+	clearCmd()
+	cmd.SetArgs([]string{command, "--method", "delete", cosPath})
+	So(cmd.Execute(), ShouldBeNil)
+	clearCmd()
+	cmd.SetArgs([]string{command, "--method", "get", cosPath})
+	So(cmd.Execute(), ShouldBeError)
+}
+
+// 依次执行应失败的命令，invalid 中的配置文件写入 dir 后作为 put 的 --file
+func testBucketConfigFail(dir, command string, invalid map[string]string, cases [][]string) {
+	cosPath := fmt.Sprintf("cos://%s", testAlias)
+	cases = append(cases,
+		[]string{command, "--method", "put", cosPath},
+		[]string{command, "--method", "put", cosPath + "/key", "--file", filepath.Join(dir, "any.json")},
+		[]string{command, "--method", "list", cosPath},
+		[]string{command, "--method", "get", cosPath, "--output", "csv"},
+		[]string{command, "--method", "get", cosPath, "--file", filepath.Join(dir, "config.txt")},
+		[]string{command, "--method", "delete", cosPath, "--file", filepath.Join(dir, "any.json")},
+	)
+	for name, content := range invalid {
+		path := filepath.Join(dir, name)
+		ioutil.WriteFile(path, []byte(content), 0644)
+		cases = append(cases, []string{command, "--method", "put", cosPath, "--file", path})
+	}
+	for _, args := range cases {
+		clearCmd()
+		cmd := rootCmd
+		cmd.SetArgs(args)
+		e := cmd.Execute()
+		fmt.Printf(" : %v", e)
+		So(e, ShouldBeError)
+	}
+}
+
+func TestBucketConfig(t *testing.T) {
+	fmt.Println("TestBucketConfig")
+	dir, err := ioutil.TempDir("", "coscli-bucket-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testBucket = randStr(8)
+	testAlias = testBucket + "-alias"
+	setUp(testBucket, testAlias, testEndpoint, false, false)
+	defer tearDown(testBucket, testAlias, testEndpoint, false)
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	Convey("Test bucket config", t, func() {
+		Convey("cors", func() {
+			content := `rules:
+  - id: web
+    allowed_origins: ["https://www.example.com", "https://*.example.com"]
+    allowed_methods: [get, PUT]
+    allowed_headers: ["*"]
+    expose_headers: [ETag]
+    max_age_seconds: 600
+  - allowed_origins: ["*"]
+    allowed_methods: [HEAD]
+response_vary: true
+`
+			want := &util.CORSConfig{
+				Rules: []util.CORSRule{
+					{
+						ID:             "web",
+						AllowedOrigins: []string{"https://www.example.com", "https://*.example.com"},
+						AllowedMethods: []string{"GET", "PUT"},
+						AllowedHeaders: []string{"*"},
+						ExposeHeaders:  []string{"ETag"},
+						MaxAgeSeconds:  600,
+					},
+					{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"HEAD"}},
+				},
+				ResponseVary: true,
+			}
+			testBucketConfigRoundTrip(dir, "bucket-cors", content, ".yaml", []string{"yaml", "json"},
+				bucketCORSCommand.load, want)
+			testBucketConfigFail(dir, "bucket-cors", map[string]string{
+				"cors-origin.yaml": "rules:\n  - allowed_methods: [GET]\n",
+				"cors-method.yaml": "rules:\n  - allowed_origins: [\"*\"]\n    allowed_methods: [PATCH]\n",
+				"cors-empty.json":  `{"rules": []}`,
+			}, nil)
+		})
+		Convey("referer", func() {
+			content := `{"type": "White-List", "domains": ["www.example.com", "*.example.com"], "allow_empty_referer": true}`
+			want := &util.RefererConfig{
+				Status:            util.LifecycleStatusEnabled,
+				Type:              util.RefererTypeWhiteList,
+				Domains:           []string{"www.example.com", "*.example.com"},
+				AllowEmptyReferer: true,
+			}
+			testBucketConfigRoundTrip(dir, "bucket-referer", content, ".json", []string{"json", "yaml"},
+				bucketRefererCommand.load, want)
+			testBucketConfigFail(dir, "bucket-referer", map[string]string{
+				"referer-type.yaml":   "type: white\ndomains: [www.example.com]\n",
+				"referer-domain.yaml": "type: Black-List\n",
+				"referer-status.json": `{"status": "On", "type": "Black-List", "domains": ["a.com"]}`,
+			}, nil)
+		})
+		Convey("website", func() {
+			content := `index: index.html
+error_document: 404.html
+redirect_protocol: https
+auto_addressing: true
+routing_rules:
+  - condition_error_code: "404"
+    redirect_replace_key: 404.html
+  - condition_prefix: docs/
+    redirect_protocol: https
+    redirect_replace_key_prefix: documents/
+`
+			want := &util.WebsiteConfig{
+				Index:            "index.html",
+				ErrorDocument:    "404.html",
+				RedirectProtocol: "https",
+				AutoAddressing:   true,
+				RoutingRules: []util.WebsiteRoutingRule{
+					{ConditionErrorCode: "404", RedirectReplaceKey: "404.html"},
+					{ConditionPrefix: "docs/", RedirectProtocol: "https", RedirectReplaceKeyPrefix: "documents/"},
+				},
+			}
+			testBucketConfigRoundTrip(dir, "bucket-website", content, ".yml", []string{"yaml", "json"},
+				bucketWebsiteCommand.load, want)
+			testBucketConfigFail(dir, "bucket-website", map[string]string{
+				"website-index.yaml":     "error_document: 404.html\n",
+				"website-protocol.yaml":  "index: index.html\nredirect_protocol: ftp\n",
+				"website-condition.yaml": "index: index.html\nrouting_rules:\n  - condition_error_code: \"404\"\n    condition_prefix: docs/\n    redirect_replace_key: a\n",
+				"website-code.yaml":      "index: index.html\nrouting_rules:\n  - condition_error_code: \"500\"\n    redirect_replace_key: a\n",
+				"website-redirect.yaml":  "index: index.html\nrouting_rules:\n  - condition_prefix: docs/\n",
+				"website-replace.yaml":   "index: index.html\nrouting_rules:\n  - condition_prefix: docs/\n    redirect_replace_key: a\n    redirect_replace_key_prefix: b\n",
+			}, nil)
+		})
+		Convey("logging", func() {
+			content := "target_bucket: logbucket-1250000000\ntarget_prefix: logs/\n"
+			want := &util.LoggingConfig{TargetBucket: "logbucket-1250000000", TargetPrefix: "logs/"}
+			testBucketConfigRoundTrip(dir, "bucket-logging", content, ".yaml", []string{"yaml", "json"},
+				bucketLoggingCommand.load, want)
+			testBucketConfigFail(dir, "bucket-logging", map[string]string{
+				"logging-bucket.yaml":  "target_bucket: logbucket\n",
+				"logging-unknown.json": `{"target_bucket": "logbucket-1250000000", "target": "logs/"}`,
+			}, nil)
+		})
+		Convey("policy", func() {
+			content := `{
+  "Statement": [
+    {
+      "Sid": "read",
+      "Principal": {"qcs": ["qcs::cam::uin/100000000001:uin/100000000002"]},
+      "Effect": "allow",
+      "Action": ["name/cos:GetObject", "name/cos:HeadObject"],
+      "Resource": ["qcs::cos:ap-guangzhou:uid/1250000000:examplebucket-1250000000/*"],
+      "Condition": {"ip_equal": {"qcs:ip": ["10.0.0.0/8"]}}
+    }
+  ]
+}`
+			want := &util.PolicyConfig{
+				Version: "2.0",
+				Statement: []cos.BucketStatement{{
+					Sid:       "read",
+					Principal: map[string][]string{"qcs": {"qcs::cam::uin/100000000001:uin/100000000002"}},
+					Effect:    "allow",
+					Action:    []string{"name/cos:GetObject", "name/cos:HeadObject"},
+					Resource:  []string{"qcs::cos:ap-guangzhou:uid/1250000000:examplebucket-1250000000/*"},
+					Condition: map[string]map[string]interface{}{"ip_equal": {"qcs:ip": []interface{}{"10.0.0.0/8"}}},
+				}},
+			}
+			testBucketConfigRoundTrip(dir, "bucket-policy", content, ".json", []string{"json"},
+				bucketPolicyCommand.load, want)
+			cosPath := fmt.Sprintf("cos://%s", testAlias)
+			testBucketConfigFail(dir, "bucket-policy", map[string]string{
+				"policy.yaml":           "version: \"2.0\"\n",
+				"policy-effect.json":    `{"statement": [{"effect": "permit", "action": ["*"], "resource": ["*"]}]}`,
+				"policy-action.json":    `{"statement": [{"effect": "deny", "resource": ["*"]}]}`,
+				"policy-empty.json":     `{"version": "2.0"}`,
+				"policy-unknown.json":   `{"statement": [{"effect": "deny", "action": ["*"], "resource": ["*"], "notaction": ["*"]}]}`,
+				"policy-malformed.json": `{"statement": [`,
+			}, [][]string{
+				{"bucket-policy", "--method", "get", cosPath, "--output", "yaml"},
+				{"bucket-policy", "--method", "get", cosPath, "--file", filepath.Join(dir, "policy.yaml")},
+			})
+		})
+	})
+}
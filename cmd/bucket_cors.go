@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"coscli/util"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var bucketCORSCmd = &cobra.Command{
+	Use:   "bucket-cors",
+	Short: "Modify bucket cors",
+	Long: `Modify bucket cors
+
+Format:
+	./coscli bucket-cors --method [method] cos://<bucket-name> [flags]
+
+The rules file is in YAML(.yaml/.yml) or JSON(.json) format, for example:
+	rules:
+	  - id: web
+	    allowed_origins: ["https://www.example.com"]
+	    allowed_methods: [GET, PUT]
+	    allowed_headers: ["*"]
+	    expose_headers: [ETag]
+	    max_age_seconds: 600
+	response_vary: true
+
+Example:
+	./coscli bucket-cors --method put cos://examplebucket --file cors.yaml
+	./coscli bucket-cors --method get cos://examplebucket
+	./coscli bucket-cors --method get cos://examplebucket --output json
+	./coscli bucket-cors --method delete cos://examplebucket`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return bucketCORSCommand.run(cmd, args)
+	},
+}
+
+var bucketCORSCommand = bucketConfigCommand{
+	name: "cors",
+	yaml: true,
+	load: func(path string) (util.BucketConfig, error) {
+		return util.LoadCORSConfig(path)
+	},
+	get: func(c *cos.Client) (util.BucketConfig, error) {
+		return util.GetCORS(c)
+	},
+	put: func(c *cos.Client, v util.BucketConfig) error {
+		return util.PutCORS(c, v.(*util.CORSConfig))
+	},
+	delete: util.DeleteCORS,
+	print: func(v util.BucketConfig) {
+		printCORS(v.(*util.CORSConfig))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bucketCORSCmd)
+	addBucketConfigFlags(bucketCORSCmd, "The YAML(.yaml/.yml) or JSON(.json) file of the cors rules, read by put and written by get")
+}
+
+// 以表格输出跨域访问规则
+func printCORS(cc *util.CORSConfig) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Allowed Origins", "Allowed Methods", "Allowed Headers", "Expose Headers", "Max Age"})
+	for _, rule := range cc.Rules {
+		maxAge := ""
+		if rule.MaxAgeSeconds > 0 {
+			maxAge = strconv.Itoa(rule.MaxAgeSeconds) + "s"
+		}
+		table.Append([]string{
+			rule.ID,
+			strings.Join(rule.AllowedOrigins, ", "),
+			strings.Join(rule.AllowedMethods, ", "),
+			strings.Join(rule.AllowedHeaders, ", "),
+			strings.Join(rule.ExposeHeaders, ", "),
+			maxAge,
+		})
+	}
+	table.SetBorder(false)
+	table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	table.Render()
+}
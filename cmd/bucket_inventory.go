@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var bucketInventoryCmd = &cobra.Command{
+	Use:   "bucket-inventory",
+	Short: "Modify bucket inventory",
+	Long: `Modify bucket inventory
+
+Format:
+	./coscli bucket-inventory --method [method] cos://<bucket-name> [--id <id>] [flags]
+
+The config file is in YAML(.yaml/.yml) or JSON(.json) format, for example:
+	id: daily
+	schedule: Daily
+	included_object_versions: Current
+	prefix: logs/
+	optional_fields: [Size, LastModifiedDate, ETag, StorageClass]
+	destination_bucket: inventorybucket-1250000000
+	destination_region: ap-guangzhou
+	destination_prefix: inventory
+	format: CSV
+The reports can be downloaded by inventory-fetch.
+
+Example:
+	./coscli bucket-inventory --method put cos://examplebucket --file inventory.yaml
+	./coscli bucket-inventory --method get cos://examplebucket --id daily
+	./coscli bucket-inventory --method list cos://examplebucket
+	./coscli bucket-inventory --method list cos://examplebucket --output yaml
+	./coscli bucket-inventory --method delete cos://examplebucket --id daily`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		method, _ := cmd.Flags().GetString("method")
+		id, _ := cmd.Flags().GetString("id")
+		switch method {
+		case "get", "delete":
+			if id == "" {
+				return fmt.Errorf("--id is required to %s bucket inventory", method)
+			}
+		default:
+			if id != "" {
+				return fmt.Errorf("--id only works with --method get or delete")
+			}
+		}
+		return bucketInventoryCommand(id).run(cmd, args)
+	},
+}
+
+// 清单配置以 ID 区分，get 与 delete 操作 --id 指定的清单，put 的 ID 由配置文件指定
+func bucketInventoryCommand(id string) bucketConfigCommand {
+	return bucketConfigCommand{
+		name: "inventory",
+		yaml: true,
+		load: func(path string) (util.BucketConfig, error) {
+			return util.LoadInventoryConfig(path)
+		},
+		get: func(c *cos.Client) (util.BucketConfig, error) {
+			return util.GetInventory(c, id)
+		},
+		put: func(c *cos.Client, v util.BucketConfig) error {
+			return util.PutInventory(c, v.(*util.InventoryConfig))
+		},
+		delete: func(c *cos.Client) error {
+			return util.DeleteInventory(c, id)
+		},
+		list: func(c *cos.Client) (util.BucketConfig, error) {
+			return util.ListInventories(c)
+		},
+		print: func(v util.BucketConfig) {
+			switch v := v.(type) {
+			case *util.InventoryConfig:
+				printInventories([]util.InventoryConfig{*v})
+			case *util.InventoryConfigList:
+				printInventories(v.Inventories)
+			}
+		},
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(bucketInventoryCmd)
+	addBucketConfigFlags(bucketInventoryCmd, "The YAML(.yaml/.yml) or JSON(.json) file of the inventory config, read by put and written by get or list")
+	bucketInventoryCmd.Flags().Lookup("method").Usage = "put/get/list/delete"
+	bucketInventoryCmd.Flags().String("id", "", "The id of the inventory, required by get and delete")
+}
+
+// 以表格输出清单配置，每个清单一行
+func printInventories(inventories []util.InventoryConfig) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Status", "Schedule", "Versions", "Prefix", "Optional Fields", "Destination Bucket", "Destination Region", "Destination Prefix", "Format", "Encryption"})
+	for _, ic := range inventories {
+		table.Append([]string{
+			ic.ID,
+			ic.Status,
+			ic.Schedule,
+			ic.IncludedObjectVersions,
+			ic.Prefix,
+			strings.Join(ic.OptionalFields, ", "),
+			ic.DestinationBucket,
+			ic.DestinationRegion,
+			ic.DestinationPrefix,
+			ic.Format,
+			strconv.FormatBool(ic.Encryption),
+		})
+	}
+	table.SetBorder(false)
+	table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	table.Render()
+}
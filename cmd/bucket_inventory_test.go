@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBucketInventory(t *testing.T) {
+	fmt.Println("TestBucketInventory")
+	dir, err := ioutil.TempDir("", "coscli-bucket-inventory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testBucket = randStr(8)
+	testAlias = testBucket + "-alias"
+	destBucket := randStr(8)
+	destAlias := destBucket + "-alias"
+	setUp(testBucket, testAlias, testEndpoint, false, false)
+	defer tearDown(testBucket, testAlias, testEndpoint, false)
+	setUp(destBucket, destAlias, testEndpoint, false, false)
+	defer tearDown(destBucket, destAlias, testEndpoint, false)
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cosPath := fmt.Sprintf("cos://%s", testAlias)
+	daily := fmt.Sprintf(`id: daily
+schedule: Daily
+prefix: logs/
+optional_fields: [Size, ETag, StorageClass]
+destination_bucket: %s-%s
+destination_region: ap-guangzhou
+destination_prefix: inventory
+encryption: true
+`, destBucket, appID)
+	weekly := fmt.Sprintf(`{"id": "weekly", "status": "Disabled", "schedule": "Weekly", "included_object_versions": "All",
+"destination_bucket": "%s-%s", "destination_region": "ap-guangzhou"}`, destBucket, appID)
+	dailyFile := filepath.Join(dir, "daily.yaml")
+	weeklyFile := filepath.Join(dir, "weekly.json")
+	ioutil.WriteFile(dailyFile, []byte(daily), 0644)
+	ioutil.WriteFile(weeklyFile, []byte(weekly), 0644)
+	wantDaily := util.InventoryConfig{
+		ID:                     "daily",
+		Status:                 util.LifecycleStatusEnabled,
+		Schedule:               util.InventoryScheduleDaily,
+		IncludedObjectVersions: util.InventoryVersionsCurrent,
+		Prefix:                 "logs/",
+		OptionalFields:         []string{"Size", "ETag", "StorageClass"},
+		DestinationBucket:      fmt.Sprintf("%s-%s", destBucket, appID),
+		DestinationRegion:      "ap-guangzhou",
+		DestinationPrefix:      "inventory",
+		Format:                 util.InventoryFormatCSV,
+		Encryption:             true,
+	}
+	wantWeekly := util.InventoryConfig{
+		ID:                     "weekly",
+		Status:                 util.LifecycleStatusDisabled,
+		Schedule:               util.InventoryScheduleWeekly,
+		IncludedObjectVersions: util.InventoryVersionsAll,
+		DestinationBucket:      fmt.Sprintf("%s-%s", destBucket, appID),
+		DestinationRegion:      "ap-guangzhou",
+		Format:                 util.InventoryFormatCSV,
+	}
+	Convey("Test bucket inventory", t, func() {
+		Convey("put, get, list and delete", func() {
+			for _, file := range []string{dailyFile, weeklyFile} {
+				clearCmd()
+				cmd.SetArgs([]string{"bucket-inventory", "--method", "put", cosPath, "--file", file})
+				So(cmd.Execute(), ShouldBeNil)
+			}
+
+			clearCmd()
+			cmd.SetArgs([]string{"bucket-inventory", "--method", "get", cosPath, "--id", "daily"})
+			So(cmd.Execute(), ShouldBeNil)
+			clearCmd()
+			cmd.SetArgs([]string{"bucket-inventory", "--method", "get", cosPath, "--id", "daily", "--output", "yaml"})
+			output, err := captureStdout(cmd.Execute)
+			So(err, ShouldBeNil)
+			outputFile := filepath.Join(dir, "daily-output.yaml")
+			ioutil.WriteFile(outputFile, []byte(output), 0644)
+			ic, err := util.LoadInventoryConfig(outputFile)
+			So(err, ShouldBeNil)
+			So(*ic, ShouldResemble, wantDaily)
+
+			clearCmd()
+			cmd.SetArgs([]string{"bucket-inventory", "--method", "list", cosPath})
+			So(cmd.Execute(), ShouldBeNil)
+			listFile := filepath.Join(dir, "inventories.json")
+			clearCmd()
+			cmd.SetArgs([]string{"bucket-inventory", "--method", "list", cosPath, "--file", listFile})
+			So(cmd.Execute(), ShouldBeNil)
+			il, err := util.LoadInventoryConfigList(listFile)
+			So(err, ShouldBeNil)
+			So(il.Inventories, ShouldResemble, []util.InventoryConfig{wantDaily, wantWeekly})
+
+			clearCmd()
+			cmd.SetArgs([]string{"bucket-inventory", "--method", "delete", cosPath, "--id", "weekly"})
+			So(cmd.Execute(), ShouldBeNil)
+			clearCmd()
+			cmd.SetArgs([]string{"bucket-inventory", "--method", "get", cosPath, "--id", "weekly"})
+			So(cmd.Execute(), ShouldBeError)
+			clearCmd()
+			cmd.SetArgs([]string{"bucket-inventory", "--method", "list", cosPath, "--output", "json"})
+			output, err = captureStdout(cmd.Execute)
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, `"id": "daily"`)
+			So(output, ShouldNotContainSubstring, `"id": "weekly"`)
+		})
+		Convey("fetch", func() {
+			clearCmd()
+			cmd.SetArgs([]string{"bucket-inventory", "--method", "put", cosPath, "--file", dailyFile})
+			So(cmd.Execute(), ShouldBeNil)
+
+			// 按清单报告的目录结构生成两次报告，以及一次尚未写入 manifest.json 的报告
+			prefix := fmt.Sprintf("inventory/%s/%s/daily/", appID, testBucket)
+			objects := map[string]string{
+				"20261017000000Z/manifest.json": fmt.Sprintf(`{"fileFormat": "CSV", "files": [{"key": "%sdata/old.csv.gz", "size": 3}]}`, prefix),
+				"20261018000000Z/manifest.json": fmt.Sprintf(`{"fileFormat": "CSV", "fileSchema": "Bucket, Key, Size", "files": [{"key": "%sdata/a.csv.gz", "size": 3}, {"key": "%sdata/b.csv.gz", "size": 3}]}`, prefix, prefix),
+				"20261019000000Z/data.tmp":      "tmp",
+				"data/old.csv.gz":               "old",
+				"data/a.csv.gz":                 "aaa",
+				"data/b.csv.gz":                 "bbb",
+			}
+			for key, content := range objects {
+				localFile := filepath.Join(dir, "object")
+				ioutil.WriteFile(localFile, []byte(content), 0644)
+				clearCmd()
+				cmd.SetArgs([]string{"cp", localFile, fmt.Sprintf("cos://%s/%s%s", destAlias, prefix, key)})
+				So(cmd.Execute(), ShouldBeNil)
+			}
+
+			localDir := filepath.Join(dir, "fetch")
+			clearCmd()
+			cmd.SetArgs([]string{"inventory-fetch", cosPath, localDir, "--id", "daily"})
+			So(cmd.Execute(), ShouldBeNil)
+			for key, content := range map[string]string{
+				"20261018000000Z/manifest.json": objects["20261018000000Z/manifest.json"],
+				"data/a.csv.gz":                 "aaa",
+				"data/b.csv.gz":                 "bbb",
+			} {
+				data, err := ioutil.ReadFile(filepath.Join(localDir, filepath.FromSlash(key)))
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, content)
+			}
+			_, err = os.Stat(filepath.Join(localDir, "data", "old.csv.gz"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+			_, err = os.Stat(filepath.Join(localDir, "20261017000000Z"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+		Convey("fail", func() {
+			clearCmd()
+			cmd.SetArgs([]string{"bucket-inventory", "--method", "put", cosPath, "--file", weeklyFile})
+			So(cmd.Execute(), ShouldBeNil)
+			cases := [][]string{
+				{"bucket-inventory", "--method", "get", cosPath},
+				{"bucket-inventory", "--method", "delete", cosPath},
+				{"bucket-inventory", "--method", "get", cosPath, "--id", "missing"},
+				{"bucket-inventory", "--method", "put", cosPath, "--file", dailyFile, "--id", "daily"},
+				{"bucket-inventory", "--method", "list", cosPath, "--id", "daily"},
+				{"bucket-inventory", "--method", "list", cosPath, "--output", "csv"},
+				{"bucket-inventory", "--method", "delete", cosPath, "--id", "daily", "--file", dailyFile},
+				{"bucket-inventory", "--method", "put", cosPath + "/key", "--file", dailyFile},
+				{"bucket-logging", "--method", "list", cosPath},
+				{"inventory-fetch", cosPath, dir},
+				{"inventory-fetch", cosPath, dir, "--id", "missing"},
+				{"inventory-fetch", cosPath, dir, "--id", "weekly"},
+				{"inventory-fetch", cosPath + "/key", dir, "--id", "weekly"},
+				{"inventory-fetch", cosPath, fmt.Sprintf("cos://%s", destAlias), "--id", "weekly"},
+			}
+			for name, content := range map[string]string{
+				"inventory-id.yaml":       "id: a/b\nschedule: Daily\ndestination_bucket: dest-1250000000\ndestination_region: ap-guangzhou\n",
+				"inventory-schedule.yaml": "id: a\nschedule: Monthly\ndestination_bucket: dest-1250000000\ndestination_region: ap-guangzhou\n",
+				"inventory-versions.yaml": "id: a\nschedule: Daily\nincluded_object_versions: Latest\ndestination_bucket: dest-1250000000\ndestination_region: ap-guangzhou\n",
+				"inventory-field.yaml":    "id: a\nschedule: Daily\noptional_fields: [Owner]\ndestination_bucket: dest-1250000000\ndestination_region: ap-guangzhou\n",
+				"inventory-bucket.yaml":   "id: a\nschedule: Daily\ndestination_bucket: dest\ndestination_region: ap-guangzhou\n",
+				"inventory-region.yaml":   "id: a\nschedule: Daily\ndestination_bucket: dest-1250000000\n",
+				"inventory-format.yaml":   "id: a\nschedule: Daily\ndestination_bucket: dest-1250000000\ndestination_region: ap-guangzhou\nformat: Parquet\n",
+			} {
+				path := filepath.Join(dir, name)
+				ioutil.WriteFile(path, []byte(content), 0644)
+				cases = append(cases, []string{"bucket-inventory", "--method", "put", cosPath, "--file", path})
+			}
+			for _, args := range cases {
+				clearCmd()
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			}
+		})
+	})
+}
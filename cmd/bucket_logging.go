@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"coscli/util"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var bucketLoggingCmd = &cobra.Command{
+	Use:   "bucket-logging",
+	Short: "Modify bucket access logging",
+	Long: `Modify bucket access logging
+
+Format:
+	./coscli bucket-logging --method [method] cos://<bucket-name> [flags]
+
+The config file is in YAML(.yaml/.yml) or JSON(.json) format, for example:
+	target_bucket: logbucket-1250000000
+	target_prefix: logs/examplebucket/
+The target bucket needs to be in the same region as the bucket.
+
+Example:
+	./coscli bucket-logging --method put cos://examplebucket --file logging.yaml
+	./coscli bucket-logging --method get cos://examplebucket
+	./coscli bucket-logging --method get cos://examplebucket --output json
+	./coscli bucket-logging --method delete cos://examplebucket`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return bucketLoggingCommand.run(cmd, args)
+	},
+}
+
+var bucketLoggingCommand = bucketConfigCommand{
+	name: "logging",
+	yaml: true,
+	load: func(path string) (util.BucketConfig, error) {
+		return util.LoadLoggingConfig(path)
+	},
+	get: func(c *cos.Client) (util.BucketConfig, error) {
+		return util.GetLogging(c)
+	},
+	put: func(c *cos.Client, v util.BucketConfig) error {
+		return util.PutLogging(c, v.(*util.LoggingConfig))
+	},
+	delete: util.DeleteLogging,
+	print: func(v util.BucketConfig) {
+		printLogging(v.(*util.LoggingConfig))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bucketLoggingCmd)
+	addBucketConfigFlags(bucketLoggingCmd, "The YAML(.yaml/.yml) or JSON(.json) file of the logging config, read by put and written by get")
+}
+
+// 以表格输出访问日志配置
+func printLogging(lc *util.LoggingConfig) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Target Bucket", "Target Prefix"})
+	table.Append([]string{lc.TargetBucket, lc.TargetPrefix})
+	table.SetBorder(false)
+	table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	table.Render()
+}
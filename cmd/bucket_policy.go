@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"coscli/util"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var bucketPolicyCmd = &cobra.Command{
+	Use:   "bucket-policy",
+	Short: "Modify bucket policy",
+	Long: `Modify bucket policy
+
+Format:
+	./coscli bucket-policy --method [method] cos://<bucket-name> [flags]
+
+The policy file is the policy JSON of COS, for example:
+	{
+	  "version": "2.0",
+	  "statement": [
+	    {
+	      "principal": {"qcs": ["qcs::cam::uin/100000000001:uin/100000000002"]},
+	      "effect": "allow",
+	      "action": ["name/cos:GetObject"],
+	      "resource": ["qcs::cos:ap-guangzhou:uid/1250000000:examplebucket-1250000000/*"]
+	    }
+	  ]
+	}
+
+Example:
+	./coscli bucket-policy --method put cos://examplebucket --file policy.json
+	./coscli bucket-policy --method get cos://examplebucket
+	./coscli bucket-policy --method get cos://examplebucket --output json
+	./coscli bucket-policy --method delete cos://examplebucket`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return bucketPolicyCommand.run(cmd, args)
+	},
+}
+
+var bucketPolicyCommand = bucketConfigCommand{
+	name: "policy",
+	load: func(path string) (util.BucketConfig, error) {
+		return util.LoadPolicyConfig(path)
+	},
+	get: func(c *cos.Client) (util.BucketConfig, error) {
+		return util.GetPolicy(c)
+	},
+	put: func(c *cos.Client, v util.BucketConfig) error {
+		return util.PutPolicy(c, v.(*util.PolicyConfig))
+	},
+	delete: util.DeletePolicy,
+	print: func(v util.BucketConfig) {
+		printPolicy(v.(*util.PolicyConfig))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bucketPolicyCmd)
+	addBucketConfigFlags(bucketPolicyCmd, "The JSON(.json) file of the bucket policy, read by put and written by get")
+}
+
+// 以表格输出存储桶策略的每条语句
+func printPolicy(pc *util.PolicyConfig) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Sid", "Effect", "Principal", "Action", "Resource", "Condition"})
+	for _, statement := range pc.Statement {
+		var principal []string
+		for name, values := range statement.Principal {
+			principal = append(principal, fmt.Sprintf("%s:%s", name, strings.Join(values, ", ")))
+		}
+		sort.Strings(principal)
+		condition := ""
+		if len(statement.Condition) > 0 {
+			data, _ := json.Marshal(statement.Condition)
+			condition = string(data)
+		}
+		table.Append([]string{
+			statement.Sid,
+			statement.Effect,
+			strings.Join(principal, " "),
+			strings.Join(statement.Action, ", "),
+			strings.Join(statement.Resource, ", "),
+			condition,
+		})
+	}
+	table.SetBorder(false)
+	table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	table.Render()
+}
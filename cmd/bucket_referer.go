@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"coscli/util"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var bucketRefererCmd = &cobra.Command{
+	Use:   "bucket-referer",
+	Short: "Modify bucket referer",
+	Long: `Modify bucket referer
+
+Format:
+	./coscli bucket-referer --method [method] cos://<bucket-name> [flags]
+
+The config file is in YAML(.yaml/.yml) or JSON(.json) format, for example:
+	status: Enabled
+	type: White-List
+	domains:
+	  - www.example.com
+	  - "*.example.com"
+	allow_empty_referer: true
+
+Example:
+	./coscli bucket-referer --method put cos://examplebucket --file referer.yaml
+	./coscli bucket-referer --method get cos://examplebucket
+	./coscli bucket-referer --method get cos://examplebucket --output json
+	./coscli bucket-referer --method delete cos://examplebucket`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return bucketRefererCommand.run(cmd, args)
+	},
+}
+
+var bucketRefererCommand = bucketConfigCommand{
+	name: "referer",
+	yaml: true,
+	load: func(path string) (util.BucketConfig, error) {
+		return util.LoadRefererConfig(path)
+	},
+	get: func(c *cos.Client) (util.BucketConfig, error) {
+		return util.GetReferer(c)
+	},
+	put: func(c *cos.Client, v util.BucketConfig) error {
+		return util.PutReferer(c, v.(*util.RefererConfig))
+	},
+	delete: util.DeleteReferer,
+	print: func(v util.BucketConfig) {
+		printReferer(v.(*util.RefererConfig))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bucketRefererCmd)
+	addBucketConfigFlags(bucketRefererCmd, "The YAML(.yaml/.yml) or JSON(.json) file of the referer config, read by put and written by get")
+}
+
+// 以表格输出防盗链配置
+func printReferer(rc *util.RefererConfig) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Status", "Type", "Domains", "Allow Empty Referer"})
+	table.Append([]string{rc.Status, rc.Type, strings.Join(rc.Domains, ", "), strconv.FormatBool(rc.AllowEmptyReferer)})
+	table.SetBorder(false)
+	table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	table.Render()
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var bucketReplicationCmd = &cobra.Command{
+	Use:   "bucket-replication",
+	Short: "Modify bucket replication or report the replication status of objects",
+	Long: `Modify bucket replication or report the replication status of objects
+
+Format:
+	./coscli bucket-replication --method [method] cos://<bucket-name> [flags]
+	./coscli bucket-replication --method status cos://<bucket-name>[/prefix] [flags]
+
+The rules file is in YAML(.yaml/.yml) or JSON(.json) format, for example:
+	role: qcs::cam::uin/100000000001:uin/100000000001
+	rules:
+	  - id: logs
+	    prefix: logs/
+	    destination_bucket: destbucket-1250000000
+	    destination_region: ap-beijing
+	    storage_class: STANDARD_IA
+Versioning needs to be enabled on both the source and the destination buckets by bucket-versioning.
+
+Example:
+	./coscli bucket-replication --method put cos://examplebucket --file replication.yaml
+	./coscli bucket-replication --method get cos://examplebucket
+	./coscli bucket-replication --method delete cos://examplebucket
+	./coscli bucket-replication --method status cos://examplebucket/logs/ --include ".*\.log"
+	./coscli bucket-replication --method status cos://examplebucket --output json`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		method, _ := cmd.Flags().GetString("method")
+		if method == "status" {
+			return replicationStatus(cmd, args[0])
+		}
+		filters, err := getFilters(cmd)
+		if err != nil {
+			return err
+		}
+		if len(filters) > 0 {
+			return fmt.Errorf("the filters only work with --method status")
+		}
+		return bucketReplicationCommand.run(cmd, args)
+	},
+}
+
+var bucketReplicationCommand = bucketConfigCommand{
+	name: "replication",
+	yaml: true,
+	load: func(path string) (util.BucketConfig, error) {
+		return util.LoadReplicationConfig(path)
+	},
+	get: func(c *cos.Client) (util.BucketConfig, error) {
+		return util.GetReplication(c)
+	},
+	put: func(c *cos.Client, v util.BucketConfig) error {
+		return util.PutReplication(c, v.(*util.ReplicationConfig))
+	},
+	delete: util.DeleteReplication,
+	print: func(v util.BucketConfig) {
+		printReplication(v.(*util.ReplicationConfig))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bucketReplicationCmd)
+	addBucketConfigFlags(bucketReplicationCmd, "The YAML(.yaml/.yml) or JSON(.json) file of the replication rules, read by put and written by get")
+	bucketReplicationCmd.Flags().Lookup("method").Usage = "put/get/delete/status"
+	bucketReplicationCmd.Flags().Int("routines", 3, "Specifies the number of objects whose replication status is got concurrently, only for --method status")
+	bucketReplicationCmd.Flags().Bool("fail-output", true, "This option determines whether the error output for failed objects is enabled. If enabled, the error messages will be recorded in a file within the specified directory (if not specified, the default is coscli_output).")
+	bucketReplicationCmd.Flags().String("fail-output-path", "coscli_output", "This option specifies the error output folder where the error messages for failed objects will be recorded.")
+	addFilterFlags(bucketReplicationCmd)
+	addMetaFilterFlags(bucketReplicationCmd)
+}
+
+// 统计前缀下对象的复制状态，与 du 相同按 --output 输出表格或结构化结果
+func replicationStatus(cmd *cobra.Command, cosPath string) error {
+	file, _ := cmd.Flags().GetString("file")
+	routines, _ := cmd.Flags().GetInt("routines")
+	failOutput, _ := cmd.Flags().GetBool("fail-output")
+	failOutputPath, _ := cmd.Flags().GetString("fail-output-path")
+	if file != "" {
+		return fmt.Errorf("--file only works with --method put or get")
+	}
+	if routines < 1 {
+		return fmt.Errorf("--routines must be greater than 0")
+	}
+	filters, err := getFilters(cmd)
+	if err != nil {
+		return err
+	}
+	if err := initOutputFormat(); err != nil {
+		return err
+	}
+
+	cosUrl, err := util.FormatUrl(cosPath)
+	if err != nil {
+		return fmt.Errorf("cos url format error:%v", err)
+	}
+	if !cosUrl.IsCosUrl() {
+		return fmt.Errorf("cospath needs to contain %s", util.SchemePrefix)
+	}
+	c, err := util.NewClient(&config, &param, cosUrl.(*util.CosUrl).Bucket)
+	if err != nil {
+		return err
+	}
+
+	fo := &util.FileOperations{
+		Operation: util.Operation{
+			Recursive:      true,
+			Filters:        filters,
+			Routines:       routines,
+			FailOutput:     failOutput,
+			FailOutputPath: failOutputPath,
+		},
+		ErrOutput: &util.ErrOutput{},
+	}
+	summary, err := util.ReplicationStatusObjects(c, cosUrl, fo)
+	if summary != nil {
+		printReplicationStatus(summary)
+	}
+	return err
+}
+
+// 以表格输出跨地域复制规则
+func printReplication(rc *util.ReplicationConfig) {
+	fmt.Printf("Role: %s\n", rc.Role)
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Status", "Prefix", "Destination Bucket", "Destination Region", "Storage Class"})
+	for _, rule := range rc.Rules {
+		table.Append([]string{rule.ID, rule.Status, rule.Prefix, rule.DestinationBucket, rule.DestinationRegion, rule.StorageClass})
+	}
+	table.SetBorder(false)
+	table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	table.Render()
+}
+
+// 输出每个对象的复制状态及按状态的统计
+func printReplicationStatus(summary *util.ReplicationStatusSummary) {
+	if !util.IsTableOutput() {
+		util.PrintSummary(summary)
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Key", "Size", "Replication Status"})
+	for _, object := range summary.Objects {
+		table.Append([]string{object.Key, util.FormatSize(object.Size), object.Status})
+	}
+	table.SetBorder(false)
+	table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	table.Render()
+	fmt.Println()
+
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Replication Status", "Objects Count", "Total Size"})
+	for _, stat := range summary.Statuses {
+		table.Append([]string{stat.Status, strconv.Itoa(stat.Objects), util.FormatSize(stat.Size)})
+	}
+	table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	table.SetBorders(tablewriter.Border{
+		Left:   false,
+		Right:  false,
+		Top:    false,
+		Bottom: true,
+	})
+	table.Render()
+	logger.Infof("Total Objects Count: %d\n", summary.TotalObjects)
+	logger.Infof("Total Objects Size:  %s\n", util.FormatSize(summary.TotalSize))
+}
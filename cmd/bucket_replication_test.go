@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"coscli/util"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBucketReplication(t *testing.T) {
+	fmt.Println("TestBucketReplication")
+	dir, err := ioutil.TempDir("", "coscli-bucket-replication")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testBucket = randStr(8)
+	testAlias = testBucket + "-alias"
+	destBucket := randStr(8)
+	destAlias := destBucket + "-alias"
+	setUp(testBucket, testAlias, testEndpoint, false, true)
+	defer tearDown(testBucket, testAlias, testEndpoint, true)
+	setUp(destBucket, destAlias, testEndpoint, false, true)
+	defer tearDown(destBucket, destAlias, testEndpoint, true)
+	plainBucket := randStr(8)
+	plainAlias := plainBucket + "-alias"
+	setUp(plainBucket, plainAlias, testEndpoint, false, false)
+	defer tearDown(plainBucket, plainAlias, testEndpoint, false)
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cosPath := fmt.Sprintf("cos://%s", testAlias)
+	content := fmt.Sprintf(`role: qcs::cam::uin/100000000001:uin/100000000001
+rules:
+  - id: logs
+    prefix: logs/
+    destination_bucket: %s-%s
+    destination_region: ap-guangzhou
+    storage_class: STANDARD_IA
+  - id: missing
+    status: Enabled
+    prefix: data/
+    destination_bucket: missing-%s
+    destination_region: ap-beijing
+`, destBucket, appID, appID)
+	Convey("Test bucket replication", t, func() {
+		Convey("put and get", func() {
+			want := &util.ReplicationConfig{
+				Role: "qcs::cam::uin/100000000001:uin/100000000001",
+				Rules: []util.ReplicationRule{
+					{
+						ID:                "logs",
+						Status:            util.LifecycleStatusEnabled,
+						Prefix:            "logs/",
+						DestinationBucket: fmt.Sprintf("%s-%s", destBucket, appID),
+						DestinationRegion: "ap-guangzhou",
+						StorageClass:      util.StandardIA,
+					},
+					{
+						ID:                "missing",
+						Status:            util.LifecycleStatusEnabled,
+						Prefix:            "data/",
+						DestinationBucket: fmt.Sprintf("missing-%s", appID),
+						DestinationRegion: "ap-beijing",
+					},
+				},
+			}
+			testBucketConfigRoundTrip(dir, "bucket-replication", content, ".yaml", []string{"yaml", "json"},
+				bucketReplicationCommand.load, want)
+		})
+		Convey("status", func() {
+			configFile := filepath.Join(dir, "replication.yaml")
+			ioutil.WriteFile(configFile, []byte(content), 0644)
+			clearCmd()
+			cmd.SetArgs([]string{"bucket-replication", "--method", "put", cosPath, "--file", configFile})
+			So(cmd.Execute(), ShouldBeNil)
+
+			localFile := filepath.Join(dir, "object.log")
+			genFile(localFile, 100)
+			for _, key := range []string{"logs/a.log", "logs/b.txt", "data/c.log", "other/d.log"} {
+				clearCmd()
+				cmd.SetArgs([]string{"cp", localFile, fmt.Sprintf("%s/%s", cosPath, key)})
+				So(cmd.Execute(), ShouldBeNil)
+			}
+
+			clearCmd()
+			cmd.SetArgs([]string{"bucket-replication", "--method", "status", cosPath})
+			So(cmd.Execute(), ShouldBeNil)
+
+			clearCmd()
+			cmd.SetArgs([]string{"bucket-replication", "--method", "status", cosPath, "--include", ".*\\.log", "--output", "json"})
+			output, err := captureStdout(cmd.Execute)
+			So(err, ShouldBeNil)
+			var summary util.ReplicationStatusSummary
+			So(json.Unmarshal([]byte(output), &summary), ShouldBeNil)
+			So(summary.TotalObjects, ShouldEqual, 3)
+			So(summary.TotalSize, ShouldEqual, 300)
+			So(summary.Objects, ShouldResemble, []util.ReplicationStatusEntry{
+				{Key: "data/c.log", Size: 100, Status: util.ReplicationStatusFailed},
+				{Key: "logs/a.log", Size: 100, Status: util.ReplicationStatusCompleted},
+				{Key: "other/d.log", Size: 100, Status: util.ReplicationStatusNone},
+			})
+			counts := make(map[string]int)
+			for _, stat := range summary.Statuses {
+				counts[stat.Status] = stat.Objects
+			}
+			So(counts, ShouldResemble, map[string]int{
+				util.ReplicationStatusPending:   0,
+				util.ReplicationStatusCompleted: 1,
+				util.ReplicationStatusFailed:    1,
+				util.ReplicationStatusReplica:   0,
+				util.ReplicationStatusNone:      1,
+			})
+
+			// 目标存储桶中复制得到的对象状态为 REPLICA
+			clearCmd()
+			cmd.SetArgs([]string{"bucket-replication", "--method", "status", fmt.Sprintf("cos://%s/logs/", destAlias), "--output", "csv"})
+			output, err = captureStdout(cmd.Execute)
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "logs/a.log,REPLICA,1,100")
+			So(output, ShouldContainSubstring, "logs/b.txt,REPLICA,1,100")
+			So(output, ShouldContainSubstring, "TOTAL")
+		})
+		Convey("fail", func() {
+			testBucketConfigFail(dir, "bucket-replication", map[string]string{
+				"replication-role.yaml":    "role: admin\nrules:\n  - destination_bucket: dest-1250000000\n    destination_region: ap-beijing\n",
+				"replication-bucket.yaml":  "role: qcs::cam::uin/1:uin/1\nrules:\n  - destination_bucket: dest\n    destination_region: ap-beijing\n",
+				"replication-region.yaml":  "role: qcs::cam::uin/1:uin/1\nrules:\n  - destination_bucket: dest-1250000000\n",
+				"replication-class.yaml":   "role: qcs::cam::uin/1:uin/1\nrules:\n  - destination_bucket: dest-1250000000\n    destination_region: ap-beijing\n    storage_class: GLACIER\n",
+				"replication-overlap.yaml": "role: qcs::cam::uin/1:uin/1\nrules:\n  - prefix: logs/\n    destination_bucket: dest-1250000000\n    destination_region: ap-beijing\n  - prefix: logs/2024/\n    destination_bucket: dest-1250000000\n    destination_region: ap-beijing\n",
+				"replication-empty.json":   `{"role": "qcs::cam::uin/1:uin/1", "rules": []}`,
+			}, [][]string{
+				{"bucket-replication", "--method", "get", cosPath, "--include", ".*"},
+				{"bucket-replication", "--method", "status", cosPath, "--file", filepath.Join(dir, "replication.yaml")},
+				{"bucket-replication", "--method", "status", cosPath, "--routines", "0"},
+				{"bucket-replication", "--method", "status", cosPath, "--output", "yaml"},
+				{"bucket-replication", "--method", "status", filepath.Join(dir, "replication.yaml")},
+				{"bucket-replication", "--method", "put", fmt.Sprintf("cos://%s", plainAlias), "--file", filepath.Join(dir, "replication.yaml")},
+			})
+		})
+	})
+}
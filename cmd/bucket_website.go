@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var bucketWebsiteCmd = &cobra.Command{
+	Use:   "bucket-website",
+	Short: "Modify bucket static website",
+	Long: `Modify bucket static website
+
+Format:
+	./coscli bucket-website --method [method] cos://<bucket-name> [flags]
+
+The config file is in YAML(.yaml/.yml) or JSON(.json) format, for example:
+	index: index.html
+	error_document: 404.html
+	redirect_protocol: https
+	auto_addressing: true
+	routing_rules:
+	  - condition_error_code: "404"
+	    redirect_replace_key: 404.html
+	  - condition_prefix: docs/
+	    redirect_replace_key_prefix: documents/
+
+Example:
+	./coscli bucket-website --method put cos://examplebucket --file website.yaml
+	./coscli bucket-website --method get cos://examplebucket
+	./coscli bucket-website --method get cos://examplebucket --output json
+	./coscli bucket-website --method delete cos://examplebucket`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return bucketWebsiteCommand.run(cmd, args)
+	},
+}
+
+var bucketWebsiteCommand = bucketConfigCommand{
+	name: "website",
+	yaml: true,
+	load: func(path string) (util.BucketConfig, error) {
+		return util.LoadWebsiteConfig(path)
+	},
+	get: func(c *cos.Client) (util.BucketConfig, error) {
+		return util.GetWebsite(c)
+	},
+	put: func(c *cos.Client, v util.BucketConfig) error {
+		return util.PutWebsite(c, v.(*util.WebsiteConfig))
+	},
+	delete: util.DeleteWebsite,
+	print: func(v util.BucketConfig) {
+		printWebsite(v.(*util.WebsiteConfig))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bucketWebsiteCmd)
+	addBucketConfigFlags(bucketWebsiteCmd, "The YAML(.yaml/.yml) or JSON(.json) file of the static website config, read by put and written by get")
+}
+
+// 以表格输出静态网站配置，有重定向规则时另起一个表格
+func printWebsite(wc *util.WebsiteConfig) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Index", "Error Document", "Redirect Protocol", "Auto Addressing"})
+	table.Append([]string{wc.Index, wc.ErrorDocument, wc.RedirectProtocol, strconv.FormatBool(wc.AutoAddressing)})
+	table.SetBorder(false)
+	table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	table.Render()
+	if len(wc.RoutingRules) == 0 {
+		return
+	}
+
+	fmt.Println()
+	table = tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Condition", "Redirect"})
+	for _, rule := range wc.RoutingRules {
+		condition := "prefix=" + rule.ConditionPrefix
+		if rule.ConditionErrorCode != "" {
+			condition = "error_code=" + rule.ConditionErrorCode
+		}
+		var redirect []string
+		if rule.RedirectProtocol != "" {
+			redirect = append(redirect, "protocol="+rule.RedirectProtocol)
+		}
+		if rule.RedirectReplaceKey != "" {
+			redirect = append(redirect, "key="+rule.RedirectReplaceKey)
+		}
+		if rule.RedirectReplaceKeyPrefix != "" {
+			redirect = append(redirect, "prefix="+rule.RedirectReplaceKeyPrefix)
+		}
+		table.Append([]string{condition, strings.Join(redirect, " ")})
+	}
+	table.SetBorder(false)
+	table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	table.Render()
+}
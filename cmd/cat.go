@@ -1,8 +1,14 @@
 package cmd
 
 import (
+	"context"
+	clilog "coscli/logger"
 	"coscli/util"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -15,8 +21,25 @@ var catCmd = &cobra.Command{
 Format:
   ./coscli cat cos://<bucket-name>-<appid>/<object>
 
+The content of the object is written to stdout as it is, binary objects included.
+
 Example:
-  ./coscli cat cos://examplebucket-1234567890/test.txt`,
+  ./coscli cat cos://examplebucket-1234567890/test.txt
+  Cat a byte range, both ends included:
+    ./coscli cat cos://examplebucket-1234567890/test.log --range 1024-2047
+  Cat the first 100 bytes or the last 20 lines:
+    ./coscli cat cos://examplebucket-1234567890/test.log --head 100
+    ./coscli cat cos://examplebucket-1234567890/test.log --tail 20 --lines
+  Cat a specified version:
+    ./coscli cat cos://examplebucket-1234567890/test.txt --version-id <version-id>
+  Cat an object stored with Content-Encoding gzip or zstd:
+    ./coscli cat cos://examplebucket-1234567890/test.log.gz --decompress
+  Follow an appendable object like tail -f, starting from its last 10 lines, stop with Ctrl-C:
+    ./coscli cat cos://examplebucket-1234567890/live.log --follow --tail 10 --lines
+  Follow with a poll interval from 500ms up to 10s when there is no new data:
+    ./coscli cat cos://examplebucket-1234567890/live.log --follow --poll-interval 500ms --max-poll-interval 10s
+  Cat an object encrypted on the client side:
+    ./coscli cat cos://examplebucket-1234567890/test.txt --encryption-key-file ~/master.key`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
 			return err
@@ -24,6 +47,16 @@ Example:
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		rangeStr, _ := cmd.Flags().GetString("range")
+		head, _ := cmd.Flags().GetInt64("head")
+		tail, _ := cmd.Flags().GetInt64("tail")
+		lines, _ := cmd.Flags().GetBool("lines")
+		versionId, _ := cmd.Flags().GetString("version-id")
+		decompress, _ := cmd.Flags().GetBool("decompress")
+		follow, _ := cmd.Flags().GetBool("follow")
+		pollInterval, _ := cmd.Flags().GetDuration("poll-interval")
+		maxPollInterval, _ := cmd.Flags().GetDuration("max-poll-interval")
+
 		cosUrl, err := util.FormatUrl(args[0])
 		if err != nil {
 			return err
@@ -31,6 +64,8 @@ Example:
 		if !cosUrl.IsCosUrl() {
 			return fmt.Errorf("cospath needs to contain cos://")
 		}
+		// 数据输出到 stdout，日志改为输出到 stderr
+		clilog.SetConsoleOutput(os.Stderr)
 
 		// 实例化cos client
 		bucketName := cosUrl.(*util.CosUrl).Bucket
@@ -39,11 +74,47 @@ Example:
 			return err
 		}
 
-		err = util.CatObject(c, cosUrl)
+		clientEncryption, err := getClientEncryption(cmd)
+		if err != nil {
+			return err
+		}
+
+		catOpt := &util.CatOptions{
+			ClientEncryption: clientEncryption,
+			VersionId:        versionId,
+			Range:            rangeStr,
+			Head:             head,
+			Tail:             tail,
+			Lines:            lines,
+			Decompress:       decompress,
+		}
+		if follow {
+			// Ctrl-C 时停止轮询并正常退出
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			followOpt := &util.FollowOptions{Interval: pollInterval, MaxInterval: maxPollInterval}
+			return util.FollowObject(ctx, c, cosUrl, catOpt, followOpt, os.Stdout)
+		}
+		if err = util.CheckCatOptions(catOpt); err != nil {
+			return err
+		}
+
+		err = util.CatObject(c, cosUrl, catOpt)
 		return err
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(catCmd)
+
+	addClientEncryptionFlags(catCmd, false)
+	catCmd.Flags().String("range", "", "Output the byte range of the object, the format is start-end, start- or -N (the last N bytes)")
+	catCmd.Flags().Int64("head", 0, "Output the first N bytes of the object, or the first N lines with --lines")
+	catCmd.Flags().Int64("tail", 0, "Output the last N bytes of the object, or the last N lines with --lines")
+	catCmd.Flags().Bool("lines", false, "Count --head and --tail in lines instead of bytes")
+	catCmd.Flags().String("version-id", "", "Output the specified version of the object")
+	catCmd.Flags().Bool("decompress", false, "Decompress the object according to its Content-Encoding, gzip and zstd are supported")
+	catCmd.Flags().BoolP("follow", "f", false, "Output the appended data of an appendable object continuously until Ctrl-C")
+	catCmd.Flags().Duration("poll-interval", time.Second, "The interval to check the length of the object with --follow, doubled each time there is no new data")
+	catCmd.Flags().Duration("max-poll-interval", 30*time.Second, "The max interval to check the length of the object with --follow")
 }
@@ -1,11 +1,27 @@
 package cmd
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"coscli/util"
+	"encoding/base64"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	. "github.com/agiledragon/gomonkey/v2"
+	"github.com/klauspost/compress/zstd"
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/tencentyun/cos-go-sdk-v5"
 )
@@ -102,5 +118,269 @@ func TestCatCmd(t *testing.T) {
 			e := cmd.Execute()
 			So(e, ShouldBeNil)
 		})
+		Convey("follow", func() {
+			c, _ := util.NewClient(&config, &param, testAlias)
+			appendData := func(key string, data string) {
+				head, err := c.Object.Head(context.Background(), key, nil)
+				position := 0
+				if err == nil {
+					position = int(head.ContentLength)
+				}
+				_, _, err = c.Object.Append(context.Background(), key, position, strings.NewReader(data), nil)
+				So(err, ShouldBeNil)
+			}
+			appendData("follow/live.log", "line 0\nline 1\nline 2\n")
+			appendData("follow/live.log", "line 3\nline 4\n")
+			cosPath := fmt.Sprintf("cos://%s/follow/live.log", testAlias)
+			pollFlags := []string{"--poll-interval", "10ms", "--max-poll-interval", "40ms"}
+
+			// 追加两次后发送 Ctrl-C，命令正常结束
+			go func() {
+				time.Sleep(300 * time.Millisecond)
+				c.Object.Append(context.Background(), "follow/live.log", 35, strings.NewReader("new data\n"), nil)
+				time.Sleep(200 * time.Millisecond)
+				c.Object.Append(context.Background(), "follow/live.log", 44, strings.NewReader("more\n"), nil)
+				time.Sleep(200 * time.Millisecond)
+				syscall.Kill(os.Getpid(), syscall.SIGINT)
+			}()
+			clearCmd()
+			cmd.SetArgs(append([]string{"cat", cosPath, "--follow", "--tail", "2", "--lines"}, pollFlags...))
+			output, err := captureStdout(cmd.Execute)
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "line 3\nline 4\nnew data\nmore\n")
+
+			// 读取新增数据时的临时错误在退避后从已输出的位置继续读取
+			appendData("follow/retry.log", "a\nb\n")
+			failed := 0
+			var patches *Patches
+			patches = ApplyMethod(c.Object, "Get", func(s *cos.ObjectService, ctx context.Context, name string, opt *cos.ObjectGetOptions, id ...string) (resp *cos.Response, err error) {
+				if name == "follow/retry.log" && opt != nil && opt.Range == "bytes=4-5" && failed == 0 {
+					failed++
+					// 只返回一个字节后连接中断
+					body := io.MultiReader(strings.NewReader("c"), iotest.ErrReader(fmt.Errorf("connection reset by peer")))
+					return &cos.Response{Response: &http.Response{StatusCode: http.StatusPartialContent, Body: ioutil.NopCloser(body)}}, nil
+				}
+				patches.Origin(func() {
+					resp, err = s.Get(ctx, name, opt, id...)
+				})
+				return resp, err
+			})
+			go func() {
+				time.Sleep(200 * time.Millisecond)
+				c.Object.Append(context.Background(), "follow/retry.log", 4, strings.NewReader("c\n"), nil)
+				time.Sleep(300 * time.Millisecond)
+				syscall.Kill(os.Getpid(), syscall.SIGINT)
+			}()
+			clearCmd()
+			cmd.SetArgs(append([]string{"cat", fmt.Sprintf("cos://%s/follow/retry.log", testAlias), "--follow"}, pollFlags...))
+			output, err = captureStdout(cmd.Execute)
+			patches.Reset()
+			So(err, ShouldBeNil)
+			So(failed, ShouldEqual, 1)
+			So(output, ShouldEqual, "a\nb\nc\n")
+
+			// 对象被删除或被普通上传覆盖时报错结束
+			appendData("follow/deleted.log", "a\n")
+			appendData("follow/overwritten.log", "b\n")
+			go func() {
+				time.Sleep(200 * time.Millisecond)
+				c.Object.Delete(context.Background(), "follow/deleted.log")
+				c.Object.Put(context.Background(), "follow/overwritten.log", strings.NewReader("c\n"), nil)
+			}()
+			for _, key := range []string{"deleted.log", "overwritten.log"} {
+				clearCmd()
+				cmd.SetArgs(append([]string{"cat", fmt.Sprintf("cos://%s/follow/%s", testAlias, key), "--follow"}, pollFlags...))
+				_, e := captureStdout(cmd.Execute)
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			}
+
+			for _, args := range [][]string{
+				{"cat", fmt.Sprintf("cos://%s/single-small", testAlias), "--follow"},
+				{"cat", fmt.Sprintf("cos://%s/follow/missing.log", testAlias), "--follow"},
+				{"cat", cosPath, "--follow", "--range", "0-1"},
+				{"cat", cosPath, "--follow", "--head", "1"},
+				{"cat", cosPath, "--follow", "--version-id", "v1"},
+				{"cat", cosPath, "--follow", "--decompress"},
+				{"cat", cosPath, "--follow", "--lines"},
+				{"cat", cosPath, "--follow", "--poll-interval", "0s"},
+				{"cat", cosPath, "--follow", "--poll-interval", "2s", "--max-poll-interval", "1s"},
+			} {
+				clearCmd()
+				cmd.SetArgs(args)
+				_, e := captureStdout(cmd.Execute)
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			}
+		})
+		Convey("range, head and tail", func() {
+			dir, err := ioutil.TempDir("", "coscli-cat")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(dir)
+			keyFile := filepath.Join(dir, "master.key")
+			ioutil.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))), 0600)
+
+			// 多行文本后接二进制数据，长度超过 AES-GCM 的两段
+			var content []byte
+			for i := 0; len(content) < 100*1024; i++ {
+				content = append(content, fmt.Sprintf("line %d\n", i)...)
+			}
+			binary := make([]byte, 50*1024)
+			rand.Read(binary)
+			content = append(append(content, binary...), "\nlast line\nno newline"...)
+			localFile := filepath.Join(dir, "content")
+			ioutil.WriteFile(localFile, content, 0644)
+			size := len(content)
+			// 完整行组成的文本，按行切分后最后一个元素为空
+			text := content[:bytes.LastIndexByte(content[:100*1024], '\n')+1]
+			lines := bytes.SplitAfter(text, []byte("\n"))
+
+			uploads := map[string][]string{
+				"plain":   nil,
+				"cse-ctr": {"--client-encryption", util.CseAesCtr, "--encryption-key-file", keyFile},
+				"cse-gcm": {"--client-encryption", util.CseAesGcm, "--encryption-key-file", keyFile},
+			}
+			for name, flags := range uploads {
+				clearCmd()
+				cmd.SetArgs(append([]string{"cp", localFile, fmt.Sprintf("cos://%s/cat/%s", testAlias, name)}, flags...))
+				So(cmd.Execute(), ShouldBeNil)
+			}
+			for name := range uploads {
+				cosPath := fmt.Sprintf("cos://%s/cat/%s", testAlias, name)
+				for _, c := range []struct {
+					flags []string
+					want  []byte
+				}{
+					{nil, content},
+					{[]string{"--range", "0-0"}, content[:1]},
+					{[]string{"--range", "17-70000"}, content[17:70001]},
+					{[]string{"--range", "65530-131080"}, content[65530:131081]},
+					{[]string{"--range", "100000-"}, content[100000:]},
+					{[]string{"--range", fmt.Sprintf("100-%d", size+100)}, content[100:]},
+					{[]string{"--range", "-5"}, content[size-5:]},
+					{[]string{"--head", "10"}, content[:10]},
+					{[]string{"--tail", "70000"}, content[size-70000:]},
+					{[]string{"--tail", fmt.Sprintf("%d", size+1)}, content},
+					{[]string{"--head", "3", "--lines"}, bytes.Join(lines[:3], nil)},
+					{[]string{"--tail", "2", "--lines"}, []byte("last line\nno newline")},
+				} {
+					clearCmd()
+					cmd.SetArgs(append([]string{"cat", cosPath, "--encryption-key-file", keyFile}, c.flags...))
+					output, err := captureStdout(cmd.Execute)
+					So(err, ShouldBeNil)
+					So(bytes.Equal([]byte(output), c.want), ShouldBeTrue)
+				}
+			}
+
+			// 行数超过末尾的首次读取长度时加倍读取
+			textFile := filepath.Join(dir, "text")
+			ioutil.WriteFile(textFile, text, 0644)
+			clearCmd()
+			cmd.SetArgs([]string{"cp", textFile, fmt.Sprintf("cos://%s/cat/text", testAlias)})
+			So(cmd.Execute(), ShouldBeNil)
+			for _, n := range []int{1, 9000, len(lines) + 10} {
+				want := text
+				if n < len(lines) {
+					want = bytes.Join(lines[len(lines)-1-n:], nil)
+				}
+				clearCmd()
+				cmd.SetArgs([]string{"cat", fmt.Sprintf("cos://%s/cat/text", testAlias), "--tail", fmt.Sprint(n), "--lines"})
+				output, err := captureStdout(cmd.Execute)
+				So(err, ShouldBeNil)
+				So(bytes.Equal([]byte(output), want), ShouldBeTrue)
+			}
+
+			// 空对象的末尾部分为空，不发送后缀范围请求
+			emptyFile := filepath.Join(dir, "empty")
+			ioutil.WriteFile(emptyFile, nil, 0644)
+			clearCmd()
+			cmd.SetArgs([]string{"cp", emptyFile, fmt.Sprintf("cos://%s/cat/empty", testAlias)})
+			So(cmd.Execute(), ShouldBeNil)
+			for _, flags := range [][]string{{"--tail", "5"}, {"--tail", "2", "--lines"}, {"--range", "-3"}} {
+				clearCmd()
+				cmd.SetArgs(append([]string{"cat", fmt.Sprintf("cos://%s/cat/empty", testAlias)}, flags...))
+				output, err := captureStdout(cmd.Execute)
+				So(err, ShouldBeNil)
+				So(output, ShouldBeEmpty)
+			}
+
+			// 按 Content-Encoding 压缩的对象默认原样输出，--decompress 时解压
+			var gz bytes.Buffer
+			gw := gzip.NewWriter(&gz)
+			gw.Write(content)
+			gw.Close()
+			encoder, _ := zstd.NewWriter(nil)
+			compressed := map[string][]byte{"gzip": gz.Bytes(), "zstd": encoder.EncodeAll(content, nil)}
+			for encoding, data := range compressed {
+				compressedFile := filepath.Join(dir, encoding)
+				ioutil.WriteFile(compressedFile, data, 0644)
+				cosPath := fmt.Sprintf("cos://%s/cat/%s", testAlias, encoding)
+				clearCmd()
+				cmd.SetArgs([]string{"cp", compressedFile, cosPath, "--meta", "Content-Encoding:" + encoding})
+				So(cmd.Execute(), ShouldBeNil)
+				for _, c := range []struct {
+					flags []string
+					want  []byte
+				}{
+					{nil, data},
+					{[]string{"--decompress"}, content},
+					{[]string{"--decompress", "--head", "20"}, content[:20]},
+					{[]string{"--decompress", "--head", "2", "--lines"}, bytes.Join(lines[:2], nil)},
+				} {
+					clearCmd()
+					cmd.SetArgs(append([]string{"cat", cosPath}, c.flags...))
+					output, err := captureStdout(cmd.Execute)
+					So(err, ShouldBeNil)
+					So(bytes.Equal([]byte(output), c.want), ShouldBeTrue)
+				}
+			}
+
+			// 输出后关闭解压器
+			closed := 0
+			patches := ApplyMethod(reflect.TypeOf(&gzip.Reader{}), "Close", func(*gzip.Reader) error {
+				closed++
+				return nil
+			})
+			clearCmd()
+			cmd.SetArgs([]string{"cat", fmt.Sprintf("cos://%s/cat/gzip", testAlias), "--decompress", "--head", "20"})
+			_, err = captureStdout(cmd.Execute)
+			patches.Reset()
+			So(err, ShouldBeNil)
+			So(closed, ShouldEqual, 1)
+
+			// 指定版本
+			clearCmd()
+			cmd.SetArgs([]string{"cat", fmt.Sprintf("cos://%s/cat/plain", testAlias), "--version-id", "missing"})
+			_, err = captureStdout(cmd.Execute)
+			So(err, ShouldBeError)
+
+			cosPath := fmt.Sprintf("cos://%s/cat/plain", testAlias)
+			for _, args := range [][]string{
+				{"--range", "10"},
+				{"--range", "10-5"},
+				{"--range", "-0"},
+				{"--range", "a-b"},
+				{"--range", fmt.Sprintf("%d-", size+10)},
+				{"--tail", "-1"},
+				{"--head", "1", "--tail", "1"},
+				{"--range", "0-1", "--head", "1"},
+				{"--lines"},
+				{"--decompress", "--tail", "1"},
+				{"--decompress", "--range", "0-1"},
+			} {
+				clearCmd()
+				cmd.SetArgs(append([]string{"cat", cosPath}, args...))
+				_, e := captureStdout(cmd.Execute)
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			}
+			for _, name := range []string{"cse-ctr", "cse-gcm"} {
+				clearCmd()
+				cmd.SetArgs([]string{"cat", fmt.Sprintf("cos://%s/cat/%s", testAlias, name), "--encryption-key-file", keyFile, "--range", fmt.Sprintf("%d-", size)})
+				_, e := captureStdout(cmd.Execute)
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			}
+		})
 	})
 }
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// 注册客户端加密相关参数，upload 为 true 时注册上传时的加密算法
+func addClientEncryptionFlags(cmd *cobra.Command, upload bool) {
+	if upload {
+		cmd.Flags().String("client-encryption", "", "Encrypt the files on the client side before uploading, aes-ctr or aes-gcm. Each file is encrypted with its own data key, which is encrypted with the master key and stored in the object meta")
+	}
+	cmd.Flags().String("encryption-key-file", "", "The file of the 32 bytes master key(raw or encoded in base64) for client-side encryption. Objects encrypted on the client side are decrypted automatically when downloaded(default is encryptionkeyfile in the config file)")
+}
+
+// 获取客户端加密设置，未指定主密钥文件时使用配置文件中的 encryptionkeyfile
+func getClientEncryption(cmd *cobra.Command) (*util.ClientEncryption, error) {
+	algorithm, _ := cmd.Flags().GetString("client-encryption")
+	keyFile, _ := cmd.Flags().GetString("encryption-key-file")
+	if keyFile == "" {
+		keyFile = config.Base.EncryptionKeyFile
+	}
+	return util.NewClientEncryption(algorithm, keyFile)
+}
+
+// 获取传输的客户端加密设置，仅上传时加密
+func getTransferClientEncryption(cmd *cobra.Command, srcUrl, destUrl util.StorageUrl) (*util.ClientEncryption, error) {
+	clientEncryption, err := getClientEncryption(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if clientEncryption != nil && clientEncryption.Algorithm != "" && !(srcUrl.IsFileUrl() && destUrl.IsCosUrl()) {
+		return nil, fmt.Errorf("--client-encryption only works with upload")
+	}
+	return clientEncryption, nil
+}
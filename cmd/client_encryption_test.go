@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"coscli/util"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestClientEncryption(t *testing.T) {
+	fmt.Println("TestClientEncryption")
+	dir, err := ioutil.TempDir("", "coscli-cse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testBucket = randStr(8)
+	testAlias = testBucket + "-alias"
+	setUp(testBucket, testAlias, testEndpoint, false, false)
+	defer tearDown(testBucket, testAlias, testEndpoint, false)
+	c, _ := util.NewClient(&config, &param, testAlias)
+
+	smallFile := filepath.Join(dir, "small.txt")
+	smallContent := []byte("client-side encryption test\n")
+	if err = ioutil.WriteFile(smallFile, smallContent, 0644); err != nil {
+		t.Fatal(err)
+	}
+	bigFile := filepath.Join(dir, "big")
+	genFile(bigFile, 2*1024*1024+100)
+	bigContent, _ := ioutil.ReadFile(bigFile)
+	keyFile := filepath.Join(dir, "master.key")
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	if err = ioutil.WriteFile(keyFile, []byte(key+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	wrongKeyFile := filepath.Join(dir, "wrong.key")
+	if err = ioutil.WriteFile(wrongKeyFile, []byte("fedcba9876543210fedcba9876543210"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	Convey("Test client-side encryption", t, func() {
+		Convey("aes-ctr", func() {
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"cp", smallFile, fmt.Sprintf("cos://%s/cse-ctr", testAlias),
+				"--client-encryption", util.CseAesCtr, "--encryption-key-file", keyFile}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+
+			// COS 上保存的是密文
+			resp, err := c.Object.Get(context.Background(), "cse-ctr", nil)
+			So(err, ShouldBeNil)
+			stored, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			So(len(stored), ShouldEqual, len(smallContent))
+			So(bytes.Equal(stored, smallContent), ShouldBeFalse)
+			So(resp.Header.Get("x-cos-meta-cse-algorithm"), ShouldEqual, util.CseAesCtr)
+			wrappedKey := resp.Header.Get("x-cos-meta-cse-key")
+			So(wrappedKey, ShouldNotBeEmpty)
+
+			// 内容未变化时跳过
+			clearCmd()
+			args = []string{"sync", smallFile, fmt.Sprintf("cos://%s/cse-ctr", testAlias),
+				"--client-encryption", util.CseAesCtr, "--encryption-key-file", keyFile}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			head, err := util.GetHead(c, "cse-ctr")
+			So(err, ShouldBeNil)
+			So(head.Header.Get("x-cos-meta-cse-key"), ShouldEqual, wrappedKey)
+
+			// cat 自动解密
+			clearCmd()
+			args = []string{"cat", fmt.Sprintf("cos://%s/cse-ctr", testAlias), "--encryption-key-file", keyFile}
+			cmd.SetArgs(args)
+			output, e := captureStdout(cmd.Execute)
+			So(e, ShouldBeNil)
+			So(output, ShouldEqual, string(smallContent))
+
+			clearCmd()
+			args = []string{"cat", fmt.Sprintf("cos://%s/cse-ctr", testAlias)}
+			cmd.SetArgs(args)
+			_, e = captureStdout(cmd.Execute)
+			fmt.Printf(" : %v", e)
+			So(e, ShouldBeError)
+		})
+		Convey("aes-gcm multipart", func() {
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"cp", bigFile, fmt.Sprintf("cos://%s/cse-gcm", testAlias),
+				"--client-encryption", util.CseAesGcm, "--encryption-key-file", keyFile, "--part-size", "1"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+
+			// 分块下载后解密
+			downloadFile := filepath.Join(dir, "download", "cse-gcm")
+			clearCmd()
+			args = []string{"cp", fmt.Sprintf("cos://%s/cse-gcm", testAlias), downloadFile,
+				"--encryption-key-file", keyFile, "--part-size", "1"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			got, err := ioutil.ReadFile(downloadFile)
+			So(err, ShouldBeNil)
+			So(bytes.Equal(got, bigContent), ShouldBeTrue)
+
+			// 替换元数据的拷贝保留加密信息
+			clearCmd()
+			args = []string{"cp", fmt.Sprintf("cos://%s/cse-gcm", testAlias), fmt.Sprintf("cos://%s/cse-gcm-copy", testAlias),
+				"--meta", "x-cos-meta-project:coscli"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			copyFile := filepath.Join(dir, "download", "cse-gcm-copy")
+			clearCmd()
+			args = []string{"cp", fmt.Sprintf("cos://%s/cse-gcm-copy", testAlias), copyFile, "--encryption-key-file", keyFile}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			got, err = ioutil.ReadFile(copyFile)
+			So(err, ShouldBeNil)
+			So(bytes.Equal(got, bigContent), ShouldBeTrue)
+
+			// 未提供或提供错误的主密钥时不保留密文
+			for _, flags := range [][]string{{}, {"--encryption-key-file", wrongKeyFile}} {
+				failFile := filepath.Join(dir, "download", "cse-gcm-fail")
+				clearCmd()
+				args = append([]string{"cp", fmt.Sprintf("cos://%s/cse-gcm", testAlias), failFile}, flags...)
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+				_, err = os.Stat(failFile)
+				So(os.IsNotExist(err), ShouldBeTrue)
+			}
+
+			// 下载失败时保留已有的本地文件
+			existFile := filepath.Join(dir, "download", "cse-gcm-exist")
+			So(ioutil.WriteFile(existFile, []byte("local copy"), 0644), ShouldBeNil)
+			for _, flags := range [][]string{{}, {"--encryption-key-file", wrongKeyFile}} {
+				clearCmd()
+				args = append([]string{"cp", fmt.Sprintf("cos://%s/cse-gcm", testAlias), existFile}, flags...)
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+				got, err = ioutil.ReadFile(existFile)
+				So(err, ShouldBeNil)
+				So(string(got), ShouldEqual, "local copy")
+				files, _ := filepath.Glob(existFile + "*")
+				So(files, ShouldHaveLength, 1)
+			}
+		})
+		Convey("fail", func() {
+			cosPath := fmt.Sprintf("cos://%s/cse-fail", testAlias)
+			for _, args := range [][]string{
+				{"cp", smallFile, cosPath, "--client-encryption", "aes-xts", "--encryption-key-file", keyFile},
+				{"cp", smallFile, cosPath, "--client-encryption", util.CseAesGcm},
+				{"cp", smallFile, cosPath, "--client-encryption", util.CseAesGcm, "--encryption-key-file", smallFile},
+				{"cp", cosPath, filepath.Join(dir, "cse-fail"), "--client-encryption", util.CseAesGcm, "--encryption-key-file", keyFile},
+			} {
+				clearCmd()
+				cmd := rootCmd
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			}
+		})
+	})
+}
+
+// 执行 f 并返回其写入标准输出的内容
+func captureStdout(f func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	output := make(chan []byte)
+	go func() {
+		data, _ := ioutil.ReadAll(r)
+		output <- data
+	}()
+	err = f()
+	os.Stdout = stdout
+	w.Close()
+	return string(<-output), err
+}
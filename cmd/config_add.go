@@ -18,10 +18,11 @@ var configAddCmd = &cobra.Command{
 	Long: `Used to add a new bucket configuration
 
 Format:
-  ./coscli config add -b <bucket-name> -e <endpoint> -a <alias> [-c <config-file-path>]
+  ./coscli config add -b <bucket-name> -e <endpoint> -a <alias> [-c <config-file-path>] [--profile <profile-name>]
 
 Example:
-  ./coscli config add -b example-1234567890 -r ap-shanghai -a example`,
+  ./coscli config add -b example-1234567890 -r ap-shanghai -a example
+  ./coscli config add -b example-1234567890 -r ap-shanghai -a example --profile other`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		err := addBucketConfig(cmd)
 		return err
@@ -70,7 +71,7 @@ func addBucketConfig(cmd *cobra.Command) error {
 	}
 
 	config.Buckets = append(config.Buckets, bucket)
-	viper.Set("cos.buckets", config.Buckets)
+	setViperBuckets(config.Buckets)
 
 	// 判断config文件是否存在。不存在则创建
 	home, err := homedir.Dir()
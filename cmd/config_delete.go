@@ -14,7 +14,7 @@ var configDeleteCmd = &cobra.Command{
 	Long: `Used to delete an existing bucket
 
 Format:
-  ./coscli config delete -a <alias> [-c <config-file-path>]
+  ./coscli config delete -a <alias> [-c <config-file-path>] [--profile <profile-name>]
 
 Example:
   ./coscli config delete -a example`,
@@ -44,7 +44,7 @@ func deleteBucketConfig(cmd *cobra.Command) error {
 	}
 	config.Buckets = append(config.Buckets[:i], config.Buckets[i+1:]...)
 
-	viper.Set("cos.buckets", config.Buckets)
+	setViperBuckets(config.Buckets)
 	if err := viper.WriteConfigAs(viper.ConfigFileUsed()); err != nil {
 		return err
 	}
@@ -59,15 +59,48 @@ func initConfigFile(cfgFlag bool) error {
 	if config.Base.Mode == "CvmRole" {
 		fmt.Println("Input Your Cvm Role Name:")
 		_, _ = fmt.Scanf("%s\n", &config.Base.CvmRoleName)
+	} else if config.Base.Mode == "WebIdentity" {
+		fmt.Println("Input The Role Arn:")
+		_, _ = fmt.Scanf("%s\n", &config.Base.RoleArn)
+		fmt.Println("Input The Path Of The Web Identity Token File:")
+		_, _ = fmt.Scanf("%s\n", &config.Base.WebIdentityTokenFile)
+		fmt.Println("Input The Identity Provider: (input nothing will use " + util.DefaultProviderId + ")")
+		_, _ = fmt.Scanf("%s\n", &config.Base.ProviderId)
 	} else {
+		// AssumeRole 方式使用配置的密钥申请角色的临时密钥
+		if config.Base.Mode == "AssumeRole" {
+			fmt.Println("Input The Role Arn:")
+			_, _ = fmt.Scanf("%s\n", &config.Base.RoleArn)
+			fmt.Println("Input The Role Session Name: (input nothing will use " + util.DefaultRoleSessionName + ")")
+			_, _ = fmt.Scanf("%s\n", &config.Base.RoleSessionName)
+			fmt.Println("Input The External Id: (input nothing if the role does not require it)")
+			_, _ = fmt.Scanf("%s\n", &config.Base.ExternalId)
+		}
+		fmt.Println("Input Your Credential Backend: (config, file, process or env, input nothing will use config)")
+		_, _ = fmt.Scanf("%s\n", &config.Base.CredentialBackend)
+		if err := util.CheckCredentialBackend(config.Base.CredentialBackend); err != nil {
+			return err
+		}
+	}
+
+	switch config.Base.CredentialBackend {
+	case util.CredentialBackendProcess:
+		fmt.Println("Use \"./coscli config set --credential_process <command>\" to set the command which provides the secrets")
+	case util.CredentialBackendEnv:
+		fmt.Println("The secrets will be read from the environment variables COS_SECRET_ID, COS_SECRET_KEY and COS_SESSION_TOKEN")
+	}
+	if config.Base.Mode != "CvmRole" && config.Base.Mode != "WebIdentity" && config.Base.CredentialBackend != util.CredentialBackendProcess &&
+		config.Base.CredentialBackend != util.CredentialBackendEnv {
 		fmt.Println("Input Your Secret ID:")
 		_, _ = fmt.Scanf("%s\n", &config.Base.SecretID)
 		fmt.Println("Input Your Secret Key:")
 		_, _ = fmt.Scanf("%s\n", &config.Base.SecretKey)
 		fmt.Println("Input Your Session Token:")
 		_, _ = fmt.Scanf("%s\n", &config.Base.SessionToken)
-		fmt.Println("Input Disable Encryption:")
-		_, _ = fmt.Scanf("%s\n", &config.Base.DisableEncryption)
+		if config.Base.CredentialBackend != util.CredentialBackendFile {
+			fmt.Println("Input Disable Encryption:")
+			_, _ = fmt.Scanf("%s\n", &config.Base.DisableEncryption)
+		}
 	}
 
 	fmt.Println("Input Auto Switch Host:")
@@ -95,12 +128,12 @@ func initConfigFile(cfgFlag bool) error {
 		fmt.Printf("- Name: %s\tEndpoint: %s\tAlias: %s\n", b.Name, b.Endpoint, b.Alias)
 	}
 	fmt.Printf("\nIf you want to configure more buckets, you can use the \"config add\" command later.\n")
-	// 默认加密存储
-	if config.Base.DisableEncryption != "true" {
-		config.Base.SecretKey, _ = util.EncryptSecret(config.Base.SecretKey)
-		config.Base.SecretID, _ = util.EncryptSecret(config.Base.SecretID)
-		config.Base.SessionToken, _ = util.EncryptSecret(config.Base.SessionToken)
+	// 按存储方式保存密钥，默认加密存储在配置文件中
+	base, err := util.SaveCredential(config.Base, true)
+	if err != nil {
+		return err
 	}
+	config.Base = base
 
 	viper.Set("cos", config)
 
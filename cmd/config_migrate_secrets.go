@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var configMigrateSecretsCmd = &cobra.Command{
+	Use:   "migrate-secrets",
+	Short: "Move the secrets saved in the configuration file into the encrypted credential file",
+	Long: `Move the secrets saved in the configuration file into the encrypted credential file
+
+The secrets encrypted with the built-in key(or saved in plain text) in the
+configuration file are written into an age encrypted credential file, then
+removed from the configuration file. The credential file is encrypted with a
+passphrase(COSCLI_CREDENTIAL_PASSPHRASE, or input in the terminal), or to the
+X25519 key in COSCLI_CREDENTIAL_KEY(AGE-SECRET-KEY-1..., generated by age-keygen),
+and can also be decrypted with the age command line tool.
+
+Format:
+  ./coscli config migrate-secrets [-c <config-file-path>] [flags]
+
+Example:
+  ./coscli config migrate-secrets --credential_file ~/.cos.credentials`,
+	Args: cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backend, _ := cmd.Flags().GetString("backend")
+		credentialFile, _ := cmd.Flags().GetString("credential_file")
+		return migrateSecrets(backend, credentialFile)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configMigrateSecretsCmd)
+
+	configMigrateSecretsCmd.Flags().String("backend", util.CredentialBackendFile, "The credential backend to move the secrets into, only file can store secrets")
+	configMigrateSecretsCmd.Flags().String("credential_file", "", "The path of the encrypted credential file(default ~/.cos.credentials)")
+}
+
+func migrateSecrets(backend, credentialFile string) error {
+	if err := util.CheckCredentialBackend(backend); err != nil {
+		return err
+	}
+	if backend != util.CredentialBackendFile {
+		return fmt.Errorf("the %s credential backend can not store secrets, only file is supported", backend)
+	}
+	if config.Base.CredentialBackend != "" && config.Base.CredentialBackend != util.CredentialBackendConfig {
+		return fmt.Errorf("the secrets are already stored by the %s credential backend", config.Base.CredentialBackend)
+	}
+	if config.Base.SecretID == "" && config.Base.SecretKey == "" && config.Base.SessionToken == "" {
+		return fmt.Errorf("no secrets in the configuration file to migrate")
+	}
+
+	// 配置文件中的密钥已在读取配置时解密
+	base := config.Base
+	base.CredentialBackend = backend
+	if credentialFile != "" {
+		base.CredentialFile = credentialFile
+	}
+	saved, err := util.SaveCredential(base, true)
+	if err != nil {
+		return err
+	}
+
+	setViperBase(saved)
+	if err := viper.WriteConfigAs(viper.ConfigFileUsed()); err != nil {
+		return err
+	}
+	config.Base = base
+	logger.Infof("Migrate secrets successfully! The secrets are removed from %s", viper.ConfigFileUsed())
+	return nil
+}
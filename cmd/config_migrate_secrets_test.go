@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+func TestConfigMigrateSecretsCmd(t *testing.T) {
+	fmt.Println("TestConfigMigrateSecretsCmd")
+	dir, err := ioutil.TempDir("", "coscli-credential")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// viper.Set 设置的值优先于配置文件，且读取配置时不会清除配置文件中没有的项，前后均需清除
+	viper.Set("cos", nil)
+	defer func() {
+		cfgFile = ""
+		viper.Set("cos", nil)
+		config = util.Config{}
+		fileConfig = util.Config{}
+		getConfig()
+	}()
+
+	// 使用旧的 AES-ECB 方式加密的配置文件
+	secretID, _ := util.EncryptSecret("migrate-secret-id")
+	secretKey, _ := util.EncryptSecret("migrate-secret-key")
+	configFile := filepath.Join(dir, "migrate.yaml")
+	credentialFile := filepath.Join(dir, "credentials")
+	content := fmt.Sprintf(`cos:
+  base:
+    secretid: %s
+    secretkey: %s
+    sessiontoken: ""
+    protocol: http
+    mode: SecretKey
+    disableencryption: ""
+    proxy: %s
+  buckets:
+  - name: coscli-test-%s
+    alias: coscli-test
+    endpoint: %s
+`, secretID, secretKey, testProxy, testAppID, testEndpoint)
+	if err = ioutil.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(util.CredentialPassphraseEnv, "coscli-test-passphrase")
+
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	Convey("Test coscli config migrate-secrets", t, func() {
+		Convey("fail", func() {
+			Convey("backend can not store secrets", func() {
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"config", "migrate-secrets", "-c", configFile, "--backend", "env", "--credential_file", credentialFile}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			})
+			Convey("invalid backend", func() {
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"config", "migrate-secrets", "-c", configFile, "--backend", "keyring", "--credential_file", credentialFile}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			})
+		})
+		Convey("success", func() {
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"config", "migrate-secrets", "-c", configFile, "--backend", "file", "--credential_file", credentialFile}
+			cmd.SetArgs(args)
+			e := cmd.Execute()
+			So(e, ShouldBeNil)
+
+			// 配置文件中不再保存密钥
+			v := viper.New()
+			v.SetConfigFile(configFile)
+			So(v.ReadInConfig(), ShouldBeNil)
+			var cfg util.Config
+			So(v.UnmarshalKey("cos", &cfg), ShouldBeNil)
+			So(cfg.Base.SecretID, ShouldEqual, "")
+			So(cfg.Base.SecretKey, ShouldEqual, "")
+			So(cfg.Base.CredentialBackend, ShouldEqual, util.CredentialBackendFile)
+			So(cfg.Base.CredentialFile, ShouldEqual, credentialFile)
+			info, err := os.Stat(credentialFile)
+			So(err, ShouldBeNil)
+			So(info.Mode().Perm(), ShouldEqual, os.FileMode(0600))
+
+			// 通过口令解密凭证文件
+			So(util.LoadCredential(&cfg.Base), ShouldBeNil)
+			So(cfg.Base.SecretID, ShouldEqual, "migrate-secret-id")
+			So(cfg.Base.SecretKey, ShouldEqual, "migrate-secret-key")
+
+			// 口令错误时无法解密
+			os.Setenv(util.CredentialPassphraseEnv, "wrong-passphrase")
+			cfg.Base.SecretID = ""
+			So(util.LoadCredential(&cfg.Base), ShouldBeError)
+			So(cfg.Base.SecretID, ShouldEqual, "")
+			os.Setenv(util.CredentialPassphraseEnv, "coscli-test-passphrase")
+
+			// 已迁移后不能重复迁移
+			clearCmd()
+			args = []string{"config", "migrate-secrets", "-c", configFile, "--backend", "file", "--credential_file", credentialFile}
+			cmd.SetArgs(args)
+			e = cmd.Execute()
+			fmt.Printf(" : %v", e)
+			So(e, ShouldBeError)
+
+			// 口令错误而未读取到密钥时，不能修改密钥，仍可修改其他配置
+			osArgs := os.Args
+			os.Args = []string{"coscli", "config"}
+			os.Setenv(util.CredentialPassphraseEnv, "wrong-passphrase")
+			clearCmd()
+			args = []string{"config", "set", "-c", configFile, "--secret_key", "new-secret-key"}
+			cmd.SetArgs(args)
+			e = cmd.Execute()
+			fmt.Printf(" : %v", e)
+			So(e, ShouldBeError)
+			clearCmd()
+			clearConfigCmd()
+			args = []string{"config", "set", "-c", configFile, "--read_timeout", "10"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			clearConfigCmd()
+			os.Args = osArgs
+			os.Setenv(util.CredentialPassphraseEnv, "coscli-test-passphrase")
+
+			cfg.Base.SecretID, cfg.Base.SecretKey = "", ""
+			So(util.LoadCredential(&cfg.Base), ShouldBeNil)
+			So(cfg.Base.SecretID, ShouldEqual, "migrate-secret-id")
+			So(cfg.Base.SecretKey, ShouldEqual, "migrate-secret-key")
+		})
+	})
+}
+
+func TestCredentialBackend(t *testing.T) {
+	fmt.Println("TestCredentialBackend")
+	dir, err := ioutil.TempDir("", "coscli-credential")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	Convey("Test credential backend", t, func() {
+		Convey("env", func() {
+			t.Setenv("COS_SECRET_ID", "env-secret-id")
+			t.Setenv("COS_SECRET_KEY", "env-secret-key")
+			t.Setenv("COS_SESSION_TOKEN", "env-session-token")
+			base := util.BaseCfg{CredentialBackend: util.CredentialBackendEnv, SecretID: "config-secret-id"}
+			So(util.LoadCredential(&base), ShouldBeNil)
+			So(base.SecretID, ShouldEqual, "env-secret-id")
+			So(base.SecretKey, ShouldEqual, "env-secret-key")
+			So(base.SessionToken, ShouldEqual, "env-session-token")
+
+			os.Setenv("COS_SECRET_KEY", "")
+			So(util.LoadCredential(&base), ShouldBeError)
+		})
+		Convey("process", func() {
+			base := util.BaseCfg{
+				CredentialBackend: util.CredentialBackendProcess,
+				CredentialProcess: `echo '{"SecretId":"process-secret-id","SecretKey":"process-secret-key"}'`,
+			}
+			So(util.LoadCredential(&base), ShouldBeNil)
+			So(base.SecretID, ShouldEqual, "process-secret-id")
+			So(base.SecretKey, ShouldEqual, "process-secret-key")
+
+			base.CredentialProcess = "echo not-json"
+			So(util.LoadCredential(&base), ShouldBeError)
+			base.CredentialProcess = "exit 1"
+			So(util.LoadCredential(&base), ShouldBeError)
+		})
+		Convey("file with key", func() {
+			identity, err := age.GenerateX25519Identity()
+			So(err, ShouldBeNil)
+			t.Setenv(util.CredentialKeyEnv, identity.String())
+			base := util.BaseCfg{
+				CredentialBackend: util.CredentialBackendFile,
+				CredentialFile:    filepath.Join(dir, "key-credentials"),
+				SecretID:          "file-secret-id",
+				SecretKey:         "file-secret-key",
+			}
+			saved, err := util.SaveCredential(base, true)
+			So(err, ShouldBeNil)
+			So(saved.SecretID, ShouldEqual, "")
+			So(saved.SecretKey, ShouldEqual, "")
+			// 凭证文件为 age 格式
+			data, err := ioutil.ReadFile(base.CredentialFile)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldStartWith, "-----BEGIN AGE ENCRYPTED FILE-----")
+			So(util.LoadCredential(&saved), ShouldBeNil)
+			So(saved.SecretID, ShouldEqual, "file-secret-id")
+			So(saved.SecretKey, ShouldEqual, "file-secret-key")
+
+			// 未设置密钥、密钥错误或格式不正确时无法解密
+			other, _ := age.GenerateX25519Identity()
+			for _, key := range []string{"", other.String(), "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="} {
+				os.Setenv(util.CredentialKeyEnv, key)
+				e := util.LoadCredential(&saved)
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			}
+		})
+		Convey("config", func() {
+			base := util.BaseCfg{SecretID: "config-secret-id"}
+			saved, err := util.SaveCredential(base, true)
+			So(err, ShouldBeNil)
+			So(saved.SecretID, ShouldNotEqual, "config-secret-id")
+			So(util.LoadCredential(&saved), ShouldBeNil)
+			So(saved.SecretID, ShouldEqual, "config-secret-id")
+
+			base.CredentialBackend = "keyring"
+			So(util.LoadCredential(&base), ShouldBeError)
+		})
+	})
+}
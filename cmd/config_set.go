@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/mitchellh/go-homedir"
 	"os"
+	"strconv"
 
 	logger "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -17,10 +18,13 @@ var configSetCmd = &cobra.Command{
 	Long: `Used to modify configuration items in the [base] group of the configuration file
 
 Format:
-  ./coscli config set [flags]
+  ./coscli config set [flags] [--profile <profile-name>]
 
 Example:
-  ./coscli config set -t example-token`,
+  ./coscli config set -t example-token
+  ./coscli config set --profile other --secret_id <id> --secret_key <key>
+  ./coscli config set --mode AssumeRole --role_arn qcs::cam::uin/100000000001:roleName/prod-ops --external_id <id>
+  ./coscli config set --mode WebIdentity --role_arn <role-arn> --web_identity_token_file /var/run/secrets/token`,
 	Args: cobra.ExactArgs(0),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		err := setConfigItem(cmd)
@@ -34,10 +38,30 @@ func init() {
 	configSetCmd.Flags().StringP("secret_id", "", "", "Set secret id")
 	configSetCmd.Flags().StringP("secret_key", "", "", "Set secret key")
 	configSetCmd.Flags().StringP("session_token", "t", "", "Set session token")
-	configSetCmd.Flags().StringP("mode", "", "", "Set mode")
+	configSetCmd.Flags().StringP("mode", "", "", "Set mode(SecretKey, CvmRole, AssumeRole or WebIdentity)")
 	configSetCmd.Flags().StringP("cvm_role_name", "", "", "Set cvm role name")
+	configSetCmd.Flags().StringP("cam_url", "", "", "Set the metadata url to get the temporary secrets of the cvm role(default "+util.CamUrl+")")
 	configSetCmd.Flags().StringP("close_auto_switch_host", "", "", "Close Auto Switch Host")
 	configSetCmd.Flags().StringP("disable_encryption", "", "", "Disable Encryption")
+	configSetCmd.Flags().StringP("credential_backend", "", "", "Set where the secrets are stored(config, file, process or env)")
+	configSetCmd.Flags().StringP("credential_file", "", "", "Set the path of the encrypted credential file used by the file backend(default ~/.cos.credentials)")
+	configSetCmd.Flags().StringP("credential_process", "", "", "Set the command whose JSON output provides the secrets for the process backend")
+	configSetCmd.Flags().StringP("role_arn", "", "", "Set the arn of the role to assume in AssumeRole or WebIdentity mode")
+	configSetCmd.Flags().StringP("role_session_name", "", "", "Set the session name of the assumed role(default "+util.DefaultRoleSessionName+")")
+	configSetCmd.Flags().StringP("duration_seconds", "", "", "Set the valid seconds of the temporary secrets of the assumed role")
+	configSetCmd.Flags().StringP("external_id", "", "", "Set the external id required by the role to assume")
+	configSetCmd.Flags().StringP("web_identity_token_file", "", "", "Set the path of the OIDC token file in WebIdentity mode")
+	configSetCmd.Flags().StringP("provider_id", "", "", "Set the identity provider name in WebIdentity mode(default "+util.DefaultProviderId+")")
+	configSetCmd.Flags().StringP("sts_endpoint", "", "", "Set the sts endpoint(default "+util.DefaultStsEndpoint+")")
+	configSetCmd.Flags().StringP("sts_region", "", "", "Set the sts region(default "+util.DefaultStsRegion+")")
+	configSetCmd.Flags().StringP("connect_timeout", "", "", "Set the timeout in seconds to connect to the server")
+	configSetCmd.Flags().StringP("read_timeout", "", "", "Set the timeout in seconds to wait for the response headers")
+	configSetCmd.Flags().StringP("proxy", "", "", "Set the http or https proxy url")
+	configSetCmd.Flags().StringP("ca_file", "", "", "Set the PEM file of the CA certificates used to verify the server")
+	configSetCmd.Flags().StringP("insecure_skip_verify", "", "", "Skip TLS certificate verification(true or false), only for private endpoints")
+	configSetCmd.Flags().StringP("max_conns", "", "", "Set the max connections to the server")
+	configSetCmd.Flags().StringP("keepalive", "", "", "Set the TCP keepalive interval in seconds, negative disables it")
+	configSetCmd.Flags().StringP("encryption_key_file", "", "", "Set the master key file for client-side encryption, objects encrypted on the client side are decrypted with it")
 }
 
 func setConfigItem(cmd *cobra.Command) error {
@@ -47,8 +71,14 @@ func setConfigItem(cmd *cobra.Command) error {
 	sessionToken, _ := cmd.Flags().GetString("session_token")
 	mode, _ := cmd.Flags().GetString("mode")
 	cvmRoleName, _ := cmd.Flags().GetString("cvm_role_name")
+	camUrl, _ := cmd.Flags().GetString("cam_url")
 	closeAutoSwitchHost, _ := cmd.Flags().GetString("close_auto_switch_host")
 	disableEncryption, _ := cmd.Flags().GetString("disable_encryption")
+	credentialBackend, _ := cmd.Flags().GetString("credential_backend")
+	credentialFile, _ := cmd.Flags().GetString("credential_file")
+	credentialProcess, _ := cmd.Flags().GetString("credential_process")
+	// 修改了密钥或密钥的存储位置时才需重新保存密钥
+	storeSecrets := secretID != "" || secretKey != "" || sessionToken != ""
 	if secretID != "" {
 		flag = true
 		if secretID == "@" {
@@ -75,8 +105,8 @@ func setConfigItem(cmd *cobra.Command) error {
 	}
 	if mode != "" {
 		flag = true
-		if mode != "SecretKey" && mode != "CvmRole" {
-			return fmt.Errorf("Please Enter Mode As SecretKey Or CvmRole!")
+		if mode != "SecretKey" && mode != "CvmRole" && mode != "AssumeRole" && mode != "WebIdentity" {
+			return fmt.Errorf("Please Enter Mode As SecretKey, CvmRole, AssumeRole Or WebIdentity!")
 		} else {
 			config.Base.Mode = mode
 		}
@@ -89,6 +119,14 @@ func setConfigItem(cmd *cobra.Command) error {
 			config.Base.CvmRoleName = cvmRoleName
 		}
 	}
+	if camUrl != "" {
+		flag = true
+		if camUrl == "@" {
+			config.Base.CamUrl = ""
+		} else {
+			config.Base.CamUrl = camUrl
+		}
+	}
 
 	if closeAutoSwitchHost != "" {
 		flag = true
@@ -108,14 +146,95 @@ func setConfigItem(cmd *cobra.Command) error {
 		}
 	}
 
+	if credentialBackend != "" {
+		flag = true
+		storeSecrets = true
+		if credentialBackend == "@" {
+			config.Base.CredentialBackend = ""
+		} else if err := util.CheckCredentialBackend(credentialBackend); err != nil {
+			return err
+		} else {
+			config.Base.CredentialBackend = credentialBackend
+		}
+	}
+
+	if credentialFile != "" {
+		flag = true
+		storeSecrets = true
+		if credentialFile == "@" {
+			config.Base.CredentialFile = ""
+		} else {
+			config.Base.CredentialFile = credentialFile
+		}
+	}
+
+	if credentialProcess != "" {
+		flag = true
+		if credentialProcess == "@" {
+			config.Base.CredentialProcess = ""
+		} else {
+			config.Base.CredentialProcess = credentialProcess
+		}
+	}
+
+	// 申请角色临时密钥及连接设置的配置项
+	items := []struct {
+		name  string
+		value *string
+	}{
+		{"role_arn", &config.Base.RoleArn},
+		{"role_session_name", &config.Base.RoleSessionName},
+		{"duration_seconds", &config.Base.DurationSeconds},
+		{"external_id", &config.Base.ExternalId},
+		{"web_identity_token_file", &config.Base.WebIdentityTokenFile},
+		{"provider_id", &config.Base.ProviderId},
+		{"sts_endpoint", &config.Base.StsEndpoint},
+		{"sts_region", &config.Base.StsRegion},
+		{"connect_timeout", &config.Base.ConnectTimeout},
+		{"read_timeout", &config.Base.ReadTimeout},
+		{"proxy", &config.Base.Proxy},
+		{"ca_file", &config.Base.CaFile},
+		{"insecure_skip_verify", &config.Base.InsecureSkipVerify},
+		{"max_conns", &config.Base.MaxConns},
+		{"keepalive", &config.Base.KeepAlive},
+		{"encryption_key_file", &config.Base.EncryptionKeyFile},
+	}
+	for _, item := range items {
+		value, _ := cmd.Flags().GetString(item.name)
+		if value == "" {
+			continue
+		}
+		flag = true
+		if value == "@" {
+			*item.value = ""
+		} else {
+			*item.value = value
+		}
+	}
+	if config.Base.DurationSeconds != "" {
+		if d, err := strconv.Atoi(config.Base.DurationSeconds); err != nil || d <= 0 {
+			return fmt.Errorf("invalid duration seconds %s", config.Base.DurationSeconds)
+		}
+	}
+	if _, err := util.GetTransportOptions(&config, &util.Param{}, nil); err != nil {
+		return err
+	}
+
 	if !flag {
 		return fmt.Errorf("Enter at least one configuration item to be modified!")
 	}
-	// 若未关闭秘钥加密，则先加密秘钥
-	if config.Base.DisableEncryption != "true" {
-		config.Base.SecretKey, _ = util.EncryptSecret(config.Base.SecretKey)
-		config.Base.SecretID, _ = util.EncryptSecret(config.Base.SecretID)
-		config.Base.SessionToken, _ = util.EncryptSecret(config.Base.SessionToken)
+	if (config.Base.CredentialBackend == util.CredentialBackendProcess || config.Base.CredentialBackend == util.CredentialBackendEnv) &&
+		((secretID != "" && secretID != "@") || (secretKey != "" && secretKey != "@") || (sessionToken != "" && sessionToken != "@")) {
+		return fmt.Errorf("secrets are provided by the %s credential backend and can not be set", config.Base.CredentialBackend)
+	}
+	// 未读取到原有的密钥时重新保存会丢失未修改的密钥
+	if storeSecrets && credentialErr != nil {
+		return fmt.Errorf("can not save the secrets because loading the current secrets failed: %v", credentialErr)
+	}
+	// 按存储方式保存密钥
+	base, err := util.SaveCredential(config.Base, storeSecrets)
+	if err != nil {
+		return err
 	}
 
 	// 判断config文件是否存在。不存在则创建
@@ -132,12 +251,12 @@ func setConfigItem(cmd *cobra.Command) error {
 	}
 	_, err = os.Stat(configFile)
 	if os.IsNotExist(err) || cfgFile != "" {
-		viper.Set("cos.base", config.Base)
+		setViperBase(base)
 		if err := viper.WriteConfigAs(configFile); err != nil {
 			return err
 		}
 	} else {
-		viper.Set("cos.base", config.Base)
+		setViperBase(base)
 		if err := viper.WriteConfigAs(viper.ConfigFileUsed()); err != nil {
 			return err
 		}
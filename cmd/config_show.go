@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"coscli/util"
 	"fmt"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -12,7 +13,7 @@ var configShowCmd = &cobra.Command{
 	Long: `Prints information from a specified configuration file
 
 Format:
-  ./coscli config show [-c <config-file-path>]
+  ./coscli config show [-c <config-file-path>] [--profile <profile-name>]
 
 Example:
   ./coscli config show`,
@@ -29,14 +30,39 @@ func showConfig() {
 	fmt.Println("Configuration file path:")
 	fmt.Printf("  %s\n", viper.ConfigFileUsed())
 	fmt.Println("====================")
+	if name := activeProfile(); name != "" {
+		fmt.Printf("Profile: %s\n", name)
+	} else {
+		fmt.Printf("Profile: %s\n", util.DefaultProfile)
+	}
 	fmt.Println("Basic Configuration Information:")
 	fmt.Printf("  Secret ID:     %s\n", config.Base.SecretID)
 	fmt.Printf("  Secret Key:    %s\n", config.Base.SecretKey)
 	fmt.Printf("  Session Token: %s\n", config.Base.SessionToken)
 	fmt.Printf("  Mode: %s\n", config.Base.Mode)
 	fmt.Printf("  CvmRoleName: %s\n", config.Base.CvmRoleName)
+	fmt.Printf("  CamUrl: %s\n", config.Base.CamUrl)
+	fmt.Printf("  RoleArn: %s\n", config.Base.RoleArn)
+	fmt.Printf("  RoleSessionName: %s\n", config.Base.RoleSessionName)
+	fmt.Printf("  DurationSeconds: %s\n", config.Base.DurationSeconds)
+	fmt.Printf("  ExternalId: %s\n", config.Base.ExternalId)
+	fmt.Printf("  WebIdentityTokenFile: %s\n", config.Base.WebIdentityTokenFile)
+	fmt.Printf("  ProviderId: %s\n", config.Base.ProviderId)
+	fmt.Printf("  StsEndpoint: %s\n", config.Base.StsEndpoint)
+	fmt.Printf("  StsRegion: %s\n", config.Base.StsRegion)
+	fmt.Printf("  ConnectTimeout: %s\n", config.Base.ConnectTimeout)
+	fmt.Printf("  ReadTimeout: %s\n", config.Base.ReadTimeout)
+	fmt.Printf("  Proxy: %s\n", config.Base.Proxy)
+	fmt.Printf("  CaFile: %s\n", config.Base.CaFile)
+	fmt.Printf("  InsecureSkipVerify: %s\n", config.Base.InsecureSkipVerify)
+	fmt.Printf("  MaxConns: %s\n", config.Base.MaxConns)
+	fmt.Printf("  KeepAlive: %s\n", config.Base.KeepAlive)
+	fmt.Printf("  EncryptionKeyFile: %s\n", config.Base.EncryptionKeyFile)
 	fmt.Printf("  CloseAutoSwitchHost: %s\n", config.Base.CloseAutoSwitchHost)
 	fmt.Printf("  DisableEncryption: %s\n", config.Base.DisableEncryption)
+	fmt.Printf("  CredentialBackend: %s\n", config.Base.CredentialBackend)
+	fmt.Printf("  CredentialFile: %s\n", config.Base.CredentialFile)
+	fmt.Printf("  CredentialProcess: %s\n", config.Base.CredentialProcess)
 	fmt.Println("====================")
 	fmt.Println("Bucket Configuration Information:")
 
@@ -47,4 +73,12 @@ func showConfig() {
 		fmt.Printf("  Alias: \t%s\n", b.Alias)
 		fmt.Printf("  Ofs: \t%v\n", b.Ofs)
 	}
+
+	if len(fileConfig.Profiles) > 0 {
+		fmt.Println("====================")
+		fmt.Println("Profiles:")
+		for _, p := range fileConfig.Profiles {
+			fmt.Printf("- %s\n", p.Name)
+		}
+	}
 }
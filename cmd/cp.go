@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"context"
+	clilog "coscli/logger"
 	"coscli/util"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	logger "github.com/sirupsen/logrus"
@@ -25,7 +27,25 @@ Example:
   Download:
     ./coscli cp cos://examplebucket/example.txt ~/example.txt
   Copy:
-    ./coscli cp cos://examplebucket1/example1.txt cos://examplebucket2/example2.txt`,
+    ./coscli cp cos://examplebucket1/example1.txt cos://examplebucket2/example2.txt
+  Copy between accounts:
+    ./coscli cp cos://examplebucket1/example1.txt cos://examplebucket2/example2.txt --src-profile account1 --dest-profile account2
+  Upload with a canned ACL:
+    ./coscli cp ~/example.txt cos://examplebucket/example.txt --acl public-read
+  Upload with object tags:
+    ./coscli cp ~/example.txt cos://examplebucket/example.txt --tags "project=coscli&env=test"
+  Upload with server-side encryption:
+    ./coscli cp ~/example.txt cos://examplebucket/example.txt --sse kms
+  Upload with client-side encryption:
+    ./coscli cp ~/example.txt cos://examplebucket/example.txt --client-encryption aes-gcm --encryption-key-file ~/master.key
+  Download an object encrypted with SSE-C:
+    ./coscli cp cos://examplebucket/example.txt ~/example.txt --sse-c-key-file ~/sse-c.key
+  Upload from stdin, the data is uploaded in parts of --part-size:
+    tar czf - ~/example | ./coscli cp - cos://examplebucket/example.tgz --part-size 8
+  Download to stdout:
+    ./coscli cp cos://examplebucket/example.tgz - | tar xzf -
+  Append the new data of a growing local file to an appendable object:
+    ./coscli cp ~/app.log cos://examplebucket/app.log --append`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if err := cobra.ExactArgs(2)(cmd, args); err != nil {
 			return err
@@ -34,8 +54,6 @@ Example:
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		recursive, _ := cmd.Flags().GetBool("recursive")
-		include, _ := cmd.Flags().GetString("include")
-		exclude, _ := cmd.Flags().GetString("exclude")
 		storageClass, _ := cmd.Flags().GetString("storage-class")
 		rateLimiting, _ := cmd.Flags().GetFloat32("rate-limiting")
 		partSize, _ := cmd.Flags().GetInt64("part-size")
@@ -43,6 +61,10 @@ Example:
 		routines, _ := cmd.Flags().GetInt("routines")
 		failOutput, _ := cmd.Flags().GetBool("fail-output")
 		failOutputPath, _ := cmd.Flags().GetString("fail-output-path")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		checkpointDir, _ := cmd.Flags().GetString("checkpoint-dir")
+		resume, _ := cmd.Flags().GetBool("resume")
+		filesFrom, _ := cmd.Flags().GetString("files-from")
 		metaString, _ := cmd.Flags().GetString("meta")
 		retryNum, _ := cmd.Flags().GetInt("retry-num")
 		errRetryNum, _ := cmd.Flags().GetInt("err-retry-num")
@@ -56,6 +78,8 @@ Example:
 		longLinksNums, _ := cmd.Flags().GetInt("long-links-nums")
 		versionId, _ := cmd.Flags().GetString("version-id")
 		move, _ := cmd.Flags().GetBool("move")
+		appendMode, _ := cmd.Flags().GetBool("append")
+		sha256, _ := cmd.Flags().GetBool("sha256")
 
 		meta, err := util.MetaStringToHeader(metaString)
 		if err != nil {
@@ -92,7 +116,30 @@ Example:
 			return fmt.Errorf("move only supports cp between cos paths")
 		}
 
-		_, filters := util.GetFilter(include, exclude)
+		filters, err := getFilters(cmd)
+		if err != nil {
+			return err
+		}
+
+		sse, err := getSSEOptions(cmd, srcUrl, destUrl)
+		if err != nil {
+			return err
+		}
+
+		clientEncryption, err := getTransferClientEncryption(cmd, srcUrl, destUrl)
+		if err != nil {
+			return err
+		}
+
+		acl, err := getTransferACLOptions(cmd, srcUrl, destUrl)
+		if err != nil {
+			return err
+		}
+
+		tagging, err := getTaggingOptions(cmd, srcUrl, destUrl)
+		if err != nil {
+			return err
+		}
 
 		fo := &util.FileOperations{
 			Operation: util.Operation{
@@ -105,6 +152,7 @@ Example:
 				Routines:          routines,
 				FailOutput:        failOutput,
 				FailOutputPath:    failOutputPath,
+				DryRun:            dryRun,
 				Meta:              meta,
 				RetryNum:          retryNum,
 				ErrRetryNum:       errRetryNum,
@@ -116,8 +164,16 @@ Example:
 				DisableChecksum:   disableChecksum,
 				DisableLongLinks:  disableLongLinks,
 				LongLinksNums:     longLinksNums,
+				CheckpointDir:     checkpointDir,
+				Resume:            resume,
 				VersionId:         versionId,
 				Move:              move,
+				Append:            appendMode,
+				Sha256:            sha256,
+				SSE:               sse,
+				ACL:               acl,
+				Tagging:           tagging,
+				ClientEncryption:  clientEncryption,
 			},
 			Monitor:    &util.FileProcessMonitor{},
 			Config:     &config,
@@ -128,10 +184,50 @@ Example:
 			BucketType: "COS",
 		}
 
-		if !fo.Operation.Recursive && len(fo.Operation.Filters) > 0 {
-			return fmt.Errorf("--include or --exclude only work with --recursive")
+		// 来源与目标可以使用不同的命名配置
+		err = initTransferProfiles(cmd, srcUrl, destUrl, fo)
+		if err != nil {
+			return err
+		}
+
+		err = util.CheckAppendOptions(srcUrl, destUrl, fo)
+		if err != nil {
+			return err
+		}
+
+		err = util.CheckSha256Options(srcUrl, destUrl, fo)
+		if err != nil {
+			return err
+		}
+
+		// 来源或目标为 - 时从标准输入上传或下载到标准输出
+		if isStreamUrl(srcUrl) || isStreamUrl(destUrl) {
+			return streamCopy(srcUrl, destUrl, filesFrom, fo)
+		}
+
+		err = initDryRun(fo)
+		if err != nil {
+			return err
+		}
+
+		err = checkFiltersRecursive(fo)
+		if err != nil {
+			return err
 		}
 
+		// 读取 --files-from 清单
+		err = util.InitFileList(filesFrom, srcUrl, fo)
+		if err != nil {
+			return err
+		}
+
+		// 断点续传任务日志实例化
+		err = util.InitCheckpoint(srcUrl, destUrl, fo)
+		if err != nil {
+			return err
+		}
+		defer util.CloseCheckpoint(fo)
+
 		srcPath := srcUrl.ToString()
 		destPath := destUrl.ToString()
 
@@ -221,7 +317,7 @@ Example:
 			logger.Infof("%s %s to %s start", operate, srcPath, destPath)
 			// 实例化来源 cos client
 			srcBucketName := srcUrl.(*util.CosUrl).Bucket
-			srcClient, err := util.NewClient(fo.Config, fo.Param, srcBucketName)
+			srcClient, err := util.NewClient(util.SrcConfig(fo), fo.Param, srcBucketName)
 			if err != nil {
 				return err
 			}
@@ -274,6 +370,12 @@ Example:
 		} else {
 			return fmt.Errorf("cospath needs to contain %s", util.SchemePrefix)
 		}
+		if fo.Operation.DryRun {
+			fo.Plan.Finish()
+			util.CloseErrorOutputFile(fo)
+			return nil
+		}
+		util.FinishCheckpoint(fo)
 		util.CloseErrorOutputFile(fo)
 		endT := time.Now().UnixNano() / 1000 / 1000
 		util.PrintCostTime(startT, endT)
@@ -293,8 +395,14 @@ func init() {
 	rootCmd.AddCommand(cpCmd)
 
 	cpCmd.Flags().BoolP("recursive", "r", false, "Copy objects recursively")
-	cpCmd.Flags().String("include", "", "Include files that meet the specified criteria")
-	cpCmd.Flags().String("exclude", "", "Exclude files that meet the specified criteria")
+	addFilterFlags(cpCmd)
+	addTransferProfileFlags(cpCmd)
+	addSSEFlags(cpCmd)
+	addClientEncryptionFlags(cpCmd, true)
+	addACLFlags(cpCmd)
+	addTaggingFlags(cpCmd)
+	addMetaFilterFlags(cpCmd)
+	addFilesFromFlag(cpCmd)
 	cpCmd.Flags().String("storage-class", "", "Specifying a storage class")
 	cpCmd.Flags().Float32("rate-limiting", 0, "Upload or download speed limit(MB/s)")
 	cpCmd.Flags().Int64("part-size", 32, "Specifies the block size(MB)")
@@ -317,6 +425,11 @@ func init() {
 	cpCmd.Flags().Int("long-links-nums", 0, "The long connection quantity parameter, if 0 or not provided, defaults to the concurrent file count.")
 	cpCmd.Flags().String("version-id", "", "Downloading a specified version of a file , only available if bucket versioning is enabled.")
 	cpCmd.Flags().Bool("move", false, "Enable migration mode (only available between COS paths), which will delete the source file after it has been successfully copied to the destination path.")
+	cpCmd.Flags().Bool("append", false, "Upload by appending to appendable objects. Only the data after the length of the object is uploaded, so a growing local file can be uploaded again to push its new data. The uploaded part must be the same as the beginning of the local file.")
+	cpCmd.Flags().Bool("sha256", false, "Calculate the SHA-256 of local files and record it in the x-cos-meta-sha256 metadata of the uploaded objects, which can be checked by verify --sha256 and shown by hash --type sha256. Only for upload.")
+	cpCmd.Flags().String("checkpoint-dir", "", "Directory to keep the job journal of a recursive transfer. Completed files are recorded in it, so the same command can be rerun with --resume after a crash or Ctrl-C. The journal is deleted after the job succeeds.")
+	cpCmd.Flags().Bool("resume", false, "Resume the job from the journal in --checkpoint-dir, skipping files already done. A cos source is listed again from the listing position kept in the journal, a local source is walked again, and every listed file is checked against the journal. Without it, the journal of the same job is cleared and the job starts over.")
+	cpCmd.Flags().Bool("dry-run", false, "Print the uploads, downloads and copies that would be performed without performing them. Use --output to print them as json, jsonl or csv")
 }
 
 func getCommandType(srcUrl util.StorageUrl, destUrl util.StorageUrl) util.CpType {
@@ -328,3 +441,91 @@ func getCommandType(srcUrl util.StorageUrl, destUrl util.StorageUrl) util.CpType
 	}
 	return util.CpTypeUpload
 }
+
+func isStreamUrl(storageUrl util.StorageUrl) bool {
+	return storageUrl.IsFileUrl() && storageUrl.ToString() == util.StreamPath
+}
+
+// 从标准输入流式上传单个对象，或将单个对象流式下载到标准输出
+func streamCopy(srcUrl, destUrl util.StorageUrl, filesFrom string, fo *util.FileOperations) error {
+	switch {
+	case fo.Operation.Recursive:
+		return fmt.Errorf("--recursive can not be used with stdin or stdout")
+	case filesFrom != "":
+		return fmt.Errorf("--files-from can not be used with stdin or stdout")
+	case fo.Operation.DryRun:
+		return fmt.Errorf("--dry-run can not be used with stdin or stdout")
+	case fo.Operation.CheckpointDir != "":
+		return fmt.Errorf("--checkpoint-dir can not be used with stdin or stdout")
+	case fo.Operation.Append:
+		return fmt.Errorf("--append can not be used with stdin or stdout")
+	case fo.Operation.Sha256:
+		return fmt.Errorf("--sha256 can not be used with stdin or stdout")
+	case fo.Operation.PartSize < 1:
+		return fmt.Errorf("--part-size must be greater than 0")
+	}
+
+	startT := time.Now().UnixNano() / 1000 / 1000
+	if isStreamUrl(srcUrl) {
+		if !destUrl.IsCosUrl() {
+			return fmt.Errorf("cospath needs to contain %s", util.SchemePrefix)
+		}
+		if fo.Operation.ClientEncryption != nil && fo.Operation.ClientEncryption.Algorithm != "" {
+			return fmt.Errorf("--client-encryption can not be used with stdin")
+		}
+		object := destUrl.(*util.CosUrl).Object
+		if object == "" || strings.HasSuffix(object, "/") {
+			return fmt.Errorf("the object key is required to upload from stdin")
+		}
+		c, err := util.NewClient(fo.Config, fo.Param, destUrl.(*util.CosUrl).Bucket, fo)
+		if err != nil {
+			return err
+		}
+		// 是否关闭crc64
+		if fo.Operation.DisableCrc64 {
+			c.Conf.EnableCRC = false
+		}
+		logger.Infof("Upload stdin to %s start", destUrl.ToString())
+		size, err := util.StreamUpload(c, os.Stdin, destUrl, fo)
+		if err != nil {
+			return fmt.Errorf("upload stdin to %s failed: %v", destUrl.ToString(), err)
+		}
+		endT := time.Now().UnixNano() / 1000 / 1000
+		util.PrintCostTime(startT, endT)
+		logger.Infof("Upload stdin to %s succeed, total size: %s", destUrl.ToString(), util.FormatSize(size))
+		return nil
+	}
+
+	if !srcUrl.IsCosUrl() {
+		return fmt.Errorf("cospath needs to contain %s", util.SchemePrefix)
+	}
+	// 数据输出到 stdout，日志改为输出到 stderr
+	clilog.SetConsoleOutput(os.Stderr)
+	object := srcUrl.(*util.CosUrl).Object
+	if object == "" || strings.HasSuffix(object, "/") {
+		return fmt.Errorf("the object key is required to download to stdout")
+	}
+	c, err := util.NewClient(fo.Config, fo.Param, srcUrl.(*util.CosUrl).Bucket, fo)
+	if err != nil {
+		return err
+	}
+	if fo.Operation.DisableCrc64 {
+		c.Conf.EnableCRC = false
+	}
+	if fo.Operation.VersionId != "" {
+		res, _, err := util.GetBucketVersioning(c)
+		if err != nil {
+			return err
+		}
+		if res.Status != util.VersionStatusEnabled {
+			return fmt.Errorf("versioning is not enabled on the current bucket")
+		}
+	}
+	logger.Infof("Download %s to stdout start", srcUrl.ToString())
+	size, err := util.StreamDownload(c, srcUrl, os.Stdout, fo)
+	if err != nil {
+		return fmt.Errorf("download %s to stdout failed: %v", srcUrl.ToString(), err)
+	}
+	logger.Infof("Download %s to stdout succeed, total size: %s", srcUrl.ToString(), util.FormatSize(size))
+	return nil
+}
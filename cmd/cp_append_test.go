@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"bytes"
+	"coscli/util"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/agiledragon/gomonkey/v2"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCpAppend(t *testing.T) {
+	fmt.Println("TestCpAppend")
+	dir, err := ioutil.TempDir("", "coscli-cp-append")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testBucket = randStr(8)
+	testAlias = testBucket + "-alias"
+	setUp(testBucket, testAlias, testEndpoint, false, false)
+	defer tearDown(testBucket, testAlias, testEndpoint, false)
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+
+	// 上传失败时 cp/sync 以退出码 2 退出，这里只记录退出码
+	exitCode := 0
+	patches := ApplyFunc(os.Exit, func(code int) {
+		exitCode = code
+	})
+	defer patches.Reset()
+
+	catObject := func(cosPath string) []byte {
+		clearCmd()
+		cmd.SetArgs([]string{"cat", cosPath})
+		output, err := captureStdout(cmd.Execute)
+		So(err, ShouldBeNil)
+		return []byte(output)
+	}
+	data := make([]byte, 2*1024*1024+100)
+	rand.Read(data)
+	Convey("Test cp append", t, func() {
+		Convey("append the growth of a local file", func() {
+			localFile := filepath.Join(dir, "app.log")
+			cosPath := fmt.Sprintf("cos://%s/append/app.log", testAlias)
+			So(ioutil.WriteFile(localFile, data[:100], 0644), ShouldBeNil)
+			clearCmd()
+			cmd.SetArgs([]string{"cp", localFile, cosPath, "--append"})
+			So(cmd.Execute(), ShouldBeNil)
+			So(bytes.Equal(catObject(cosPath), data[:100]), ShouldBeTrue)
+
+			// 新增的数据按 --part-size 分多次追加
+			So(ioutil.WriteFile(localFile, data, 0644), ShouldBeNil)
+			clearCmd()
+			cmd.SetArgs([]string{"cp", localFile, cosPath, "--append", "--part-size", "1"})
+			So(cmd.Execute(), ShouldBeNil)
+			So(bytes.Equal(catObject(cosPath), data), ShouldBeTrue)
+
+			// 没有新增数据时跳过
+			clearCmd()
+			cmd.SetArgs([]string{"cp", localFile, cosPath, "--append"})
+			So(cmd.Execute(), ShouldBeNil)
+			So(exitCode, ShouldEqual, 0)
+			So(bytes.Equal(catObject(cosPath), data), ShouldBeTrue)
+		})
+		Convey("sync append with snapshot", func() {
+			localDir := filepath.Join(dir, "logs")
+			os.MkdirAll(localDir, 0755)
+			snapshotPath := filepath.Join(dir, "snapshot")
+			localFile := filepath.Join(localDir, "a.log")
+			cosDir := fmt.Sprintf("cos://%s/logs/", testAlias)
+			cosPath := cosDir + "a.log"
+			sync := func() {
+				clearCmd()
+				cmd.SetArgs([]string{"sync", localDir + "/", cosDir, "-r", "--append", "--snapshot-path", snapshotPath})
+				So(cmd.Execute(), ShouldBeNil)
+			}
+
+			So(ioutil.WriteFile(localFile, []byte("line 1\n"), 0644), ShouldBeNil)
+			sync()
+			So(exitCode, ShouldEqual, 0)
+			So(string(catObject(cosPath)), ShouldEqual, "line 1\n")
+
+			So(ioutil.WriteFile(localFile, []byte("line 1\nline 2\n"), 0644), ShouldBeNil)
+			sync()
+			So(exitCode, ShouldEqual, 0)
+			So(string(catObject(cosPath)), ShouldEqual, "line 1\nline 2\n")
+
+			// 本地文件被截断时报错，对象保持不变
+			So(ioutil.WriteFile(localFile, []byte("new\n"), 0644), ShouldBeNil)
+			sync()
+			So(exitCode, ShouldEqual, 2)
+			exitCode = 0
+			So(string(catObject(cosPath)), ShouldEqual, "line 1\nline 2\n")
+
+			// 本地文件轮转后又增长超过上次的追加位置时报错，对象保持不变
+			So(ioutil.WriteFile(localFile, []byte("rotated 1\nrotated 2\n"), 0644), ShouldBeNil)
+			sync()
+			So(exitCode, ShouldEqual, 2)
+			exitCode = 0
+			So(string(catObject(cosPath)), ShouldEqual, "line 1\nline 2\n")
+		})
+		Convey("dry run", func() {
+			localFile := filepath.Join(dir, "dry.log")
+			cosPath := fmt.Sprintf("cos://%s/append/dry.log", testAlias)
+			So(ioutil.WriteFile(localFile, []byte("dry run"), 0644), ShouldBeNil)
+			clearCmd()
+			cmd.SetArgs([]string{"cp", localFile, cosPath, "--append", "--dry-run"})
+			So(cmd.Execute(), ShouldBeNil)
+			clearCmd()
+			cmd.SetArgs([]string{"cat", cosPath})
+			_, err := captureStdout(cmd.Execute)
+			So(err, ShouldBeError)
+		})
+		Convey("fail", func() {
+			Convey("local file modified", func() {
+				localFile := filepath.Join(dir, "modified.log")
+				cosPath := fmt.Sprintf("cos://%s/append/modified.log", testAlias)
+				So(ioutil.WriteFile(localFile, []byte("hello"), 0644), ShouldBeNil)
+				clearCmd()
+				cmd.SetArgs([]string{"cp", localFile, cosPath, "--append"})
+				So(cmd.Execute(), ShouldBeNil)
+
+				So(ioutil.WriteFile(localFile, []byte("HELLO world"), 0644), ShouldBeNil)
+				clearCmd()
+				cmd.SetArgs([]string{"cp", localFile, cosPath, "--append"})
+				So(cmd.Execute(), ShouldBeNil)
+				So(exitCode, ShouldEqual, 2)
+				exitCode = 0
+				So(string(catObject(cosPath)), ShouldEqual, "hello")
+			})
+			Convey("object not appendable", func() {
+				localFile := filepath.Join(dir, "normal.log")
+				cosPath := fmt.Sprintf("cos://%s/append/normal.log", testAlias)
+				So(ioutil.WriteFile(localFile, []byte("normal"), 0644), ShouldBeNil)
+				clearCmd()
+				cmd.SetArgs([]string{"cp", localFile, cosPath})
+				So(cmd.Execute(), ShouldBeNil)
+
+				So(ioutil.WriteFile(localFile, []byte("normal object"), 0644), ShouldBeNil)
+				clearCmd()
+				cmd.SetArgs([]string{"cp", localFile, cosPath, "--append"})
+				So(cmd.Execute(), ShouldBeNil)
+				So(exitCode, ShouldEqual, 2)
+				exitCode = 0
+				So(string(catObject(cosPath)), ShouldEqual, "normal")
+			})
+			Convey("invalid options", func() {
+				localFile := filepath.Join(dir, "app.log")
+				cosPath := fmt.Sprintf("cos://%s/append/app.log", testAlias)
+				keyFile := filepath.Join(dir, "master.key")
+				ioutil.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))), 0600)
+				cases := [][]string{
+					{"cp", cosPath, localFile, "--append"},
+					{"cp", cosPath, cosPath + ".bak", "--append"},
+					{"cp", localFile, cosPath, "--append", "--client-encryption", util.CseAesCtr, "--encryption-key-file", keyFile},
+					{"cp", "-", cosPath, "--append"},
+					{"sync", cosPath, localFile, "--append"},
+				}
+				for _, args := range cases {
+					clearCmd()
+					cmd.SetArgs(args)
+					e := withStdin([]byte("stdin"), cmd.Execute)
+					fmt.Printf(" : %v", e)
+					So(e, ShouldBeError)
+				}
+			})
+		})
+	})
+}
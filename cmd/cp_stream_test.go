@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"coscli/util"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// 将 data 作为标准输入执行 f
+func withStdin(data []byte, f func() error) error {
+	file, err := ioutil.TempFile("", "coscli-stdin")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	if _, err = file.Write(data); err != nil {
+		return err
+	}
+	if _, err = file.Seek(0, 0); err != nil {
+		return err
+	}
+	stdin := os.Stdin
+	os.Stdin = file
+	defer func() { os.Stdin = stdin }()
+	return f()
+}
+
+func TestCpStream(t *testing.T) {
+	fmt.Println("TestCpStream")
+	dir, err := ioutil.TempDir("", "coscli-cp-stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testBucket = randStr(8)
+	testAlias = testBucket + "-alias"
+	setUp(testBucket, testAlias, testEndpoint, false, false)
+	defer tearDown(testBucket, testAlias, testEndpoint, false)
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	large := make([]byte, 3*1024*1024+100)
+	rand.Read(large)
+	small := []byte("hello coscli stream")
+	Convey("Test cp stream", t, func() {
+		Convey("upload from stdin and download to stdout", func() {
+			for name, data := range map[string][]byte{"large": large, "small": small, "empty": {}} {
+				cosPath := fmt.Sprintf("cos://%s/stream/%s", testAlias, name)
+				clearCmd()
+				cmd.SetArgs([]string{"cp", "-", cosPath, "--part-size", "1", "--thread-num", "2"})
+				So(withStdin(data, cmd.Execute), ShouldBeNil)
+
+				clearCmd()
+				cmd.SetArgs([]string{"cp", cosPath, "-"})
+				output, err := captureStdout(cmd.Execute)
+				So(err, ShouldBeNil)
+				So(bytes.Equal([]byte(output), data), ShouldBeTrue)
+
+				// 流式上传的对象可以正常下载到本地文件
+				localFile := filepath.Join(dir, name)
+				clearCmd()
+				cmd.SetArgs([]string{"cp", cosPath, localFile})
+				So(cmd.Execute(), ShouldBeNil)
+				content, err := ioutil.ReadFile(localFile)
+				So(err, ShouldBeNil)
+				So(bytes.Equal(content, data), ShouldBeTrue)
+			}
+		})
+		Convey("fail", func() {
+			cosPath := fmt.Sprintf("cos://%s/stream/small", testAlias)
+			keyFile := filepath.Join(dir, "master.key")
+			ioutil.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))), 0600)
+			cases := [][]string{
+				{"cp", "-", fmt.Sprintf("cos://%s", testAlias)},
+				{"cp", "-", fmt.Sprintf("cos://%s/stream/", testAlias)},
+				{"cp", "-", cosPath, "--recursive"},
+				{"cp", "-", cosPath, "--dry-run"},
+				{"cp", "-", cosPath, "--part-size", "0"},
+				{"cp", "-", cosPath, "--checkpoint-dir", dir},
+				{"cp", "-", cosPath, "--files-from", filepath.Join(dir, "list.txt")},
+				{"cp", "-", cosPath, "--client-encryption", util.CseAesCtr, "--encryption-key-file", keyFile},
+				{"cp", "-", dir},
+				{"cp", dir, "-"},
+				{"cp", fmt.Sprintf("cos://%s/stream/missing", testAlias), "-"},
+				{"cp", fmt.Sprintf("cos://%s/stream/", testAlias), "-"},
+				{"cp", cosPath, "-", "--version-id", "v1"},
+			}
+			for _, args := range cases {
+				clearCmd()
+				cmd.SetArgs(args)
+				e := withStdin(small, func() error {
+					_, err := captureStdout(cmd.Execute)
+					return err
+				})
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			}
+		})
+	})
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"coscli/util"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	. "github.com/agiledragon/gomonkey/v2"
@@ -139,6 +143,228 @@ func TestCpCmd(t *testing.T) {
 				e := cmd.Execute()
 				So(e, ShouldBeNil)
 			})
+			Convey("按过滤规则上传多个小文件", func() {
+				localFileName := fmt.Sprintf("%s/small-file", testDir)
+				c1, _ := util.NewClient(&config, &param, testAlias1)
+				countObjects := func(prefix string) int {
+					res, _, err := c1.Bucket.Get(context.Background(), &cos.BucketGetOptions{Prefix: prefix})
+					So(err, ShouldBeNil)
+					return len(res.Contents)
+				}
+
+				// glob 规则按顺序匹配，第一条匹配的规则生效
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"cp", localFileName, fmt.Sprintf("cos://%s/%s", testAlias1, "filter-glob"), "-r",
+					"--filter-syntax", "glob", "--exclude", "/0", "--include", "[0-1]"}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				So(e, ShouldBeNil)
+				So(countObjects("filter-glob/"), ShouldEqual, 1)
+
+				// gitignore 语法的规则文件，后面的规则优先
+				filterFile := fmt.Sprintf("%s/filter-rules", testDir)
+				ioutil.WriteFile(filterFile, []byte("# 只上传 2\n*\n!2\n"), 0644)
+				clearCmd()
+				cmd = rootCmd
+				args = []string{"cp", localFileName, fmt.Sprintf("cos://%s/%s", testAlias1, "filter-from"), "-r",
+					"--filter-from", filterFile}
+				cmd.SetArgs(args)
+				e = cmd.Execute()
+				So(e, ShouldBeNil)
+				So(countObjects("filter-from/"), ShouldEqual, 1)
+			})
+			Convey("过滤规则按相对源目录的路径匹配", func() {
+				// 源目录的上级目录与规则同名，不应影响匹配
+				localDir := fmt.Sprintf("%s/build/src", testDir)
+				for _, name := range []string{"keep/a.txt", "build/out.o", "sub/build/x.o", "docs/build", "docs/a.txt", "sub/docs/b.txt"} {
+					os.MkdirAll(filepath.Dir(filepath.Join(localDir, name)), 0755)
+					ioutil.WriteFile(filepath.Join(localDir, name), []byte(name), 0644)
+				}
+				c1, _ := util.NewClient(&config, &param, testAlias1)
+				listKeys := func(prefix string) map[string]bool {
+					res, _, err := c1.Bucket.Get(context.Background(), &cos.BucketGetOptions{Prefix: prefix})
+					So(err, ShouldBeNil)
+					keys := make(map[string]bool)
+					for _, object := range res.Contents {
+						keys[strings.TrimPrefix(object.Key, prefix)] = true
+					}
+					return keys
+				}
+
+				// 以 / 结尾的规则只排除目录，与规则同名的文件和上级目录不受影响
+				filterFile := fmt.Sprintf("%s/filter-dir-only", testDir)
+				ioutil.WriteFile(filterFile, []byte("build/\n"), 0644)
+				clearCmd()
+				cmd := rootCmd
+				cmd.SetArgs([]string{"cp", localDir + "/", fmt.Sprintf("cos://%s/%s", testAlias1, "filter-dir-only/"), "-r",
+					"--filter-from", filterFile})
+				So(cmd.Execute(), ShouldBeNil)
+				keys := listKeys("filter-dir-only/")
+				So(keys["keep/a.txt"], ShouldBeTrue)
+				So(keys["docs/build"], ShouldBeTrue)
+				So(keys["build/out.o"], ShouldBeFalse)
+				So(keys["build/"], ShouldBeFalse)
+				So(keys["sub/build/x.o"], ShouldBeFalse)
+
+				// 中间含 / 的规则从源目录开头匹配
+				clearCmd()
+				cmd = rootCmd
+				cmd.SetArgs([]string{"cp", localDir + "/", fmt.Sprintf("cos://%s/%s", testAlias1, "filter-anchored/"), "-r",
+					"--filter-syntax", "glob", "--exclude", "docs/*.txt", "--exclude", "/build/**"})
+				So(cmd.Execute(), ShouldBeNil)
+				keys = listKeys("filter-anchored/")
+				So(keys["docs/a.txt"], ShouldBeFalse)
+				So(keys["build/out.o"], ShouldBeFalse)
+				So(keys["sub/docs/b.txt"], ShouldBeTrue)
+				So(keys["sub/build/x.o"], ShouldBeTrue)
+				So(keys["keep/a.txt"], ShouldBeTrue)
+
+				// 下载时按去掉源路径前缀后的对象键匹配
+				downloadDir := fmt.Sprintf("%s/download-anchored", testDir)
+				clearCmd()
+				cmd = rootCmd
+				cmd.SetArgs([]string{"cp", fmt.Sprintf("cos://%s/%s", testAlias1, "filter-anchored/"), downloadDir, "-r",
+					"--filter-syntax", "glob", "--exclude", "/keep/**"})
+				So(cmd.Execute(), ShouldBeNil)
+				_, err := os.Stat(filepath.Join(downloadDir, "keep/a.txt"))
+				So(os.IsNotExist(err), ShouldBeTrue)
+				_, err = os.Stat(filepath.Join(downloadDir, "sub/build/x.o"))
+				So(err, ShouldBeNil)
+			})
+			Convey("按大小、修改时间与存储类型上传和下载多个小文件", func() {
+				localFileName := fmt.Sprintf("%s/small-file", testDir)
+				cosFileName := fmt.Sprintf("cos://%s/%s", testAlias1, "filter-meta")
+				c1, _ := util.NewClient(&config, &param, testAlias1)
+				countObjects := func(prefix string) int {
+					res, _, err := c1.Bucket.Get(context.Background(), &cos.BucketGetOptions{Prefix: prefix})
+					So(err, ShouldBeNil)
+					return len(res.Contents)
+				}
+
+				// 小文件均为 30KB
+				for _, filterArgs := range [][]string{
+					{"--min-size", "1MiB"},
+					{"--max-size", "10KB"},
+					{"--older-than", "1h"},
+				} {
+					clearCmd()
+					cmd := rootCmd
+					cmd.SetArgs(append([]string{"cp", localFileName, cosFileName, "-r"}, filterArgs...))
+					e := cmd.Execute()
+					So(e, ShouldBeNil)
+					So(countObjects("filter-meta/"), ShouldEqual, 0)
+				}
+
+				clearCmd()
+				cmd := rootCmd
+				cmd.SetArgs([]string{"cp", localFileName, cosFileName, "-r", "--min-size", "10KB", "--max-size", "1MB", "--newer-than", "1d"})
+				e := cmd.Execute()
+				So(e, ShouldBeNil)
+				So(countObjects("filter-meta/"), ShouldEqual, 3)
+
+				downloadDir := fmt.Sprintf("%s/filter-meta", testDir)
+				defer os.RemoveAll(downloadDir)
+				clearCmd()
+				cmd = rootCmd
+				cmd.SetArgs([]string{"cp", cosFileName, downloadDir, "-r", "--storage-class-filter", "ARCHIVE,DEEP_ARCHIVE"})
+				e = cmd.Execute()
+				So(e, ShouldBeNil)
+				files, _ := ioutil.ReadDir(downloadDir)
+				So(len(files), ShouldEqual, 0)
+
+				clearCmd()
+				cmd = rootCmd
+				cmd.SetArgs([]string{"cp", cosFileName, downloadDir, "-r", "--storage-class-filter", "standard", "--newer-than", "2006-01-02"})
+				e = cmd.Execute()
+				So(e, ShouldBeNil)
+				files, _ = ioutil.ReadDir(downloadDir)
+				So(len(files), ShouldEqual, 3)
+			})
+			Convey("按清单上传和下载多个小文件", func() {
+				localFileName := fmt.Sprintf("%s/small-file", testDir)
+				cosFileName := fmt.Sprintf("cos://%s/%s", testAlias1, "files-from/")
+				listFile := fmt.Sprintf("%s/files-from-list", testDir)
+				ioutil.WriteFile(listFile, []byte("0\n\"2\"\n"), 0644)
+				clearCmd()
+				cmd := rootCmd
+				cmd.SetArgs([]string{"cp", localFileName, cosFileName, "-r", "--files-from", listFile})
+				e := cmd.Execute()
+				So(e, ShouldBeNil)
+				c1, _ := util.NewClient(&config, &param, testAlias1)
+				res, _, err := c1.Bucket.Get(context.Background(), &cos.BucketGetOptions{Prefix: "files-from/"})
+				So(err, ShouldBeNil)
+				// 源目录名与普通的递归上传一样保留在目标路径中
+				So(len(res.Contents), ShouldEqual, 2)
+
+				downloadDir := fmt.Sprintf("%s/files-from-download/", testDir)
+				defer os.RemoveAll(downloadDir)
+				ioutil.WriteFile(listFile, []byte("key,version_id\nsmall-file/2,\n"), 0644)
+				clearCmd()
+				cmd = rootCmd
+				cmd.SetArgs([]string{"cp", cosFileName, downloadDir, "-r", "--files-from", listFile})
+				e = cmd.Execute()
+				So(e, ShouldBeNil)
+				files, _ := ioutil.ReadDir(downloadDir + "small-file")
+				So(len(files), ShouldEqual, 1)
+				So(files[0].Name(), ShouldEqual, "2")
+			})
+			Convey("dry-run上传多个小文件", func() {
+				clearCmd()
+				cmd := rootCmd
+				localFileName := fmt.Sprintf("%s/small-file", testDir)
+				cosFileName := fmt.Sprintf("cos://%s/%s", testAlias1, "multi-dry-run")
+				args := []string{"cp", localFileName, cosFileName, "-r", "--dry-run", "--output", "json"}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				So(e, ShouldBeNil)
+				// dry-run 不上传文件
+				c1, _ := util.NewClient(&config, &param, testAlias1)
+				res, _, err := c1.Bucket.Get(context.Background(), &cos.BucketGetOptions{Prefix: "multi-dry-run"})
+				So(err, ShouldBeNil)
+				So(len(res.Contents), ShouldEqual, 0)
+			})
+			Convey("断点续传上传多个小文件", func() {
+				localFileName := fmt.Sprintf("%s/small-file", testDir)
+				cosFileName := fmt.Sprintf("cos://%s/%s", testAlias1, "multi-resume")
+				checkpointDir := fmt.Sprintf("%s/checkpoint", testDir)
+				// 保留任务日志，模拟任务中断
+				patches := ApplyFunc(util.FinishCheckpoint, func(fo *util.FileOperations) {})
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"cp", localFileName, cosFileName, "-r", "--checkpoint-dir", checkpointDir}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				patches.Reset()
+				So(e, ShouldBeNil)
+
+				// 删除一个已上传的对象，续传时已完成的文件不再上传
+				c1, _ := util.NewClient(&config, &param, testAlias1)
+				res, _, err := c1.Bucket.Get(context.Background(), &cos.BucketGetOptions{Prefix: "multi-resume/"})
+				So(err, ShouldBeNil)
+				So(len(res.Contents), ShouldBeGreaterThan, 0)
+				key := res.Contents[0].Key
+				_, err = c1.Object.Delete(context.Background(), key)
+				So(err, ShouldBeNil)
+				clearCmd()
+				cmd = rootCmd
+				args = []string{"cp", localFileName, cosFileName, "-r", "--checkpoint-dir", checkpointDir, "--resume"}
+				cmd.SetArgs(args)
+				e = cmd.Execute()
+				So(e, ShouldBeNil)
+				_, err = c1.Object.Head(context.Background(), key, nil)
+				So(err, ShouldBeError)
+
+				// 不指定 --resume 时重新上传
+				clearCmd()
+				cmd = rootCmd
+				args = []string{"cp", localFileName, cosFileName, "-r", "--checkpoint-dir", checkpointDir}
+				cmd.SetArgs(args)
+				e = cmd.Execute()
+				So(e, ShouldBeNil)
+				_, err = c1.Object.Head(context.Background(), key, nil)
+				So(err, ShouldBeNil)
+			})
 		})
 		Convey("Copy", func() {
 			Convey("桶内拷贝单个文件", func() {
@@ -243,6 +469,56 @@ func TestCpCmd(t *testing.T) {
 				e := cmd.Execute()
 				So(e, ShouldBeNil)
 			})
+			Convey("断点续传下载时从列举位置继续列举", func() {
+				if testServer == nil {
+					return
+				}
+				// 每页列出两个对象：a b | c d | e
+				testServer.MaxKeys = 2
+				defer func() { testServer.MaxKeys = 0 }()
+				c1, _ := util.NewClient(&config, &param, testAlias1)
+				for _, name := range []string{"a", "b", "c", "d", "e"} {
+					opt := &cos.ObjectPutOptions{ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{}}
+					if name == "d" {
+						// 归档对象无法直接下载，第二页不能完成
+						opt.XCosStorageClass = "ARCHIVE"
+					}
+					_, err := c1.Object.Put(context.Background(), "list-resume/"+name, strings.NewReader("old "+name), opt)
+					So(err, ShouldBeNil)
+				}
+				localDir := fmt.Sprintf("%s/download/list-resume", testDir)
+				cosDir := fmt.Sprintf("cos://%s/%s", testAlias1, "list-resume/")
+				checkpointDir := fmt.Sprintf("%s/list-checkpoint", testDir)
+				// 下载失败时 cp 以退出码 2 退出，这里只记录退出码
+				exitCode := 0
+				patches := ApplyFunc(os.Exit, func(code int) {
+					exitCode = code
+				})
+				defer patches.Reset()
+				clearCmd()
+				cmd := rootCmd
+				cmd.SetArgs([]string{"cp", cosDir, localDir, "-r", "--checkpoint-dir", checkpointDir})
+				cmd.Execute()
+				So(exitCode, ShouldEqual, 2)
+				_, err := os.Stat(filepath.Join(localDir, "d"))
+				So(os.IsNotExist(err), ShouldBeTrue)
+
+				// 第一页之前的对象在续传时不再列出，修改后也不会重新下载
+				_, err = c1.Object.Put(context.Background(), "list-resume/a", strings.NewReader("new content of a"), nil)
+				So(err, ShouldBeNil)
+				_, err = c1.Object.Put(context.Background(), "list-resume/d", strings.NewReader("new d"), nil)
+				So(err, ShouldBeNil)
+				clearCmd()
+				cmd = rootCmd
+				cmd.SetArgs([]string{"cp", cosDir, localDir, "-r", "--checkpoint-dir", checkpointDir, "--resume"})
+				So(cmd.Execute(), ShouldBeNil)
+				data, err := ioutil.ReadFile(filepath.Join(localDir, "a"))
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, "old a")
+				data, err = ioutil.ReadFile(filepath.Join(localDir, "d"))
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, "new d")
+			})
 		})
 		Convey("fail", func() {
 			Convey("Not enough argument", func() {
@@ -342,12 +618,62 @@ func TestCpCmd(t *testing.T) {
 				fmt.Printf(" : %v", e)
 				So(e, ShouldBeError)
 			})
+			Convey("invalid filter", func() {
+				for _, args := range [][]string{
+					{"cp", "./abc", "cos://123", "-r", "--include", "("},
+					{"cp", "./abc", "cos://123", "-r", "--include", "abc", "--filter-syntax", "shell"},
+					{"cp", "./abc", "cos://123", "-r", "--exclude", "*.keep", "--include", ""},
+					{"rm", "cos://123/abc/", "-r", "--exclude", ""},
+					{"cp", "./abc", "cos://123", "-r", "--filter-from", "./not-exist-filter-file"},
+					{"cp", "./abc", "cos://123", "--files-from", "./not-exist-list"},
+					{"cp", "./abc", "cos://123", "-r", "--files-from", "./not-exist-list"},
+					{"cp", "./abc", "cos://123", "-r", "--min-size", "abc"},
+					{"cp", "./abc", "cos://123", "-r", "--min-size", "2MB", "--max-size", "1MB"},
+					{"cp", "./abc", "cos://123", "-r", "--newer-than", "yesterday"},
+					{"cp", "./abc", "cos://123", "-r", "--newer-than", "1d", "--older-than", "2d"},
+				} {
+					clearCmd()
+					cmd := rootCmd
+					cmd.SetArgs(args)
+					e := cmd.Execute()
+					fmt.Printf(" : %v", e)
+					So(e, ShouldBeError)
+				}
+			})
+			Convey("resume without checkpoint-dir", func() {
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"cp", "./abc", "cos://123", "-r", "--resume"}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			})
+			Convey("no -r but checkpoint-dir", func() {
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"cp", "./abc", "cos://123", "--checkpoint-dir", "./checkpoint"}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			})
+			Convey("no -r but --min-size", func() {
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"cp", "./abc", "cos://123", "--min-size", "1KB"}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+				So(e.Error(), ShouldContainSubstring, "--min-size")
+			})
 			Convey("no -r but -i", func() {
-				patches := ApplyFunc(util.GetFilter, func(string, string) (bool, []util.FilterOptionType) {
+				patches := ApplyFunc(util.GetFilter, func([]util.FilterRule, string, string) ([]util.FilterOptionType, error) {
 					tmp := []util.FilterOptionType{
 						{},
 					}
-					return true, tmp
+					return tmp, nil
 				})
 				defer patches.Reset()
 				clearCmd()
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"coscli/util"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+// 模拟 CVM 元数据服务，按角色返回临时密钥
+type testMetadataServer struct {
+	mu        sync.Mutex
+	hits      map[string]int
+	expiresIn map[string]time.Duration
+	fail      map[string]bool
+}
+
+func (s *testMetadataServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	role := strings.TrimPrefix(r.URL.Path, "/meta-data/cam/security-credentials/")
+	s.hits[role]++
+	data := util.Data{Code: "Success"}
+	if s.fail[role] {
+		data.Code = "Failed"
+	} else {
+		expiredTime := time.Now().Add(s.expiresIn[role])
+		data.TmpSecretId = testSecretID
+		data.TmpSecretKey = testSecretKey
+		data.Token = fmt.Sprintf("token-%s-%d", role, s.hits[role])
+		data.ExpiredTime = int(expiredTime.Unix())
+		data.Expiration = expiredTime.UTC().Format(time.RFC3339)
+	}
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func (s *testMetadataServer) Hits(role string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[role]
+}
+
+func TestCvmRole(t *testing.T) {
+	fmt.Println("TestCvmRole")
+	if testServer == nil {
+		t.Skip("the metadata service is only available in the offline cos service")
+	}
+	dir, err := ioutil.TempDir("", "coscli-cvm-role")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	viper.Set("cos", nil)
+	defer func() {
+		cfgFile = ""
+		viper.Set("cos", nil)
+		config = util.Config{}
+		fileConfig = util.Config{}
+		getConfig()
+	}()
+
+	metadata := &testMetadataServer{
+		hits: make(map[string]int),
+		expiresIn: map[string]time.Duration{
+			"long":    time.Hour,
+			"short":   time.Minute,
+			"refresh": time.Minute,
+		},
+		fail: map[string]bool{"bad": true},
+	}
+	metadataServer := httptest.NewServer(metadata)
+	defer metadataServer.Close()
+
+	// 每个角色使用单独的配置文件
+	writeConfig := func(role string) string {
+		configFile := filepath.Join(dir, role+".yaml")
+		content := fmt.Sprintf(`cos:
+  base:
+    protocol: http
+    mode: CvmRole
+    cvmrolename: %s
+    camurl: %s/meta-data/cam/security-credentials/
+    disableencryption: "true"
+    proxy: %s
+  buckets:
+  - name: coscli-test-%s
+    alias: coscli-test
+    endpoint: %s
+`, role, metadataServer.URL, testProxy, testAppID, testEndpoint)
+		if err := ioutil.WriteFile(configFile, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return configFile
+	}
+
+	localDir := filepath.Join(dir, "upload")
+	os.MkdirAll(localDir, 0755)
+	for i := 0; i < 3; i++ {
+		genFile(filepath.Join(localDir, fmt.Sprintf("file%d", i)), 1024)
+	}
+
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	Convey("Test coscli with cvm role", t, func() {
+		Convey("credential shared by clients", func() {
+			configFile := writeConfig("long")
+			for i := 0; i < 2; i++ {
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"ls", "cos://coscli-test", "-c", configFile}
+				cmd.SetArgs(args)
+				So(cmd.Execute(), ShouldBeNil)
+			}
+			So(metadata.Hits("long"), ShouldEqual, 1)
+		})
+		Convey("refresh before expired", func() {
+			// 临时密钥在刷新窗口内，每次请求前都会重新获取
+			configFile := writeConfig("short")
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"cp", localDir, "cos://coscli-test/cvm-role/", "-r", "-c", configFile}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			So(metadata.Hits("short"), ShouldBeGreaterThan, 3)
+		})
+		Convey("keep using the credential when refresh failed", func() {
+			configFile := writeConfig("refresh")
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"ls", "cos://coscli-test", "-c", configFile}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+
+			metadata.mu.Lock()
+			metadata.fail["refresh"] = true
+			metadata.mu.Unlock()
+			hits := metadata.Hits("refresh")
+			clearCmd()
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			So(metadata.Hits("refresh"), ShouldBeGreaterThan, hits)
+		})
+		Convey("fail to get credential", func() {
+			configFile := writeConfig("bad")
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"ls", "cos://coscli-test", "-c", configFile}
+			cmd.SetArgs(args)
+			e := cmd.Execute()
+			fmt.Printf(" : %v", e)
+			So(e, ShouldBeError)
+		})
+	})
+}
@@ -18,10 +18,14 @@ Example:
   ./coscli du cos://examplebucket/test/`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		include, _ := cmd.Flags().GetString("include")
-		exclude, _ := cmd.Flags().GetString("exclude")
 		allVersions, _ := cmd.Flags().GetBool("all-versions")
-		_, filters := util.GetFilter(include, exclude)
+		filters, err := getFilters(cmd)
+		if err != nil {
+			return err
+		}
+		if err := initOutputFormat(); err != nil {
+			return err
+		}
 
 		cosPath := args[0]
 		cosUrl, err := util.FormatUrl(cosPath)
@@ -56,7 +60,7 @@ Example:
 
 func init() {
 	rootCmd.AddCommand(duCmd)
-	duCmd.Flags().String("include", "", "List files that meet the specified criteria")
-	duCmd.Flags().String("exclude", "", "Exclude files that meet the specified criteria")
+	addFilterFlags(duCmd)
+	addMetaFilterFlags(duCmd)
 	duCmd.Flags().BoolP("all-versions", "", false, "List all versions of objects, only available if bucket versioning is enabled.")
 }
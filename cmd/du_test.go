@@ -57,6 +57,16 @@ func TestDuCmd(t *testing.T) {
 				e := cmd.Execute()
 				So(e, ShouldBeNil)
 			})
+			Convey("duBucket --output", func() {
+				for _, format := range []string{"json", "jsonl", "csv"} {
+					clearCmd()
+					cmd := rootCmd
+					args = []string{"du", fmt.Sprintf("cos://%s", testAlias), "--output", format}
+					cmd.SetArgs(args)
+					e := cmd.Execute()
+					So(e, ShouldBeNil)
+				}
+			})
 			Convey("duCosObjects", func() {
 				clearCmd()
 				cmd := rootCmd
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// filterValue 记录 --include/--exclude 规则，两个参数共用一个列表以保留规则出现的顺序
+type filterValue struct {
+	name  string
+	rules *[]util.FilterRule
+}
+
+func (v *filterValue) String() string {
+	if v.rules == nil {
+		return ""
+	}
+	patterns := make([]string, 0)
+	for _, rule := range *v.rules {
+		if rule.Name == v.name {
+			patterns = append(patterns, rule.Pattern)
+		}
+	}
+	return strings.Join(patterns, ",")
+}
+
+// Set 追加一条规则，规则不能为空
+func (v *filterValue) Set(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("the pattern of %s can not be empty", v.name)
+	}
+	*v.rules = append(*v.rules, util.FilterRule{Name: v.name, Pattern: pattern})
+	return nil
+}
+
+func (v *filterValue) Type() string {
+	return "stringArray"
+}
+
+// 注册 --include、--exclude、--filter-syntax 与 --filter-from
+func addFilterFlags(cmd *cobra.Command) {
+	rules := &[]util.FilterRule{}
+	cmd.Flags().Var(&filterValue{name: util.IncludePrompt, rules: rules}, "include",
+		"Include files that meet the specified criteria, can be repeated. Rules of --include and --exclude are applied in the given order, the first matching rule wins")
+	cmd.Flags().Var(&filterValue{name: util.ExcludePrompt, rules: rules}, "exclude",
+		"Exclude files that meet the specified criteria, can be repeated. Rules of --include and --exclude are applied in the given order, the first matching rule wins")
+	cmd.Flags().String("filter-syntax", util.FilterSyntaxRegex,
+		"Syntax of --include and --exclude(regex or glob). glob supports *, ? and **, a single rule can also be prefixed with glob: or regex:. Rules are matched against the path relative to the source path, a glob containing / is anchored at the source path")
+	cmd.Flags().String("filter-from", "",
+		"Read rules in .gitignore syntax from the file, they are applied after --include and --exclude")
+}
+
+// 注册 --files-from
+func addFilesFromFlag(cmd *cobra.Command) {
+	cmd.Flags().String("files-from", "",
+		"Read the paths to operate on from the file(- for stdin) instead of listing the source path. One path relative to the source path per line, or CSV lines of key,version_id")
+}
+
+// 注册按大小、修改时间与存储类型过滤的参数
+func addMetaFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().String("min-size", "", "Only include files or objects whose size is not less than the given size, such as 100, 10KB, 1GiB")
+	cmd.Flags().String("max-size", "", "Only include files or objects whose size is not greater than the given size, such as 100, 10KB, 1GiB")
+	cmd.Flags().String("newer-than", "", "Only include files or objects modified after the given time, a duration(30m, 12h, 7d, 2w) before now or a timestamp(2006-01-02T15:04:05Z, 2006-01-02)")
+	cmd.Flags().String("older-than", "", "Only include files or objects modified before the given time, a duration(30m, 12h, 7d, 2w) before now or a timestamp(2006-01-02T15:04:05Z, 2006-01-02)")
+	cmd.Flags().String("storage-class-filter", "", "Only include objects of the given storage classes, separated by commas, such as ARCHIVE,DEEP_ARCHIVE. Local files are not filtered by storage class")
+}
+
+// 过滤规则与元数据过滤条件都只在递归拷贝或同步时生效
+func checkFiltersRecursive(fo *util.FileOperations) error {
+	if !fo.Operation.Recursive && len(fo.Operation.Filters) > 0 {
+		return fmt.Errorf("--include, --exclude, --filter-from, --min-size, --max-size, --newer-than, --older-than and --storage-class-filter only work with --recursive")
+	}
+	return nil
+}
+
+// 按顺序生成过滤规则，命令注册了元数据过滤参数时追加元数据过滤条件
+func getFilters(cmd *cobra.Command) ([]util.FilterOptionType, error) {
+	var rules []util.FilterRule
+	if flag := cmd.Flags().Lookup("include"); flag != nil {
+		rules = *flag.Value.(*filterValue).rules
+	}
+	syntax, _ := cmd.Flags().GetString("filter-syntax")
+	filterFrom, _ := cmd.Flags().GetString("filter-from")
+	filters, err := util.GetFilter(rules, syntax, filterFrom)
+	if err != nil || cmd.Flags().Lookup("min-size") == nil {
+		return filters, err
+	}
+
+	var opt util.MetaFilterOptions
+	opt.MinSize, _ = cmd.Flags().GetString("min-size")
+	opt.MaxSize, _ = cmd.Flags().GetString("max-size")
+	opt.NewerThan, _ = cmd.Flags().GetString("newer-than")
+	opt.OlderThan, _ = cmd.Flags().GetString("older-than")
+	storageClasses, _ := cmd.Flags().GetString("storage-class-filter")
+	if storageClasses != "" {
+		opt.StorageClasses = strings.Split(storageClasses, ",")
+	}
+	metaFilters, err := util.GetMetaFilter(opt)
+	if err != nil {
+		return nil, err
+	}
+	return append(filters, metaFilters...), nil
+}
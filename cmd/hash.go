@@ -4,10 +4,12 @@ import (
 	"coscli/util"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	logger "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/tencentyun/cos-go-sdk-v5"
 )
 
 var hashCmd = &cobra.Command{
@@ -17,16 +19,25 @@ var hashCmd = &cobra.Command{
 
 Format:
   ./coscli hash <file-path> [--type <hash-type>]
+  ./coscli hash <dir-path> --files-from <list-file> [--type <hash-type>]
 
 Example:
-  ./coscli hash cos://example --type md5`,
+  ./coscli hash cos://example --type md5
+  ./coscli hash ~/example.txt --type sha256
+  ./coscli hash cos://example/dir/ --files-from keys.txt`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		bucketName, path := util.ParsePath(args[0])
 		hashType, _ := cmd.Flags().GetString("type")
 		hashType = strings.ToLower(hashType)
-		var err error
-		if bucketName != "" {
+		filesFrom, _ := cmd.Flags().GetString("files-from")
+		err := initOutputFormat()
+		if err != nil {
+			return err
+		}
+		if filesFrom != "" {
+			err = hashFileList(bucketName, path, hashType, filesFrom)
+		} else if bucketName != "" {
 			err = showHash(bucketName, path, hashType)
 		} else {
 			_, err = calculateHash(path, hashType)
@@ -39,7 +50,8 @@ Example:
 func init() {
 	rootCmd.AddCommand(hashCmd)
 
-	hashCmd.Flags().StringP("type", "", "crc64", "Choose the hash type(md5 or crc64)")
+	hashCmd.Flags().StringP("type", "", "crc64", "Choose the hash type(md5, crc64 or sha256). The SHA-256 of an object is the one recorded by uploading with --sha256")
+	addFilesFromFlag(hashCmd)
 }
 
 func showHash(bucketName string, path string, hashType string) error {
@@ -47,33 +59,68 @@ func showHash(bucketName string, path string, hashType string) error {
 	if err != nil {
 		return err
 	}
+	summary := &util.HashSummary{
+		Path:     util.SchemePrefix + bucketName + "/" + path,
+		Source:   "cos",
+		HashType: hashType,
+	}
 	switch hashType {
 	case "crc64":
 		h, _, _, err := util.ShowHash(c, path, "crc64")
 		if err != nil {
 			return err
 		}
+		if !util.IsTableOutput() {
+			summary.Hash = h
+			return util.PrintSummary(summary)
+		}
 		logger.Infoln("crc64-ecma:  ", h)
 	case "md5":
 		h, b, _, err := util.ShowHash(c, path, "md5")
 		if err != nil {
 			return err
 		}
+		if !util.IsTableOutput() {
+			summary.Hash = h
+			summary.Base64 = b
+			return util.PrintSummary(summary)
+		}
 		logger.Infoln("md5:    ", h)
 		logger.Infoln("base64: ", b)
+	case "sha256":
+		h, b, _, err := util.ShowHash(c, path, "sha256")
+		if err != nil {
+			return err
+		}
+		if !util.IsTableOutput() {
+			summary.Hash = h
+			summary.Base64 = b
+			return util.PrintSummary(summary)
+		}
+		logger.Infoln("sha256: ", h)
+		logger.Infoln("base64: ", b)
 	default:
-		return fmt.Errorf("--type can only be selected between MD5 and CRC64")
+		return fmt.Errorf("--type can only be selected between MD5, CRC64 and SHA256")
 	}
 	return nil
 }
 
 func calculateHash(path string, hashType string) (h string, err error) {
+	summary := &util.HashSummary{
+		Path:     path,
+		Source:   "local",
+		HashType: hashType,
+	}
 	switch hashType {
 	case "crc64":
 		h, _, err = util.CalculateHash(path, "crc64")
 		if err != nil {
 			return "", err
 		}
+		if !util.IsTableOutput() {
+			summary.Hash = h
+			return h, util.PrintSummary(summary)
+		}
 		logger.Infoln("crc64-ecma:  ", h)
 	case "md5":
 		f, err := os.Stat(path)
@@ -90,10 +137,111 @@ func calculateHash(path string, hashType string) (h string, err error) {
 			return "", err
 		}
 		h = hash
+		if !util.IsTableOutput() {
+			summary.Hash = h
+			summary.Base64 = b
+			return h, util.PrintSummary(summary)
+		}
 		logger.Infof("md5:     %s\n", h)
 		logger.Infoln("base64: ", b)
+	case "sha256":
+		h, b, err := util.CalculateHash(path, "sha256")
+		if err != nil {
+			return "", err
+		}
+		if !util.IsTableOutput() {
+			summary.Hash = h
+			summary.Base64 = b
+			return h, util.PrintSummary(summary)
+		}
+		logger.Infoln("sha256: ", h)
+		logger.Infoln("base64: ", b)
+		return h, nil
 	default:
-		return "", fmt.Errorf("--type can only be selected between MD5 and CRC64")
+		return "", fmt.Errorf("--type can only be selected between MD5, CRC64 and SHA256")
 	}
 	return h, err
 }
+
+// 逐个计算 --files-from 清单中文件或对象的哈希值，清单中的路径相对于 dirPath
+func hashFileList(bucketName string, dirPath string, hashType string, filesFrom string) error {
+	if hashType != "crc64" && hashType != "md5" && hashType != "sha256" {
+		return fmt.Errorf("--type can only be selected between MD5, CRC64 and SHA256")
+	}
+	entries, err := util.ReadFileList(filesFrom)
+	if err != nil {
+		return err
+	}
+
+	var c *cos.Client
+	if bucketName != "" {
+		c, err = util.NewClient(&config, &param, bucketName)
+		if err != nil {
+			return err
+		}
+	}
+
+	var writer *util.RecordWriter
+	if !util.IsTableOutput() {
+		writer = util.NewRecordWriter(os.Stdout)
+	}
+	failed := 0
+	for _, entry := range entries {
+		summary := &util.HashSummary{HashType: hashType, VersionId: entry.VersionId}
+		if bucketName != "" {
+			key := strings.TrimPrefix(entry.Key, "/")
+			if dirPath != "" && !strings.HasSuffix(dirPath, "/") {
+				key = dirPath + "/" + key
+			} else {
+				key = dirPath + key
+			}
+			summary.Path = util.SchemePrefix + bucketName + "/" + key
+			summary.Source = "cos"
+			summary.Hash, summary.Base64, _, err = util.ShowObjectHash(c, key, hashType, entry.VersionId)
+		} else if entry.VersionId != "" {
+			summary.Path = filepath.Join(dirPath, entry.Key)
+			err = fmt.Errorf("version id is not supported for local file")
+		} else {
+			summary.Path = filepath.Join(dirPath, entry.Key)
+			summary.Source = "local"
+			summary.Hash, summary.Base64, err = calculateListHash(summary.Path, hashType)
+		}
+		if err != nil {
+			failed++
+			logger.Errorf("Hash %s failed: %v", summary.Path, err)
+			continue
+		}
+
+		if writer != nil {
+			if err = writer.Write(summary); err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("%s  %s\n", summary.Hash, summary.Path)
+		}
+	}
+	if writer != nil {
+		if err = writer.Close(); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed to hash", failed, len(entries))
+	}
+	return nil
+}
+
+// 与单个文件相同，md5 不支持 32MB 以上的文件
+func calculateListHash(path string, hashType string) (string, string, error) {
+	if hashType == "md5" {
+		f, err := os.Stat(path)
+		if err != nil {
+			return "", "", err
+		}
+		if (float64(f.Size()) / 1024 / 1024) > 32 {
+			return "", "", fmt.Errorf("MD5 of large files is not supported")
+		}
+	}
+	return util.CalculateHash(path, hashType)
+}
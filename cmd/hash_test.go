@@ -3,6 +3,7 @@ package cmd
 import (
 	"coscli/util"
 	"fmt"
+	"io/ioutil"
 	"testing"
 
 	. "github.com/agiledragon/gomonkey/v2"
@@ -46,6 +47,29 @@ func TestHashCmd(t *testing.T) {
 				So(e, ShouldBeNil)
 			})
 		})
+		Convey("files-from", func() {
+			listFile := fmt.Sprintf("%s/hash-files-from", testDir)
+			ioutil.WriteFile(listFile, []byte("0\n1\n"), 0644)
+			for _, args := range [][]string{
+				{"hash", localFileName, "--files-from", listFile},
+				{"hash", localFileName, "--files-from", listFile, "--type", "md5", "--output", "csv"},
+				{"hash", cosFileName, "--files-from", listFile, "--output", "json"},
+			} {
+				clearCmd()
+				cmd := rootCmd
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				So(e, ShouldBeNil)
+			}
+
+			ioutil.WriteFile(listFile, []byte("0\nnot-exist\n"), 0644)
+			clearCmd()
+			cmd := rootCmd
+			cmd.SetArgs([]string{"hash", cosFileName, "--files-from", listFile})
+			e := cmd.Execute()
+			fmt.Printf(" : %v", e)
+			So(e, ShouldBeError)
+		})
 		Convey("cos file", func() {
 			Convey("crc64", func() {
 				clearCmd()
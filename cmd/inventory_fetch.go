@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var inventoryFetchCmd = &cobra.Command{
+	Use:   "inventory-fetch",
+	Short: "Download the latest inventory report of a bucket",
+	Long: `Download the latest inventory report of a bucket
+
+Format:
+	./coscli inventory-fetch cos://<bucket-name> <local_path> --id <id> [flags]
+
+The destination of the inventory is got by bucket-inventory, and the latest manifest.json
+with the CSV.gz files listed in it are downloaded to local_path, keeping their paths
+relative to <destination_prefix>/<appid>/<bucket-name>/<id>/ in the destination bucket.
+
+Example:
+	./coscli inventory-fetch cos://examplebucket ./inventory --id daily`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+			return err
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, _ := cmd.Flags().GetString("id")
+		routines, _ := cmd.Flags().GetInt("routines")
+		threadNum, _ := cmd.Flags().GetInt("thread-num")
+		partSize, _ := cmd.Flags().GetInt64("part-size")
+		rateLimiting, _ := cmd.Flags().GetFloat32("rate-limiting")
+		failOutput, _ := cmd.Flags().GetBool("fail-output")
+		failOutputPath, _ := cmd.Flags().GetString("fail-output-path")
+		if id == "" {
+			return fmt.Errorf("--id is required")
+		}
+		if routines < 1 {
+			return fmt.Errorf("--routines must be greater than 0")
+		}
+
+		cosUrl, err := util.FormatUrl(args[0])
+		if err != nil {
+			return fmt.Errorf("cos url format error:%v", err)
+		}
+		if !cosUrl.IsCosUrl() {
+			return fmt.Errorf("cospath needs to contain %s", util.SchemePrefix)
+		}
+		if cosUrl.(*util.CosUrl).Object != "" {
+			return fmt.Errorf("inventory-fetch only works with buckets")
+		}
+		fileUrl, err := util.FormatUrl(args[1])
+		if err != nil {
+			return fmt.Errorf("format local path error,%v", err)
+		}
+		if !fileUrl.IsFileUrl() {
+			return fmt.Errorf("local_path can not be a cos path")
+		}
+
+		fo := &util.FileOperations{
+			Operation: util.Operation{
+				Recursive:      true,
+				RateLimiting:   rateLimiting,
+				PartSize:       partSize,
+				ThreadNum:      threadNum,
+				Routines:       routines,
+				FailOutput:     failOutput,
+				FailOutputPath: failOutputPath,
+			},
+			Monitor:    &util.FileProcessMonitor{},
+			Config:     &config,
+			Param:      &param,
+			ErrOutput:  &util.ErrOutput{},
+			CpType:     util.CpTypeDownload,
+			Command:    util.CommandCP,
+			BucketType: "COS",
+		}
+		err = util.CheckPath(fileUrl, fo, util.TypeFailOutputPath)
+		if err != nil {
+			return err
+		}
+
+		c, err := util.NewClient(&config, &param, cosUrl.(*util.CosUrl).Bucket)
+		if err != nil {
+			return err
+		}
+		inventory, err := util.GetInventory(c, id)
+		if err != nil {
+			return err
+		}
+		// 清单报告目录使用存储桶的完整名称，命令行中可能是别名
+		bucket, _, _ := util.FindBucket(&config, cosUrl.(*util.CosUrl).Bucket)
+		prefix := util.InventoryReportPrefix(inventory, bucket.Name)
+
+		destClient, err := newInventoryDestinationClient(inventory, fo)
+		if err != nil {
+			return err
+		}
+		manifestKey, manifest, err := util.LatestInventoryManifest(destClient, prefix)
+		if err != nil {
+			return err
+		}
+
+		startT := time.Now().UnixNano() / 1000 / 1000
+		logger.Infof("Fetch inventory %s of %s from cos://%s/%s to %s start", id, args[0], inventory.DestinationBucket, manifestKey, args[1])
+		err = util.FetchInventory(destClient, inventory.DestinationBucket, prefix, manifestKey, manifest, fileUrl.ToString(), fo)
+		util.CloseErrorOutputFile(fo)
+		if err != nil {
+			return err
+		}
+		endT := time.Now().UnixNano() / 1000 / 1000
+		util.PrintCostTime(startT, endT)
+
+		if fo.Monitor.ErrNum > 0 {
+			return fmt.Errorf("fetch inventory %s of %s %s", id, args[0], fo.Monitor.GetFinishInfo())
+		}
+		logger.Infof("Fetch inventory %s of %s %s, the file schema is: %s", id, args[0], fo.Monitor.GetFinishInfo(), manifest.FileSchema)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(inventoryFetchCmd)
+
+	inventoryFetchCmd.Flags().String("id", "", "The id of the inventory")
+	inventoryFetchCmd.Flags().Float32("rate-limiting", 0, "Download speed limit(MB/s)")
+	inventoryFetchCmd.Flags().Int64("part-size", 32, "Specifies the block size(MB)")
+	inventoryFetchCmd.Flags().Int("thread-num", 5, "Specifies the number of partition concurrent download threads")
+	inventoryFetchCmd.Flags().Int("routines", 3, "Specifies the number of files concurrent download threads")
+	inventoryFetchCmd.Flags().Bool("fail-output", true, "This option determines whether the error output for failed file downloads is enabled. If enabled, the error messages will be recorded in a file within the specified directory (if not specified, the default is coscli_output).")
+	inventoryFetchCmd.Flags().String("fail-output-path", "coscli_output", "This option specifies the error output folder where the error messages for failed file downloads will be recorded.")
+}
+
+// 清单目标存储桶不在配置文件中且未指定 --endpoint 时，按清单配置中的地域访问
+func newInventoryDestinationClient(inventory *util.InventoryConfig, fo *util.FileOperations) (*cos.Client, error) {
+	if _, i, _ := util.FindBucket(&config, inventory.DestinationBucket); i >= 0 || param.Endpoint != "" {
+		return util.NewClient(&config, &param, inventory.DestinationBucket, fo)
+	}
+	destParam := param
+	destParam.Endpoint = fmt.Sprintf("cos.%s.myqcloud.com", inventory.DestinationRegion)
+	return util.NewClient(&config, &destParam, inventory.DestinationBucket, fo)
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var lifecycleCmd = &cobra.Command{
+	Use:   "lifecycle",
+	Short: "Modify bucket lifecycle",
+	Long: `Modify bucket lifecycle
+
+Format:
+	./coscli lifecycle --method [method] cos://<bucket-name> [flags]
+
+The rules file is in YAML(.yaml/.yml) or JSON(.json) format, for example:
+	rules:
+	  - id: archive-logs
+	    status: Enabled
+	    prefix: logs/
+	    tags:
+	      - key: env
+	        value: test
+	    transitions:
+	      - days: 30
+	        storage_class: STANDARD_IA
+	      - days: 90
+	        storage_class: ARCHIVE
+	    expiration:
+	      days: 365
+	    noncurrent_version_expiration_days: 30
+	    abort_incomplete_multipart_upload_days: 7
+
+Example:
+	./coscli lifecycle --method put cos://examplebucket --file rules.yaml
+	./coscli lifecycle --method get cos://examplebucket
+	./coscli lifecycle --method get cos://examplebucket --output yaml > rules.yaml
+	./coscli lifecycle --method get cos://examplebucket --file rules.json
+	./coscli lifecycle --method delete cos://examplebucket`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return lifecycleCommand.run(cmd, args)
+	},
+}
+
+var lifecycleCommand = bucketConfigCommand{
+	name: "lifecycle",
+	yaml: true,
+	load: func(path string) (util.BucketConfig, error) {
+		return util.LoadLifecycleConfig(path)
+	},
+	get: func(c *cos.Client) (util.BucketConfig, error) {
+		return util.GetLifecycle(c)
+	},
+	put: func(c *cos.Client, v util.BucketConfig) error {
+		return util.PutLifecycle(c, v.(*util.LifecycleConfig))
+	},
+	delete: util.DeleteLifecycle,
+	print: func(v util.BucketConfig) {
+		printLifecycle(v.(*util.LifecycleConfig))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lifecycleCmd)
+	addBucketConfigFlags(lifecycleCmd, "The YAML(.yaml/.yml) or JSON(.json) file of the lifecycle rules, read by put and written by get")
+}
+
+// 以表格输出生命周期规则
+func printLifecycle(lc *util.LifecycleConfig) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Status", "Filter", "Transitions", "Expiration", "Noncurrent Expiration", "Abort Multipart"})
+	for _, rule := range lc.Rules {
+		var filter []string
+		if rule.Prefix != "" {
+			filter = append(filter, "prefix="+rule.Prefix)
+		}
+		for _, tag := range rule.Tags {
+			filter = append(filter, fmt.Sprintf("tag:%s=%s", tag.Key, tag.Value))
+		}
+		var transitions []string
+		for _, t := range rule.Transitions {
+			transitions = append(transitions, lifecycleTime(t.Days, t.Date)+" "+t.StorageClass)
+		}
+		var expiration []string
+		if e := rule.Expiration; e != nil {
+			if e.Days > 0 || e.Date != "" {
+				expiration = append(expiration, lifecycleTime(e.Days, e.Date))
+			}
+			if e.ExpiredObjectDeleteMarker {
+				expiration = append(expiration, "expired delete markers")
+			}
+		}
+		table.Append([]string{
+			rule.ID,
+			rule.Status,
+			strings.Join(filter, " "),
+			strings.Join(transitions, ", "),
+			strings.Join(expiration, ", "),
+			lifecycleTime(rule.NoncurrentVersionExpirationDays, ""),
+			lifecycleTime(rule.AbortIncompleteMultipartUploadDays, ""),
+		})
+	}
+	table.SetBorder(false)
+	table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	table.Render()
+}
+
+// 天数显示为 30d，未设置时为空
+func lifecycleTime(days int, date string) string {
+	if date != "" {
+		return date
+	}
+	if days > 0 {
+		return strconv.Itoa(days) + "d"
+	}
+	return ""
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const lifecycleYaml = `rules:
+  - id: archive-logs
+    prefix: logs/
+    tags:
+      - key: env
+        value: test
+    transitions:
+      - days: 30
+        storage_class: STANDARD_IA
+      - days: 90
+        storage_class: ARCHIVE
+    expiration:
+      days: 365
+  - id: versions
+    status: Disabled
+    noncurrent_version_expiration_days: 30
+    abort_incomplete_multipart_upload_days: 7
+    expiration:
+      expired_object_delete_marker: true
+`
+
+func TestLifecycle(t *testing.T) {
+	fmt.Println("TestLifecycle")
+	dir, err := ioutil.TempDir("", "coscli-lifecycle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testBucket = randStr(8)
+	testAlias = testBucket + "-alias"
+	setUp(testBucket, testAlias, testEndpoint, false, false)
+	defer tearDown(testBucket, testAlias, testEndpoint, false)
+	c, _ := util.NewClient(&config, &param, testAlias)
+
+	rulesFile := filepath.Join(dir, "rules.yaml")
+	ioutil.WriteFile(rulesFile, []byte(lifecycleYaml), 0644)
+	want := &util.LifecycleConfig{Rules: []util.LifecycleRule{
+		{
+			ID:     "archive-logs",
+			Status: util.LifecycleStatusEnabled,
+			Prefix: "logs/",
+			Tags:   []util.LifecycleTag{{Key: "env", Value: "test"}},
+			Transitions: []util.LifecycleTransition{
+				{Days: 30, StorageClass: util.StandardIA},
+				{Days: 90, StorageClass: util.Archive},
+			},
+			Expiration: &util.LifecycleExpiration{Days: 365},
+		},
+		{
+			ID:                                 "versions",
+			Status:                             util.LifecycleStatusDisabled,
+			Expiration:                         &util.LifecycleExpiration{ExpiredObjectDeleteMarker: true},
+			NoncurrentVersionExpirationDays:    30,
+			AbortIncompleteMultipartUploadDays: 7,
+		},
+	}}
+
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	Convey("Test lifecycle", t, func() {
+		Convey("put and get", func() {
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"lifecycle", "--method", "put", fmt.Sprintf("cos://%s", testAlias), "--file", rulesFile}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			lc, err := util.GetLifecycle(c)
+			So(err, ShouldBeNil)
+			So(lc, ShouldResemble, want)
+
+			clearCmd()
+			args = []string{"lifecycle", "--method", "get", fmt.Sprintf("cos://%s", testAlias)}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+
+			// get 的输出可作为规则文件再次 put
+			for _, format := range []string{"yaml", "json"} {
+				clearCmd()
+				args = []string{"lifecycle", "--method", "get", fmt.Sprintf("cos://%s", testAlias), "--output", format}
+				cmd.SetArgs(args)
+				output, err := captureStdout(cmd.Execute)
+				So(err, ShouldBeNil)
+				outputFile := filepath.Join(dir, "output."+format)
+				ioutil.WriteFile(outputFile, []byte(output), 0644)
+				lc, err := util.LoadLifecycleConfig(outputFile)
+				So(err, ShouldBeNil)
+				So(lc, ShouldResemble, want)
+			}
+
+			jsonFile := filepath.Join(dir, "saved.json")
+			clearCmd()
+			args = []string{"lifecycle", "--method", "get", fmt.Sprintf("cos://%s", testAlias), "--file", jsonFile}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			clearCmd()
+			args = []string{"lifecycle", "--method", "put", fmt.Sprintf("cos://%s", testAlias), "--file", jsonFile}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			lc, err = util.GetLifecycle(c)
+			So(err, ShouldBeNil)
+			So(lc, ShouldResemble, want)
+		})
+		Convey("delete", func() {
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"lifecycle", "--method", "delete", fmt.Sprintf("cos://%s", testAlias)}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			_, err := util.GetLifecycle(c)
+			So(err, ShouldBeError)
+		})
+		Convey("fail", func() {
+			invalid := map[string]string{
+				"unknown.yaml":   "rules:\n  - id: a\n    expire_days: 30\n",
+				"class.yaml":     "rules:\n  - transitions:\n      - days: 30\n        storage_class: STANDARD\n",
+				"both.yaml":      "rules:\n  - expiration:\n      days: 30\n      date: 2026-01-01T00:00:00+08:00\n",
+				"date.json":      `{"rules": [{"expiration": {"date": "2026-01-01"}}]}`,
+				"status.json":    `{"rules": [{"status": "On", "expiration": {"days": 1}}]}`,
+				"duplicate.json": `{"rules": [{"id": "a", "expiration": {"days": 1}}, {"id": "a", "expiration": {"days": 2}}]}`,
+				"noaction.yaml":  "rules:\n  - id: a\n    prefix: logs/\n",
+				"empty.yaml":     "rules: []\n",
+				"rules.txt":      lifecycleYaml,
+				"malformed.json": `{"rules": [`,
+			}
+			cosPath := fmt.Sprintf("cos://%s", testAlias)
+			cases := [][]string{
+				{"lifecycle", "--method", "put", cosPath},
+				{"lifecycle", "--method", "put", cosPath, "--file", filepath.Join(dir, "not-exist.yaml")},
+				{"lifecycle", "--method", "put", cosPath + "/logs", "--file", rulesFile},
+				{"lifecycle", "--method", "list", cosPath},
+				{"lifecycle", "--method", "get", cosPath, "--output", "csv"},
+				{"lifecycle", "--method", "delete", cosPath, "--file", rulesFile},
+			}
+			for name, content := range invalid {
+				path := filepath.Join(dir, name)
+				ioutil.WriteFile(path, []byte(content), 0644)
+				cases = append(cases, []string{"lifecycle", "--method", "put", cosPath, "--file", path})
+			}
+			for _, args := range cases {
+				clearCmd()
+				cmd := rootCmd
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			}
+		})
+	})
+}
@@ -21,9 +21,10 @@ Example:
 	RunE: func(cmd *cobra.Command, args []string) error {
 		limit, _ := cmd.Flags().GetInt("limit")
 		recursive, _ := cmd.Flags().GetBool("recursive")
-		include, _ := cmd.Flags().GetString("include")
-		exclude, _ := cmd.Flags().GetString("exclude")
 		allVersions, _ := cmd.Flags().GetBool("all-versions")
+		if err := initOutputFormat(); err != nil {
+			return err
+		}
 
 		if limit == 0 {
 			limit = 10000
@@ -68,7 +69,10 @@ Example:
 				}
 			}
 
-			_, filters := util.GetFilter(include, exclude)
+			filters, err := getFilters(cmd)
+			if err != nil {
+				return err
+			}
 			// 根据s.Header判断是否是融合桶或者普通桶
 			s, err := c.Bucket.Head(context.Background())
 			if err != nil {
@@ -105,7 +109,7 @@ func init() {
 
 	lsCmd.Flags().Int("limit", 0, "Limit the number of objects listed(0~1000)")
 	lsCmd.Flags().BoolP("recursive", "r", false, "List objects recursively")
-	lsCmd.Flags().String("include", "", "List files that meet the specified criteria")
-	lsCmd.Flags().String("exclude", "", "Exclude files that meet the specified criteria")
+	addFilterFlags(lsCmd)
+	addMetaFilterFlags(lsCmd)
 	lsCmd.Flags().BoolP("all-versions", "", false, "List all versions of objects, only available if bucket versioning is enabled.")
 }
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"coscli/util"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"testing"
@@ -86,8 +87,79 @@ func TestLsCmd(t *testing.T) {
 				e := cmd.Execute()
 				So(e, ShouldBeNil)
 			})
+			Convey("--output", func() {
+				for _, format := range []string{"json", "jsonl", "csv"} {
+					clearCmd()
+					cmd := rootCmd
+					args := []string{"ls", fmt.Sprintf("cos://%s", testAlias), "-r", "--output", format}
+					cmd.SetArgs(args)
+					e := cmd.Execute()
+					So(e, ShouldBeNil)
+				}
+				clearCmd()
+				cmd := rootCmd
+				cmd.SetArgs([]string{"ls", "--output", "table"})
+				e := cmd.Execute()
+				So(e, ShouldBeNil)
+			})
+			Convey("--output is_latest", func() {
+				// 未开启版本控制的列举没有 is_latest，列出对象版本时才有
+				clearCmd()
+				cmd := rootCmd
+				cmd.SetArgs([]string{"ls", fmt.Sprintf("cos://%s", testAlias), "-r", "--output", "jsonl"})
+				output, e := captureStdout(cmd.Execute)
+				So(e, ShouldBeNil)
+				So(output, ShouldContainSubstring, "multi-small")
+				So(output, ShouldNotContainSubstring, "is_latest")
+
+				clearCmd()
+				cmd.SetArgs([]string{"ls", fmt.Sprintf("cos://%s", testVersionBucketAlias), "-r", "--all-versions", "--output", "jsonl"})
+				output, e = captureStdout(cmd.Execute)
+				So(e, ShouldBeNil)
+				So(output, ShouldContainSubstring, "\"is_latest\":true")
+			})
+			Convey("按大小、修改时间与存储类型过滤", func() {
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"ls", fmt.Sprintf("cos://%s", testAlias), "-r", "--min-size", "1KB", "--newer-than", "7d",
+					"--storage-class-filter", "STANDARD"}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				So(e, ShouldBeNil)
+			})
 		})
 		Convey("fail", func() {
+			Convey("参数--max-size", func() {
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"ls", fmt.Sprintf("cos://%s", testAlias), "--max-size", "-1"}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			})
+			Convey("--output write error", func() {
+				patches := ApplyFunc(json.Marshal, func(v interface{}) ([]byte, error) {
+					return nil, fmt.Errorf("test marshal error")
+				})
+				defer patches.Reset()
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"ls", fmt.Sprintf("cos://%s", testAlias), "-r", "--output", "jsonl"}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			})
+			Convey("参数--output", func() {
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"ls", "--output", "xml"}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			})
 			Convey("参数--limit<0", func() {
 				clearCmd()
 				cmd := rootCmd
@@ -18,9 +18,13 @@ Example:
   ./coscli lsdu cos://examplebucket/test/`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		include, _ := cmd.Flags().GetString("include")
-		exclude, _ := cmd.Flags().GetString("exclude")
-		_, filters := util.GetFilter(include, exclude)
+		filters, err := getFilters(cmd)
+		if err != nil {
+			return err
+		}
+		if err := initOutputFormat(); err != nil {
+			return err
+		}
 
 		cosPath := args[0]
 		cosUrl, err := util.FormatUrl(cosPath)
@@ -45,6 +49,5 @@ Example:
 
 func init() {
 	rootCmd.AddCommand(lsduCmd)
-	lsduCmd.Flags().String("include", "", "List files that meet the specified criteria")
-	lsduCmd.Flags().String("exclude", "", "Exclude files that meet the specified criteria")
+	addFilterFlags(lsduCmd)
 }
@@ -45,6 +45,17 @@ func TestLsduCmd(t *testing.T) {
 				e := cmd.Execute()
 				So(e, ShouldBeNil)
 			})
+			Convey("duObjects --output csv", func() {
+				clearCmd()
+				cmd := rootCmd
+				args = []string{"lsdu", cosFileName, "--output", "csv"}
+				cmd.SetArgs(args)
+				output, e := captureStdout(cmd.Execute)
+				So(e, ShouldBeNil)
+				// csv 中只有各目录与文件的行，不混入合计行
+				So(output, ShouldStartWith, "key,type,objects,size\n")
+				So(output, ShouldNotContainSubstring, ",total,")
+			})
 		})
 		Convey("fail", func() {
 			Convey("not enough arguments", func() {
@@ -19,9 +19,10 @@ Example:
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		limit, _ := cmd.Flags().GetInt("limit")
-		include, _ := cmd.Flags().GetString("include")
-		exclude, _ := cmd.Flags().GetString("exclude")
 		uploadId, _ := cmd.Flags().GetString("upload-id")
+		if err := initOutputFormat(); err != nil {
+			return err
+		}
 		if limit == 0 {
 			limit = 10000
 		} else if limit < 0 {
@@ -37,7 +38,10 @@ Example:
 			return fmt.Errorf("cospath needs to contain cos://")
 		}
 
-		_, filters := util.GetFilter(include, exclude)
+		filters, err := getFilters(cmd)
+		if err != nil {
+			return err
+		}
 
 		bucketName := cosUrl.(*util.CosUrl).Bucket
 
@@ -60,7 +64,6 @@ func init() {
 	rootCmd.AddCommand(lspartsCmd)
 
 	lspartsCmd.Flags().Int("limit", 0, "Limit the number of parts listed(0~1000)")
-	lspartsCmd.Flags().String("include", "", "List files that meet the specified criteria")
-	lspartsCmd.Flags().String("exclude", "", "Exclude files that meet the specified criteria")
+	addFilterFlags(lspartsCmd)
 	lspartsCmd.Flags().String("upload-id", "", "Identify the ID of this multipart upload, which is obtained when initializing the multipart upload using the Initiate Multipart Upload interface.")
 }
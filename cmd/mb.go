@@ -19,7 +19,8 @@ Format:
   ./coscli mb cos://<bucket-name>-<appid> -e <endpoint>
 
 Example:
-  ./coscli mb cos://examplebucket-1234567890 -e cos.ap-beijing.myqcloud.com`,
+  ./coscli mb cos://examplebucket-1234567890 -e cos.ap-beijing.myqcloud.com
+  ./coscli mb cos://examplebucket-1234567890 -e cos.ap-beijing.myqcloud.com --acl public-read`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
 			return err
@@ -42,6 +43,7 @@ func init() {
 	mbCmd.Flags().StringP("region", "r", "", "Region")
 	mbCmd.Flags().BoolP("ofs", "o", false, "Ofs")
 	mbCmd.Flags().BoolP("maz", "m", false, "Maz")
+	addACLFlags(mbCmd)
 }
 
 func createBucket(cmd *cobra.Command, args []string) error {
@@ -52,16 +54,20 @@ func createBucket(cmd *cobra.Command, args []string) error {
 		param.Endpoint = fmt.Sprintf("cos.%s.myqcloud.com", flagRegion)
 	}
 	bucketIDName, _ := util.ParsePath(args[0])
+	acl, err := getACLOptions(cmd, true)
+	if err != nil {
+		return err
+	}
 
 	c, err := util.CreateClient(&config, &param, bucketIDName)
 	if err != nil {
 		return err
 	}
 	opt := &cos.BucketPutOptions{
-		XCosACL:                   "",
-		XCosGrantRead:             "",
+		XCosACL:                   acl.ACL,
+		XCosGrantRead:             acl.GrantRead,
 		XCosGrantWrite:            "",
-		XCosGrantFullControl:      "",
+		XCosGrantFullControl:      acl.GrantFullControl,
 		XCosGrantReadACP:          "",
 		XCosGrantWriteACP:         "",
 		CreateBucketConfiguration: &cos.CreateBucketConfiguration{},
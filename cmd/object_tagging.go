@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var objectTaggingCmd = &cobra.Command{
+	Use:   "object-tagging",
+	Short: "Modify object tagging",
+	Long: `Modify object tagging
+
+Format:
+	./coscli object-tagging --method [method] cos://<bucket-name>/<object> [flags]
+
+Example:
+	./coscli object-tagging --method put cos://examplebucket/test.txt --tags "tag1=test1&tag2=test2"
+	./coscli object-tagging --method get cos://examplebucket/test.txt
+	./coscli object-tagging --method delete cos://examplebucket/test.txt
+	./coscli object-tagging --method put cos://examplebucket/logs/ -r --include ".*\.log" --tags "expire=30d"
+	./coscli object-tagging --method get cos://examplebucket/logs/ -r`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		method, _ := cmd.Flags().GetString("method")
+		tagsString, _ := cmd.Flags().GetString("tags")
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		versionId, _ := cmd.Flags().GetString("version-id")
+		routines, _ := cmd.Flags().GetInt("routines")
+		failOutput, _ := cmd.Flags().GetBool("fail-output")
+		failOutputPath, _ := cmd.Flags().GetString("fail-output-path")
+
+		if method != util.ObjectTaggingPut && method != util.ObjectTaggingGet && method != util.ObjectTaggingDelete {
+			return fmt.Errorf("--method can only be put, get or delete")
+		}
+		var tags []cos.ObjectTaggingTag
+		if method == util.ObjectTaggingPut {
+			if tagsString == "" {
+				return fmt.Errorf("--tags is required to put object tagging")
+			}
+			var err error
+			if tags, err = util.ParseObjectTags(tagsString); err != nil {
+				return err
+			}
+		} else if tagsString != "" {
+			return fmt.Errorf("--tags only works with --method put")
+		}
+		if recursive && versionId != "" {
+			return fmt.Errorf("--version-id can not be used with --recursive")
+		}
+		if routines < 1 {
+			return fmt.Errorf("--routines must be greater than 0")
+		}
+
+		cosUrl, err := util.FormatUrl(args[0])
+		if err != nil {
+			return fmt.Errorf("cos url format error:%v", err)
+		}
+		if !cosUrl.IsCosUrl() {
+			return fmt.Errorf("cospath needs to contain cos://")
+		}
+		object := cosUrl.(*util.CosUrl).Object
+		if !recursive && object == "" {
+			return fmt.Errorf("the object key is required, use --recursive to modify the tagging of objects in the bucket")
+		}
+
+		filters, err := getFilters(cmd)
+		if err != nil {
+			return err
+		}
+
+		bucketName := cosUrl.(*util.CosUrl).Bucket
+		c, err := util.NewClient(&config, &param, bucketName)
+		if err != nil {
+			return err
+		}
+
+		if !recursive {
+			var id []string
+			if versionId != "" {
+				id = append(id, versionId)
+			}
+			switch method {
+			case util.ObjectTaggingPut:
+				err = util.PutObjectTags(c, object, tags, id...)
+			case util.ObjectTaggingGet:
+				var objectTags []cos.ObjectTaggingTag
+				if objectTags, err = util.GetObjectTags(c, object, id...); err == nil {
+					printObjectTags([]util.ObjectTagsResult{{Key: object, Tags: objectTags}}, false)
+				}
+			case util.ObjectTaggingDelete:
+				err = util.DeleteObjectTags(c, object, id...)
+			}
+			if err != nil {
+				return err
+			}
+			if method != util.ObjectTaggingGet {
+				logger.Infof("%s tagging of %s successfully", method, args[0])
+			}
+			return nil
+		}
+
+		fo := &util.FileOperations{
+			Operation: util.Operation{
+				Recursive:      recursive,
+				Filters:        filters,
+				Routines:       routines,
+				FailOutput:     failOutput,
+				FailOutputPath: failOutputPath,
+			},
+			ErrOutput: &util.ErrOutput{},
+		}
+		results, err := util.ObjectTaggingObjects(c, cosUrl, method, tags, fo)
+		if method == util.ObjectTaggingGet {
+			printObjectTags(results, true)
+		}
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(objectTaggingCmd)
+	objectTaggingCmd.Flags().String("method", "", "put/get/delete")
+	objectTaggingCmd.Flags().String("tags", "", "The tags to put, the format is key=value&key2=value2, replacing all tags of the objects")
+	objectTaggingCmd.Flags().BoolP("recursive", "r", false, "Modify the tagging of objects under the prefix recursively")
+	objectTaggingCmd.Flags().String("version-id", "", "Version id of the object")
+	objectTaggingCmd.Flags().Int("routines", 3, "Specifies the number of objects processed concurrently")
+	objectTaggingCmd.Flags().Bool("fail-output", true, "This option determines whether the error output for failed objects is enabled. If enabled, the error messages will be recorded in a file within the specified directory (if not specified, the default is coscli_output).")
+	objectTaggingCmd.Flags().String("fail-output-path", "coscli_output", "This option specifies the error output folder where the error messages for failed objects will be recorded.")
+	addFilterFlags(objectTaggingCmd)
+	addMetaFilterFlags(objectTaggingCmd)
+}
+
+// 以表格输出对象标签，withKey 为 true 时输出对象键
+func printObjectTags(results []util.ObjectTagsResult, withKey bool) {
+	table := tablewriter.NewWriter(os.Stdout)
+	if withKey {
+		table.SetHeader([]string{"Object", "Key", "Value"})
+	} else {
+		table.SetHeader([]string{"Key", "Value"})
+	}
+	for _, result := range results {
+		for _, t := range result.Tags {
+			if withKey {
+				table.Append([]string{result.Key, t.Key, t.Value})
+			} else {
+				table.Append([]string{t.Key, t.Value})
+			}
+		}
+	}
+	table.SetBorder(false)
+	table.SetAlignment(tablewriter.ALIGN_RIGHT)
+	table.Render()
+}
+
+// 注册上传或拷贝时设置对象标签的参数
+func addTaggingFlags(cmd *cobra.Command) {
+	cmd.Flags().String("tags", "", "Set the tags of the uploaded or copied objects, the format is key=value&key2=value2")
+	cmd.Flags().String("tagging-directive", "", "Whether the copied objects keep the tags of the source objects(copy) or use --tags(replace), default is replace if --tags is set, otherwise copy")
+}
+
+// 按传输方向校验并获取对象标签设置
+func getTaggingOptions(cmd *cobra.Command, srcUrl, destUrl util.StorageUrl) (util.TaggingOptions, error) {
+	tagsString, _ := cmd.Flags().GetString("tags")
+	directive, _ := cmd.Flags().GetString("tagging-directive")
+
+	var opt util.TaggingOptions
+	if tagsString != "" {
+		if !destUrl.IsCosUrl() {
+			return opt, fmt.Errorf("--tags only works with upload or copy")
+		}
+		tags, err := util.ParseObjectTags(tagsString)
+		if err != nil {
+			return opt, err
+		}
+		opt.Tags = tags
+	}
+
+	switch directive {
+	case "":
+		if srcUrl.IsCosUrl() && destUrl.IsCosUrl() && opt.Tags != nil {
+			opt.Directive = util.TaggingDirectiveReplace
+		}
+	case util.TaggingDirectiveCopy, util.TaggingDirectiveReplace:
+		if !(srcUrl.IsCosUrl() && destUrl.IsCosUrl()) {
+			return opt, fmt.Errorf("--tagging-directive only works with copy between cos paths")
+		}
+		if directive == util.TaggingDirectiveCopy && opt.Tags != nil {
+			return opt, fmt.Errorf("--tags can not be used with --tagging-directive copy")
+		}
+		opt.Directive = directive
+	default:
+		return opt, fmt.Errorf("--tagging-directive must be copy or replace")
+	}
+	return opt, nil
+}
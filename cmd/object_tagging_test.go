@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+func TestObjectTagging(t *testing.T) {
+	fmt.Println("TestObjectTagging")
+	dir, err := ioutil.TempDir("", "coscli-tagging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testBucket = randStr(8)
+	testAlias = testBucket + "-alias"
+	setUp(testBucket, testAlias, testEndpoint, false, false)
+	defer tearDown(testBucket, testAlias, testEndpoint, false)
+	c, _ := util.NewClient(&config, &param, testAlias)
+
+	localDir := filepath.Join(dir, "logs")
+	os.MkdirAll(localDir, 0755)
+	for _, name := range []string{"a.log", "b.log", "c.txt"} {
+		genFile(filepath.Join(localDir, name), 1024)
+	}
+	bigFile := filepath.Join(dir, "big")
+	genFile(bigFile, 2*1024*1024+100)
+	projectTags := []cos.ObjectTaggingTag{{Key: "env", Value: "test"}, {Key: "project", Value: "coscli"}}
+
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	Convey("Test object tagging", t, func() {
+		Convey("upload and copy", func() {
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"cp", localDir + string(filepath.Separator), fmt.Sprintf("cos://%s/logs/", testAlias), "-r", "--tags", "project=coscli&env=test"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			tags, err := util.GetObjectTags(c, "logs/a.log")
+			So(err, ShouldBeNil)
+			So(tags, ShouldResemble, projectTags)
+
+			// 分块上传
+			clearCmd()
+			args = []string{"sync", bigFile, fmt.Sprintf("cos://%s/big", testAlias), "--part-size", "1", "--tags", "size=big"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			tags, err = util.GetObjectTags(c, "big")
+			So(err, ShouldBeNil)
+			So(tags, ShouldResemble, []cos.ObjectTaggingTag{{Key: "size", Value: "big"}})
+
+			// 拷贝默认保留来源对象的标签，指定 --tags 时替换
+			clearCmd()
+			args = []string{"cp", fmt.Sprintf("cos://%s/logs/a.log", testAlias), fmt.Sprintf("cos://%s/copy/a.log", testAlias)}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			tags, err = util.GetObjectTags(c, "copy/a.log")
+			So(err, ShouldBeNil)
+			So(tags, ShouldResemble, projectTags)
+
+			clearCmd()
+			args = []string{"cp", fmt.Sprintf("cos://%s/logs/a.log", testAlias), fmt.Sprintf("cos://%s/copy/b.log", testAlias), "--tags", "copied=true"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			tags, err = util.GetObjectTags(c, "copy/b.log")
+			So(err, ShouldBeNil)
+			So(tags, ShouldResemble, []cos.ObjectTaggingTag{{Key: "copied", Value: "true"}})
+
+			clearCmd()
+			args = []string{"cp", fmt.Sprintf("cos://%s/logs/a.log", testAlias), fmt.Sprintf("cos://%s/copy/c.log", testAlias), "--tagging-directive", "replace"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			tags, err = util.GetObjectTags(c, "copy/c.log")
+			So(err, ShouldBeNil)
+			So(tags, ShouldBeEmpty)
+		})
+		Convey("object-tagging command", func() {
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"object-tagging", "--method", "put", fmt.Sprintf("cos://%s/logs/", testAlias), "-r",
+				"--include", ".*\\.log", "--tags", "expire=30d", "--routines", "2"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			for key, want := range map[string][]cos.ObjectTaggingTag{
+				"logs/a.log": {{Key: "expire", Value: "30d"}},
+				"logs/b.log": {{Key: "expire", Value: "30d"}},
+				"logs/c.txt": projectTags,
+			} {
+				tags, err := util.GetObjectTags(c, key)
+				So(err, ShouldBeNil)
+				So(tags, ShouldResemble, want)
+			}
+
+			clearCmd()
+			args = []string{"object-tagging", "--method", "get", fmt.Sprintf("cos://%s/logs/", testAlias), "-r"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+
+			clearCmd()
+			args = []string{"object-tagging", "--method", "delete", fmt.Sprintf("cos://%s/logs/", testAlias), "-r", "--exclude", ".*\\.txt"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			tags, err := util.GetObjectTags(c, "logs/a.log")
+			So(err, ShouldBeNil)
+			So(tags, ShouldBeEmpty)
+			tags, err = util.GetObjectTags(c, "logs/c.txt")
+			So(err, ShouldBeNil)
+			So(tags, ShouldResemble, projectTags)
+
+			// 单个对象
+			clearCmd()
+			args = []string{"object-tagging", "--method", "put", fmt.Sprintf("cos://%s/big", testAlias), "--tags", "a=1&b=2"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			clearCmd()
+			args = []string{"object-tagging", "--method", "get", fmt.Sprintf("cos://%s/big", testAlias)}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			clearCmd()
+			args = []string{"object-tagging", "--method", "delete", fmt.Sprintf("cos://%s/big", testAlias)}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			tags, err = util.GetObjectTags(c, "big")
+			So(err, ShouldBeNil)
+			So(tags, ShouldBeEmpty)
+		})
+		Convey("copy between accounts", func() {
+			if testServer == nil {
+				return
+			}
+			// viper.Set 设置的值优先于配置文件，结束后清除并重新读取测试配置
+			viper.Set("cos", nil)
+			defer func() {
+				cfgFile = ""
+				profileName = ""
+				viper.Set("cos", nil)
+				config = util.Config{}
+				fileConfig = util.Config{}
+				getConfig()
+			}()
+
+			// 另一个账号的桶只接受该账号的密钥
+			otherSecretID := "AKIDcoscliTaggingAccount"
+			otherBucket := randStr(8) + "-" + testAppID
+			testServer.CreateBucket(otherBucket, false)
+			testServer.SetBucketOwner(otherBucket, otherSecretID)
+			configFile := filepath.Join(dir, "profile.yaml")
+			content := fmt.Sprintf(`cos:
+  base:
+    secretid: %s
+    secretkey: %s
+    protocol: http
+    mode: SecretKey
+    disableencryption: "true"
+    proxy: %s
+  buckets:
+  - name: %s-%s
+    alias: %s
+    endpoint: %s
+  profiles:
+  - name: other
+    base:
+      secretid: %s
+      secretkey: otherSecretKey
+      protocol: http
+      mode: SecretKey
+      disableencryption: "true"
+      proxy: %s
+    buckets:
+    - name: %s
+      alias: other
+      endpoint: %s
+`, testSecretID, testSecretKey, testProxy, testBucket, appID, testAlias, testEndpoint, otherSecretID, testProxy, otherBucket, testEndpoint)
+			So(ioutil.WriteFile(configFile, []byte(content), 0600), ShouldBeNil)
+
+			// 大于分块大小时分块读取并上传到另一个账号的桶
+			clearCmd()
+			profileName = ""
+			cmd := rootCmd
+			args := []string{"cp", bigFile, fmt.Sprintf("cos://%s/profile/big", testAlias), "-c", configFile}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			clearCmd()
+			args = []string{"cp", fmt.Sprintf("cos://%s/profile/big", testAlias), "cos://other/profile/big", "-c", configFile,
+				"--dest-profile", "other", "--part-size", "1", "--tags", "project=coscli"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			otherConfig, err := util.LoadProfile(fileConfig, "other")
+			So(err, ShouldBeNil)
+			otherClient, err := util.NewClient(otherConfig, &param, "other")
+			So(err, ShouldBeNil)
+			tags, err := util.GetObjectTags(otherClient, "profile/big")
+			So(err, ShouldBeNil)
+			So(tags, ShouldResemble, []cos.ObjectTaggingTag{{Key: "project", Value: "coscli"}})
+
+			// 跨账号拷贝默认保留来源对象的标签
+			clearCmd()
+			args = []string{"cp", "cos://other/profile/big", fmt.Sprintf("cos://%s/profile/back", testAlias), "-c", configFile,
+				"--src-profile", "other"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			tags, err = util.GetObjectTags(c, "profile/back")
+			So(err, ShouldBeNil)
+			So(tags, ShouldResemble, []cos.ObjectTaggingTag{{Key: "project", Value: "coscli"}})
+		})
+		Convey("fail", func() {
+			cosPath := fmt.Sprintf("cos://%s/logs/a.log", testAlias)
+			for _, args := range [][]string{
+				{"cp", bigFile, cosPath, "--tags", "a=1&a=2"},
+				{"cp", bigFile, cosPath, "--tags", "=1"},
+				{"cp", bigFile, cosPath, "--tags", "a=%zz"},
+				{"cp", bigFile, cosPath, "--tagging-directive", "copy"},
+				{"cp", cosPath, filepath.Join(dir, "download"), "--tags", "a=1"},
+				{"cp", cosPath, fmt.Sprintf("cos://%s/copy/d.log", testAlias), "--tagging-directive", "keep"},
+				{"cp", cosPath, fmt.Sprintf("cos://%s/copy/d.log", testAlias), "--tagging-directive", "copy", "--tags", "a=1"},
+				{"object-tagging", "--method", "put", cosPath},
+				{"object-tagging", "--method", "get", cosPath, "--tags", "a=1"},
+				{"object-tagging", "--method", "list", cosPath},
+				{"object-tagging", "--method", "get", fmt.Sprintf("cos://%s", testAlias)},
+				{"object-tagging", "--method", "get", fmt.Sprintf("cos://%s/logs/", testAlias), "-r", "--version-id", "MTg0NDUxNz"},
+				{"object-tagging", "--method", "put", cosPath, "--tags", "qcs:system=1"},
+			} {
+				clearCmd()
+				cmd := rootCmd
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			}
+		})
+	})
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// 当前使用的命名配置，为空时使用配置文件顶层的 base 与 buckets
+func activeProfile() string {
+	name := profileName
+	if name == "" {
+		name = os.Getenv(util.ProfileEnv)
+	}
+	if name == util.DefaultProfile {
+		return ""
+	}
+	return name
+}
+
+// 获取指定命名配置，name 为空时使用当前配置
+func profileConfig(name string) (*util.Config, error) {
+	if name == "" {
+		return &config, nil
+	}
+	return util.LoadProfile(fileConfig, name)
+}
+
+// 写入修改后的基础配置，使用命名配置时写入 profiles 中对应的项
+func setViperBase(base util.BaseCfg) {
+	name := activeProfile()
+	if name == "" {
+		viper.Set("cos.base", base)
+		return
+	}
+	profile, _ := util.FindProfile(&fileConfig, name)
+	if profile != nil {
+		util.SetProfile(&fileConfig, name, base, profile.Buckets)
+	} else {
+		util.SetProfile(&fileConfig, name, base, config.Buckets)
+	}
+	viper.Set("cos.profiles", fileConfig.Profiles)
+}
+
+// 写入修改后的桶列表，使用命名配置时写入 profiles 中对应的项
+func setViperBuckets(buckets []util.Bucket) {
+	name := activeProfile()
+	if name == "" {
+		viper.Set("cos.buckets", buckets)
+		return
+	}
+	profile, _ := util.FindProfile(&fileConfig, name)
+	if profile != nil {
+		util.SetProfile(&fileConfig, name, profile.Base, buckets)
+	} else {
+		util.SetProfile(&fileConfig, name, util.BaseCfg{Protocol: "https"}, buckets)
+	}
+	viper.Set("cos.profiles", fileConfig.Profiles)
+}
+
+// 注册 --src-profile 与 --dest-profile
+func addTransferProfileFlags(cmd *cobra.Command) {
+	cmd.Flags().String("src-profile", "", "Use the named profile for the source cos path, so that objects can be copied between buckets of different accounts(default is --profile)")
+	cmd.Flags().String("dest-profile", "", "Use the named profile for the destination cos path(default is --profile)")
+}
+
+// 按 --src-profile 与 --dest-profile 设置 cos 路径使用的配置。
+// 拷贝的来源与目标使用不同的命名配置时设置 fo.SrcConfig，拷贝改为读取来源对象后上传
+func initTransferProfiles(cmd *cobra.Command, srcUrl, destUrl util.StorageUrl, fo *util.FileOperations) error {
+	srcProfile, _ := cmd.Flags().GetString("src-profile")
+	destProfile, _ := cmd.Flags().GetString("dest-profile")
+	if srcProfile != "" && !srcUrl.IsCosUrl() {
+		return fmt.Errorf("--src-profile only works with a cos source path")
+	}
+	if destProfile != "" && !destUrl.IsCosUrl() {
+		return fmt.Errorf("--dest-profile only works with a cos destination path")
+	}
+	if srcProfile == "" && destProfile == "" {
+		return nil
+	}
+
+	srcConfig, err := profileConfig(srcProfile)
+	if err != nil {
+		return err
+	}
+	destConfig, err := profileConfig(destProfile)
+	if err != nil {
+		return err
+	}
+
+	if srcUrl.IsCosUrl() && destUrl.IsFileUrl() {
+		fo.Config = srcConfig
+		return nil
+	}
+	fo.Config = destConfig
+	if srcUrl.IsCosUrl() && profileOrActive(srcProfile) != profileOrActive(destProfile) {
+		fo.SrcConfig = srcConfig
+	}
+	return nil
+}
+
+// 未指定时为当前使用的命名配置，default 表示顶层配置
+func profileOrActive(name string) string {
+	if name == "" {
+		return activeProfile()
+	}
+	if name == util.DefaultProfile {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"coscli/util"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+func TestProfile(t *testing.T) {
+	fmt.Println("TestProfile")
+	if testServer == nil {
+		t.Skip("cross-account buckets are only available in the offline cos service")
+	}
+	dir, err := ioutil.TempDir("", "coscli-profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// viper.Set 设置的值优先于配置文件，结束后清除并重新读取测试配置
+	viper.Set("cos", nil)
+	defer func() {
+		cfgFile = ""
+		profileName = ""
+		viper.Set("cos", nil)
+		config = util.Config{}
+		fileConfig = util.Config{}
+		getConfig()
+		clearConfigCmd()
+	}()
+
+	// 另一个账号的桶只接受该账号的密钥
+	otherSecretID := "AKIDcoscliOtherAccount"
+	otherBucket := "coscli-other-" + testAppID
+	testServer.CreateBucket(otherBucket, false)
+	testServer.SetBucketOwner(otherBucket, otherSecretID)
+
+	configFile := filepath.Join(dir, "profile.yaml")
+	content := fmt.Sprintf(`cos:
+  base:
+    secretid: %s
+    secretkey: %s
+    protocol: http
+    mode: SecretKey
+    disableencryption: "true"
+    proxy: %s
+  buckets:
+  - name: coscli-test-%s
+    alias: coscli-test
+    endpoint: %s
+  profiles:
+  - name: other
+    base:
+      secretid: %s
+      secretkey: otherSecretKey
+      protocol: http
+      mode: SecretKey
+      disableencryption: "true"
+      proxy: %s
+    buckets:
+    - name: %s
+      alias: other
+      endpoint: %s
+`, testSecretID, testSecretKey, testProxy, testAppID, testEndpoint, otherSecretID, testProxy, otherBucket, testEndpoint)
+	if err = ioutil.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	localFile := filepath.Join(dir, "profile-file")
+	genFile(localFile, 3*1024*1024+100)
+
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	Convey("Test coscli profile", t, func() {
+		Convey("list bucket of another account", func() {
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"ls", "cos://other", "-c", configFile, "--profile", "other"}
+			cmd.SetArgs(args)
+			e := cmd.Execute()
+			So(e, ShouldBeNil)
+
+			clearCmd()
+			profileName = ""
+			args = []string{"ls", "cos://" + otherBucket, "-c", configFile, "-e", testEndpoint}
+			cmd.SetArgs(args)
+			e = cmd.Execute()
+			fmt.Printf(" : %v", e)
+			So(e, ShouldBeError)
+		})
+		Convey("profile from environment variable", func() {
+			os.Setenv(util.ProfileEnv, "other")
+			defer os.Unsetenv(util.ProfileEnv)
+			clearCmd()
+			profileName = ""
+			cmd := rootCmd
+			args := []string{"ls", "cos://other", "-c", configFile}
+			cmd.SetArgs(args)
+			e := cmd.Execute()
+			So(e, ShouldBeNil)
+			So(config.Base.SecretID, ShouldEqual, otherSecretID)
+		})
+		Convey("copy between accounts", func() {
+			clearCmd()
+			profileName = ""
+			cmd := rootCmd
+			args := []string{"cp", localFile, "cos://coscli-test/profile/file", "-c", configFile}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+
+			// 大于分块大小时分块读取并上传
+			clearCmd()
+			args = []string{"cp", "cos://coscli-test/profile/file", "cos://other/profile/file", "-c", configFile,
+				"--dest-profile", "other", "--part-size", "1"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+
+			otherConfig, err := util.LoadProfile(fileConfig, "other")
+			So(err, ShouldBeNil)
+			c, err := util.NewClient(otherConfig, &param, "other")
+			So(err, ShouldBeNil)
+			resp, err := c.Object.Head(context.Background(), "profile/file", nil)
+			So(err, ShouldBeNil)
+			So(resp.ContentLength, ShouldEqual, 3*1024*1024+100)
+
+			// 从另一个账号的桶拷贝回来
+			clearCmd()
+			args = []string{"cp", "cos://other/profile/file", "cos://coscli-test/profile/back", "-c", configFile,
+				"--src-profile", "other"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+
+			clearCmd()
+			args = []string{"cp", localFile, "cos://other/profile/file", "-c", configFile, "--src-profile", "other"}
+			cmd.SetArgs(args)
+			e := cmd.Execute()
+			fmt.Printf(" : %v", e)
+			So(e, ShouldBeError)
+		})
+		Convey("config add, set and delete with profile", func() {
+			// config 命令中可以指定不存在的命名配置以新建
+			osArgs := os.Args
+			os.Args = []string{"coscli", "config"}
+			defer func() { os.Args = osArgs }()
+			clearCmd()
+			profileName = ""
+			cmd := rootCmd
+			args := []string{"config", "add", "-c", configFile, "--profile", "new", "-b", "example-" + testAppID,
+				"-e", testEndpoint, "-a", "example", "-r", ""}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+
+			clearCmd()
+			args = []string{"config", "set", "-c", configFile, "--profile", "new", "--secret_id", "new-secret-id",
+				"--secret_key", "new-secret-key", "--disable_encryption", "true"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+
+			v := viper.New()
+			v.SetConfigFile(configFile)
+			So(v.ReadInConfig(), ShouldBeNil)
+			var cfg util.Config
+			So(v.UnmarshalKey("cos", &cfg), ShouldBeNil)
+			So(cfg.Base.SecretID, ShouldEqual, testSecretID)
+			So(len(cfg.Buckets), ShouldEqual, 1)
+			profile, _ := util.FindProfile(&cfg, "new")
+			So(profile, ShouldNotBeNil)
+			So(profile.Base.SecretID, ShouldEqual, "new-secret-id")
+			So(len(profile.Buckets), ShouldEqual, 1)
+			So(profile.Buckets[0].Alias, ShouldEqual, "example")
+			other, _ := util.FindProfile(&cfg, "other")
+			So(other, ShouldNotBeNil)
+			So(other.Base.SecretID, ShouldEqual, otherSecretID)
+
+			clearCmd()
+			args = []string{"config", "show", "-c", configFile, "--profile", "new"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+
+			clearCmd()
+			args = []string{"config", "delete", "-c", configFile, "--profile", "new", "-a", "example"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			So(config.Buckets, ShouldBeEmpty)
+		})
+	})
+}
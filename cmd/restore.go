@@ -20,18 +20,21 @@ Example:
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		recursive, _ := cmd.Flags().GetBool("recursive")
-		include, _ := cmd.Flags().GetString("include")
-		exclude, _ := cmd.Flags().GetString("exclude")
 		days, _ := cmd.Flags().GetInt("days")
 		mode, _ := cmd.Flags().GetString("mode")
 		failOutput, _ := cmd.Flags().GetBool("fail-output")
 		failOutputPath, _ := cmd.Flags().GetString("fail-output-path")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		filesFrom, _ := cmd.Flags().GetString("files-from")
 
 		if days < 1 || days > 365 {
 			return fmt.Errorf("Flag --days should in range 1~365")
 		}
 
-		_, filters := util.GetFilter(include, exclude)
+		filters, err := getFilters(cmd)
+		if err != nil {
+			return err
+		}
 
 		fo := &util.FileOperations{
 			Operation: util.Operation{
@@ -39,6 +42,7 @@ Example:
 				Filters:        filters,
 				FailOutput:     failOutput,
 				FailOutputPath: failOutputPath,
+				DryRun:         dryRun,
 				Days:           days,
 				RestoreMode:    mode,
 			},
@@ -46,6 +50,11 @@ Example:
 			Command:   util.CommandRestore,
 		}
 
+		err = initDryRun(fo)
+		if err != nil {
+			return err
+		}
+
 		cosPath := ""
 		if len(args) != 0 {
 			cosPath = args[0]
@@ -58,6 +67,11 @@ Example:
 			return fmt.Errorf("cospath needs to contain %s", util.SchemePrefix)
 		}
 
+		err = util.InitFileList(filesFrom, cosUrl, fo)
+		if err != nil {
+			return err
+		}
+
 		bucketName := cosUrl.(*util.CosUrl).Bucket
 		c, err := util.NewClient(&config, &param, bucketName)
 		if err != nil {
@@ -66,9 +80,14 @@ Example:
 
 		if recursive {
 			err = util.RestoreObjects(c, cosUrl, fo)
+		} else if fo.Operation.DryRun {
+			fo.Plan.Add(&util.PlanRecord{Action: util.PlanRestore, Source: cosPath})
 		} else {
 			_, err = util.TryRestoreObject(c, bucketName, cosUrl.(*util.CosUrl).Object, days, mode)
 		}
+		if err == nil && fo.Operation.DryRun {
+			fo.Plan.Finish()
+		}
 		return err
 	},
 }
@@ -77,10 +96,12 @@ func init() {
 	rootCmd.AddCommand(restoreCmd)
 
 	restoreCmd.Flags().BoolP("recursive", "r", false, "Restore objects recursively")
-	restoreCmd.Flags().String("include", "", "Include files that meet the specified criteria")
-	restoreCmd.Flags().String("exclude", "", "Exclude files that meet the specified criteria")
+	addFilterFlags(restoreCmd)
+	addMetaFilterFlags(restoreCmd)
+	addFilesFromFlag(restoreCmd)
 	restoreCmd.Flags().IntP("days", "d", 3, "Specifies the expiration time of temporary files")
 	restoreCmd.Flags().StringP("mode", "m", "Standard", "Specifies the mode for fetching temporary files")
 	restoreCmd.Flags().Bool("fail-output", true, "This option determines whether error output for failed file restore is enabled. If enabled, any error messages for failed file reheats will be recorded in a file within the specified directory (if not specified, the default directory is coscli_output). If disabled, only the number of error files will be output to the console.")
 	restoreCmd.Flags().String("fail-output-path", "coscli_output", "This option specifies the error output folder where error messages for file restore failures will be recorded. By providing a custom folder path, you can control the location and name of the error output folder. If this option is not set, the default error log folder (coscli_output) will be used.")
+	restoreCmd.Flags().Bool("dry-run", false, "Print the objects that would be restored without restoring them. Use --output to print them as json, jsonl or csv")
 }
@@ -3,6 +3,7 @@ package cmd
 import (
 	"coscli/util"
 	"fmt"
+	"io/ioutil"
 	"testing"
 
 	. "github.com/agiledragon/gomonkey/v2"
@@ -53,8 +54,34 @@ func TestRestoreCmd(t *testing.T) {
 				e := cmd.Execute()
 				So(e, ShouldBeNil)
 			})
+			Convey("RestoreObjects --files-from", func() {
+				listFile := fmt.Sprintf("%s/restore-files-from", testDir)
+				ioutil.WriteFile(listFile, []byte("0\n2\n"), 0644)
+				for _, extra := range [][]string{{"--dry-run", "--output", "jsonl"}, {}} {
+					clearCmd()
+					cmd := rootCmd
+					args := append([]string{"restore", cosFileName, "-r", "--files-from", listFile}, extra...)
+					cmd.SetArgs(args)
+					e := cmd.Execute()
+					So(e, ShouldBeNil)
+				}
+				// 已恢复的对象不再处于归档状态
+				c, _ := util.NewClient(&config, &param, testAlias)
+				resp, err := util.GetHead(c, "multi-small/0")
+				So(err, ShouldBeNil)
+				So(resp.Header.Get("x-cos-restore"), ShouldNotBeEmpty)
+			})
 		})
 		Convey("fail", func() {
+			Convey("files-from without -r", func() {
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"restore", cosFileName, "--files-from", "./not-exist-list"}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			})
 			Convey("Not enough arguments", func() {
 				clearCmd()
 				cmd := rootCmd
@@ -34,14 +34,17 @@ Example:
 		force, _ := cmd.Flags().GetBool("force")
 		onlyCurrentDir, _ := cmd.Flags().GetBool("only-current-dir")
 		retryNum, _ := cmd.Flags().GetInt("retry-num")
-		include, _ := cmd.Flags().GetString("include")
-		exclude, _ := cmd.Flags().GetString("exclude")
 		failOutput, _ := cmd.Flags().GetBool("fail-output")
 		failOutputPath, _ := cmd.Flags().GetString("fail-output-path")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
 		allVersions, _ := cmd.Flags().GetBool("all-versions")
 		versionId, _ := cmd.Flags().GetString("version-id")
+		filesFrom, _ := cmd.Flags().GetString("files-from")
 
-		_, filters := util.GetFilter(include, exclude)
+		filters, err := getFilters(cmd)
+		if err != nil {
+			return err
+		}
 
 		if versionId != "" && recursive {
 			return fmt.Errorf("version-id can only be used to delete a single version of an object")
@@ -60,6 +63,7 @@ Example:
 				RetryNum:       retryNum,
 				FailOutput:     failOutput,
 				FailOutputPath: failOutputPath,
+				DryRun:         dryRun,
 				AllVersions:    allVersions,
 				VersionId:      versionId,
 			},
@@ -69,12 +73,34 @@ Example:
 			ErrOutput: &util.ErrOutput{},
 			Command:   util.CommandRm,
 		}
-		var err error
+
+		err = initDryRun(fo)
+		if err != nil {
+			return err
+		}
+
+		if filesFrom != "" {
+			if allVersions {
+				return fmt.Errorf("--all-versions can not be used with --files-from, specify the version ids in the file instead")
+			}
+			// 清单从标准输入读取时无法再确认删除
+			if filesFrom == "-" && !force && !dryRun {
+				return fmt.Errorf("--files-from - must be used with --force")
+			}
+			err = util.InitFileList(filesFrom, nil, fo)
+			if err != nil {
+				return err
+			}
+		}
+
 		if recursive {
 			err = util.RemoveObjects(args, fo)
 		} else {
 			err = util.RemoveObject(args, fo)
 		}
+		if err == nil && fo.Operation.DryRun {
+			fo.Plan.Finish()
+		}
 		return err
 	},
 }
@@ -86,10 +112,12 @@ func init() {
 	rmCmd.Flags().BoolP("force", "f", false, "Force delete")
 	rmCmd.Flags().Bool("only-current-dir", false, "Upload only the files in the current directory, ignoring subdirectories and their contents")
 	rmCmd.Flags().Int("retry-num", 0, "Rate-limited retry. Specify 1-10 times. When multiple machines concurrently execute download operations on the same COS directory, rate-limited retry can be performed by specifying this parameter.")
-	rmCmd.Flags().String("include", "", "List files that meet the specified criteria")
-	rmCmd.Flags().String("exclude", "", "Exclude files that meet the specified criteria")
+	addFilterFlags(rmCmd)
+	addMetaFilterFlags(rmCmd)
+	addFilesFromFlag(rmCmd)
 	rmCmd.Flags().Bool("fail-output", true, "This option determines whether error output for failed file deletions is enabled. If enabled, any error messages for failed file deletions will be recorded in a file within the specified directory (if not specified, the default directory is coscli_output). If disabled, only the number of error files will be output to the console.")
 	rmCmd.Flags().String("fail-output-path", "coscli_output", "This option specifies the error output folder where error messages for failed file deletions will be recorded. By providing a custom folder path, you can control the location and name of the error output folder. If this option is not set, the default error log folder (coscli_output) will be used.")
 	rmCmd.Flags().BoolP("all-versions", "", false, "remove all versions of objects, only available if bucket versioning is enabled.")
 	rmCmd.Flags().String("version-id", "", "remove Downloading a specified version of a object, only available if bucket versioning is enabled.")
+	rmCmd.Flags().Bool("dry-run", false, "Print the objects that would be deleted without deleting them. Use --output to print them as json, jsonl or csv")
 }
@@ -1,11 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"coscli/util"
 	"fmt"
 	. "github.com/agiledragon/gomonkey/v2"
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/tencentyun/cos-go-sdk-v5"
+	"io/ioutil"
 	"testing"
 )
 
@@ -47,6 +49,21 @@ func TestRmCmd(t *testing.T) {
 	cmd.Execute()
 	Convey("Test coscli rm", t, func() {
 		Convey("success", func() {
+			Convey("rm --dry-run", func() {
+				for _, format := range []string{"table", "jsonl"} {
+					clearCmd()
+					cmd := rootCmd
+					args := []string{"rm", cosFileName, "-r", "--dry-run", "--output", format}
+					cmd.SetArgs(args)
+					e := cmd.Execute()
+					So(e, ShouldBeNil)
+				}
+				// dry-run 不删除对象
+				c, _ := util.NewClient(&config, &param, testAlias)
+				res, _, err := c.Bucket.Get(context.Background(), &cos.BucketGetOptions{Prefix: "multi-small"})
+				So(err, ShouldBeNil)
+				So(len(res.Contents), ShouldBeGreaterThan, 0)
+			})
 			Convey("rm single object", func() {
 				clearCmd()
 				cmd := rootCmd
@@ -75,6 +92,36 @@ func TestRmCmd(t *testing.T) {
 				fmt.Printf(" : %v", e)
 				So(e, ShouldBeNil)
 			})
+			Convey("rm --files-from", func() {
+				listFile := fmt.Sprintf("%s/rm-files-from", testDir)
+				ioutil.WriteFile(listFile, []byte("# 只删除 0\n0\n"), 0644)
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"rm", cosFileName, "-r", "-f", "--files-from", listFile}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				So(e, ShouldBeNil)
+				c, _ := util.NewClient(&config, &param, testAlias)
+				res, _, err := c.Bucket.Get(context.Background(), &cos.BucketGetOptions{Prefix: "multi-small/"})
+				So(err, ShouldBeNil)
+				So(len(res.Contents), ShouldEqual, 2)
+
+				// 按版本 ID 删除
+				vc, _ := util.NewClient(&config, &param, testVersionBucketAlias)
+				versions, _, err := vc.Bucket.GetObjectVersions(context.Background(), &cos.BucketGetObjectVersionsOptions{Prefix: "multi-small/1"})
+				So(err, ShouldBeNil)
+				So(len(versions.Version), ShouldEqual, 1)
+				ioutil.WriteFile(listFile, []byte("key,version_id\n1,"+versions.Version[0].VersionId+"\n"), 0644)
+				clearCmd()
+				cmd = rootCmd
+				args = []string{"rm", versioningFileName, "-r", "-f", "--files-from", listFile}
+				cmd.SetArgs(args)
+				e = cmd.Execute()
+				So(e, ShouldBeNil)
+				versions, _, err = vc.Bucket.GetObjectVersions(context.Background(), &cos.BucketGetObjectVersionsOptions{Prefix: "multi-small/1"})
+				So(err, ShouldBeNil)
+				So(len(versions.Version), ShouldEqual, 0)
+			})
 			Convey("rm cos objects", func() {
 				clearCmd()
 				cmd := rootCmd
@@ -122,6 +169,21 @@ func TestRmCmd(t *testing.T) {
 				fmt.Printf(" : %v", e)
 				So(e, ShouldBeError)
 			})
+			Convey("files-from", func() {
+				for _, args := range [][]string{
+					{"rm", cosFileName, "--files-from", "./not-exist-list"},
+					{"rm", cosFileName, "-r", "-f", "--files-from", "./not-exist-list"},
+					{"rm", cosFileName, "-r", "--files-from", "-"},
+					{"rm", versioningFileName, "-r", "--all-versions", "--files-from", "./not-exist-list"},
+				} {
+					clearCmd()
+					cmd := rootCmd
+					cmd.SetArgs(args)
+					e := cmd.Execute()
+					fmt.Printf(" : %v", e)
+					So(e, ShouldBeError)
+				}
+			})
 			Convey("all-versions use in single object", func() {
 				clearCmd()
 				cmd := rootCmd
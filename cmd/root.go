@@ -17,7 +17,11 @@ import (
 var cfgFile string
 var initSkip bool
 var logPath string
+var outputFormat string
 var config util.Config
+var fileConfig util.Config // 配置文件中的全部内容，密钥未解密
+var credentialErr error    // 按存储方式读取密钥的错误
+var profileName string
 var param util.Param
 var cmdCnt int //控制某些函数在一个命令中被调用的次数
 
@@ -47,8 +51,42 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&param.Endpoint, "endpoint", "e", "", "config endpoint")
 	rootCmd.PersistentFlags().BoolVarP(&param.Customized, "customized", "", false, "config customized")
 	rootCmd.PersistentFlags().StringVarP(&param.Protocol, "protocol", "p", "", "config protocol")
+	rootCmd.PersistentFlags().IntVarP(&param.ConnectTimeout, "connect-timeout", "", 0, "timeout in seconds to connect to the server(default 30)")
+	rootCmd.PersistentFlags().IntVarP(&param.ReadTimeout, "read-timeout", "", 0, "timeout in seconds to wait for the response headers after sending a request(default no timeout)")
+	rootCmd.PersistentFlags().StringVarP(&param.Proxy, "proxy", "", "", "http or https proxy url(default is $HTTPS_PROXY or $HTTP_PROXY)")
+	rootCmd.PersistentFlags().StringVarP(&param.CaFile, "ca-file", "", "", "PEM file of the CA certificates used to verify the server")
+	rootCmd.PersistentFlags().BoolVarP(&param.InsecureSkipVerify, "insecure-skip-verify", "", false, "skip TLS certificate verification, only for private endpoints")
+	rootCmd.PersistentFlags().IntVarP(&param.MaxConns, "max-conns", "", 0, "max connections to the server(default no limit)")
+	rootCmd.PersistentFlags().IntVarP(&param.KeepAlive, "keepalive", "", 0, "TCP keepalive interval in seconds, negative disables it(default 30)")
 	rootCmd.PersistentFlags().BoolVarP(&initSkip, "init-skip", "", false, "skip config init")
+	rootCmd.PersistentFlags().StringVarP(&profileName, "profile", "", "", "use the named profile in the config file(default is $COSCLI_PROFILE, or the top-level base and buckets)")
 	rootCmd.PersistentFlags().StringVarP(&logPath, "log-path", "", "", "coscli log dir")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "", "table", "output format of ls, du, lsdu, lsparts, hash and --dry-run(table, json, jsonl or csv), or of bucket config commands such as lifecycle and bucket-cors(table, json or yaml)")
+}
+
+// 设置输出格式，结构化输出时日志改为输出到 stderr，避免与结果混在一起
+func initOutputFormat() error {
+	if err := util.SetOutputFormat(outputFormat); err != nil {
+		return err
+	}
+	if util.IsTableOutput() {
+		clilog.SetConsoleOutput(os.Stdout)
+	} else {
+		clilog.SetConsoleOutput(os.Stderr)
+	}
+	return nil
+}
+
+// --dry-run 时按 --output 指定的格式输出将要执行的操作
+func initDryRun(fo *util.FileOperations) error {
+	if !fo.Operation.DryRun {
+		return nil
+	}
+	if err := initOutputFormat(); err != nil {
+		return err
+	}
+	fo.Plan = util.NewDryRunPlan()
+	return nil
 }
 
 func initConfig() {
@@ -114,29 +152,28 @@ func initConfig() {
 
 	viper.AutomaticEnv()
 	if err := viper.ReadInConfig(); err == nil {
-		if err := viper.UnmarshalKey("cos", &config); err != nil {
+		if err := viper.UnmarshalKey("cos", &fileConfig); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		// 使用 --profile 或 COSCLI_PROFILE 指定的命名配置，config 命令中可以指定不存在的命名配置以新建
+		config, err = util.UseProfile(fileConfig, activeProfile())
+		if err != nil && firstArg != "config" {
 			fmt.Println(err)
 			os.Exit(1)
 		}
 		if config.Base.Protocol == "" {
 			config.Base.Protocol = "https"
 		}
-		// 若未关闭秘钥加密，则先解密秘钥
-		if config.Base.DisableEncryption != "true" {
-			secretKey, err := util.DecryptSecret(config.Base.SecretKey)
-			if err == nil {
-				config.Base.SecretKey = secretKey
-			}
-			secretId, err := util.DecryptSecret(config.Base.SecretID)
-			if err == nil {
-				config.Base.SecretID = secretId
-			}
-			sessionToken, err := util.DecryptSecret(config.Base.SessionToken)
-			if err == nil {
-				config.Base.SessionToken = sessionToken
+		// 按配置的存储方式读取密钥，config 命令中读取失败时仍可修改配置，但不能重新保存密钥
+		credentialErr = util.LoadCredential(&config.Base)
+		if credentialErr != nil {
+			if firstArg != "config" {
+				fmt.Println(credentialErr)
+				os.Exit(1)
 			}
+			logger.Warnln(credentialErr)
 		}
-
 	} else {
 		fmt.Println(err)
 		os.Exit(1)
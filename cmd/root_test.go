@@ -1,8 +1,14 @@
 package cmd
 
 import (
+	"coscli/util"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -29,3 +35,122 @@ func TestSkipCmd(t *testing.T) {
 		So(e, ShouldBeError)
 	})
 }
+
+func TestConnectionFlags(t *testing.T) {
+	fmt.Println("TestConnectionFlags")
+	dir, err := ioutil.TempDir("", "coscli-transport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testBucket = randStr(8)
+	testAlias = testBucket + "-alias"
+	setUp(testBucket, testAlias, testEndpoint, false, false)
+	defer tearDown(testBucket, testAlias, testEndpoint, false)
+	invalidCaFile := filepath.Join(dir, "ca.pem")
+	if err = ioutil.WriteFile(invalidCaFile, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	Convey("Test connection flags", t, func() {
+		Convey("success", func() {
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"ls", "cos://" + testAlias, "--connect-timeout", "5", "--read-timeout", "30",
+				"--max-conns", "2", "--keepalive", "-1", "--insecure-skip-verify"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+		})
+		Convey("customized endpoint", func() {
+			if testServer == nil {
+				return
+			}
+			// 自定义域名中没有桶名，内存服务使用默认桶
+			testServer.DefaultBucket = fmt.Sprintf("%s-%s", testBucket, appID)
+			defer func() { testServer.DefaultBucket = "" }()
+			// 配置文件中不设置代理，请求直接发送到 --endpoint 指定的内存服务地址
+			configFile := filepath.Join(dir, "customized.yaml")
+			content := fmt.Sprintf(`cos:
+  base:
+    secretid: %s
+    secretkey: %s
+    protocol: http
+    mode: SecretKey
+    disableencryption: "true"
+  buckets:
+  - name: %s-%s
+    alias: %s
+    endpoint: %s
+`, testSecretID, testSecretKey, testBucket, appID, testAlias, testEndpoint)
+			So(ioutil.WriteFile(configFile, []byte(content), 0600), ShouldBeNil)
+			localFile := filepath.Join(dir, "customized")
+			So(ioutil.WriteFile(localFile, []byte("customized endpoint"), 0600), ShouldBeNil)
+			for _, args := range [][]string{
+				{"cp", localFile, "cos://" + testAlias + "/customized"},
+				{"ls", "cos://" + testAlias},
+				{"cp", "cos://" + testAlias + "/customized", localFile + ".download"},
+			} {
+				clearCmd()
+				cmd := rootCmd
+				cmd.SetArgs(append(args, "-c", configFile, "-e", testServer.Endpoint(), "--customized"))
+				So(cmd.Execute(), ShouldBeNil)
+			}
+			data, err := ioutil.ReadFile(localFile + ".download")
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "customized endpoint")
+		})
+		Convey("transport hook", func() {
+			var hooked []*http.Transport
+			util.TransportHook = func(transport *http.Transport) {
+				hooked = append(hooked, transport)
+			}
+			defer func() { util.TransportHook = nil }()
+			// 使用未用过的连接设置，确保创建新的 Transport
+			clearCmd()
+			cmd := rootCmd
+			cmd.SetArgs([]string{"ls", "cos://" + testAlias, "--connect-timeout", "17"})
+			So(cmd.Execute(), ShouldBeNil)
+			So(len(hooked), ShouldBeGreaterThan, 0)
+			So(hooked[0].DialContext, ShouldNotBeNil)
+		})
+		Convey("options", func() {
+			cfg := util.Config{Base: util.BaseCfg{ConnectTimeout: "10", MaxConns: "4", Proxy: "http://127.0.0.1:3128"}}
+			fo := &util.FileOperations{Operation: util.Operation{Routines: 8}}
+			opt, err := util.GetTransportOptions(&cfg, &util.Param{ConnectTimeout: 3}, fo)
+			So(err, ShouldBeNil)
+			So(opt.ConnectTimeout, ShouldEqual, 3*time.Second)
+			So(opt.Proxy, ShouldEqual, "http://127.0.0.1:3128")
+			So(opt.MaxConns, ShouldEqual, 4)
+			So(opt.MaxIdleConns, ShouldEqual, 4)
+
+			fo.Operation.DisableLongLinks = true
+			opt, err = util.GetTransportOptions(&cfg, &util.Param{}, fo)
+			So(err, ShouldBeNil)
+			So(opt.ConnectTimeout, ShouldEqual, 10*time.Second)
+			So(opt.DisableKeepAlives, ShouldBeTrue)
+
+			cfg.Base.ReadTimeout = "soon"
+			_, err = util.GetTransportOptions(&cfg, &util.Param{}, nil)
+			So(err, ShouldBeError)
+		})
+		Convey("fail", func() {
+			for _, flags := range [][]string{
+				{"--proxy", "://invalid-proxy"},
+				{"--ca-file", filepath.Join(dir, "not-exist.pem")},
+				{"--ca-file", invalidCaFile},
+			} {
+				clearCmd()
+				cmd := rootCmd
+				args := append([]string{"ls", "cos://" + testAlias}, flags...)
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			}
+		})
+	})
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// 注册服务端加密相关参数
+func addSSEFlags(cmd *cobra.Command) {
+	cmd.Flags().String("sse", "", "Server-side encryption of the uploaded or copied objects, cos(SSE-COS) or kms(SSE-KMS)")
+	cmd.Flags().String("sse-kms-key-id", "", "The KMS key id used with --sse kms, the default key of KMS is used if not specified")
+	cmd.Flags().String("sse-c-key", "", "The customer key of SSE-C, 32 bytes or 32 bytes encoded in base64. Objects are uploaded or copied with it, and it is required to download objects encrypted with it")
+	cmd.Flags().String("sse-c-key-file", "", "Read the customer key of SSE-C from the file")
+	cmd.Flags().String("source-sse-c-key", "", "The customer key of SSE-C for reading the source objects of a copy, if they are encrypted with a different key")
+	cmd.Flags().String("source-sse-c-key-file", "", "Read the customer key of SSE-C for the source objects of a copy from the file")
+}
+
+// 按传输方向校验并获取服务端加密设置
+func getSSEOptions(cmd *cobra.Command, srcUrl, destUrl util.StorageUrl) (util.SSEOptions, error) {
+	sse, _ := cmd.Flags().GetString("sse")
+	kmsKeyId, _ := cmd.Flags().GetString("sse-kms-key-id")
+	customerKey, _ := cmd.Flags().GetString("sse-c-key")
+	customerKeyFile, _ := cmd.Flags().GetString("sse-c-key-file")
+	sourceKey, _ := cmd.Flags().GetString("source-sse-c-key")
+	sourceKeyFile, _ := cmd.Flags().GetString("source-sse-c-key-file")
+
+	opt, err := util.NewSSEOptions(sse, kmsKeyId, customerKey, customerKeyFile, sourceKey, sourceKeyFile)
+	if err != nil {
+		return opt, err
+	}
+	if srcUrl.IsCosUrl() && destUrl.IsFileUrl() && opt.Algorithm != "" {
+		return opt, fmt.Errorf("--sse can not use in download, objects encrypted with SSE-COS or SSE-KMS are decrypted automatically")
+	}
+	if opt.SourceCustomerKey != nil && !(srcUrl.IsCosUrl() && destUrl.IsCosUrl()) {
+		return opt, fmt.Errorf("--source-sse-c-key only works with copy between cos paths")
+	}
+	return opt, nil
+}
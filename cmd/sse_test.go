@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"coscli/util"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSSE(t *testing.T) {
+	fmt.Println("TestSSE")
+	if testServer == nil {
+		t.Skip("SSE-KMS and SSE-C are only checked in the offline cos service")
+	}
+	dir, err := ioutil.TempDir("", "coscli-sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testBucket1 = randStr(8)
+	testAlias1 = testBucket1 + "-alias"
+	testBucket2 = randStr(8)
+	testAlias2 = testBucket2 + "-alias"
+	setUp(testBucket1, testAlias1, testEndpoint, false, false)
+	defer tearDown(testBucket1, testAlias1, testEndpoint, false)
+	setUp(testBucket2, testAlias2, testEndpoint, false, false)
+	defer tearDown(testBucket2, testAlias2, testEndpoint, false)
+	c1, _ := util.NewClient(&config, &param, testAlias1)
+	c2, _ := util.NewClient(&config, &param, testAlias2)
+
+	smallFile := filepath.Join(dir, "small")
+	genFile(smallFile, 30*1024)
+	bigFile := filepath.Join(dir, "big")
+	genFile(bigFile, 2*1024*1024+100)
+	key1 := "0123456789abcdef0123456789abcdef"
+	key2 := base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210"))
+	keyFile := filepath.Join(dir, "sse-c.key")
+	if err = ioutil.WriteFile(keyFile, []byte(key1+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	Convey("Test server-side encryption", t, func() {
+		Convey("sse-cos and sse-kms", func() {
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"cp", smallFile, fmt.Sprintf("cos://%s/sse-cos", testAlias1), "--sse", "cos"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			resp, err := util.GetHead(c1, "sse-cos")
+			So(err, ShouldBeNil)
+			So(resp.Header.Get("x-cos-server-side-encryption"), ShouldEqual, "AES256")
+
+			clearCmd()
+			args = []string{"cp", bigFile, fmt.Sprintf("cos://%s/sse-kms", testAlias1), "--sse", "kms",
+				"--sse-kms-key-id", "coscli-kms-key", "--part-size", "1"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			resp, err = util.GetHead(c1, "sse-kms")
+			So(err, ShouldBeNil)
+			So(resp.Header.Get("x-cos-server-side-encryption"), ShouldEqual, "cos/kms")
+			So(resp.Header.Get("x-cos-server-side-encryption-cos-kms-key-id"), ShouldEqual, "coscli-kms-key")
+
+			// 拷贝时指定目标对象的加密方式
+			clearCmd()
+			args = []string{"cp", fmt.Sprintf("cos://%s/sse-cos", testAlias1), fmt.Sprintf("cos://%s/sse-kms-copy", testAlias2), "--sse", "kms"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			resp, err = util.GetHead(c2, "sse-kms-copy")
+			So(err, ShouldBeNil)
+			So(resp.Header.Get("x-cos-server-side-encryption"), ShouldEqual, "cos/kms")
+		})
+		Convey("sse-c", func() {
+			// 分块上传的每个分块均需携带密钥
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"cp", bigFile, fmt.Sprintf("cos://%s/sse-c", testAlias1), "--sse-c-key", key1, "--part-size", "1"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			_, err := util.GetHead(c1, "sse-c")
+			So(err, ShouldBeError)
+			resp, err := util.GetHeadWithKey(c1, "sse-c", []byte(key1))
+			So(err, ShouldBeNil)
+			So(resp.Header.Get("x-cos-server-side-encryption-customer-algorithm"), ShouldEqual, "AES256")
+
+			// 已上传且密钥相同时跳过
+			clearCmd()
+			args = []string{"sync", bigFile, fmt.Sprintf("cos://%s/sse-c", testAlias1), "--sse-c-key-file", keyFile, "--part-size", "1"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+
+			// 下载时需提供密钥
+			downloadFile := filepath.Join(dir, "download", "sse-c")
+			clearCmd()
+			args = []string{"cp", fmt.Sprintf("cos://%s/sse-c", testAlias1), downloadFile, "--sse-c-key-file", keyFile, "--part-size", "1"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			want, _ := ioutil.ReadFile(bigFile)
+			got, err := ioutil.ReadFile(downloadFile)
+			So(err, ShouldBeNil)
+			So(bytes.Equal(got, want), ShouldBeTrue)
+
+			clearCmd()
+			args = []string{"cp", fmt.Sprintf("cos://%s/sse-c", testAlias1), filepath.Join(dir, "download", "no-key")}
+			cmd.SetArgs(args)
+			e := cmd.Execute()
+			fmt.Printf(" : %v", e)
+			So(e, ShouldBeError)
+
+			// 拷贝为使用另一密钥加密的对象
+			clearCmd()
+			args = []string{"cp", fmt.Sprintf("cos://%s/sse-c", testAlias1), fmt.Sprintf("cos://%s/sse-c-copy", testAlias2),
+				"--source-sse-c-key-file", keyFile, "--sse-c-key", key2}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			newKey, _ := base64.StdEncoding.DecodeString(key2)
+			_, err = util.GetHeadWithKey(c2, "sse-c-copy", newKey)
+			So(err, ShouldBeNil)
+			_, err = util.GetHeadWithKey(c2, "sse-c-copy", []byte(key1))
+			So(err, ShouldBeError)
+
+			clearCmd()
+			args = []string{"cp", fmt.Sprintf("cos://%s/sse-c", testAlias1), fmt.Sprintf("cos://%s/sse-c-copy", testAlias2), "--sse-c-key", key2}
+			cmd.SetArgs(args)
+			e = cmd.Execute()
+			fmt.Printf(" : %v", e)
+			So(e, ShouldBeError)
+		})
+		Convey("fail", func() {
+			cosPath := fmt.Sprintf("cos://%s/sse-fail", testAlias1)
+			for _, args := range [][]string{
+				{"cp", smallFile, cosPath, "--sse", "aes"},
+				{"cp", smallFile, cosPath, "--sse", "cos", "--sse-kms-key-id", "coscli-kms-key"},
+				{"cp", smallFile, cosPath, "--sse", "cos", "--sse-c-key", key1},
+				{"cp", smallFile, cosPath, "--sse-c-key", "short-key"},
+				{"cp", smallFile, cosPath, "--sse-c-key", key1, "--sse-c-key-file", keyFile},
+				{"cp", smallFile, cosPath, "--source-sse-c-key", key1},
+				{"cp", cosPath, filepath.Join(dir, "sse-fail"), "--sse", "cos"},
+				{"sync", smallFile, cosPath, "--sse-c-key-file", filepath.Join(dir, "not-exist.key")},
+			} {
+				clearCmd()
+				cmd := rootCmd
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			}
+		})
+	})
+}
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"coscli/util"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/viper"
+)
+
+// 模拟 STS 服务，按会话名称统计请求次数，proxied 为经代理发送的请求数
+type testStsServer struct {
+	mu      sync.Mutex
+	hits    map[string]int
+	proxied int
+	token   string
+}
+
+func (s *testStsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var params map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&params)
+	session, _ := params["RoleSessionName"].(string)
+	s.hits[session]++
+	if r.URL.IsAbs() {
+		s.proxied++
+	}
+
+	var errCode string
+	switch r.Header.Get("X-TC-Action") {
+	case "AssumeRole":
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "TC3-HMAC-SHA256 Credential=source-secret-id/") {
+			errCode = "AuthFailure.SignatureFailure"
+		} else if params["ExternalId"] != "coscli-external-id" {
+			errCode = "InvalidParameter.ExternalIdMismatch"
+		}
+	case "AssumeRoleWithWebIdentity":
+		if params["WebIdentityToken"] != s.token || params["ProviderId"] != util.DefaultProviderId {
+			errCode = "InvalidParameter.WebIdentityTokenError"
+		}
+	default:
+		errCode = "InvalidAction"
+	}
+
+	resp := map[string]interface{}{"RequestId": "coscli-test"}
+	if errCode != "" {
+		resp["Error"] = map[string]string{"Code": errCode, "Message": "test error"}
+	} else {
+		expiredTime := time.Now().Add(time.Hour)
+		resp["Credentials"] = map[string]string{
+			"TmpSecretId":  testSecretID,
+			"TmpSecretKey": testSecretKey,
+			"Token":        "sts-token-" + session,
+		}
+		resp["ExpiredTime"] = expiredTime.Unix()
+		resp["Expiration"] = expiredTime.UTC().Format(time.RFC3339)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"Response": resp})
+}
+
+func (s *testStsServer) Proxied() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.proxied
+}
+
+func (s *testStsServer) Hits(session string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[session]
+}
+
+func TestSts(t *testing.T) {
+	fmt.Println("TestSts")
+	if testServer == nil {
+		t.Skip("the sts service is only available in the offline cos service")
+	}
+	dir, err := ioutil.TempDir("", "coscli-sts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	viper.Set("cos", nil)
+	defer func() {
+		cfgFile = ""
+		viper.Set("cos", nil)
+		config = util.Config{}
+		fileConfig = util.Config{}
+		getConfig()
+		clearConfigCmd()
+	}()
+
+	tokenFile := filepath.Join(dir, "token")
+	sts := &testStsServer{hits: make(map[string]int), token: "coscli-oidc-token"}
+	if err = ioutil.WriteFile(tokenFile, []byte(sts.token+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	stsServer := httptest.NewServer(sts)
+	defer stsServer.Close()
+	// 配置文件中的代理同时转发 STS 与 COS 的请求，申请临时密钥时同样使用配置的代理
+	stsHost := strings.TrimPrefix(stsServer.URL, "http://")
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == stsHost {
+			sts.ServeHTTP(w, r)
+			return
+		}
+		testServer.ServeHTTP(w, r)
+	}))
+	defer proxyServer.Close()
+
+	// 配置文件中的密钥只能用于申请临时密钥，不能直接访问桶
+	writeConfig := func(name, base string) string {
+		configFile := filepath.Join(dir, name+".yaml")
+		content := fmt.Sprintf(`cos:
+  base:
+    protocol: http
+    disableencryption: "true"
+    proxy: %s
+    rolearn: qcs::cam::uin/100000000001:roleName/coscli-test
+    rolesessionname: %s
+    stsendpoint: %s
+%s
+  buckets:
+  - name: coscli-test-%s
+    alias: coscli-test
+    endpoint: %s
+`, proxyServer.URL, name, stsServer.URL, base, testAppID, testEndpoint)
+		if err := ioutil.WriteFile(configFile, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return configFile
+	}
+
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	Convey("Test coscli with sts", t, func() {
+		Convey("assume role", func() {
+			configFile := writeConfig("assume", `    mode: AssumeRole
+    secretid: source-secret-id
+    secretkey: source-secret-key
+    externalid: coscli-external-id`)
+			for i := 0; i < 2; i++ {
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"ls", "cos://coscli-test", "-c", configFile}
+				cmd.SetArgs(args)
+				So(cmd.Execute(), ShouldBeNil)
+			}
+			// 第二次使用磁盘上缓存的临时密钥
+			So(sts.Hits("assume"), ShouldEqual, 1)
+			So(sts.Proxied(), ShouldBeGreaterThan, 0)
+			files, err := ioutil.ReadDir(filepath.Join(os.Getenv("HOME"), ".cos.sts-cache"))
+			So(err, ShouldBeNil)
+			So(len(files), ShouldBeGreaterThanOrEqualTo, 1)
+			So(files[0].Mode().Perm(), ShouldEqual, os.FileMode(0600))
+		})
+		Convey("external id mismatch", func() {
+			configFile := writeConfig("mismatch", `    mode: AssumeRole
+    secretid: source-secret-id
+    secretkey: source-secret-key
+    externalid: wrong-external-id`)
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"ls", "cos://coscli-test", "-c", configFile}
+			cmd.SetArgs(args)
+			e := cmd.Execute()
+			fmt.Printf(" : %v", e)
+			So(e, ShouldBeError)
+		})
+		Convey("web identity", func() {
+			configFile := writeConfig("web", `    mode: WebIdentity
+    webidentitytokenfile: `+tokenFile)
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"ls", "cos://coscli-test", "-c", configFile}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			So(sts.Hits("web"), ShouldEqual, 1)
+		})
+		Convey("web identity without token file", func() {
+			configFile := writeConfig("notoken", `    mode: WebIdentity
+    webidentitytokenfile: `+filepath.Join(dir, "not-exist"))
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"ls", "cos://coscli-test", "-c", configFile}
+			cmd.SetArgs(args)
+			e := cmd.Execute()
+			fmt.Printf(" : %v", e)
+			So(e, ShouldBeError)
+		})
+		Convey("config set", func() {
+			osArgs := os.Args
+			os.Args = []string{"coscli", "config"}
+			defer func() { os.Args = osArgs }()
+			configFile := writeConfig("set", "    mode: SecretKey")
+			clearCmd()
+			cmd := rootCmd
+			args := []string{"config", "set", "-c", configFile, "--mode", "AssumeRole", "--external_id", "set-external-id",
+				"--duration_seconds", "3600"}
+			cmd.SetArgs(args)
+			So(cmd.Execute(), ShouldBeNil)
+			v := viper.New()
+			v.SetConfigFile(configFile)
+			So(v.ReadInConfig(), ShouldBeNil)
+			var cfg util.Config
+			So(v.UnmarshalKey("cos", &cfg), ShouldBeNil)
+			So(cfg.Base.Mode, ShouldEqual, "AssumeRole")
+			So(cfg.Base.ExternalId, ShouldEqual, "set-external-id")
+			So(cfg.Base.DurationSeconds, ShouldEqual, "3600")
+			So(cfg.Base.StsEndpoint, ShouldEqual, stsServer.URL)
+
+			clearCmd()
+			args = []string{"config", "set", "-c", configFile, "--duration_seconds", "one-hour"}
+			cmd.SetArgs(args)
+			e := cmd.Execute()
+			fmt.Printf(" : %v", e)
+			So(e, ShouldBeError)
+		})
+	})
+}
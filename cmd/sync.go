@@ -26,7 +26,11 @@ Example:
   Sync Download:
     ./coscli sync cos://examplebucket/example.txt ~/example.txt
   Sync Copy:
-    ./coscli sync cos://examplebucket1/example1.txt cos://examplebucket2/example2.txt`,
+    ./coscli sync cos://examplebucket1/example1.txt cos://examplebucket2/example2.txt
+  Sync Upload with SSE-C:
+    ./coscli sync ~/example.txt cos://examplebucket/example.txt --sse-c-key-file ~/sse-c.key
+  Sync Upload the new data of growing log files by append upload, recording the append positions:
+    ./coscli sync ~/logs/ cos://examplebucket/logs/ -r --append --snapshot-path ~/.coscli-snapshot`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if err := cobra.ExactArgs(2)(cmd, args); err != nil {
 			return err
@@ -35,8 +39,6 @@ Example:
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		recursive, _ := cmd.Flags().GetBool("recursive")
-		include, _ := cmd.Flags().GetString("include")
-		exclude, _ := cmd.Flags().GetString("exclude")
 		storageClass, _ := cmd.Flags().GetString("storage-class")
 		rateLimiting, _ := cmd.Flags().GetFloat32("rate-limiting")
 		partSize, _ := cmd.Flags().GetInt64("part-size")
@@ -50,11 +52,16 @@ Example:
 		routines, _ := cmd.Flags().GetInt("routines")
 		failOutput, _ := cmd.Flags().GetBool("fail-output")
 		failOutputPath, _ := cmd.Flags().GetString("fail-output-path")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		checkpointDir, _ := cmd.Flags().GetString("checkpoint-dir")
+		resume, _ := cmd.Flags().GetBool("resume")
 		onlyCurrentDir, _ := cmd.Flags().GetBool("only-current-dir")
 		disableAllSymlink, _ := cmd.Flags().GetBool("disable-all-symlink")
 		enableSymlinkDir, _ := cmd.Flags().GetBool("enable-symlink-dir")
 		disableCrc64, _ := cmd.Flags().GetBool("disable-crc64")
 		disableChecksum, _ := cmd.Flags().GetBool("disable-checksum")
+		appendMode, _ := cmd.Flags().GetBool("append")
+		sha256, _ := cmd.Flags().GetBool("sha256")
 		disableLongLinks, _ := cmd.Flags().GetBool("disable-long-links")
 		longLinksNums, _ := cmd.Flags().GetInt("long-links-nums")
 		backupDir, _ := cmd.Flags().GetString("backup-dir")
@@ -91,7 +98,30 @@ Example:
 			return fmt.Errorf("not support cp between local directory")
 		}
 
-		_, filters := util.GetFilter(include, exclude)
+		filters, err := getFilters(cmd)
+		if err != nil {
+			return err
+		}
+
+		sse, err := getSSEOptions(cmd, srcUrl, destUrl)
+		if err != nil {
+			return err
+		}
+
+		clientEncryption, err := getTransferClientEncryption(cmd, srcUrl, destUrl)
+		if err != nil {
+			return err
+		}
+
+		acl, err := getTransferACLOptions(cmd, srcUrl, destUrl)
+		if err != nil {
+			return err
+		}
+
+		tagging, err := getTaggingOptions(cmd, srcUrl, destUrl)
+		if err != nil {
+			return err
+		}
 
 		fo := &util.FileOperations{
 			Operation: util.Operation{
@@ -104,6 +134,7 @@ Example:
 				Routines:          routines,
 				FailOutput:        failOutput,
 				FailOutputPath:    failOutputPath,
+				DryRun:            dryRun,
 				Meta:              meta,
 				RetryNum:          retryNum,
 				ErrRetryNum:       errRetryNum,
@@ -115,10 +146,18 @@ Example:
 				DisableChecksum:   disableChecksum,
 				DisableLongLinks:  disableLongLinks,
 				LongLinksNums:     longLinksNums,
+				CheckpointDir:     checkpointDir,
+				Resume:            resume,
 				SnapshotPath:      snapshotPath,
 				Delete:            delete,
 				BackupDir:         backupDir,
 				Force:             force,
+				Append:            appendMode,
+				Sha256:            sha256,
+				SSE:               sse,
+				ACL:               acl,
+				Tagging:           tagging,
+				ClientEncryption:  clientEncryption,
 			},
 			Monitor:   &util.FileProcessMonitor{},
 			Config:    &config,
@@ -128,11 +167,45 @@ Example:
 			Command:   util.CommandSync,
 		}
 
+		// 来源与目标可以使用不同的命名配置
+		err = initTransferProfiles(cmd, srcUrl, destUrl, fo)
+		if err != nil {
+			return err
+		}
+
+		err = util.CheckAppendOptions(srcUrl, destUrl, fo)
+		if err != nil {
+			return err
+		}
+
+		err = util.CheckSha256Options(srcUrl, destUrl, fo)
+		if err != nil {
+			return err
+		}
+
+		err = initDryRun(fo)
+		if err != nil {
+			return err
+		}
+
+		err = checkFiltersRecursive(fo)
+		if err != nil {
+			return err
+		}
+
 		// 快照db实例化
 		err = util.InitSnapshotDb(srcUrl, destUrl, fo)
 		if err != nil {
 			return err
 		}
+		defer util.CloseSnapshotDb(fo)
+
+		// 断点续传任务日志实例化
+		err = util.InitCheckpoint(srcUrl, destUrl, fo)
+		if err != nil {
+			return err
+		}
+		defer util.CloseCheckpoint(fo)
 
 		srcPath := srcUrl.ToString()
 		destPath := destUrl.ToString()
@@ -220,7 +293,7 @@ Example:
 			logger.Infof("Copy %s to %s start", srcPath, destPath)
 			// 实例化来源 cos client
 			srcBucketName := srcUrl.(*util.CosUrl).Bucket
-			srcClient, err := util.NewClient(fo.Config, fo.Param, srcBucketName)
+			srcClient, err := util.NewClient(util.SrcConfig(fo), fo.Param, srcBucketName)
 			if err != nil {
 				return err
 			}
@@ -257,6 +330,12 @@ Example:
 		} else {
 			return fmt.Errorf("cospath needs to contain cos://")
 		}
+		if fo.Operation.DryRun {
+			fo.Plan.Finish()
+			util.CloseErrorOutputFile(fo)
+			return nil
+		}
+		util.FinishCheckpoint(fo)
 		util.CloseErrorOutputFile(fo)
 		endT := time.Now().UnixNano() / 1000 / 1000
 		util.PrintCostTime(startT, endT)
@@ -275,8 +354,13 @@ func init() {
 	rootCmd.AddCommand(syncCmd)
 
 	syncCmd.Flags().BoolP("recursive", "r", false, "Synchronize objects recursively")
-	syncCmd.Flags().String("include", "", "List files that meet the specified criteria")
-	syncCmd.Flags().String("exclude", "", "Exclude files that meet the specified criteria")
+	addFilterFlags(syncCmd)
+	addTransferProfileFlags(syncCmd)
+	addSSEFlags(syncCmd)
+	addClientEncryptionFlags(syncCmd, true)
+	addACLFlags(syncCmd)
+	addTaggingFlags(syncCmd)
+	addMetaFilterFlags(syncCmd)
 	syncCmd.Flags().String("storage-class", "", "Specifying a storage class")
 	syncCmd.Flags().Float32("rate-limiting", 0, "Upload or download speed limit(MB/s)")
 	syncCmd.Flags().Int64("part-size", 32, "Specifies the block size(MB)")
@@ -304,6 +388,8 @@ func init() {
 		"In addition, coscli does not automatically delete snapshot-path snapshot information, "+
 		"in order to avoid too much snapshot information, when the snapshot information is useless, "+
 		"please clean up your own snapshot-path on your own immediately.")
+	syncCmd.Flags().Bool("append", false, "Upload by appending to appendable objects, only the new data of growing local files is uploaded. With --snapshot-path the append positions are recorded, so the uploaded data is not read again, and a local file shorter than the recorded position is reported as truncated.")
+	syncCmd.Flags().Bool("sha256", false, "Calculate the SHA-256 of local files and record it in the x-cos-meta-sha256 metadata of the uploaded objects, which can be checked by verify --sha256 and shown by hash --type sha256. Only for upload.")
 	syncCmd.Flags().Bool("delete", false, "Delete any other files in the specified destination path, only keeping the files synced this time. It is recommended to enable version control before using the --delete option to prevent accidental data deletion.")
 	syncCmd.Flags().Int("retry-num", 0, "Rate-limited retry. Specify 1-10 times. When multiple machines concurrently execute download operations on the same COS directory, rate-limited retry can be performed by specifying this parameter.")
 	syncCmd.Flags().Int("err-retry-num", 0, "Error retry attempts. Specify 1-10 times, or 0 for no retry.")
@@ -320,4 +406,7 @@ func init() {
 	syncCmd.Flags().Bool("long-links-nums", false, "The long connection quantity parameter, if 0 or not provided, defaults to the concurrent file count.")
 	syncCmd.Flags().String("backup-dir", "", "Synchronize deleted file backups, used to save the destination-side files that have been deleted but do not exist on the source side.")
 	syncCmd.Flags().Bool("force", false, "Force the operation without prompting for confirmation")
+	syncCmd.Flags().String("checkpoint-dir", "", "Directory to keep the job journal of a recursive transfer. Completed files are recorded in it, so the same command can be rerun with --resume after a crash or Ctrl-C. The journal is deleted after the job succeeds.")
+	syncCmd.Flags().Bool("resume", false, "Resume the job from the journal in --checkpoint-dir, skipping files already done. A cos source is listed again from the listing position kept in the journal, a local source is walked again, and every listed file is checked against the journal. Without it, the journal of the same job is cleared and the job starts over.")
+	syncCmd.Flags().Bool("dry-run", false, "Print the uploads, downloads, copies, skips and deletes that would be performed without performing them. Use --output to print them as json, jsonl or csv")
 }
@@ -4,9 +4,13 @@ import (
 	"context"
 	"coscli/util"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/agiledragon/gomonkey/v2"
 	. "github.com/smartystreets/goconvey/convey"
@@ -51,6 +55,71 @@ func TestSyncCmd(t *testing.T) {
 				e := cmd.Execute()
 				So(e, ShouldBeNil)
 			})
+			Convey("dry-run同步并删除多余对象", func() {
+				clearCmd()
+				cmd := rootCmd
+				localFileName := fmt.Sprintf("%s/big-file", testDir)
+				cosFileName := fmt.Sprintf("cos://%s/%s", testAlias1, "multi-small")
+				args := []string{"sync", localFileName, cosFileName, "-r", "--delete", "--force", "--dry-run", "--output", "csv"}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				So(e, ShouldBeNil)
+				// dry-run 不删除对象
+				c1, _ := util.NewClient(&config, &param, testAlias1)
+				res, _, err := c1.Bucket.Get(context.Background(), &cos.BucketGetOptions{Prefix: "multi-small/"})
+				So(err, ShouldBeNil)
+				So(len(res.Contents), ShouldEqual, 3)
+			})
+			Convey("按修改时间或大小过滤时不删除来源中仍存在的文件", func() {
+				localDir := fmt.Sprintf("%s/delete-meta-filter", testDir)
+				os.MkdirAll(localDir, 0755)
+				ioutil.WriteFile(localDir+"/old.txt", []byte("old"), 0644)
+				ioutil.WriteFile(localDir+"/new.txt", []byte("new"), 0644)
+				cosFileName := fmt.Sprintf("cos://%s/%s", testAlias1, "delete-meta-filter/")
+				clearCmd()
+				cmd := rootCmd
+				cmd.SetArgs([]string{"sync", localDir + "/", cosFileName, "-r"})
+				So(cmd.Execute(), ShouldBeNil)
+
+				old := time.Now().Add(-48 * time.Hour)
+				os.Chtimes(localDir+"/old.txt", old, old)
+				ioutil.WriteFile(localDir+"/new.txt", []byte("new file grows past the limit"), 0644)
+				for _, args := range [][]string{
+					{"--newer-than", "1h"},
+					{"--max-size", "10B"},
+				} {
+					clearCmd()
+					cmd.SetArgs(append([]string{"sync", localDir + "/", cosFileName, "-r", "--delete", "--force"}, args...))
+					So(cmd.Execute(), ShouldBeNil)
+					c1, _ := util.NewClient(&config, &param, testAlias1)
+					res, _, err := c1.Bucket.Get(context.Background(), &cos.BucketGetOptions{Prefix: "delete-meta-filter/"})
+					So(err, ShouldBeNil)
+					So(len(res.Contents), ShouldEqual, 2)
+				}
+			})
+			Convey("源目录的上级目录与排除规则同名时仍保留被排除的对象", func() {
+				localDir := fmt.Sprintf("%s/keep/delete-rel-filter", testDir)
+				os.MkdirAll(localDir+"/keep", 0755)
+				ioutil.WriteFile(localDir+"/a.txt", []byte("a"), 0644)
+				cosFileName := fmt.Sprintf("cos://%s/%s", testAlias1, "delete-rel-filter/")
+				c1, _ := util.NewClient(&config, &param, testAlias1)
+				_, err := c1.Object.Put(context.Background(), "delete-rel-filter/keep/remote.txt", strings.NewReader("remote"), nil)
+				So(err, ShouldBeNil)
+
+				clearCmd()
+				cmd := rootCmd
+				cmd.SetArgs([]string{"sync", localDir + "/", cosFileName, "-r", "--delete", "--force",
+					"--filter-syntax", "glob", "--exclude", "keep/**"})
+				So(cmd.Execute(), ShouldBeNil)
+				res, _, err := c1.Bucket.Get(context.Background(), &cos.BucketGetOptions{Prefix: "delete-rel-filter/"})
+				So(err, ShouldBeNil)
+				keys := make([]string, 0)
+				for _, object := range res.Contents {
+					keys = append(keys, object.Key)
+				}
+				So(keys, ShouldContain, "delete-rel-filter/a.txt")
+				So(keys, ShouldContain, "delete-rel-filter/keep/remote.txt")
+			})
 			Convey("上传单个大文件", func() {
 				clearCmd()
 				cmd := rootCmd
@@ -274,12 +343,22 @@ func TestSyncCmd(t *testing.T) {
 				fmt.Printf(" : %v", e)
 				So(e, ShouldBeError)
 			})
+			Convey("no -r but --min-size", func() {
+				clearCmd()
+				cmd := rootCmd
+				args := []string{"sync", "./abc", "cos://123", "--min-size", "1KB"}
+				cmd.SetArgs(args)
+				e := cmd.Execute()
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+				So(e.Error(), ShouldContainSubstring, "--min-size")
+			})
 			Convey("no -r but -i", func() {
-				patches := ApplyFunc(util.GetFilter, func(string, string) (bool, []util.FilterOptionType) {
+				patches := ApplyFunc(util.GetFilter, func([]util.FilterRule, string, string) ([]util.FilterOptionType, error) {
 					tmp := []util.FilterOptionType{
 						util.FilterOptionType{},
 					}
-					return true, tmp
+					return tmp, nil
 				})
 				defer patches.Reset()
 				clearCmd()
@@ -497,6 +576,17 @@ func TestSyncCmd(t *testing.T) {
 					fmt.Printf(" : %v", e)
 					So(e, ShouldBeError)
 				})
+				Convey("getDeleteKeys", func() {
+					patches := ApplyFunc(util.GetCosKeys, func(c *cos.Client, cosUrl util.StorageUrl, keys map[string]string, fo *util.FileOperations) error {
+						return fmt.Errorf("test GetCosKeys error")
+					})
+					defer patches.Reset()
+					cmd.SetArgs([]string{"sync", fmt.Sprintf("cos://%s/%s", testAlias1, "multi-big"),
+						fmt.Sprintf("cos://%s/%s", testAlias1, "multi-copy"), "-r", "--delete", "--force"})
+					e := cmd.Execute()
+					fmt.Printf(" : %v", e)
+					So(e, ShouldBeError)
+				})
 			})
 		})
 	})
@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"coscli/cosmock"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/mitchellh/go-homedir"
@@ -31,7 +34,24 @@ var testOfsBucketAlias string
 var testVersionBucket string
 var testVersionBucketAlias string
 
+// 离线测试使用的内存版 COS 服务
+var testServer *cosmock.Server
+
+// 离线测试时配置文件中的代理地址，即内存服务的地址，测试中自行生成的配置文件也需设置
+var testProxy string
+
+const (
+	testSecretID  = "AKIDcoscliTestSecretId"
+	testSecretKey = "coscliTestSecretKey"
+	testAppID     = "1250000000"
+)
+
+// 测试中使用 gomonkey 打桩，需关闭内联运行：go test -gcflags=all=-l ./...
 func init() {
+	// 未设置 COSCLI_TEST_ONLINE=true 时，使用内存版 COS 服务离线运行测试
+	if os.Getenv("COSCLI_TEST_ONLINE") != "true" {
+		startTestServer()
+	}
 	// 读取配置文件
 	getConfig()
 	// 初始化 app-id
@@ -44,6 +64,45 @@ func init() {
 	}
 }
 
+// 启动内存版 COS 服务，并生成指向它的临时配置文件
+func startTestServer() {
+	testServer = cosmock.NewServer()
+	testServer.SecretID = testSecretID
+	// 配置文件中以内存服务为代理，<bucket>.cos.<region>.myqcloud.com 形式的默认域名的请求都发送到内存服务
+	testProxy = testServer.URL
+
+	home, err := ioutil.TempDir("", "coscli-test-home")
+	if err != nil {
+		logger.Fatalln(err)
+	}
+	homedir.DisableCache = true
+	os.Setenv("HOME", home)
+
+	bucketName := "coscli-test-" + testAppID
+	testServer.CreateBucket(bucketName, false)
+	content := fmt.Sprintf(`cos:
+  base:
+    secretid: %s
+    secretkey: %s
+    sessiontoken: ""
+    protocol: http
+    mode: SecretKey
+    cvmrolename: ""
+    closeautoswitchhost: "true"
+    disableencryption: "true"
+    proxy: %s
+  buckets:
+  - name: %s
+    alias: coscli-test
+    region: ap-guangzhou
+    endpoint: %s
+    ofs: false
+`, testSecretID, testSecretKey, testProxy, bucketName, testEndpoint)
+	if err = ioutil.WriteFile(filepath.Join(home, ".cos.yaml"), []byte(content), 0600); err != nil {
+		logger.Fatalln(err)
+	}
+}
+
 func getConfig() {
 	home, err := homedir.Dir()
 	if err != nil {
@@ -188,7 +247,8 @@ func tearDown(testBucket, testAlias, testEndpoint string, versioning bool) {
 
 func copyYaml() {
 	// 打开源文件
-	sourceFile, err := os.Open("/root/.cos.yaml")
+	home, _ := homedir.Dir()
+	sourceFile, err := os.Open(home + "/.cos.yaml")
 	if err != nil {
 		logger.Errorln("failed to open source file: %w", err)
 	}
@@ -268,8 +328,21 @@ func clearCmd() {
 		flag.Value.Set(flag.DefValue)
 	})
 
-	// 重置子命令的状态
+	// 重置子命令的状态，--include 与 --exclude 的规则不能设置为空，直接清空
 	for _, subCmd := range rootCmd.Commands() {
+		subCmd.Flags().VisitAll(func(flag *pflag.Flag) {
+			if v, ok := flag.Value.(*filterValue); ok {
+				*v.rules = nil
+				return
+			}
+			flag.Value.Set(flag.DefValue)
+		})
+	}
+}
+
+// clearCmd 不会重置 config 子命令的参数，修改过 config 子命令参数的测试结束后需调用
+func clearConfigCmd() {
+	for _, subCmd := range configCmd.Commands() {
 		subCmd.Flags().VisitAll(func(flag *pflag.Flag) {
 			flag.Value.Set(flag.DefValue)
 		})
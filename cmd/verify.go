@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"coscli/util"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	logger "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify a local directory against a cos prefix, or two cos prefixes, by size and CRC64",
+	Long: `Verify a local directory against a cos prefix, or two cos prefixes, by size and CRC64
+
+The files and objects are compared by their paths relative to the directory or the prefix.
+Files only in the source are reported as missing, files only in the target are reported as extra,
+and files with different sizes or checksums are reported as mismatch.
+The CRC64 of large local files is calculated in parts of --part-size concurrently and then combined.
+With --sha256, the SHA-256 recorded in x-cos-meta-sha256 by uploading with --sha256 is also compared.
+
+Format:
+	./coscli verify <source-path> <target-path> [flags]
+
+Example:
+	./coscli verify ~/example/ cos://examplebucket/example/
+	./coscli verify cos://examplebucket1/example/ cos://examplebucket2/example/ --output json
+	./coscli verify ~/example/ cos://examplebucket/example/ --sha256 --routines 8`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+			return err
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		routines, _ := cmd.Flags().GetInt("routines")
+		partSize, _ := cmd.Flags().GetInt64("part-size")
+		sha256, _ := cmd.Flags().GetBool("sha256")
+		if routines < 1 {
+			return fmt.Errorf("--routines must be greater than 0")
+		}
+		if partSize < 1 {
+			return fmt.Errorf("--part-size must be greater than 0")
+		}
+		filters, err := getFilters(cmd)
+		if err != nil {
+			return err
+		}
+		if err := initOutputFormat(); err != nil {
+			return err
+		}
+
+		source, err := newVerifySide(args[0])
+		if err != nil {
+			return err
+		}
+		target, err := newVerifySide(args[1])
+		if err != nil {
+			return err
+		}
+		if source.Client == nil && target.Client == nil {
+			return fmt.Errorf("verify needs at least one cos path which contains %s", util.SchemePrefix)
+		}
+
+		fo := &util.FileOperations{
+			Operation: util.Operation{
+				Recursive: true,
+				Filters:   filters,
+				Routines:  routines,
+				PartSize:  partSize,
+				Sha256:    sha256,
+			},
+		}
+		summary, err := util.VerifyObjects(source, target, fo)
+		if err != nil {
+			return err
+		}
+		printVerifySummary(summary)
+		if n := summary.Differences(); n > 0 {
+			return fmt.Errorf("verify found %d differences, missing: %d, extra: %d, mismatched: %d, failed: %d",
+				n, summary.Missing, summary.Extra, summary.Mismatched, summary.Failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().Int("routines", 3, "Specifies the number of files hashed and objects got concurrently")
+	verifyCmd.Flags().Int64("part-size", 32, "Local files larger than the part size(MB) are hashed in parts concurrently")
+	verifyCmd.Flags().Bool("sha256", false, "Also compare the SHA-256 recorded in x-cos-meta-sha256 by uploading with --sha256, local files are hashed entirely without parts")
+	addFilterFlags(verifyCmd)
+}
+
+// 本地目录或 COS 前缀
+func newVerifySide(path string) (*util.VerifySide, error) {
+	storageUrl, err := util.FormatUrl(path)
+	if err != nil {
+		return nil, fmt.Errorf("format url error:%v", err)
+	}
+	side := &util.VerifySide{Url: storageUrl}
+	if storageUrl.IsCosUrl() {
+		side.Client, err = util.NewClient(&config, &param, storageUrl.(*util.CosUrl).Bucket)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return side, nil
+}
+
+// 以表格输出不一致的项及统计，或按 --output 输出结构化结果
+func printVerifySummary(summary *util.VerifySummary) {
+	if !util.IsTableOutput() {
+		util.PrintSummary(summary)
+		return
+	}
+
+	if len(summary.Entries) > 0 {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Key", "Status", "Source Size", "Target Size", "Detail"})
+		for _, entry := range summary.Entries {
+			table.Append([]string{entry.Key, entry.Status, strconv.FormatInt(entry.SourceSize, 10), strconv.FormatInt(entry.TargetSize, 10), entry.Detail})
+		}
+		table.SetBorder(false)
+		table.SetAlignment(tablewriter.ALIGN_RIGHT)
+		table.Render()
+		fmt.Println()
+	}
+	logger.Infof("Source: %s", summary.Source)
+	logger.Infof("Target: %s", summary.Target)
+	logger.Infof("Matched: %d, Missing: %d, Extra: %d, Mismatched: %d, Failed: %d",
+		summary.Matched, summary.Missing, summary.Extra, summary.Mismatched, summary.Failed)
+}
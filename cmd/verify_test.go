@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"coscli/util"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestVerifyCmd(t *testing.T) {
+	fmt.Println("TestVerifyCmd")
+	dir, err := ioutil.TempDir("", "coscli-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testBucket = randStr(8)
+	testAlias = testBucket + "-alias"
+	setUp(testBucket, testAlias, testEndpoint, false, false)
+	defer tearDown(testBucket, testAlias, testEndpoint, false)
+	clearCmd()
+	cmd := rootCmd
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+
+	localDir := filepath.Join(dir, "data")
+	os.MkdirAll(filepath.Join(localDir, "sub"), 0755)
+	large := make([]byte, 3*1024*1024+100)
+	rand.Read(large)
+	ioutil.WriteFile(filepath.Join(localDir, "large.bin"), large, 0644)
+	ioutil.WriteFile(filepath.Join(localDir, "a.txt"), []byte("hello verify"), 0644)
+	ioutil.WriteFile(filepath.Join(localDir, "sub", "b.txt"), []byte("sub file"), 0644)
+	cosDir := fmt.Sprintf("cos://%s/verify/", testAlias)
+	clearCmd()
+	cmd.SetArgs([]string{"cp", localDir + "/", cosDir, "-r", "--sha256", "--part-size", "1"})
+	cmd.Execute()
+
+	verify := func(args ...string) (*util.VerifySummary, error) {
+		clearCmd()
+		cmd.SetArgs(append([]string{"verify"}, append(args, "--output", "json")...))
+		output, err := captureStdout(cmd.Execute)
+		summary := &util.VerifySummary{}
+		json.Unmarshal([]byte(output), summary)
+		return summary, err
+	}
+	Convey("Test coscli verify", t, func() {
+		Convey("local and cos", func() {
+			for _, args := range [][]string{
+				{localDir, cosDir},
+				{localDir, cosDir, "--part-size", "1", "--routines", "4"},
+				{localDir, cosDir, "--sha256"},
+				{cosDir, localDir},
+			} {
+				summary, err := verify(args...)
+				So(err, ShouldBeNil)
+				So(summary.Matched, ShouldEqual, 3)
+				So(summary.Entries, ShouldBeEmpty)
+			}
+
+			clearCmd()
+			cmd.SetArgs([]string{"verify", localDir, cosDir, "--include", ".*\\.txt"})
+			So(cmd.Execute(), ShouldBeNil)
+		})
+		Convey("cos and cos", func() {
+			copyDir := fmt.Sprintf("cos://%s/verify-copy/", testAlias)
+			clearCmd()
+			cmd.SetArgs([]string{"cp", cosDir, copyDir, "-r"})
+			So(cmd.Execute(), ShouldBeNil)
+			summary, err := verify(cosDir, copyDir, "--sha256")
+			So(err, ShouldBeNil)
+			So(summary.Matched, ShouldEqual, 3)
+		})
+		Convey("sha256 hash", func() {
+			clearCmd()
+			cmd.SetArgs([]string{"hash", cosDir + "large.bin", "--type", "sha256", "--output", "json"})
+			cosOutput, err := captureStdout(cmd.Execute)
+			So(err, ShouldBeNil)
+			clearCmd()
+			cmd.SetArgs([]string{"hash", filepath.Join(localDir, "large.bin"), "--type", "sha256", "--output", "json"})
+			localOutput, err := captureStdout(cmd.Execute)
+			So(err, ShouldBeNil)
+			cosSummary, localSummary := &util.HashSummary{}, &util.HashSummary{}
+			json.Unmarshal([]byte(cosOutput), cosSummary)
+			json.Unmarshal([]byte(localOutput), localSummary)
+			So(cosSummary.Hash, ShouldNotBeEmpty)
+			So(cosSummary.Hash, ShouldEqual, localSummary.Hash)
+		})
+		Convey("differences", func() {
+			diffDir := filepath.Join(dir, "diff")
+			os.MkdirAll(diffDir, 0755)
+			ioutil.WriteFile(filepath.Join(diffDir, "a.txt"), []byte("hello VERIFY"), 0644)
+			ioutil.WriteFile(filepath.Join(diffDir, "b.txt"), []byte("different size"), 0644)
+			ioutil.WriteFile(filepath.Join(diffDir, "large.bin"), large, 0644)
+			ioutil.WriteFile(filepath.Join(diffDir, "new.txt"), []byte("new"), 0644)
+			diffCosDir := fmt.Sprintf("cos://%s/verify-diff/", testAlias)
+			clearCmd()
+			cmd.SetArgs([]string{"cp", filepath.Join(localDir, "a.txt"), diffCosDir + "a.txt"})
+			So(cmd.Execute(), ShouldBeNil)
+			clearCmd()
+			cmd.SetArgs([]string{"cp", filepath.Join(localDir, "sub", "b.txt"), diffCosDir + "b.txt"})
+			So(cmd.Execute(), ShouldBeNil)
+			clearCmd()
+			cmd.SetArgs([]string{"cp", filepath.Join(localDir, "large.bin"), diffCosDir + "large.bin"})
+			So(cmd.Execute(), ShouldBeNil)
+			clearCmd()
+			cmd.SetArgs([]string{"cp", filepath.Join(localDir, "a.txt"), diffCosDir + "extra.txt"})
+			So(cmd.Execute(), ShouldBeNil)
+
+			summary, err := verify(diffDir, diffCosDir, "--part-size", "1")
+			So(err, ShouldBeError)
+			So(summary.Matched, ShouldEqual, 1)
+			So(summary.Missing, ShouldEqual, 1)
+			So(summary.Extra, ShouldEqual, 1)
+			So(summary.Mismatched, ShouldEqual, 2)
+			details := make(map[string]string)
+			for _, entry := range summary.Entries {
+				details[entry.Key] = entry.Status + " " + entry.Detail
+			}
+			So(details, ShouldResemble, map[string]string{
+				"a.txt":     "mismatch crc64",
+				"b.txt":     "mismatch size",
+				"extra.txt": "extra ",
+				"new.txt":   "missing ",
+			})
+
+			// 未记录 SHA-256 的对象
+			summary, err = verify(filepath.Join(localDir, "sub"), fmt.Sprintf("cos://%s/verify-diff/", testAlias), "--sha256", "--include", "b\\.txt")
+			So(err, ShouldBeError)
+			So(summary.Entries, ShouldHaveLength, 1)
+			So(summary.Entries[0].Detail, ShouldEqual, "sha256 not available")
+
+			clearCmd()
+			cmd.SetArgs([]string{"verify", diffDir, diffCosDir})
+			e := cmd.Execute()
+			fmt.Printf(" : %v", e)
+			So(e, ShouldBeError)
+		})
+		Convey("fail", func() {
+			cases := [][]string{
+				{"verify", localDir, dir},
+				{"verify", localDir, cosDir, "--routines", "0"},
+				{"verify", localDir, cosDir, "--part-size", "0"},
+				{"verify", filepath.Join(localDir, "a.txt"), cosDir},
+				{"verify", filepath.Join(dir, "not-exist"), cosDir},
+				{"hash", cosDir + "large.bin", "--type", "md5"},
+				{"hash", cosDir + "large.bin", "--type", "sha1"},
+				{"cp", cosDir + "a.txt", filepath.Join(dir, "a.txt"), "--sha256"},
+				{"cp", "-", cosDir + "stdin.txt", "--sha256"},
+				{"sync", filepath.Join(localDir, "a.txt"), cosDir + "a.txt", "--sha256", "--append"},
+			}
+			for _, args := range cases {
+				clearCmd()
+				cmd.SetArgs(args)
+				e := withStdin([]byte("stdin"), cmd.Execute)
+				fmt.Printf(" : %v", e)
+				So(e, ShouldBeError)
+			}
+		})
+	})
+}
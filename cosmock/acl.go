@@ -0,0 +1,126 @@
+package cosmock
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+const (
+	ownerID      = "qcs::cam::uin/100000000001:uin/100000000001"
+	allUsersURI  = "http://cam.qcloud.com/groups/global/AllUsers"
+	authUsersURI = "http://cam.qcloud.com/groups/global/AllAuthenticatedUsers"
+)
+
+// 被授权者 ID 的格式，如 qcs::cam::uin/100000000001:uin/100000000002
+var granteeIDRegexp = regexp.MustCompile(`^qcs::cam::(uin/[0-9]+:uin/[0-9]+|anyone:anyone)$`)
+
+// 预设 ACL 对应的公共读写权限
+var cannedGrants = map[string][]cos.ACLGrant{
+	"default":                   nil,
+	"private":                   nil,
+	"bucket-owner-read":         nil,
+	"bucket-owner-full-control": nil,
+	"public-read":               {groupGrant(allUsersURI, "READ")},
+	"public-read-write":         {groupGrant(allUsersURI, "READ"), groupGrant(allUsersURI, "WRITE")},
+	"authenticated-read":        {groupGrant(authUsersURI, "READ")},
+}
+
+// 对象或存储桶的访问权限
+type accessControl struct {
+	canned string
+	grants []cos.ACLGrant
+}
+
+func groupGrant(uri, permission string) cos.ACLGrant {
+	return cos.ACLGrant{Grantee: &cos.ACLGrantee{Type: "Group", URI: uri}, Permission: permission}
+}
+
+// 从 x-cos-acl 及 x-cos-grant-* 请求头中读取访问权限
+func parseACLHeader(header http.Header) (accessControl, error) {
+	ac := accessControl{canned: header.Get("x-cos-acl")}
+	if _, ok := cannedGrants[ac.canned]; ac.canned != "" && !ok {
+		return ac, fmt.Errorf("invalid x-cos-acl %s", ac.canned)
+	}
+	for name, permission := range map[string]string{
+		"x-cos-grant-read":         "READ",
+		"x-cos-grant-write":        "WRITE",
+		"x-cos-grant-full-control": "FULL_CONTROL",
+		"x-cos-grant-read-acp":     "READ_ACP",
+		"x-cos-grant-write-acp":    "WRITE_ACP",
+	} {
+		value := header.Get(name)
+		if value == "" {
+			continue
+		}
+		for _, grantee := range strings.Split(value, ",") {
+			grantee = strings.TrimSpace(grantee)
+			var g cos.ACLGrantee
+			switch {
+			case strings.HasPrefix(grantee, "id=\"") && strings.HasSuffix(grantee, "\"") &&
+				granteeIDRegexp.MatchString(grantee[len("id=\""):len(grantee)-1]):
+				g = cos.ACLGrantee{Type: "CanonicalUser", ID: grantee[len("id=\"") : len(grantee)-1]}
+			case grantee == "uri=\""+allUsersURI+"\"" || grantee == "uri=\""+authUsersURI+"\"":
+				g = cos.ACLGrantee{Type: "Group", URI: grantee[len("uri=\"") : len(grantee)-1]}
+			default:
+				return ac, fmt.Errorf("invalid %s %s", name, value)
+			}
+			ac.grants = append(ac.grants, cos.ACLGrant{Grantee: &g, Permission: permission})
+		}
+	}
+	return ac, nil
+}
+
+// 生成 Get ACL 的响应，所有者总是拥有完全控制权限
+func (ac accessControl) result() *cos.ACLXml {
+	res := &cos.ACLXml{
+		Owner: &cos.Owner{ID: ownerID, DisplayName: "100000000001"},
+		AccessControlList: []cos.ACLGrant{{
+			Grantee:    &cos.ACLGrantee{Type: "CanonicalUser", ID: ownerID, DisplayName: "100000000001"},
+			Permission: "FULL_CONTROL",
+		}},
+	}
+	res.AccessControlList = append(res.AccessControlList, cannedGrants[ac.canned]...)
+	res.AccessControlList = append(res.AccessControlList, ac.grants...)
+	return res
+}
+
+func (ac accessControl) writeHeader(h http.Header) {
+	if ac.canned != "" {
+		h.Set("x-cos-acl", ac.canned)
+	}
+}
+
+// 处理 Put ACL 请求，请求头与 XML 请求体只能二选一
+func putACL(w http.ResponseWriter, r *http.Request, ac *accessControl) {
+	headerACL, err := parseACLHeader(r.Header)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", err.Error())
+		return
+	}
+	if r.ContentLength > 0 {
+		if headerACL.canned != "" || len(headerACL.grants) > 0 {
+			writeError(w, r, http.StatusBadRequest, "InvalidRequest", "The ACL can not be specified in both the header and the body.")
+			return
+		}
+		var body cos.ACLXml
+		if err := readXML(r, &body); err != nil {
+			writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		for _, grant := range body.AccessControlList {
+			if grant.Grantee != nil && grant.Grantee.ID != "" && !granteeIDRegexp.MatchString(grant.Grantee.ID) {
+				writeError(w, r, http.StatusBadRequest, "InvalidArgument", fmt.Sprintf("invalid grantee %s", grant.Grantee.ID))
+				return
+			}
+			if grant.Grantee != nil && grant.Grantee.ID != ownerID {
+				headerACL.grants = append(headerACL.grants, grant)
+			}
+		}
+	}
+	*ac = headerACL
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,473 @@
+package cosmock
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+const defaultMaxKeys = 1000
+
+type bucket struct {
+	name        string
+	region      string
+	ofs         bool
+	created     time.Time
+	versioning  string
+	tags        []cos.BucketTaggingTag
+	acl         accessControl
+	lifecycle   []cos.BucketLifecycleRule
+	cors        *cos.BucketPutCORSOptions
+	referer     *cos.BucketPutRefererOptions
+	website     *cos.BucketPutWebsiteOptions
+	policy      []byte
+	replication *cos.PutBucketReplicationOptions
+	logging     *cos.BucketLoggingEnabled
+	inventories map[string]*cos.BucketPutInventoryOptions
+	// 每个对象键对应的版本列表，最后一个为最新版本
+	objects map[string][]*object
+	uploads map[string]*upload
+	// 所属账号的 SecretId，为空时属于 Server.SecretID 对应的账号
+	owner string
+}
+
+func newBucket(name, region string, ofs bool) *bucket {
+	return &bucket{
+		name:    name,
+		region:  region,
+		ofs:     ofs,
+		created: time.Now(),
+		objects: make(map[string][]*object),
+		uploads: make(map[string]*upload),
+	}
+}
+
+// 获取最新版本，不存在或为删除标记时返回 nil
+func (b *bucket) latest(key string) *object {
+	versions := b.objects[key]
+	if len(versions) == 0 {
+		return nil
+	}
+	o := versions[len(versions)-1]
+	if o.deleteMarker {
+		return nil
+	}
+	return o
+}
+
+// 获取指定版本，versionId 为空时返回最新版本
+func (b *bucket) version(key, versionId string) *object {
+	if versionId == "" {
+		return b.latest(key)
+	}
+	for _, o := range b.objects[key] {
+		if o.versionId == versionId {
+			return o
+		}
+	}
+	return nil
+}
+
+// 写入新对象，按桶的多版本状态决定是否保留历史版本
+func (s *Server) store(b *bucket, o *object) {
+	switch b.versioning {
+	case "Enabled":
+		o.versionId = s.nextVersionId()
+		b.objects[o.key] = append(b.objects[o.key], o)
+	case "Suspended":
+		o.versionId = "null"
+		versions := b.objects[o.key][:0:0]
+		for _, v := range b.objects[o.key] {
+			if v.versionId != "null" {
+				versions = append(versions, v)
+			}
+		}
+		b.objects[o.key] = append(versions, o)
+	default:
+		b.objects[o.key] = []*object{o}
+	}
+	if !o.deleteMarker {
+		s.replicate(b, o)
+	}
+}
+
+// 删除对象，versionId 为空时在多版本桶中写入删除标记
+func (s *Server) remove(b *bucket, key, versionId string) (deleteMarker bool, deletedVersionId string) {
+	if versionId != "" {
+		versions := b.objects[key]
+		for i, o := range versions {
+			if o.versionId == versionId {
+				b.objects[key] = append(versions[:i:i], versions[i+1:]...)
+				if len(b.objects[key]) == 0 {
+					delete(b.objects, key)
+				}
+				return o.deleteMarker, versionId
+			}
+		}
+		return false, versionId
+	}
+	if b.versioning == "" {
+		delete(b.objects, key)
+		return false, ""
+	}
+	marker := &object{key: key, deleteMarker: true, modified: time.Now()}
+	s.store(b, marker)
+	return true, marker.versionId
+}
+
+func (s *Server) listBuckets(w http.ResponseWriter, r *http.Request) {
+	res := &cos.ServiceGetResult{
+		Owner: &cos.Owner{ID: "qcs::cam::uin/100000000001:uin/100000000001", DisplayName: "100000000001"},
+	}
+	names := make([]string, 0, len(s.buckets))
+	for name := range s.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b := s.buckets[name]
+		bucketType := "cos"
+		if b.ofs {
+			bucketType = "ofs"
+		}
+		res.Buckets = append(res.Buckets, cos.Bucket{
+			Name:         b.name,
+			Region:       b.region,
+			CreationDate: b.created.UTC().Format(time.RFC3339),
+			BucketType:   bucketType,
+		})
+	}
+	writeXML(w, http.StatusOK, res)
+}
+
+func (s *Server) putBucket(w http.ResponseWriter, r *http.Request, name string) {
+	if _, ok := s.buckets[name]; ok {
+		writeError(w, r, http.StatusConflict, "BucketAlreadyOwnedByYou", "The bucket you tried to create already exists, and you own it.")
+		return
+	}
+	acl, err := parseACLHeader(r.Header)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", err.Error())
+		return
+	}
+	var conf cos.CreateBucketConfiguration
+	if r.ContentLength > 0 {
+		if err := readXML(r, &conf); err != nil {
+			writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+	}
+	b := newBucket(name, s.region(r.Host), conf.BucketArchConfig == "OFS")
+	b.acl = acl
+	s.buckets[name] = b
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) serveBucket(w http.ResponseWriter, r *http.Request, b *bucket, query url.Values) {
+	switch r.Method {
+	case http.MethodHead:
+		w.Header().Set("X-Cos-Bucket-Region", b.region)
+		if b.ofs {
+			w.Header().Set("X-Cos-Bucket-Arch", "OFS")
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		switch {
+		case has(query, "versions"):
+			s.listObjectVersions(w, r, b, query)
+		case has(query, "uploads"):
+			s.listUploads(w, r, b, query)
+		case has(query, "tagging"):
+			if len(b.tags) == 0 {
+				writeError(w, r, http.StatusNotFound, "NoSuchTagSet", "The TagSet does not exist.")
+				return
+			}
+			writeXML(w, http.StatusOK, &cos.BucketGetTaggingResult{TagSet: b.tags})
+		case has(query, "versioning"):
+			writeXML(w, http.StatusOK, &cos.BucketGetVersionResult{Status: b.versioning})
+		case has(query, "lifecycle"), has(query, "cors"), has(query, "referer"), has(query, "website"), has(query, "policy"),
+			has(query, "replication"), has(query, "logging"), has(query, "inventory"):
+			getBucketConfig(w, r, b, query)
+		case has(query, "acl"):
+			b.acl.writeHeader(w.Header())
+			writeXML(w, http.StatusOK, b.acl.result())
+		default:
+			s.listObjects(w, r, b, query)
+		}
+	case http.MethodPut:
+		switch {
+		case has(query, "tagging"):
+			var opt cos.BucketPutTaggingOptions
+			if err := readXML(r, &opt); err != nil {
+				writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+				return
+			}
+			for _, tag := range opt.TagSet {
+				if !validTagKey(tag.Key) {
+					writeError(w, r, http.StatusBadRequest, "InvalidTag", "The tag key is reserved or invalid.")
+					return
+				}
+			}
+			b.tags = opt.TagSet
+			w.WriteHeader(http.StatusNoContent)
+		case has(query, "acl"):
+			putACL(w, r, &b.acl)
+		case has(query, "lifecycle"), has(query, "cors"), has(query, "referer"), has(query, "website"), has(query, "policy"),
+			has(query, "replication"), has(query, "logging"), has(query, "inventory"):
+			putBucketConfig(w, r, b, query)
+		case has(query, "versioning"):
+			var opt cos.BucketPutVersionOptions
+			if err := readXML(r, &opt); err != nil {
+				writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+				return
+			}
+			if opt.Status != "Enabled" && opt.Status != "Suspended" {
+				writeError(w, r, http.StatusBadRequest, "MalformedXML", "invalid versioning status")
+				return
+			}
+			b.versioning = opt.Status
+			w.WriteHeader(http.StatusOK)
+		default:
+			writeError(w, r, http.StatusConflict, "BucketAlreadyOwnedByYou", "The bucket you tried to create already exists, and you own it.")
+		}
+	case http.MethodPost:
+		if has(query, "delete") {
+			s.deleteObjects(w, r, b)
+			return
+		}
+		writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed.")
+	case http.MethodDelete:
+		if has(query, "tagging") {
+			b.tags = nil
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if has(query, "lifecycle") || has(query, "cors") || has(query, "referer") || has(query, "website") || has(query, "policy") ||
+			has(query, "replication") || has(query, "inventory") {
+			deleteBucketConfig(w, r, b, query)
+			return
+		}
+		if len(b.objects) > 0 {
+			writeError(w, r, http.StatusConflict, "BucketNotEmpty", "The bucket you tried to delete is not empty.")
+			return
+		}
+		delete(s.buckets, b.name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed.")
+	}
+}
+
+// 列出对象（List Objects v1）
+func (s *Server) listObjects(w http.ResponseWriter, r *http.Request, b *bucket, query url.Values) {
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	marker := query.Get("marker")
+	encodingType := query.Get("encoding-type")
+	maxKeys := s.pageSize(maxKeysOf(query, "max-keys"))
+
+	res := &cos.BucketGetResult{
+		Name:         b.name,
+		Prefix:       encodeKey(prefix, encodingType),
+		Marker:       encodeKey(marker, encodingType),
+		Delimiter:    encodeKey(delimiter, encodingType),
+		MaxKeys:      maxKeys,
+		EncodingType: encodingType,
+	}
+
+	lastPrefix := ""
+	count := 0
+	for _, key := range b.sortedKeys() {
+		o := b.latest(key)
+		if o == nil || !strings.HasPrefix(key, prefix) || key <= marker {
+			continue
+		}
+		commonPrefix := commonPrefixOf(key, prefix, delimiter)
+		if commonPrefix != "" && commonPrefix == lastPrefix {
+			continue
+		}
+		if commonPrefix != "" && commonPrefix <= marker {
+			continue
+		}
+		if count == maxKeys {
+			res.IsTruncated = true
+			break
+		}
+		count++
+		if commonPrefix != "" {
+			lastPrefix = commonPrefix
+			res.CommonPrefixes = append(res.CommonPrefixes, encodeKey(commonPrefix, encodingType))
+			res.NextMarker = encodeKey(commonPrefix, encodingType)
+			continue
+		}
+		res.Contents = append(res.Contents, o.listEntry(encodingType))
+		res.NextMarker = encodeKey(key, encodingType)
+	}
+	if !res.IsTruncated {
+		res.NextMarker = ""
+	}
+	writeXML(w, http.StatusOK, res)
+}
+
+// 列出对象版本
+func (s *Server) listObjectVersions(w http.ResponseWriter, r *http.Request, b *bucket, query url.Values) {
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	keyMarker := query.Get("key-marker")
+	versionIdMarker := query.Get("version-id-marker")
+	encodingType := query.Get("encoding-type")
+	maxKeys := s.pageSize(maxKeysOf(query, "max-keys"))
+
+	res := &cos.BucketGetObjectVersionsResult{
+		Name:            b.name,
+		EncodingType:    encodingType,
+		Prefix:          encodeKey(prefix, encodingType),
+		KeyMarker:       encodeKey(keyMarker, encodingType),
+		VersionIdMarker: versionIdMarker,
+		MaxKeys:         maxKeys,
+		Delimiter:       encodeKey(delimiter, encodingType),
+	}
+
+	lastPrefix := ""
+	count := 0
+	for _, key := range b.sortedKeys() {
+		if !strings.HasPrefix(key, prefix) || key < keyMarker {
+			continue
+		}
+		commonPrefix := commonPrefixOf(key, prefix, delimiter)
+		if commonPrefix != "" {
+			if commonPrefix == lastPrefix || commonPrefix <= keyMarker {
+				continue
+			}
+			if count == maxKeys {
+				res.IsTruncated = true
+				break
+			}
+			count++
+			lastPrefix = commonPrefix
+			res.CommonPrefixes = append(res.CommonPrefixes, encodeKey(commonPrefix, encodingType))
+			res.NextKeyMarker = encodeKey(commonPrefix, encodingType)
+			res.NextVersionIdMarker = ""
+			continue
+		}
+
+		versions := b.objects[key]
+		// 从最新版本开始输出
+		skip := key == keyMarker
+		for i := len(versions) - 1; i >= 0; i-- {
+			o := versions[i]
+			if skip {
+				if versionIdMarker != "" && o.versionId == versionIdMarker {
+					skip = false
+				}
+				continue
+			}
+			if count == maxKeys {
+				res.IsTruncated = true
+				break
+			}
+			count++
+			if o.deleteMarker {
+				res.DeleteMarker = append(res.DeleteMarker, cos.ListVersionsResultDeleteMarker{
+					Key:          encodeKey(key, encodingType),
+					VersionId:    versionIdOf(o),
+					IsLatest:     i == len(versions)-1,
+					LastModified: formatTime(o.modified),
+				})
+			} else {
+				res.Version = append(res.Version, cos.ListVersionsResultVersion{
+					Key:          encodeKey(key, encodingType),
+					VersionId:    versionIdOf(o),
+					IsLatest:     i == len(versions)-1,
+					LastModified: formatTime(o.modified),
+					ETag:         o.etag,
+					Size:         int64(len(o.data)),
+					StorageClass: o.storageClass,
+				})
+			}
+			res.NextKeyMarker = encodeKey(key, encodingType)
+			res.NextVersionIdMarker = versionIdOf(o)
+		}
+		if res.IsTruncated {
+			break
+		}
+	}
+	if !res.IsTruncated {
+		res.NextKeyMarker = ""
+		res.NextVersionIdMarker = ""
+	}
+	writeXML(w, http.StatusOK, res)
+}
+
+// 批量删除
+func (s *Server) deleteObjects(w http.ResponseWriter, r *http.Request, b *bucket) {
+	var opt cos.ObjectDeleteMultiOptions
+	if err := readXML(r, &opt); err != nil {
+		writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+	if len(opt.Objects) > 1000 {
+		writeError(w, r, http.StatusBadRequest, "MalformedXML", "too many objects")
+		return
+	}
+	res := &cos.ObjectDeleteMultiResult{}
+	for _, o := range opt.Objects {
+		s.remove(b, o.Key, o.VersionId)
+		if !opt.Quiet {
+			res.DeletedObjects = append(res.DeletedObjects, cos.Object{Key: o.Key, VersionId: o.VersionId})
+		}
+	}
+	writeXML(w, http.StatusOK, res)
+}
+
+func (b *bucket) sortedKeys() []string {
+	keys := make([]string, 0, len(b.objects))
+	for key := range b.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// 计算对象键在 delimiter 下的公共前缀
+func commonPrefixOf(key, prefix, delimiter string) string {
+	if delimiter == "" {
+		return ""
+	}
+	i := strings.Index(key[len(prefix):], delimiter)
+	if i < 0 {
+		return ""
+	}
+	return key[:len(prefix)+i+len(delimiter)]
+}
+
+// 设置了 MaxKeys 时按其限制每页的数量
+func (s *Server) pageSize(maxKeys int) int {
+	if s.MaxKeys > 0 && maxKeys > s.MaxKeys {
+		return s.MaxKeys
+	}
+	return maxKeys
+}
+
+func maxKeysOf(query url.Values, name string) int {
+	n, err := strconv.Atoi(query.Get(name))
+	if err != nil || n <= 0 || n > defaultMaxKeys {
+		return defaultMaxKeys
+	}
+	return n
+}
+
+// qcs: 前缀为系统保留标签
+func validTagKey(key string) bool {
+	return key != "" && !strings.HasPrefix(key, "qcs:")
+}
+
+func has(query url.Values, name string) bool {
+	_, ok := query[name]
+	return ok
+}
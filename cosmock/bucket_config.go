@@ -0,0 +1,339 @@
+package cosmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// 处理生命周期、跨域访问、防盗链、静态网站、存储桶策略、跨地域复制、访问日志及清单的 Get 请求，未设置时返回 404
+func getBucketConfig(w http.ResponseWriter, r *http.Request, b *bucket, query url.Values) {
+	switch {
+	case has(query, "lifecycle"):
+		if len(b.lifecycle) == 0 {
+			writeError(w, r, http.StatusNotFound, "NoSuchLifecycleConfiguration", "The lifecycle configuration does not exist.")
+			return
+		}
+		writeXML(w, http.StatusOK, &cos.BucketGetLifecycleResult{Rules: b.lifecycle})
+	case has(query, "cors"):
+		if b.cors == nil {
+			writeError(w, r, http.StatusNotFound, "NoSuchCORSConfiguration", "The CORS configuration does not exist.")
+			return
+		}
+		writeXML(w, http.StatusOK, &cos.BucketGetCORSResult{Rules: b.cors.Rules, ResponseVary: b.cors.ResponseVary})
+	case has(query, "referer"):
+		if b.referer == nil {
+			writeError(w, r, http.StatusNotFound, "NoSuchRefererConfiguration", "The referer configuration does not exist.")
+			return
+		}
+		writeXML(w, http.StatusOK, b.referer)
+	case has(query, "website"):
+		if b.website == nil {
+			writeError(w, r, http.StatusNotFound, "NoSuchWebsiteConfiguration", "The specified bucket does not have a website configuration.")
+			return
+		}
+		writeXML(w, http.StatusOK, b.website)
+	case has(query, "policy"):
+		if b.policy == nil {
+			writeError(w, r, http.StatusNotFound, "NoSuchBucketPolicy", "The specified bucket does not have a bucket policy.")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", fmt.Sprint(len(b.policy)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(b.policy)
+	case has(query, "replication"):
+		if b.replication == nil {
+			writeError(w, r, http.StatusNotFound, "NoSuchReplicationConfiguration", "The replication configuration does not exist.")
+			return
+		}
+		writeXML(w, http.StatusOK, b.replication)
+	case has(query, "logging"):
+		// 未开启访问日志时返回不含 LoggingEnabled 的配置
+		writeXML(w, http.StatusOK, &cos.BucketGetLoggingResult{LoggingEnabled: b.logging})
+	case has(query, "inventory"):
+		id := query.Get("id")
+		if id == "" {
+			listInventories(w, b)
+			return
+		}
+		if b.inventories[id] == nil {
+			writeError(w, r, http.StatusNotFound, "NoSuchInventoryConfiguration", "The specified inventory configuration does not exist.")
+			return
+		}
+		writeXML(w, http.StatusOK, (*cos.BucketGetInventoryResult)(b.inventories[id]))
+	}
+}
+
+// 按 ID 顺序列出存储桶的全部清单配置
+func listInventories(w http.ResponseWriter, b *bucket) {
+	ids := make([]string, 0, len(b.inventories))
+	for id := range b.inventories {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	res := &cos.ListBucketInventoryConfigResult{}
+	for _, id := range ids {
+		res.InventoryConfigurations = append(res.InventoryConfigurations, cos.BucketListInventoryConfiguartion(*b.inventories[id]))
+	}
+	writeXML(w, http.StatusOK, res)
+}
+
+// 处理存储桶配置的 Put 请求，校验后覆盖已有的配置
+func putBucketConfig(w http.ResponseWriter, r *http.Request, b *bucket, query url.Values) {
+	var code, message string
+	switch {
+	case has(query, "lifecycle"):
+		var opt cos.BucketPutLifecycleOptions
+		if err := readXML(r, &opt); err != nil {
+			writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		if code, message = checkLifecycle(opt.Rules); code == "" {
+			b.lifecycle = opt.Rules
+		}
+	case has(query, "cors"):
+		var opt cos.BucketPutCORSOptions
+		if err := readXML(r, &opt); err != nil {
+			writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		if code, message = checkCORS(opt.Rules); code == "" {
+			b.cors = &opt
+		}
+	case has(query, "referer"):
+		// SDK 通过空请求体的 Put 删除防盗链配置
+		if r.ContentLength == 0 {
+			b.referer = nil
+			break
+		}
+		var opt cos.BucketPutRefererOptions
+		if err := readXML(r, &opt); err != nil {
+			writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		if opt.RefererType != "White-List" && opt.RefererType != "Black-List" || len(opt.DomainList) == 0 {
+			code, message = "MalformedXML", "invalid referer configuration"
+		} else {
+			b.referer = &opt
+		}
+	case has(query, "website"):
+		var opt cos.BucketPutWebsiteOptions
+		if err := readXML(r, &opt); err != nil {
+			writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		if opt.Index == "" {
+			code, message = "MalformedXML", "The index document is required."
+		} else {
+			b.website = &opt
+		}
+	case has(query, "policy"):
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "MalformedPolicy", err.Error())
+			return
+		}
+		var opt cos.BucketPutPolicyOptions
+		if err = json.Unmarshal(data, &opt); err != nil || len(opt.Statement) == 0 {
+			code, message = "MalformedPolicy", "The policy is not a valid json or has no statement."
+		} else {
+			b.policy = data
+		}
+	case has(query, "replication"):
+		var opt cos.PutBucketReplicationOptions
+		if err := readXML(r, &opt); err != nil {
+			writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		if b.versioning != "Enabled" {
+			code, message = "InvalidRequest", "Versioning must be enabled on the bucket to put replication."
+		} else if code, message = checkReplication(opt); code == "" {
+			b.replication = &opt
+		}
+	case has(query, "logging"):
+		// 不含 LoggingEnabled 的配置关闭访问日志
+		var opt cos.BucketPutLoggingOptions
+		if err := readXML(r, &opt); err != nil {
+			writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		if opt.LoggingEnabled != nil && opt.LoggingEnabled.TargetBucket == "" {
+			code, message = "MalformedXML", "TargetBucket is required."
+		} else {
+			b.logging = opt.LoggingEnabled
+		}
+	case has(query, "inventory"):
+		var opt cos.BucketPutInventoryOptions
+		if err := readXML(r, &opt); err != nil {
+			writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		if code, message = checkInventory(query.Get("id"), opt); code == "" {
+			if b.inventories == nil {
+				b.inventories = make(map[string]*cos.BucketPutInventoryOptions)
+			}
+			b.inventories[opt.ID] = &opt
+		}
+	}
+	if code != "" {
+		writeError(w, r, http.StatusBadRequest, code, message)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// 处理存储桶配置的 Delete 请求
+func deleteBucketConfig(w http.ResponseWriter, r *http.Request, b *bucket, query url.Values) {
+	switch {
+	case has(query, "lifecycle"):
+		b.lifecycle = nil
+	case has(query, "cors"):
+		b.cors = nil
+	case has(query, "referer"):
+		b.referer = nil
+	case has(query, "website"):
+		b.website = nil
+	case has(query, "policy"):
+		b.policy = nil
+	case has(query, "replication"):
+		b.replication = nil
+	case has(query, "inventory"):
+		delete(b.inventories, query.Get("id"))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// 校验生命周期规则的状态、规则 ID 及各项操作，返回错误码及信息
+func checkLifecycle(rules []cos.BucketLifecycleRule) (string, string) {
+	if len(rules) == 0 {
+		return "MalformedXML", "The lifecycle configuration has no rules."
+	}
+	ids := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.Status != "Enabled" && rule.Status != "Disabled" {
+			return "MalformedXML", "invalid lifecycle rule status"
+		}
+		if rule.ID != "" && ids[rule.ID] {
+			return "InvalidArgument", "Rule ID must be unique."
+		}
+		ids[rule.ID] = true
+		if len(rule.Transition) == 0 && rule.Expiration == nil && rule.NoncurrentVersionExpiration == nil &&
+			len(rule.NoncurrentVersionTransition) == 0 && rule.AbortIncompleteMultipartUpload == nil {
+			return "InvalidRequest", "At least one action needs to be specified in a rule."
+		}
+		for _, t := range rule.Transition {
+			if t.StorageClass == "" || t.StorageClass == "STANDARD" {
+				return "InvalidArgument", "invalid transition storage class"
+			}
+		}
+	}
+	return "", ""
+}
+
+// 校验跨域访问规则的来源及请求方法
+func checkCORS(rules []cos.BucketCORSRule) (string, string) {
+	if len(rules) == 0 {
+		return "MalformedXML", "The CORS configuration has no rules."
+	}
+	for _, rule := range rules {
+		if len(rule.AllowedOrigins) == 0 || len(rule.AllowedMethods) == 0 {
+			return "MalformedXML", "AllowedOrigin and AllowedMethod are required."
+		}
+		for _, method := range rule.AllowedMethods {
+			switch method {
+			case "GET", "PUT", "POST", "DELETE", "HEAD":
+			default:
+				return "InvalidArgument", fmt.Sprintf("invalid CORS method %s", method)
+			}
+		}
+	}
+	return "", ""
+}
+
+// 校验跨地域复制规则的角色及目标存储桶
+func checkReplication(opt cos.PutBucketReplicationOptions) (string, string) {
+	if opt.Role == "" || len(opt.Rule) == 0 {
+		return "MalformedXML", "Role and Rule are required."
+	}
+	for _, rule := range opt.Rule {
+		if rule.Status != "Enabled" && rule.Status != "Disabled" {
+			return "MalformedXML", "invalid replication rule status"
+		}
+		if rule.Destination == nil {
+			return "MalformedXML", "Destination is required."
+		}
+		if _, ok := destinationBucket(rule.Destination.Bucket); !ok {
+			return "InvalidArgument", fmt.Sprintf("invalid destination bucket %s", rule.Destination.Bucket)
+		}
+	}
+	return "", ""
+}
+
+// 校验清单配置的 ID、调度周期及目标存储桶，ID 需与请求参数中的一致
+func checkInventory(id string, opt cos.BucketPutInventoryOptions) (string, string) {
+	if id == "" || opt.ID != id {
+		return "InvalidArgument", "The inventory id in the request does not match the configuration."
+	}
+	if opt.IsEnabled != "true" && opt.IsEnabled != "false" {
+		return "MalformedXML", "invalid inventory IsEnabled"
+	}
+	if opt.IncludedObjectVersions != "All" && opt.IncludedObjectVersions != "Current" {
+		return "MalformedXML", "invalid inventory IncludedObjectVersions"
+	}
+	if opt.Schedule == nil || opt.Schedule.Frequency != "Daily" && opt.Schedule.Frequency != "Weekly" {
+		return "MalformedXML", "invalid inventory schedule"
+	}
+	if opt.Destination == nil || opt.Destination.Format != "CSV" {
+		return "MalformedXML", "The destination with CSV format is required."
+	}
+	if _, ok := destinationBucket(opt.Destination.Bucket); !ok {
+		return "InvalidArgument", fmt.Sprintf("invalid destination bucket %s", opt.Destination.Bucket)
+	}
+	return "", ""
+}
+
+// 从 qcs::cos:<region>::<bucket> 格式的目标存储桶中解析存储桶名
+func destinationBucket(destination string) (string, bool) {
+	if !strings.HasPrefix(destination, "qcs::cos:") {
+		return "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(destination, "qcs::cos:"), "::", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// 按存储桶的复制规则将新写入的对象复制到目标存储桶，目标存储桶不存在或未开启版本控制时复制失败。
+// 复制得到的对象不会再次复制
+func (s *Server) replicate(b *bucket, o *object) {
+	if b.replication == nil || o.replicationStatus == "REPLICA" {
+		return
+	}
+	for _, rule := range b.replication.Rule {
+		if rule.Status != "Enabled" || !strings.HasPrefix(o.key, rule.Prefix) {
+			continue
+		}
+		name, _ := destinationBucket(rule.Destination.Bucket)
+		dest := s.buckets[name]
+		if dest == nil || dest.versioning != "Enabled" {
+			o.replicationStatus = "FAILED"
+			return
+		}
+		replica := *o
+		replica.replicationStatus = "REPLICA"
+		if rule.Destination.StorageClass != "" {
+			replica.storageClass = rule.Destination.StorageClass
+		}
+		s.store(dest, &replica)
+		o.replicationStatus = "COMPLETED"
+		return
+	}
+}
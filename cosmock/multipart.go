@@ -0,0 +1,299 @@
+package cosmock
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash/crc64"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+type upload struct {
+	id        string
+	key       string
+	header    http.Header
+	initiated time.Time
+	parts     map[int]*part
+}
+
+type part struct {
+	data     []byte
+	etag     string
+	modified time.Time
+}
+
+func (s *Server) initUpload(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+	if _, err := customerKeyMD5(r.Header, sseHeaderPrefix); err != nil {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", err.Error())
+		return
+	}
+	if _, err := parseACLHeader(r.Header); err != nil {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", err.Error())
+		return
+	}
+	s.seq++
+	u := &upload{
+		id:        fmt.Sprintf("%d%08d", time.Now().Unix(), s.seq),
+		key:       key,
+		header:    r.Header.Clone(),
+		initiated: time.Now(),
+		parts:     make(map[int]*part),
+	}
+	b.uploads[u.id] = u
+	writeXML(w, http.StatusOK, &cos.InitiateMultipartUploadResult{
+		Bucket:   b.name,
+		Key:      key,
+		UploadID: u.id,
+	})
+}
+
+func (s *Server) findUpload(w http.ResponseWriter, r *http.Request, b *bucket, query url.Values) *upload {
+	u := b.uploads[query.Get("uploadId")]
+	if u == nil {
+		writeError(w, r, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist.")
+	}
+	return u
+}
+
+func (s *Server) uploadPart(w http.ResponseWriter, r *http.Request, b *bucket, key string, query url.Values) {
+	u := s.findUpload(w, r, b, query)
+	if u == nil {
+		return
+	}
+	partNumber, err := strconv.Atoi(query.Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > 10000 {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", "invalid partNumber")
+		return
+	}
+	// 使用 SSE-C 的分块上传，每个分块须携带相同的密钥
+	keyMD5, _ := customerKeyMD5(u.header, sseHeaderPrefix)
+	if status, code, message := checkCustomerKey(r.Header, sseHeaderPrefix, keyMD5); status != 0 {
+		writeError(w, r, status, code, message)
+		return
+	}
+
+	// 分块拷贝
+	if r.Header.Get("x-cos-copy-source") != "" {
+		src, code, message := s.copySource(r, "x-cos-copy-source")
+		if src == nil {
+			status := http.StatusNotFound
+			switch code {
+			case "AccessDenied", "InvalidObjectState":
+				status = http.StatusForbidden
+			case "InvalidArgument", "InvalidRequest":
+				status = http.StatusBadRequest
+			}
+			writeError(w, r, status, code, message)
+			return
+		}
+		data := src.data
+		if v := r.Header.Get("x-cos-copy-source-range"); v != "" {
+			start, end, ok := parseRange(v, int64(len(src.data)))
+			if !ok {
+				writeError(w, r, http.StatusBadRequest, "InvalidArgument", "invalid x-cos-copy-source-range")
+				return
+			}
+			data = src.data[start : end+1]
+		}
+		p := &part{data: append([]byte(nil), data...), etag: fmt.Sprintf("\"%s\"", md5Hex(data)), modified: time.Now()}
+		u.parts[partNumber] = p
+		writeXML(w, http.StatusOK, &cos.CopyPartResult{ETag: p.etag, LastModified: formatTime(p.modified)})
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	p := &part{data: data, etag: fmt.Sprintf("\"%s\"", md5Hex(data)), modified: time.Now()}
+	u.parts[partNumber] = p
+	w.Header().Set("ETag", p.etag)
+	w.Header().Set("x-cos-hash-crc64ecma", strconv.FormatUint(crc64.Checksum(data, crc64Table), 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) listParts(w http.ResponseWriter, r *http.Request, b *bucket, key string, query url.Values) {
+	u := s.findUpload(w, r, b, query)
+	if u == nil {
+		return
+	}
+	encodingType := query.Get("encoding-type")
+	marker, _ := strconv.Atoi(query.Get("part-number-marker"))
+	maxParts := maxKeysOf(query, "max-parts")
+
+	numbers := make([]int, 0, len(u.parts))
+	for n := range u.parts {
+		if n > marker {
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Ints(numbers)
+
+	res := &cos.ObjectListPartsResult{
+		Bucket:           b.name,
+		EncodingType:     encodingType,
+		Key:              encodeKey(u.key, encodingType),
+		UploadID:         u.id,
+		StorageClass:     storageClassOf(u.header),
+		PartNumberMarker: strconv.Itoa(marker),
+		MaxParts:         strconv.Itoa(maxParts),
+	}
+	for i, n := range numbers {
+		if i == maxParts {
+			res.IsTruncated = true
+			break
+		}
+		p := u.parts[n]
+		res.Parts = append(res.Parts, cos.Object{
+			PartNumber:   n,
+			ETag:         p.etag,
+			Size:         int64(len(p.data)),
+			LastModified: formatTime(p.modified),
+		})
+		res.NextPartNumberMarker = strconv.Itoa(n)
+	}
+	writeXML(w, http.StatusOK, res)
+}
+
+func (s *Server) completeUpload(w http.ResponseWriter, r *http.Request, b *bucket, key string, query url.Values) {
+	u := s.findUpload(w, r, b, query)
+	if u == nil {
+		return
+	}
+	var opt cos.CompleteMultipartUploadOptions
+	if err := readXML(r, &opt); err != nil {
+		writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+	if len(opt.Parts) == 0 {
+		writeError(w, r, http.StatusBadRequest, "MalformedXML", "no parts")
+		return
+	}
+
+	var data bytes.Buffer
+	var md5s []byte
+	last := 0
+	for _, p := range opt.Parts {
+		stored := u.parts[p.PartNumber]
+		if p.PartNumber <= last {
+			writeError(w, r, http.StatusBadRequest, "InvalidPartOrder", "The list of parts was not in ascending order.")
+			return
+		}
+		if stored == nil || strings.Trim(stored.etag, "\"") != strings.Trim(p.ETag, "\"") {
+			writeError(w, r, http.StatusBadRequest, "InvalidPart", fmt.Sprintf("part %d not found", p.PartNumber))
+			return
+		}
+		last = p.PartNumber
+		data.Write(stored.data)
+		sum, _ := hex.DecodeString(strings.Trim(stored.etag, "\""))
+		md5s = append(md5s, sum...)
+	}
+
+	o := newObject(u.key, data.Bytes(), u.header)
+	o.etag = fmt.Sprintf("\"%x-%d\"", md5.Sum(md5s), len(opt.Parts))
+	s.store(b, o)
+	delete(b.uploads, u.id)
+
+	s.writeVersionId(w, o)
+	w.Header().Set("x-cos-hash-crc64ecma", o.crc64)
+	writeXML(w, http.StatusOK, &cos.CompleteMultipartUploadResult{
+		Location: r.Host + "/" + key,
+		Bucket:   b.name,
+		Key:      key,
+		ETag:     o.etag,
+	})
+}
+
+func (s *Server) abortUpload(w http.ResponseWriter, r *http.Request, b *bucket, query url.Values) {
+	u := s.findUpload(w, r, b, query)
+	if u == nil {
+		return
+	}
+	delete(b.uploads, u.id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listUploads(w http.ResponseWriter, r *http.Request, b *bucket, query url.Values) {
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	keyMarker := query.Get("key-marker")
+	uploadIdMarker := query.Get("upload-id-marker")
+	encodingType := query.Get("encoding-type")
+	maxUploads := maxKeysOf(query, "max-uploads")
+
+	uploads := make([]*upload, 0, len(b.uploads))
+	for _, u := range b.uploads {
+		if strings.HasPrefix(u.key, prefix) {
+			uploads = append(uploads, u)
+		}
+	}
+	sort.Slice(uploads, func(i, j int) bool {
+		if uploads[i].key != uploads[j].key {
+			return uploads[i].key < uploads[j].key
+		}
+		return uploads[i].id < uploads[j].id
+	})
+
+	res := &cos.ObjectListUploadsResult{
+		Bucket:         b.name,
+		EncodingType:   encodingType,
+		KeyMarker:      encodeKey(keyMarker, encodingType),
+		UploadIdMarker: uploadIdMarker,
+		MaxUploads:     strconv.Itoa(maxUploads),
+		Prefix:         encodeKey(prefix, encodingType),
+		Delimiter:      encodeKey(delimiter, encodingType),
+	}
+	lastPrefix := ""
+	count := 0
+	for _, u := range uploads {
+		if u.key < keyMarker || (u.key == keyMarker && (uploadIdMarker == "" || u.id <= uploadIdMarker)) {
+			continue
+		}
+		commonPrefix := commonPrefixOf(u.key, prefix, delimiter)
+		if commonPrefix != "" && (commonPrefix == lastPrefix || commonPrefix <= keyMarker) {
+			continue
+		}
+		if count == maxUploads {
+			res.IsTruncated = true
+			break
+		}
+		count++
+		if commonPrefix != "" {
+			lastPrefix = commonPrefix
+			res.CommonPrefixes = append(res.CommonPrefixes, encodeKey(commonPrefix, encodingType))
+			res.NextKeyMarker = encodeKey(commonPrefix, encodingType)
+			res.NextUploadIdMarker = ""
+			continue
+		}
+		res.Upload = append(res.Upload, cos.ListUploadsResultUpload{
+			Key:          encodeKey(u.key, encodingType),
+			UploadID:     u.id,
+			StorageClass: storageClassOf(u.header),
+			Initiated:    formatTime(u.initiated),
+		})
+		res.NextKeyMarker = encodeKey(u.key, encodingType)
+		res.NextUploadIdMarker = u.id
+	}
+	if !res.IsTruncated {
+		res.NextKeyMarker = ""
+		res.NextUploadIdMarker = ""
+	}
+	writeXML(w, http.StatusOK, res)
+}
+
+func storageClassOf(header http.Header) string {
+	if v := header.Get("x-cos-storage-class"); v != "" {
+		return strings.ToUpper(v)
+	}
+	return "STANDARD"
+}
@@ -0,0 +1,579 @@
+package cosmock
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash/crc64"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+var crc64Table = crc64.MakeTable(crc64.ECMA)
+
+type object struct {
+	key           string
+	versionId     string
+	data          []byte
+	etag          string
+	crc64         string
+	modified      time.Time
+	storageClass  string
+	contentType   string
+	meta          http.Header
+	tags          []cos.ObjectTaggingTag
+	deleteMarker  bool
+	symlinkTarget string
+	restored      bool
+	acl           accessControl
+	// 跨地域复制状态，源对象为 COMPLETED 或 FAILED，目标对象为 REPLICA
+	replicationStatus string
+	// 服务端加密方式，SSE-C 仅保存密钥的 MD5
+	sse               string
+	kmsKeyId          string
+	sseCustomerKeyMD5 string
+	// 追加上传生成的对象，可继续追加，Put 覆盖后为普通对象
+	appendable bool
+}
+
+func newObject(key string, data []byte, header http.Header) *object {
+	o := &object{
+		key:          key,
+		data:         data,
+		etag:         fmt.Sprintf("\"%x\"", md5.Sum(data)),
+		crc64:        strconv.FormatUint(crc64.Checksum(data, crc64Table), 10),
+		modified:     time.Now(),
+		storageClass: "STANDARD",
+		contentType:  "application/octet-stream",
+		meta:         make(http.Header),
+	}
+	o.setHeader(header)
+	o.setEncryption(header)
+	o.acl, _ = parseACLHeader(header)
+	return o
+}
+
+// 从请求头中读取对象属性
+func (o *object) setHeader(header http.Header) {
+	if v := header.Get("x-cos-storage-class"); v != "" {
+		o.storageClass = strings.ToUpper(v)
+	}
+	if v := header.Get("Content-Type"); v != "" {
+		o.contentType = v
+	}
+	for _, name := range []string{"Cache-Control", "Content-Disposition", "Content-Encoding", "Content-Language", "Expires"} {
+		if v := header.Get(name); v != "" {
+			o.meta.Set(name, v)
+		}
+	}
+	for name, values := range header {
+		if strings.HasPrefix(strings.ToLower(name), "x-cos-meta-") {
+			o.meta[name] = values
+		}
+	}
+	if v := header.Get("x-cos-tagging"); v != "" {
+		o.tags = parseTagging(v)
+	}
+}
+
+func (o *object) writeHeader(w http.ResponseWriter) {
+	h := w.Header()
+	for name, values := range o.meta {
+		h[name] = values
+	}
+	h.Set("Content-Type", o.contentType)
+	h.Set("Content-Length", strconv.Itoa(len(o.data)))
+	h.Set("ETag", o.etag)
+	h.Set("Last-Modified", o.modified.UTC().Format(http.TimeFormat))
+	h.Set("Accept-Ranges", "bytes")
+	h.Set("x-cos-hash-crc64ecma", o.crc64)
+	if o.storageClass != "STANDARD" {
+		h.Set("x-cos-storage-class", o.storageClass)
+	}
+	if o.versionId != "" {
+		h.Set("x-cos-version-id", o.versionId)
+	}
+	if len(o.tags) > 0 {
+		h.Set("x-cos-tagging-count", strconv.Itoa(len(o.tags)))
+	}
+	if o.symlinkTarget != "" {
+		h.Set("x-cos-object-type", "symlink")
+	}
+	if o.appendable {
+		h.Set("x-cos-object-type", "appendable")
+		h.Set("x-cos-next-append-position", strconv.Itoa(len(o.data)))
+	}
+	if o.restored {
+		h.Set("x-cos-restore", "ongoing-request=\"false\"")
+	}
+	if o.replicationStatus != "" {
+		h.Set("x-cos-replication-status", o.replicationStatus)
+	}
+	o.writeEncryptionHeader(h)
+	o.acl.writeHeader(h)
+}
+
+func (o *object) listEntry(encodingType string) cos.Object {
+	return cos.Object{
+		Key:          encodeKey(o.key, encodingType),
+		ETag:         o.etag,
+		Size:         int64(len(o.data)),
+		LastModified: formatTime(o.modified),
+		StorageClass: o.storageClass,
+		Owner:        &cos.Owner{ID: "100000000001", DisplayName: "100000000001"},
+	}
+}
+
+func (o *object) archived() bool {
+	switch o.storageClass {
+	case "ARCHIVE", "DEEP_ARCHIVE", "MAZ_ARCHIVE":
+		return !o.restored
+	}
+	return false
+}
+
+func versionIdOf(o *object) string {
+	if o.versionId == "" {
+		return "null"
+	}
+	return o.versionId
+}
+
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, b *bucket, key string, query url.Values) {
+	switch r.Method {
+	case http.MethodHead:
+		s.headObject(w, r, b, key, query)
+	case http.MethodGet:
+		switch {
+		case has(query, "uploadId"):
+			s.listParts(w, r, b, key, query)
+		case has(query, "tagging"):
+			o := b.version(key, query.Get("versionId"))
+			if o == nil {
+				writeError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+				return
+			}
+			writeXML(w, http.StatusOK, &cos.ObjectGetTaggingResult{TagSet: o.tags})
+		case has(query, "acl"):
+			o := b.version(key, query.Get("versionId"))
+			if o == nil || o.deleteMarker {
+				writeError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+				return
+			}
+			o.acl.writeHeader(w.Header())
+			writeXML(w, http.StatusOK, o.acl.result())
+		case has(query, "symlink"):
+			o := b.version(key, query.Get("versionId"))
+			if o == nil || o.symlinkTarget == "" {
+				writeError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+				return
+			}
+			w.Header().Set("x-cos-symlink-target", o.symlinkTarget)
+			w.WriteHeader(http.StatusOK)
+		default:
+			s.getObject(w, r, b, key, query)
+		}
+	case http.MethodPut:
+		switch {
+		case has(query, "uploadId"):
+			s.uploadPart(w, r, b, key, query)
+		case has(query, "tagging"):
+			o := b.version(key, query.Get("versionId"))
+			if o == nil {
+				writeError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+				return
+			}
+			var opt cos.ObjectPutTaggingOptions
+			if err := readXML(r, &opt); err != nil {
+				writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+				return
+			}
+			for _, tag := range opt.TagSet {
+				if !validTagKey(tag.Key) {
+					writeError(w, r, http.StatusBadRequest, "InvalidTag", "The tag key is reserved or invalid.")
+					return
+				}
+			}
+			o.tags = opt.TagSet
+			w.WriteHeader(http.StatusOK)
+		case has(query, "acl"):
+			o := b.version(key, query.Get("versionId"))
+			if o == nil || o.deleteMarker {
+				writeError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+				return
+			}
+			putACL(w, r, &o.acl)
+		case has(query, "symlink"):
+			target, _ := url.QueryUnescape(r.Header.Get("x-cos-symlink-target"))
+			o := newObject(key, nil, r.Header)
+			o.symlinkTarget = target
+			s.store(b, o)
+			s.writeVersionId(w, o)
+			w.WriteHeader(http.StatusOK)
+		case has(query, "rename"):
+			s.renameObject(w, r, b, key)
+		case r.Header.Get("x-cos-copy-source") != "":
+			s.copyObject(w, r, b, key)
+		default:
+			if _, err := customerKeyMD5(r.Header, sseHeaderPrefix); err != nil {
+				writeError(w, r, http.StatusBadRequest, "InvalidArgument", err.Error())
+				return
+			}
+			if _, err := parseACLHeader(r.Header); err != nil {
+				writeError(w, r, http.StatusBadRequest, "InvalidArgument", err.Error())
+				return
+			}
+			data, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, "IncompleteBody", err.Error())
+				return
+			}
+			o := newObject(key, data, r.Header)
+			s.store(b, o)
+			s.writeVersionId(w, o)
+			w.Header().Set("ETag", o.etag)
+			w.Header().Set("x-cos-hash-crc64ecma", o.crc64)
+			w.WriteHeader(http.StatusOK)
+		}
+	case http.MethodPost:
+		switch {
+		case has(query, "uploads"):
+			s.initUpload(w, r, b, key)
+		case has(query, "uploadId"):
+			s.completeUpload(w, r, b, key, query)
+		case has(query, "restore"):
+			s.restoreObject(w, r, b, key, query)
+		case has(query, "append"):
+			s.appendObject(w, r, b, key, query)
+		default:
+			writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed.")
+		}
+	case http.MethodDelete:
+		switch {
+		case has(query, "uploadId"):
+			s.abortUpload(w, r, b, query)
+		case has(query, "tagging"):
+			if o := b.version(key, query.Get("versionId")); o != nil {
+				o.tags = nil
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			deleteMarker, versionId := s.remove(b, key, query.Get("versionId"))
+			if deleteMarker {
+				w.Header().Set("x-cos-delete-marker", "true")
+			}
+			if versionId != "" {
+				w.Header().Set("x-cos-version-id", versionId)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed.")
+	}
+}
+
+func (s *Server) writeVersionId(w http.ResponseWriter, o *object) {
+	if o.versionId != "" {
+		w.Header().Set("x-cos-version-id", o.versionId)
+	}
+}
+
+// 追加上传，对象不存在时创建可追加对象，position 须等于对象当前的长度
+func (s *Server) appendObject(w http.ResponseWriter, r *http.Request, b *bucket, key string, query url.Values) {
+	position, err := strconv.Atoi(query.Get("position"))
+	if err != nil || position < 0 {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", "invalid position")
+		return
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	o := b.version(key, "")
+	if o == nil || o.deleteMarker {
+		if position != 0 {
+			writeError(w, r, http.StatusConflict, "PositionNotEqualToLength", "The position is not equal to the object length.")
+			return
+		}
+		o = newObject(key, nil, r.Header)
+		o.appendable = true
+		s.store(b, o)
+	}
+	if !o.appendable {
+		writeError(w, r, http.StatusConflict, "ObjectNotAppendable", "The object is not appendable.")
+		return
+	}
+	if position != len(o.data) {
+		writeError(w, r, http.StatusConflict, "PositionNotEqualToLength", "The position is not equal to the object length.")
+		return
+	}
+	o.data = append(o.data, data...)
+	o.etag = fmt.Sprintf("\"%x\"", md5.Sum(o.data))
+	o.crc64 = strconv.FormatUint(crc64.Checksum(o.data, crc64Table), 10)
+	o.modified = time.Now()
+	w.Header().Set("ETag", o.etag)
+	w.Header().Set("x-cos-next-append-position", strconv.Itoa(len(o.data)))
+	// SDK 开启校验时以本次追加数据的 MD5 比对该响应头
+	sum := md5.Sum(data)
+	w.Header().Set("x-cos-content-sha1", hex.EncodeToString(sum[:]))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) headObject(w http.ResponseWriter, r *http.Request, b *bucket, key string, query url.Values) {
+	o := b.version(key, query.Get("versionId"))
+	if o == nil || o.deleteMarker {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if status, _, _ := checkCustomerKey(r.Header, sseHeaderPrefix, o.sseCustomerKeyMD5); status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+	o.writeHeader(w)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, b *bucket, key string, query url.Values) {
+	o := b.version(key, query.Get("versionId"))
+	if o == nil || o.deleteMarker {
+		writeError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+	if o.archived() {
+		writeError(w, r, http.StatusForbidden, "InvalidObjectState", "The operation is not valid for the object's storage class.")
+		return
+	}
+	if status, code, message := checkCustomerKey(r.Header, sseHeaderPrefix, o.sseCustomerKeyMD5); status != 0 {
+		writeError(w, r, status, code, message)
+		return
+	}
+	o.writeHeader(w)
+	if v := query.Get("response-content-type"); v != "" {
+		w.Header().Set("Content-Type", v)
+	}
+
+	data := o.data
+	status := http.StatusOK
+	if v := r.Header.Get("Range"); v != "" {
+		start, end, ok := parseRange(v, int64(len(o.data)))
+		if !ok {
+			w.Header().Del("Content-Length")
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(o.data)))
+			writeError(w, r, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "The requested range is not satisfiable.")
+			return
+		}
+		data = o.data[start : end+1]
+		status = http.StatusPartialContent
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(o.data)))
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	}
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// 解析 Range 头，返回闭区间 [start, end]
+func parseRange(v string, size int64) (start, end int64, ok bool) {
+	if !strings.HasPrefix(v, "bytes=") || strings.Contains(v, ",") {
+		return 0, 0, false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(v, "bytes="), "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	var err error
+	switch {
+	case parts[0] == "":
+		n, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		if n > size {
+			n = size
+		}
+		start, end = size-n, size-1
+	default:
+		if start, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+			return 0, 0, false
+		}
+		end = size - 1
+		if parts[1] != "" {
+			if end, err = strconv.ParseInt(parts[1], 10, 64); err != nil || end < start {
+				return 0, 0, false
+			}
+			if end > size-1 {
+				end = size - 1
+			}
+		}
+	}
+	if start >= size || size == 0 {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// 解析 x-cos-copy-source，格式为 <bucket>.cos.<region>.myqcloud.com/<key>[?versionId=<id>]
+func (s *Server) copySource(r *http.Request, header string) (*object, string, string) {
+	source := r.Header.Get(header)
+	parts := strings.SplitN(source, "/", 2)
+	if len(parts) != 2 {
+		return nil, "InvalidArgument", "invalid x-cos-copy-source"
+	}
+	srcBucket := s.buckets[s.bucketName(parts[0])]
+	if srcBucket == nil {
+		return nil, "NoSuchBucket", "The specified bucket does not exist."
+	}
+	if !s.checkAuth(r, srcBucket) {
+		return nil, "AccessDenied", "Access Denied."
+	}
+	keyPart, versionId := parts[1], ""
+	if i := strings.Index(keyPart, "?versionId="); i >= 0 {
+		keyPart, versionId = keyPart[:i], keyPart[i+len("?versionId="):]
+	}
+	srcKey, err := url.PathUnescape(keyPart)
+	if err != nil {
+		return nil, "InvalidArgument", err.Error()
+	}
+	o := srcBucket.version(srcKey, versionId)
+	if o == nil || o.deleteMarker {
+		return nil, "NoSuchKey", "The specified key does not exist."
+	}
+	if o.archived() {
+		return nil, "InvalidObjectState", "The operation is not valid for the object's storage class."
+	}
+	if status, code, message := checkCustomerKey(r.Header, sseCopySourcePrefix, o.sseCustomerKeyMD5); status != 0 {
+		return nil, code, message
+	}
+	return o, "", ""
+}
+
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+	if _, err := customerKeyMD5(r.Header, sseHeaderPrefix); err != nil {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", err.Error())
+		return
+	}
+	acl, err := parseACLHeader(r.Header)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", err.Error())
+		return
+	}
+	src, code, message := s.copySource(r, "x-cos-copy-source")
+	if src == nil {
+		status := http.StatusBadRequest
+		switch code {
+		case "NoSuchBucket", "NoSuchKey":
+			status = http.StatusNotFound
+		case "InvalidObjectState", "AccessDenied":
+			status = http.StatusForbidden
+		}
+		writeError(w, r, status, code, message)
+		return
+	}
+	data := append([]byte(nil), src.data...)
+	o := newObject(key, data, nil)
+	o.contentType = src.contentType
+	o.storageClass = src.storageClass
+	if strings.EqualFold(r.Header.Get("x-cos-metadata-directive"), "Replaced") {
+		o.contentType = "application/octet-stream"
+		o.setHeader(r.Header)
+	} else {
+		for name, values := range src.meta {
+			o.meta[name] = values
+		}
+		if v := r.Header.Get("x-cos-storage-class"); v != "" {
+			o.storageClass = strings.ToUpper(v)
+		}
+	}
+	// 目标对象的加密方式由拷贝请求指定，与元数据是否替换无关
+	o.setEncryption(r.Header)
+	// 拷贝不保留来源对象的访问权限
+	o.acl = acl
+	// 默认保留来源对象的标签
+	if strings.EqualFold(r.Header.Get("x-cos-tagging-directive"), "Replaced") {
+		o.tags = parseTagging(r.Header.Get("x-cos-tagging"))
+	} else {
+		o.tags = src.tags
+	}
+	s.store(b, o)
+	s.writeVersionId(w, o)
+	w.Header().Set("x-cos-hash-crc64ecma", o.crc64)
+	writeXML(w, http.StatusOK, &cos.ObjectCopyResult{
+		ETag:         o.etag,
+		LastModified: formatTime(o.modified),
+		CRC64:        o.crc64,
+		VersionId:    o.versionId,
+	})
+}
+
+// OFS 桶的重命名
+func (s *Server) renameObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+	source, err := url.PathUnescape(r.Header.Get("x-cos-rename-source"))
+	if err != nil || source == "" {
+		writeError(w, r, http.StatusBadRequest, "InvalidArgument", "invalid x-cos-rename-source")
+		return
+	}
+	source = strings.TrimPrefix(source, "/")
+	o := b.latest(source)
+	if o == nil {
+		writeError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+	if b.latest(key) != nil {
+		writeError(w, r, http.StatusConflict, "ObjectAlreadyExists", "The destination object already exists.")
+		return
+	}
+	delete(b.objects, source)
+	o.key = key
+	o.modified = time.Now()
+	b.objects[key] = []*object{o}
+	w.WriteHeader(http.StatusOK)
+}
+
+// 恢复归档对象，这里直接视为恢复完成
+func (s *Server) restoreObject(w http.ResponseWriter, r *http.Request, b *bucket, key string, query url.Values) {
+	o := b.version(key, query.Get("versionId"))
+	if o == nil || o.deleteMarker {
+		writeError(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+	var opt cos.ObjectRestoreOptions
+	if err := readXML(r, &opt); err != nil {
+		writeError(w, r, http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+	switch {
+	case o.storageClass != "ARCHIVE" && o.storageClass != "DEEP_ARCHIVE" && o.storageClass != "MAZ_ARCHIVE":
+		writeError(w, r, http.StatusForbidden, "InvalidObjectState", "The operation is not valid for the object's storage class.")
+	case o.restored:
+		writeError(w, r, http.StatusConflict, "RestoreAlreadyInProgress", "Object restore is already in progress.")
+	default:
+		o.restored = true
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// 解析 x-cos-tagging 形式的标签
+func parseTagging(v string) []cos.ObjectTaggingTag {
+	values, err := url.ParseQuery(v)
+	if err != nil {
+		return nil
+	}
+	var tags []cos.ObjectTaggingTag
+	for k := range values {
+		tags = append(tags, cos.ObjectTaggingTag{Key: k, Value: values.Get(k)})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+	return tags
+}
+
+func md5Hex(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
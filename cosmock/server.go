@@ -0,0 +1,229 @@
+// Package cosmock 在内存中实现了 coscli 用到的 COS XML API，供测试离线使用
+package cosmock
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 桶名格式 <bucket>-<appid>
+var bucketNameRegexp = regexp.MustCompile(`^[a-z0-9-]+-[0-9]+$`)
+
+// 从默认域名中解析地域
+var regionRegexp = regexp.MustCompile(`cos\.([a-z0-9-]+)\.`)
+
+// Server 内存版 COS 服务
+type Server struct {
+	*httptest.Server
+
+	// SecretID 非空时校验请求签名中的 q-ak
+	SecretID string
+	// DefaultBucket 在 Host 中无法解析出桶名时使用的桶，用于 --customized 自定义域名
+	DefaultBucket string
+	// DefaultRegion 在 Host 中无法解析出地域时使用的地域
+	DefaultRegion string
+	// MaxKeys 大于 0 时，列出对象及对象版本每页最多返回的数量，用于测试分页
+	MaxKeys int
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	seq     int64
+}
+
+// NewServer 启动一个内存版 COS 服务
+func NewServer() *Server {
+	s := &Server{
+		DefaultRegion: "ap-guangzhou",
+		buckets:       make(map[string]*bucket),
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Endpoint 返回服务监听地址，可配合 --customized 使用。
+// 也可将 URL 设置为客户端的 HTTP 代理，<bucket>.cos.<region>.myqcloud.com 形式的默认域名的请求都会发送到本服务
+func (s *Server) Endpoint() string {
+	return s.Listener.Addr().String()
+}
+
+// CreateBucket 直接在服务中创建桶，ofs 为 true 时创建 OFS 桶
+func (s *Server) CreateBucket(name string, ofs bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.buckets[name]; !ok {
+		s.buckets[name] = newBucket(name, s.DefaultRegion, ofs)
+	}
+}
+
+// SetBucketOwner 将桶设为属于另一个账号，之后只接受该账号 SecretId 签名的请求，
+// 其他账号也不能将其中的对象作为拷贝源
+func (s *Server) SetBucketOwner(name, secretID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.buckets[name]; ok {
+		b.owner = secretID
+	}
+}
+
+// Reset 清空服务中的所有数据
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buckets = make(map[string]*bucket)
+}
+
+// cosError COS 错误响应
+type cosError struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string
+	Message   string
+	Resource  string `xml:"Resource,omitempty"`
+	RequestId string `xml:"RequestId,omitempty"`
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.seq++
+	requestId := fmt.Sprintf("NjM%013d", s.seq)
+	s.mu.Unlock()
+	w.Header().Set("x-cos-request-id", requestId)
+	w.Header().Set("Server", "tencent-cos")
+
+	bucketName := s.bucketName(r.Host)
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.checkAuth(r, s.buckets[bucketName]) {
+		writeError(w, r, http.StatusForbidden, "InvalidAccessKeyId", "The access key Id format you provided is invalid.")
+		return
+	}
+
+	if bucketName == "" {
+		if r.Method == http.MethodGet && key == "" {
+			s.listBuckets(w, r)
+			return
+		}
+		writeError(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed.")
+		return
+	}
+
+	b := s.buckets[bucketName]
+	if key == "" && r.Method == http.MethodPut && len(query) == 0 {
+		s.putBucket(w, r, bucketName)
+		return
+	}
+	if b == nil {
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist.")
+		return
+	}
+
+	if key == "" {
+		s.serveBucket(w, r, b, query)
+		return
+	}
+	s.serveObject(w, r, b, key, query)
+}
+
+// 校验签名中的 SecretId，桶设置了所属账号时须使用该账号的 SecretId
+func (s *Server) checkAuth(r *http.Request, b *bucket) bool {
+	if b != nil && b.owner != "" {
+		return requestSecretID(r) == b.owner
+	}
+	if s.SecretID == "" {
+		return true
+	}
+	return requestSecretID(r) == s.SecretID
+}
+
+// 请求签名中的 q-ak
+func requestSecretID(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		auth = r.URL.RawQuery
+	}
+	// q-sign-time 等字段中含有分号，不能直接用 url.ParseQuery 解析
+	for _, field := range strings.Split(auth, "&") {
+		if v := strings.TrimPrefix(field, "q-ak="); v != field {
+			ak, _ := url.QueryUnescape(v)
+			return ak
+		}
+	}
+	return ""
+}
+
+// 从 Host 中解析桶名
+func (s *Server) bucketName(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	label := strings.SplitN(host, ".", 2)[0]
+	if bucketNameRegexp.MatchString(label) {
+		return label
+	}
+	return s.DefaultBucket
+}
+
+// 从 Host 中解析地域
+func (s *Server) region(host string) string {
+	if m := regionRegexp.FindStringSubmatch(host); m != nil {
+		return m[1]
+	}
+	return s.DefaultRegion
+}
+
+func (s *Server) nextVersionId() string {
+	s.seq++
+	return fmt.Sprintf("MTg0NDUxNz%010d", s.seq)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+	writeXML(w, status, &cosError{
+		Code:      code,
+		Message:   message,
+		Resource:  r.Host + r.URL.Path,
+		RequestId: w.Header().Get("x-cos-request-id"),
+	})
+}
+
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func readXML(r *http.Request, v interface{}) error {
+	return xml.NewDecoder(r.Body).Decode(v)
+}
+
+// 按 encoding-type=url 编码对象键
+func encodeKey(key string, encodingType string) string {
+	if encodingType != "url" {
+		return key
+	}
+	return strings.ReplaceAll(url.QueryEscape(key), "+", "%20")
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
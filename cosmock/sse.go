@@ -0,0 +1,73 @@
+package cosmock
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+)
+
+const (
+	sseHeaderPrefix     = "x-cos-"
+	sseCopySourcePrefix = "x-cos-copy-source-"
+)
+
+// 读取请求中的 SSE-C 密钥并校验，返回密钥的 MD5，未携带密钥时为空
+func customerKeyMD5(header http.Header, prefix string) (string, error) {
+	algorithm := header.Get(prefix + "server-side-encryption-customer-algorithm")
+	encodedKey := header.Get(prefix + "server-side-encryption-customer-key")
+	keyMD5 := header.Get(prefix + "server-side-encryption-customer-key-MD5")
+	if algorithm == "" && encodedKey == "" && keyMD5 == "" {
+		return "", nil
+	}
+	if algorithm != "AES256" {
+		return "", fmt.Errorf("invalid %sserver-side-encryption-customer-algorithm", prefix)
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != 32 {
+		return "", fmt.Errorf("invalid %sserver-side-encryption-customer-key", prefix)
+	}
+	sum := md5.Sum(key)
+	if base64.StdEncoding.EncodeToString(sum[:]) != keyMD5 {
+		return "", fmt.Errorf("the MD5 of %sserver-side-encryption-customer-key does not match", prefix)
+	}
+	return keyMD5, nil
+}
+
+// 从请求头中读取对象的加密方式，SSE-C 密钥需已校验
+func (o *object) setEncryption(header http.Header) {
+	o.sse = header.Get("x-cos-server-side-encryption")
+	o.kmsKeyId = header.Get("x-cos-server-side-encryption-cos-kms-key-id")
+	o.sseCustomerKeyMD5, _ = customerKeyMD5(header, sseHeaderPrefix)
+}
+
+func (o *object) writeEncryptionHeader(h http.Header) {
+	if o.sse != "" {
+		h.Set("x-cos-server-side-encryption", o.sse)
+	}
+	if o.kmsKeyId != "" {
+		h.Set("x-cos-server-side-encryption-cos-kms-key-id", o.kmsKeyId)
+	}
+	if o.sseCustomerKeyMD5 != "" {
+		h.Set("x-cos-server-side-encryption-customer-algorithm", "AES256")
+		h.Set("x-cos-server-side-encryption-customer-key-MD5", o.sseCustomerKeyMD5)
+	}
+}
+
+// 读取使用 SSE-C 加密的对象时须携带相同的密钥，未携带时返回 400，密钥不同时返回 403
+func checkCustomerKey(header http.Header, prefix, keyMD5 string) (status int, code, message string) {
+	requestMD5, err := customerKeyMD5(header, prefix)
+	if err != nil {
+		return http.StatusBadRequest, "InvalidArgument", err.Error()
+	}
+	if keyMD5 == "" {
+		return 0, "", ""
+	}
+	if requestMD5 == "" {
+		return http.StatusBadRequest, "InvalidRequest", "The object was stored using a form of SSE-C, the customer key must be provided."
+	}
+	if requestMD5 != keyMD5 {
+		return http.StatusForbidden, "AccessDenied", "The provided SSE-C key does not match the key of the object."
+	}
+	return 0, "", ""
+}
@@ -3,7 +3,9 @@ module coscli
 go 1.18
 
 require (
+	filippo.io/age v1.0.0
 	github.com/agiledragon/gomonkey/v2 v2.12.0
+	github.com/klauspost/compress v1.16.7
 	github.com/lestrrat-go/file-rotatelogs v2.4.0+incompatible
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/mozillazg/go-httpheader v0.4.0
@@ -16,7 +18,7 @@ require (
 	github.com/tencentyun/cos-go-sdk-v5 v0.7.57
 )
 
-require golang.org/x/term v0.0.0-20201126162022-7de9c90e9dd1 // indirect
+require golang.org/x/term v0.0.0-20210615171337-6886f2dfbf5b
 
 require (
 	github.com/clbanning/mxj v1.8.4 // indirect
@@ -44,5 +46,5 @@ require (
 	golang.org/x/sys v0.0.0-20211205182925-97ca703d548d // indirect
 	golang.org/x/text v0.3.7 // indirect
 	gopkg.in/ini.v1 v1.66.2 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
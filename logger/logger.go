@@ -15,6 +15,8 @@ import (
 
 var logName = "coscli.log"
 
+var fileWriter io.Writer
+
 func InitLoggerWithDir(path string) {
 	if path == "" {
 		var err error
@@ -41,6 +43,7 @@ func InitLoggerWithDir(path string) {
 		panic(err)
 	}
 
+	fileWriter = fsWriter
 	multiWriter := io.MultiWriter(fsWriter, os.Stdout)
 	log.SetOutput(multiWriter)
 	log.SetLevel(log.InfoLevel)
@@ -54,3 +57,12 @@ func InitLoggerWithDir(path string) {
 		FullTimestamp:   true,
 	})
 }
+
+// SetConsoleOutput 修改日志在终端的输出位置，日志文件不受影响
+func SetConsoleOutput(w io.Writer) {
+	if fileWriter == nil {
+		log.SetOutput(w)
+		return
+	}
+	log.SetOutput(io.MultiWriter(fileWriter, w))
+}
@@ -0,0 +1,132 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// 对象支持的预设 ACL
+var objectCannedACLs = []string{
+	cos.ACL.Default,
+	cos.ACL.Private,
+	cos.ACL.PublicRead,
+	cos.ACL.BucketOwnerRead,
+	cos.ACL.BucketOwnerFullControl,
+}
+
+// 存储桶支持的预设 ACL
+var bucketCannedACLs = []string{
+	cos.ACL.Private,
+	cos.ACL.PublicRead,
+	cos.ACL.PublicReadWrite,
+	cos.ACL.AuthenticatedRead,
+}
+
+// ACLOptions 写入对象或存储桶时指定的访问权限，可为预设 ACL 或授权列表
+type ACLOptions struct {
+	ACL              string
+	GrantRead        string
+	GrantFullControl string
+}
+
+// 被授权者的账号 ID 及 CAM 格式
+var (
+	granteeUinRegexp = regexp.MustCompile(`^[0-9]+$`)
+	granteeIDRegexp  = regexp.MustCompile(`^qcs::cam::(uin/[0-9]+:uin/[0-9]+|anyone:anyone)$`)
+)
+
+// NewACLOptions 校验并生成访问权限设置，bucket 为 true 时按存储桶支持的预设 ACL 校验。
+// 授权列表以逗号分隔，每项为账号 ID 或 id="..."、uri="..." 格式的被授权者
+func NewACLOptions(acl, grantRead, grantFullControl string, bucket bool) (opt ACLOptions, err error) {
+	if acl != "" {
+		cannedACLs := objectCannedACLs
+		if bucket {
+			cannedACLs = bucketCannedACLs
+		}
+		if !containsString(cannedACLs, acl) {
+			return opt, fmt.Errorf("--acl must be one of %s", strings.Join(cannedACLs, ", "))
+		}
+		opt.ACL = acl
+	}
+	if opt.GrantRead, err = formatGrantees("--grant-read", grantRead); err != nil {
+		return opt, err
+	}
+	if opt.GrantFullControl, err = formatGrantees("--grant-full-control", grantFullControl); err != nil {
+		return opt, err
+	}
+	return opt, nil
+}
+
+// IsEmpty 是否未指定任何访问权限
+func (opt ACLOptions) IsEmpty() bool {
+	return opt.ACL == "" && opt.GrantRead == "" && opt.GrantFullControl == ""
+}
+
+// 生成请求头，未指定访问权限时对象继承存储桶的权限
+func (opt ACLOptions) headerOptions() *cos.ACLHeaderOptions {
+	return &cos.ACLHeaderOptions{
+		XCosACL:              opt.ACL,
+		XCosGrantRead:        opt.GrantRead,
+		XCosGrantFullControl: opt.GrantFullControl,
+	}
+}
+
+// 将授权列表转换为 x-cos-grant-* 请求头的格式，账号 ID 转换为 id="qcs::cam::uin/<id>:uin/<id>"
+func formatGrantees(name, grantees string) (string, error) {
+	if grantees == "" {
+		return "", nil
+	}
+	var items []string
+	for _, grantee := range strings.Split(grantees, ",") {
+		grantee = strings.TrimSpace(grantee)
+		switch {
+		case grantee == "":
+			return "", fmt.Errorf("%s has an empty grantee", name)
+		case granteeUinRegexp.MatchString(grantee):
+			items = append(items, fmt.Sprintf("id=\"qcs::cam::uin/%s:uin/%s\"", grantee, grantee))
+		case strings.HasPrefix(grantee, "id=\"") && strings.HasSuffix(grantee, "\"") &&
+			granteeIDRegexp.MatchString(grantee[len("id=\""):len(grantee)-1]):
+			items = append(items, grantee)
+		case strings.HasPrefix(grantee, "uri=\"") && strings.HasSuffix(grantee, "\"") && len(grantee) > len("uri=\"\""):
+			items = append(items, grantee)
+		default:
+			return "", fmt.Errorf("%s has an invalid grantee %s, the format is like 100000000001 or id=\"qcs::cam::uin/100000000001:uin/100000000001\"", name, grantee)
+		}
+	}
+	return strings.Join(items, ","), nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// GetACL 获取对象的访问权限，cosUrl 不含对象键时获取存储桶的访问权限
+func GetACL(c *cos.Client, cosUrl StorageUrl, id ...string) (*cos.ACLXml, error) {
+	object := cosUrl.(*CosUrl).Object
+	if object == "" {
+		res, _, err := c.Bucket.GetACL(context.Background())
+		return res, err
+	}
+	res, _, err := c.Object.GetACL(context.Background(), object, id...)
+	return res, err
+}
+
+// PutACL 设置对象的访问权限，cosUrl 不含对象键时设置存储桶的访问权限
+func PutACL(c *cos.Client, cosUrl StorageUrl, opt ACLOptions, id ...string) error {
+	object := cosUrl.(*CosUrl).Object
+	if object == "" {
+		_, err := c.Bucket.PutACL(context.Background(), &cos.BucketPutACLOptions{Header: opt.headerOptions()})
+		return err
+	}
+	_, err := c.Object.PutACL(context.Background(), object, &cos.ObjectPutACLOptions{Header: opt.headerOptions()}, id...)
+	return err
+}
@@ -0,0 +1,195 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// 追加位置在快照中的 key，与 sync 上传快照的 key 区分
+func getAppendSnapshotKey(absLocalFilePath string, bucket string, object string) string {
+	return "append" + SnapshotConnector + getUploadSnapshotKey(absLocalFilePath, bucket, object)
+}
+
+// CheckAppendOptions 检查 --append 可同时使用的选项
+func CheckAppendOptions(srcUrl, destUrl StorageUrl, fo *FileOperations) error {
+	if !fo.Operation.Append {
+		return nil
+	}
+	switch {
+	case !(srcUrl.IsFileUrl() && destUrl.IsCosUrl()):
+		return fmt.Errorf("--append only works with upload")
+	case fo.Operation.ClientEncryption != nil && fo.Operation.ClientEncryption.Algorithm != "":
+		return fmt.Errorf("--append can not be used with --client-encryption")
+	case fo.Operation.SSE.CustomerKey != nil:
+		return fmt.Errorf("--append can not be used with SSE-C")
+	}
+	return nil
+}
+
+// 可追加对象的下一个追加位置，对象不存在时为 0，存在但不可追加时报错。同时返回对象的 CRC64
+func appendPosition(c *cos.Client, cosPath string) (int64, string, error) {
+	resp, err := c.Object.Head(context.Background(), cosPath, nil)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return 0, "", nil
+		}
+		return 0, "", err
+	}
+	if resp.Header.Get("x-cos-object-type") != "appendable" {
+		return 0, "", fmt.Errorf("%s already exists and is not appendable, remove it or upload without --append", cosPath)
+	}
+	position := resp.ContentLength
+	if v := resp.Header.Get("x-cos-next-append-position"); v != "" {
+		if position, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, "", fmt.Errorf("invalid x-cos-next-append-position %s", v)
+		}
+	}
+	return position, resp.Header.Get("x-cos-hash-crc64ecma"), nil
+}
+
+// 本地文件前 n 个字节的 CRC64
+func filePrefixCrc64(localPath string, n int64) (uint64, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	hash := crc64.New(crc64.MakeTable(crc64.ECMA))
+	if _, err = io.CopyN(hash, f, n); err != nil {
+		return 0, err
+	}
+	return hash.Sum64(), nil
+}
+
+// 快照中记录的上次追加位置及本地文件在该位置之前数据的 CRC64，格式为 <位置>,<CRC64>
+func getAppendRecord(fo *FileOperations, snapshotKey string) (position int64, crc uint64, ok bool) {
+	if fo.SnapshotDb == nil {
+		return 0, 0, false
+	}
+	recorded, err := fo.SnapshotDb.Get([]byte(snapshotKey), nil)
+	if err != nil {
+		return 0, 0, false
+	}
+	fields := strings.Split(string(recorded), ",")
+	if len(fields) != 2 {
+		return 0, 0, false
+	}
+	position, err = strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	crc, err = strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return position, crc, true
+}
+
+func putAppendRecord(fo *FileOperations, snapshotKey string, position int64, crc uint64) {
+	if fo.SnapshotDb == nil {
+		return
+	}
+	fo.SnapshotDb.Put([]byte(snapshotKey), []byte(strconv.FormatInt(position, 10)+","+strconv.FormatUint(crc, 10)), nil)
+}
+
+// 计算本地文件需要追加的起始位置，同时返回本地文件在起始位置之前数据的 CRC64。
+// 快照中记录了上次的追加位置时，须与对象当前的追加位置一致，且本地文件开头部分的 CRC64 与记录的一致，
+// 以发现本地文件被截断或轮转后又增长超过该位置的情况；未记录时校验对象内容与本地文件的开头部分一致
+func appendStart(c *cos.Client, fo *FileOperations, localPath, cosPath, snapshotKey string, size int64) (int64, uint64, error) {
+	position, cosCrc, err := appendPosition(c, cosPath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	recordedPosition, recordedCrc, recorded := getAppendRecord(fo, snapshotKey)
+	if recorded && recordedPosition != position {
+		return 0, 0, fmt.Errorf("the append position %d of %s differs from the recorded position %d, the object may be modified by others",
+			position, cosPath, recordedPosition)
+	}
+	if size < position {
+		return 0, 0, fmt.Errorf("%s is truncated, its size %d is less than the append position %d of %s", localPath, size, position, cosPath)
+	}
+	checkCosCrc := cosCrc != "" && c.Conf.EnableCRC
+	// 无需校验也无需记录时不读取本地文件
+	if position == 0 || (fo.SnapshotDb == nil && !checkCosCrc) {
+		return position, 0, nil
+	}
+
+	localCrc, err := filePrefixCrc64(localPath, position)
+	if err != nil {
+		return 0, 0, err
+	}
+	if recorded {
+		if localCrc != recordedCrc {
+			return 0, 0, fmt.Errorf("the first %d bytes of %s differ from the data appended last time, the file may be truncated or rotated", position, localPath)
+		}
+	} else if checkCosCrc && strconv.FormatUint(localCrc, 10) != cosCrc {
+		return 0, 0, fmt.Errorf("the content of %s is not the beginning of %s, the file may be modified", cosPath, localPath)
+	}
+	return position, localCrc, nil
+}
+
+// 将本地文件 [start, size) 的数据按 --part-size 分次追加到对象，每次追加成功后记录追加位置及之前数据的 CRC64，
+// startCrc 为 [0, start) 的 CRC64。返回追加的字节数
+func appendUpload(c *cos.Client, fo *FileOperations, localPath, cosPath, snapshotKey string, start int64, startCrc uint64, size int64) (int64, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	partSize := fo.Operation.PartSize * 1024 * 1024
+	position := start
+	crc := startCrc
+	for position < size {
+		n := size - position
+		if n > partSize {
+			n = partSize
+		}
+		opt := &cos.ObjectPutOptions{
+			ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
+				ContentLength:    n,
+				XCosTrafficLimit: (int)(fo.Operation.RateLimiting * 1024 * 1024 * 8),
+			},
+		}
+		// 对象的属性在首次追加时设置
+		if position == 0 {
+			opt.ACLHeaderOptions = fo.Operation.ACL.headerOptions()
+			opt.ObjectPutHeaderOptions = &cos.ObjectPutHeaderOptions{
+				CacheControl:       fo.Operation.Meta.CacheControl,
+				ContentDisposition: fo.Operation.Meta.ContentDisposition,
+				ContentEncoding:    fo.Operation.Meta.ContentEncoding,
+				ContentType:        fo.Operation.Meta.ContentType,
+				ContentLanguage:    fo.Operation.Meta.ContentLanguage,
+				Expires:            fo.Operation.Meta.Expires,
+				XCosMetaXXX:        fo.Operation.Meta.XCosMetaXXX,
+				XCosStorageClass:   fo.Operation.StorageClass,
+				ContentLength:      n,
+				XCosTrafficLimit:   (int)(fo.Operation.RateLimiting * 1024 * 1024 * 8),
+			}
+			fo.Operation.SSE.setPutHeader(opt.ObjectPutHeaderOptions)
+			fo.Operation.Tagging.setPutHeader(opt.ObjectPutHeaderOptions)
+		}
+		next, _, err := c.Object.Append(context.Background(), cosPath, int(position), io.NewSectionReader(f, position, n), opt)
+		if err != nil {
+			return position - start, err
+		}
+		if fo.SnapshotDb != nil {
+			hash := crc64.New(crc64.MakeTable(crc64.ECMA))
+			if _, err = io.Copy(hash, io.NewSectionReader(f, position, n)); err != nil {
+				return int64(next) - start, err
+			}
+			crc = crc64Combine(crc, hash.Sum64(), n)
+		}
+		position = int64(next)
+		putAppendRecord(fo, snapshotKey, position, crc)
+	}
+	return position - start, nil
+}
@@ -0,0 +1,90 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 存储桶配置文件格式
+const (
+	ConfigFormatJson = "json"
+	ConfigFormatYaml = "yaml"
+)
+
+// 配置中引用的其他存储桶需使用 <bucket-name>-<appid> 格式的完整名称
+var bucketIDNameRegexp = regexp.MustCompile(`^[a-z0-9-]+-[0-9]+$`)
+
+// 生成 qcs::cos:<region>::<bucket> 格式的目标存储桶
+func qcsBucket(region, bucket string) string {
+	return fmt.Sprintf("qcs::cos:%s::%s", region, bucket)
+}
+
+// 解析 qcs::cos:<region>::<bucket> 格式的目标存储桶，格式不符时整体作为存储桶名
+func parseQcsBucket(s string) (region, bucket string) {
+	parts := strings.SplitN(strings.TrimPrefix(s, "qcs::cos:"), "::", 2)
+	if len(parts) != 2 {
+		return "", s
+	}
+	return parts[0], parts[1]
+}
+
+// BucketConfig 可从文件读取的存储桶配置，读取后校验
+type BucketConfig interface {
+	Validate() error
+}
+
+// ConfigFileFormat 根据文件扩展名判断配置文件格式
+func ConfigFileFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ConfigFormatJson, nil
+	case ".yaml", ".yml":
+		return ConfigFormatYaml, nil
+	}
+	return "", fmt.Errorf("unsupported config file %s, the extension must be .yaml, .yml or .json", path)
+}
+
+// LoadConfigFile 读取并校验 YAML 或 JSON 格式的存储桶配置文件，未知字段视为错误
+func LoadConfigFile(path string, v BucketConfig) error {
+	format, err := ConfigFileFormat(path)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if format == ConfigFormatJson {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(v)
+	} else {
+		err = yaml.UnmarshalStrict(data, v)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s error: %v", path, err)
+	}
+	if err = v.Validate(); err != nil {
+		return fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// MarshalConfig 按 format 序列化存储桶配置，输出可再次作为配置文件使用
+func MarshalConfig(v interface{}, format string) ([]byte, error) {
+	if format == ConfigFormatYaml {
+		return yaml.Marshal(v)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,117 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+const corsMaxRules = 100
+
+var corsMethods = []string{"GET", "PUT", "POST", "DELETE", "HEAD"}
+
+// CORSConfig 跨域访问规则文件
+type CORSConfig struct {
+	Rules []CORSRule `yaml:"rules" json:"rules"`
+	// 为 true 时响应携带 Vary: Origin
+	ResponseVary bool `yaml:"response_vary,omitempty" json:"response_vary,omitempty"`
+}
+
+type CORSRule struct {
+	ID             string   `yaml:"id,omitempty" json:"id,omitempty"`
+	AllowedOrigins []string `yaml:"allowed_origins" json:"allowed_origins"`
+	AllowedMethods []string `yaml:"allowed_methods" json:"allowed_methods"`
+	AllowedHeaders []string `yaml:"allowed_headers,omitempty" json:"allowed_headers,omitempty"`
+	ExposeHeaders  []string `yaml:"expose_headers,omitempty" json:"expose_headers,omitempty"`
+	MaxAgeSeconds  int      `yaml:"max_age_seconds,omitempty" json:"max_age_seconds,omitempty"`
+}
+
+// Validate 校验跨域访问规则，请求方法统一转为大写
+func (cc *CORSConfig) Validate() error {
+	if len(cc.Rules) == 0 {
+		return fmt.Errorf("no rules")
+	}
+	if len(cc.Rules) > corsMaxRules {
+		return fmt.Errorf("a bucket can have at most %d cors rules", corsMaxRules)
+	}
+	for i := range cc.Rules {
+		rule := &cc.Rules[i]
+		name := fmt.Sprintf("rule %d", i+1)
+		if rule.ID != "" {
+			name = fmt.Sprintf("rule %s", rule.ID)
+		}
+		if len(rule.AllowedOrigins) == 0 {
+			return fmt.Errorf("%s: allowed_origins is required", name)
+		}
+		if len(rule.AllowedMethods) == 0 {
+			return fmt.Errorf("%s: allowed_methods is required", name)
+		}
+		for j, method := range rule.AllowedMethods {
+			rule.AllowedMethods[j] = strings.ToUpper(method)
+			if !containsString(corsMethods, rule.AllowedMethods[j]) {
+				return fmt.Errorf("%s: invalid method %s, must be one of %s", name, method, strings.Join(corsMethods, ", "))
+			}
+		}
+		if rule.MaxAgeSeconds < 0 {
+			return fmt.Errorf("%s: max_age_seconds must be greater than 0", name)
+		}
+	}
+	return nil
+}
+
+// LoadCORSConfig 读取并校验 YAML 或 JSON 格式的跨域访问规则文件
+func LoadCORSConfig(path string) (*CORSConfig, error) {
+	var cc CORSConfig
+	if err := LoadConfigFile(path, &cc); err != nil {
+		return nil, err
+	}
+	return &cc, nil
+}
+
+// GetCORS 获取存储桶的跨域访问规则
+func GetCORS(c *cos.Client) (*CORSConfig, error) {
+	res, _, err := c.Bucket.GetCORS(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	cc := &CORSConfig{ResponseVary: res.ResponseVary == "true"}
+	for _, r := range res.Rules {
+		cc.Rules = append(cc.Rules, CORSRule{
+			ID:             r.ID,
+			AllowedOrigins: r.AllowedOrigins,
+			AllowedMethods: r.AllowedMethods,
+			AllowedHeaders: r.AllowedHeaders,
+			ExposeHeaders:  r.ExposeHeaders,
+			MaxAgeSeconds:  r.MaxAgeSeconds,
+		})
+	}
+	return cc, nil
+}
+
+// PutCORS 设置存储桶的跨域访问规则，覆盖已有的规则
+func PutCORS(c *cos.Client, cc *CORSConfig) error {
+	opt := &cos.BucketPutCORSOptions{}
+	if cc.ResponseVary {
+		opt.ResponseVary = "true"
+	}
+	for _, r := range cc.Rules {
+		opt.Rules = append(opt.Rules, cos.BucketCORSRule{
+			ID:             r.ID,
+			AllowedOrigins: r.AllowedOrigins,
+			AllowedMethods: r.AllowedMethods,
+			AllowedHeaders: r.AllowedHeaders,
+			ExposeHeaders:  r.ExposeHeaders,
+			MaxAgeSeconds:  r.MaxAgeSeconds,
+		})
+	}
+	_, err := c.Bucket.PutCORS(context.Background(), opt)
+	return err
+}
+
+// DeleteCORS 删除存储桶的所有跨域访问规则
+func DeleteCORS(c *cos.Client) error {
+	_, err := c.Bucket.DeleteCORS(context.Background())
+	return err
+}
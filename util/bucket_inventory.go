@@ -0,0 +1,331 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+const (
+	InventoryScheduleDaily   = "Daily"
+	InventoryScheduleWeekly  = "Weekly"
+	InventoryVersionsCurrent = "Current"
+	InventoryVersionsAll     = "All"
+	InventoryFormatCSV       = "CSV"
+
+	inventoryManifest = "manifest.json"
+)
+
+// 清单可包含的对象属性
+var inventoryOptionalFields = []string{
+	"Size", "LastModifiedDate", "ETag", "StorageClass", "IsMultipartUploaded",
+	"ReplicationStatus", "Tag", "Crc64", "x-cos-meta-*",
+}
+
+var inventoryIDRegexp = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// InventoryConfig 清单配置文件，每个存储桶可有多个以 ID 区分的清单，清单报告按 Schedule 定期生成到目标存储桶
+type InventoryConfig struct {
+	ID                     string   `yaml:"id" json:"id"`
+	Status                 string   `yaml:"status,omitempty" json:"status,omitempty"`
+	Schedule               string   `yaml:"schedule" json:"schedule"`
+	IncludedObjectVersions string   `yaml:"included_object_versions,omitempty" json:"included_object_versions,omitempty"`
+	Prefix                 string   `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	OptionalFields         []string `yaml:"optional_fields,omitempty" json:"optional_fields,omitempty"`
+	DestinationBucket      string   `yaml:"destination_bucket" json:"destination_bucket"`
+	DestinationRegion      string   `yaml:"destination_region" json:"destination_region"`
+	DestinationPrefix      string   `yaml:"destination_prefix,omitempty" json:"destination_prefix,omitempty"`
+	Format                 string   `yaml:"format,omitempty" json:"format,omitempty"`
+	// 为 true 时清单报告使用 SSE-COS 加密
+	Encryption bool `yaml:"encryption,omitempty" json:"encryption,omitempty"`
+}
+
+// InventoryConfigList 存储桶的全部清单配置
+type InventoryConfigList struct {
+	Inventories []InventoryConfig `yaml:"inventories" json:"inventories"`
+}
+
+// InventoryManifest 清单报告的 manifest.json，Files 为本次生成的 CSV.gz 清单文件
+type InventoryManifest struct {
+	SourceBucket      string                  `json:"sourceBucket"`
+	DestinationBucket string                  `json:"destinationBucket"`
+	CreationTimestamp string                  `json:"creationTimestamp"`
+	FileFormat        string                  `json:"fileFormat"`
+	FileSchema        string                  `json:"fileSchema"`
+	Files             []InventoryManifestFile `json:"files"`
+}
+
+type InventoryManifestFile struct {
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	MD5Checksum string `json:"MD5checksum"`
+}
+
+// Validate 校验清单配置，未指定时默认启用、仅包含当前版本并使用 CSV 格式
+func (ic *InventoryConfig) Validate() error {
+	if !inventoryIDRegexp.MatchString(ic.ID) {
+		return fmt.Errorf("invalid id %q, it can only contain letters, digits, '.', '_' and '-', and must not exceed 64 characters", ic.ID)
+	}
+	switch ic.Status {
+	case "":
+		ic.Status = LifecycleStatusEnabled
+	case LifecycleStatusEnabled, LifecycleStatusDisabled:
+	default:
+		return fmt.Errorf("status must be %s or %s", LifecycleStatusEnabled, LifecycleStatusDisabled)
+	}
+	if ic.Schedule != InventoryScheduleDaily && ic.Schedule != InventoryScheduleWeekly {
+		return fmt.Errorf("schedule must be %s or %s", InventoryScheduleDaily, InventoryScheduleWeekly)
+	}
+	switch ic.IncludedObjectVersions {
+	case "":
+		ic.IncludedObjectVersions = InventoryVersionsCurrent
+	case InventoryVersionsCurrent, InventoryVersionsAll:
+	default:
+		return fmt.Errorf("included_object_versions must be %s or %s", InventoryVersionsCurrent, InventoryVersionsAll)
+	}
+	for _, field := range ic.OptionalFields {
+		if !containsString(inventoryOptionalFields, field) {
+			return fmt.Errorf("invalid optional field %s, must be one of %s", field, strings.Join(inventoryOptionalFields, ", "))
+		}
+	}
+	if !bucketIDNameRegexp.MatchString(ic.DestinationBucket) {
+		return fmt.Errorf("invalid destination_bucket %s, the format is <bucket-name>-<appid>", ic.DestinationBucket)
+	}
+	if ic.DestinationRegion == "" {
+		return fmt.Errorf("destination_region is required")
+	}
+	switch ic.Format {
+	case "":
+		ic.Format = InventoryFormatCSV
+	case InventoryFormatCSV:
+	default:
+		return fmt.Errorf("format can only be %s", InventoryFormatCSV)
+	}
+	return nil
+}
+
+// Validate 校验每个清单配置，ID 不能重复
+func (il *InventoryConfigList) Validate() error {
+	ids := make(map[string]bool)
+	for i := range il.Inventories {
+		if err := il.Inventories[i].Validate(); err != nil {
+			return fmt.Errorf("inventory %d: %v", i+1, err)
+		}
+		if ids[il.Inventories[i].ID] {
+			return fmt.Errorf("inventory %d: duplicate id %s", i+1, il.Inventories[i].ID)
+		}
+		ids[il.Inventories[i].ID] = true
+	}
+	return nil
+}
+
+// LoadInventoryConfig 读取并校验 YAML 或 JSON 格式的清单配置文件
+func LoadInventoryConfig(path string) (*InventoryConfig, error) {
+	var ic InventoryConfig
+	if err := LoadConfigFile(path, &ic); err != nil {
+		return nil, err
+	}
+	return &ic, nil
+}
+
+// LoadInventoryConfigList 读取并校验 list 保存的全部清单配置
+func LoadInventoryConfigList(path string) (*InventoryConfigList, error) {
+	var il InventoryConfigList
+	if err := LoadConfigFile(path, &il); err != nil {
+		return nil, err
+	}
+	return &il, nil
+}
+
+func (ic *InventoryConfig) toCos() *cos.BucketPutInventoryOptions {
+	opt := &cos.BucketPutInventoryOptions{
+		ID:                     ic.ID,
+		IsEnabled:              "true",
+		IncludedObjectVersions: ic.IncludedObjectVersions,
+		Schedule:               &cos.BucketInventorySchedule{Frequency: ic.Schedule},
+		Destination: &cos.BucketInventoryDestination{
+			Bucket: qcsBucket(ic.DestinationRegion, ic.DestinationBucket),
+			Prefix: ic.DestinationPrefix,
+			Format: ic.Format,
+		},
+	}
+	if ic.Status == LifecycleStatusDisabled {
+		opt.IsEnabled = "false"
+	}
+	if ic.Prefix != "" {
+		opt.Filter = &cos.BucketInventoryFilter{Prefix: ic.Prefix}
+	}
+	if len(ic.OptionalFields) > 0 {
+		opt.OptionalFields = &cos.BucketInventoryOptionalFields{BucketInventoryFields: ic.OptionalFields}
+	}
+	if ic.Encryption {
+		opt.Destination.Encryption = &cos.BucketInventoryEncryption{}
+	}
+	return opt
+}
+
+func inventoryConfigFromCos(opt *cos.BucketPutInventoryOptions) InventoryConfig {
+	ic := InventoryConfig{
+		ID:                     opt.ID,
+		Status:                 LifecycleStatusEnabled,
+		IncludedObjectVersions: opt.IncludedObjectVersions,
+	}
+	if opt.IsEnabled != "true" {
+		ic.Status = LifecycleStatusDisabled
+	}
+	if opt.Schedule != nil {
+		ic.Schedule = opt.Schedule.Frequency
+	}
+	if opt.Filter != nil {
+		ic.Prefix = opt.Filter.Prefix
+	}
+	if opt.OptionalFields != nil {
+		ic.OptionalFields = opt.OptionalFields.BucketInventoryFields
+	}
+	if opt.Destination != nil {
+		ic.DestinationRegion, ic.DestinationBucket = parseQcsBucket(opt.Destination.Bucket)
+		ic.DestinationPrefix = opt.Destination.Prefix
+		ic.Format = opt.Destination.Format
+		ic.Encryption = opt.Destination.Encryption != nil
+	}
+	return ic
+}
+
+// GetInventory 获取存储桶指定 ID 的清单配置
+func GetInventory(c *cos.Client, id string) (*InventoryConfig, error) {
+	res, _, err := c.Bucket.GetInventory(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	ic := inventoryConfigFromCos((*cos.BucketPutInventoryOptions)(res))
+	return &ic, nil
+}
+
+// ListInventories 分页获取存储桶的全部清单配置
+func ListInventories(c *cos.Client) (*InventoryConfigList, error) {
+	il := &InventoryConfigList{Inventories: []InventoryConfig{}}
+	token := ""
+	for {
+		res, _, err := c.Bucket.ListInventoryConfigurations(context.Background(), token)
+		if err != nil {
+			return nil, err
+		}
+		for i := range res.InventoryConfigurations {
+			opt := cos.BucketPutInventoryOptions(res.InventoryConfigurations[i])
+			il.Inventories = append(il.Inventories, inventoryConfigFromCos(&opt))
+		}
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			return il, nil
+		}
+		token = res.NextContinuationToken
+	}
+}
+
+// PutInventory 设置存储桶的清单配置，已存在相同 ID 的清单时覆盖
+func PutInventory(c *cos.Client, ic *InventoryConfig) error {
+	_, err := c.Bucket.PutInventory(context.Background(), ic.ID, ic.toCos())
+	return err
+}
+
+// DeleteInventory 删除存储桶指定 ID 的清单配置
+func DeleteInventory(c *cos.Client, id string) error {
+	_, err := c.Bucket.DeleteInventory(context.Background(), id)
+	return err
+}
+
+// InventoryReportPrefix 清单报告在目标存储桶中的目录 <destination_prefix>/<appid>/<bucket-name>/<id>/，
+// 其下每次生成的报告位于以生成时间命名的子目录，清单文件位于 data/ 子目录。sourceBucket 为 <bucket-name>-<appid> 格式
+func InventoryReportPrefix(ic *InventoryConfig, sourceBucket string) string {
+	name, appId := sourceBucket, ""
+	if index := strings.LastIndex(sourceBucket, "-"); index > 0 {
+		name, appId = sourceBucket[:index], sourceBucket[index+1:]
+	}
+	prefix := ic.DestinationPrefix
+	if prefix != "" && !strings.HasSuffix(prefix, CosSeparator) {
+		prefix += CosSeparator
+	}
+	return prefix + appId + CosSeparator + name + CosSeparator + ic.ID + CosSeparator
+}
+
+// LatestInventoryManifest 获取目录下最近一次生成的清单报告，返回其 manifest.json 的对象键及内容。
+// 尚未写入 manifest.json 的报告视为未生成完成，跳过
+func LatestInventoryManifest(c *cos.Client, prefix string) (string, *InventoryManifest, error) {
+	opt := &cos.BucketGetOptions{
+		Prefix:       prefix,
+		Delimiter:    CosSeparator,
+		EncodingType: "url",
+		MaxKeys:      1000,
+	}
+	var reports []string
+	for {
+		res, err := tryGetObjects(c, opt)
+		if err != nil {
+			return "", nil, err
+		}
+		for _, report := range res.CommonPrefixes {
+			report, _ = url.QueryUnescape(report)
+			if report != prefix+"data/" {
+				reports = append(reports, report)
+			}
+		}
+		if !res.IsTruncated {
+			break
+		}
+		opt.Marker, _ = url.QueryUnescape(res.NextMarker)
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(reports)))
+	for _, report := range reports {
+		key := report + inventoryManifest
+		resp, err := c.Object.Get(context.Background(), key, nil)
+		if err != nil {
+			if resp != nil && resp.StatusCode == 404 {
+				continue
+			}
+			return "", nil, err
+		}
+		var manifest InventoryManifest
+		err = json.NewDecoder(resp.Body).Decode(&manifest)
+		resp.Body.Close()
+		if err != nil {
+			return "", nil, fmt.Errorf("parse %s error: %v", key, err)
+		}
+		return key, &manifest, nil
+	}
+	return "", nil, fmt.Errorf("no inventory report found under %s", prefix)
+}
+
+// FetchInventory 通过下载流程将 manifest.json 及其列出的清单文件下载到本地目录，保留相对 prefix 的目录结构
+func FetchInventory(c *cos.Client, bucket, prefix, manifestKey string, manifest *InventoryManifest, localPath string, fo *FileOperations) error {
+	fo.FileList = []FileListEntry{{Key: strings.TrimPrefix(manifestKey, prefix)}}
+	for _, file := range manifest.Files {
+		if !strings.HasPrefix(file.Key, prefix) {
+			return fmt.Errorf("the inventory file %s is not under %s", file.Key, prefix)
+		}
+		fo.FileList = append(fo.FileList, FileListEntry{Key: strings.TrimPrefix(file.Key, prefix)})
+	}
+
+	if !strings.HasSuffix(localPath, string(filepath.Separator)) {
+		localPath += string(filepath.Separator)
+	}
+	if err := os.MkdirAll(localPath, 0755); err != nil {
+		return fmt.Errorf("mkdir %s failed:%v", localPath, err)
+	}
+	cosUrl, err := FormatUrl(SchemePrefix + bucket + CosSeparator + prefix)
+	if err != nil {
+		return err
+	}
+	fileUrl, err := FormatUrl(localPath)
+	if err != nil {
+		return err
+	}
+	return Download(c, cosUrl, fileUrl, fo)
+}
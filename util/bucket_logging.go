@@ -0,0 +1,64 @@
+package util
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// LoggingConfig 访问日志配置文件，日志投递到 TargetBucket 的 TargetPrefix 前缀下，目标存储桶需与源存储桶位于同一地域
+type LoggingConfig struct {
+	TargetBucket string `yaml:"target_bucket" json:"target_bucket"`
+	TargetPrefix string `yaml:"target_prefix,omitempty" json:"target_prefix,omitempty"`
+}
+
+// Validate 校验访问日志配置
+func (lc *LoggingConfig) Validate() error {
+	if !bucketIDNameRegexp.MatchString(lc.TargetBucket) {
+		return fmt.Errorf("invalid target_bucket %s, the format is <bucket-name>-<appid>", lc.TargetBucket)
+	}
+	return nil
+}
+
+// LoadLoggingConfig 读取并校验 YAML 或 JSON 格式的访问日志配置文件
+func LoadLoggingConfig(path string) (*LoggingConfig, error) {
+	var lc LoggingConfig
+	if err := LoadConfigFile(path, &lc); err != nil {
+		return nil, err
+	}
+	return &lc, nil
+}
+
+// GetLogging 获取存储桶的访问日志配置，未开启访问日志时返回错误
+func GetLogging(c *cos.Client) (*LoggingConfig, error) {
+	res, _, err := c.Bucket.GetLogging(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if res.LoggingEnabled == nil {
+		return nil, fmt.Errorf("access logging is not enabled on the bucket")
+	}
+	return &LoggingConfig{
+		TargetBucket: res.LoggingEnabled.TargetBucket,
+		TargetPrefix: res.LoggingEnabled.TargetPrefix,
+	}, nil
+}
+
+// PutLogging 开启存储桶的访问日志
+func PutLogging(c *cos.Client, lc *LoggingConfig) error {
+	opt := &cos.BucketPutLoggingOptions{
+		LoggingEnabled: &cos.BucketLoggingEnabled{
+			TargetBucket: lc.TargetBucket,
+			TargetPrefix: lc.TargetPrefix,
+		},
+	}
+	_, err := c.Bucket.PutLogging(context.Background(), opt)
+	return err
+}
+
+// DeleteLogging 关闭存储桶的访问日志，COS 通过不含 LoggingEnabled 的 Put 请求关闭
+func DeleteLogging(c *cos.Client) error {
+	_, err := c.Bucket.PutLogging(context.Background(), &cos.BucketPutLoggingOptions{})
+	return err
+}
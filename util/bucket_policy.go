@@ -0,0 +1,79 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+const policyVersion = "2.0"
+
+// PolicyConfig 存储桶策略，即 COS 的策略 JSON
+type PolicyConfig cos.BucketPutPolicyOptions
+
+// Validate 校验存储桶策略，未指定版本时默认 2.0
+func (pc *PolicyConfig) Validate() error {
+	if pc.Version == "" {
+		pc.Version = policyVersion
+	}
+	if len(pc.Statement) == 0 {
+		return fmt.Errorf("no statement")
+	}
+	for i, statement := range pc.Statement {
+		name := fmt.Sprintf("statement %d", i+1)
+		if statement.Sid != "" {
+			name = fmt.Sprintf("statement %s", statement.Sid)
+		}
+		if effect := strings.ToLower(statement.Effect); effect != "allow" && effect != "deny" {
+			return fmt.Errorf("%s: effect must be allow or deny", name)
+		}
+		if len(statement.Action) == 0 {
+			return fmt.Errorf("%s: action is required", name)
+		}
+		if len(statement.Resource) == 0 {
+			return fmt.Errorf("%s: resource is required", name)
+		}
+	}
+	return nil
+}
+
+// LoadPolicyConfig 读取并校验 JSON 格式的存储桶策略文件
+func LoadPolicyConfig(path string) (*PolicyConfig, error) {
+	format, err := ConfigFileFormat(path)
+	if err != nil {
+		return nil, err
+	}
+	if format != ConfigFormatJson {
+		return nil, fmt.Errorf("unsupported policy file %s, the bucket policy must be a .json file", path)
+	}
+	var pc PolicyConfig
+	if err := LoadConfigFile(path, &pc); err != nil {
+		return nil, err
+	}
+	return &pc, nil
+}
+
+// GetPolicy 获取存储桶策略
+func GetPolicy(c *cos.Client) (*PolicyConfig, error) {
+	res, _, err := c.Bucket.GetPolicy(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	pc := PolicyConfig(*res)
+	return &pc, nil
+}
+
+// PutPolicy 设置存储桶策略，覆盖已有的策略
+func PutPolicy(c *cos.Client, pc *PolicyConfig) error {
+	opt := cos.BucketPutPolicyOptions(*pc)
+	_, err := c.Bucket.PutPolicy(context.Background(), &opt)
+	return err
+}
+
+// DeletePolicy 删除存储桶策略
+func DeletePolicy(c *cos.Client) error {
+	_, err := c.Bucket.DeletePolicy(context.Background())
+	return err
+}
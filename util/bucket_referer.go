@@ -0,0 +1,88 @@
+package util
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+const (
+	RefererTypeWhiteList = "White-List"
+	RefererTypeBlackList = "Black-List"
+)
+
+// RefererConfig 防盗链配置文件，AllowEmptyReferer 为 true 时允许空 Referer 访问
+type RefererConfig struct {
+	Status            string   `yaml:"status,omitempty" json:"status,omitempty"`
+	Type              string   `yaml:"type" json:"type"`
+	Domains           []string `yaml:"domains" json:"domains"`
+	AllowEmptyReferer bool     `yaml:"allow_empty_referer,omitempty" json:"allow_empty_referer,omitempty"`
+}
+
+// Validate 校验防盗链配置，未指定状态时默认启用
+func (rc *RefererConfig) Validate() error {
+	switch rc.Status {
+	case "":
+		rc.Status = LifecycleStatusEnabled
+	case LifecycleStatusEnabled, LifecycleStatusDisabled:
+	default:
+		return fmt.Errorf("status must be %s or %s", LifecycleStatusEnabled, LifecycleStatusDisabled)
+	}
+	if rc.Type != RefererTypeWhiteList && rc.Type != RefererTypeBlackList {
+		return fmt.Errorf("type must be %s or %s", RefererTypeWhiteList, RefererTypeBlackList)
+	}
+	if len(rc.Domains) == 0 {
+		return fmt.Errorf("domains is required")
+	}
+	for _, domain := range rc.Domains {
+		if domain == "" {
+			return fmt.Errorf("the domain is empty")
+		}
+	}
+	return nil
+}
+
+// LoadRefererConfig 读取并校验 YAML 或 JSON 格式的防盗链配置文件
+func LoadRefererConfig(path string) (*RefererConfig, error) {
+	var rc RefererConfig
+	if err := LoadConfigFile(path, &rc); err != nil {
+		return nil, err
+	}
+	return &rc, nil
+}
+
+// GetReferer 获取存储桶的防盗链配置
+func GetReferer(c *cos.Client) (*RefererConfig, error) {
+	res, _, err := c.Bucket.GetReferer(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &RefererConfig{
+		Status:            res.Status,
+		Type:              res.RefererType,
+		Domains:           res.DomainList,
+		AllowEmptyReferer: res.EmptyReferConfiguration == "Allow",
+	}, nil
+}
+
+// PutReferer 设置存储桶的防盗链配置
+func PutReferer(c *cos.Client, rc *RefererConfig) error {
+	opt := &cos.BucketPutRefererOptions{
+		Status:                  rc.Status,
+		RefererType:             rc.Type,
+		DomainList:              rc.Domains,
+		EmptyReferConfiguration: "Deny",
+	}
+	if rc.AllowEmptyReferer {
+		opt.EmptyReferConfiguration = "Allow"
+	}
+	_, err := c.Bucket.PutReferer(context.Background(), opt)
+	return err
+}
+
+// DeleteReferer 删除存储桶的防盗链配置
+func DeleteReferer(c *cos.Client) error {
+	_, err := c.Bucket.DeleteReferer(context.Background())
+	return err
+}
@@ -0,0 +1,197 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// 对象的跨地域复制状态，未匹配复制规则的对象没有复制状态，统计为 NONE
+const (
+	ReplicationStatusPending   = "PENDING"
+	ReplicationStatusCompleted = "COMPLETED"
+	ReplicationStatusFailed    = "FAILED"
+	ReplicationStatusReplica   = "REPLICA"
+	ReplicationStatusNone      = "NONE"
+
+	replicationStatusHeader = "x-cos-replication-status"
+)
+
+// 复制状态的输出顺序
+var replicationStatuses = []string{
+	ReplicationStatusPending, ReplicationStatusCompleted, ReplicationStatusFailed,
+	ReplicationStatusReplica, ReplicationStatusNone,
+}
+
+// 目标存储桶可使用的存储类型
+var replicationStorageClasses = []string{
+	Standard, StandardIA, IntelligentTiering, Archive, DeepArchive,
+	MAZStandard, MAZStandardIA, MAZIntelligentTiering, MAZArchive,
+}
+
+// ReplicationConfig 跨地域复制规则文件，Role 为执行复制的角色，如 qcs::cam::uin/100000000001:uin/100000000001
+type ReplicationConfig struct {
+	Role  string            `yaml:"role" json:"role"`
+	Rules []ReplicationRule `yaml:"rules" json:"rules"`
+}
+
+// ReplicationRule 将前缀下的对象复制到目标地域的存储桶，StorageClass 为空时与源对象相同
+type ReplicationRule struct {
+	ID                string `yaml:"id,omitempty" json:"id,omitempty"`
+	Status            string `yaml:"status,omitempty" json:"status,omitempty"`
+	Prefix            string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	DestinationBucket string `yaml:"destination_bucket" json:"destination_bucket"`
+	DestinationRegion string `yaml:"destination_region" json:"destination_region"`
+	StorageClass      string `yaml:"storage_class,omitempty" json:"storage_class,omitempty"`
+}
+
+// Validate 校验跨地域复制规则，规则的前缀不能重叠，未指定状态的规则默认启用
+func (rc *ReplicationConfig) Validate() error {
+	if !strings.HasPrefix(rc.Role, "qcs::cam::") {
+		return fmt.Errorf("invalid role %s, the format is like qcs::cam::uin/100000000001:uin/100000000001", rc.Role)
+	}
+	if len(rc.Rules) == 0 {
+		return fmt.Errorf("no rules")
+	}
+	ids := make(map[string]bool)
+	for i := range rc.Rules {
+		rule := &rc.Rules[i]
+		name := fmt.Sprintf("rule %d", i+1)
+		if rule.ID != "" {
+			name = fmt.Sprintf("rule %s", rule.ID)
+			if ids[rule.ID] {
+				return fmt.Errorf("%s: duplicate id", name)
+			}
+			ids[rule.ID] = true
+		}
+		switch rule.Status {
+		case "":
+			rule.Status = LifecycleStatusEnabled
+		case LifecycleStatusEnabled, LifecycleStatusDisabled:
+		default:
+			return fmt.Errorf("%s: status must be %s or %s", name, LifecycleStatusEnabled, LifecycleStatusDisabled)
+		}
+		if !bucketIDNameRegexp.MatchString(rule.DestinationBucket) {
+			return fmt.Errorf("%s: invalid destination_bucket %s, the format is <bucket-name>-<appid>", name, rule.DestinationBucket)
+		}
+		if rule.DestinationRegion == "" {
+			return fmt.Errorf("%s: destination_region is required", name)
+		}
+		if rule.StorageClass != "" && !containsString(replicationStorageClasses, rule.StorageClass) {
+			return fmt.Errorf("%s: invalid storage_class %s, must be one of %s", name, rule.StorageClass, strings.Join(replicationStorageClasses, ", "))
+		}
+		for j := 0; j < i; j++ {
+			if strings.HasPrefix(rule.Prefix, rc.Rules[j].Prefix) || strings.HasPrefix(rc.Rules[j].Prefix, rule.Prefix) {
+				return fmt.Errorf("%s: the prefix %q overlaps with the prefix %q of rule %d", name, rule.Prefix, rc.Rules[j].Prefix, j+1)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadReplicationConfig 读取并校验 YAML 或 JSON 格式的跨地域复制规则文件
+func LoadReplicationConfig(path string) (*ReplicationConfig, error) {
+	var rc ReplicationConfig
+	if err := LoadConfigFile(path, &rc); err != nil {
+		return nil, err
+	}
+	return &rc, nil
+}
+
+// GetReplication 获取存储桶的跨地域复制规则
+func GetReplication(c *cos.Client) (*ReplicationConfig, error) {
+	res, _, err := c.Bucket.GetBucketReplication(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	rc := &ReplicationConfig{Role: res.Role}
+	for _, r := range res.Rule {
+		rule := ReplicationRule{ID: r.ID, Status: r.Status, Prefix: r.Prefix}
+		if r.Destination != nil {
+			rule.DestinationRegion, rule.DestinationBucket = parseQcsBucket(r.Destination.Bucket)
+			rule.StorageClass = r.Destination.StorageClass
+		}
+		rc.Rules = append(rc.Rules, rule)
+	}
+	return rc, nil
+}
+
+// PutReplication 设置存储桶的跨地域复制规则，存储桶需已开启版本控制
+func PutReplication(c *cos.Client, rc *ReplicationConfig) error {
+	res, _, err := GetBucketVersioning(c)
+	if err != nil {
+		return err
+	}
+	if res.Status != VersionStatusEnabled {
+		return fmt.Errorf("versioning is not enabled on the current bucket, please enable it by bucket-versioning first")
+	}
+	opt := &cos.PutBucketReplicationOptions{Role: rc.Role}
+	for _, r := range rc.Rules {
+		opt.Rule = append(opt.Rule, cos.BucketReplicationRule{
+			ID:     r.ID,
+			Status: r.Status,
+			Prefix: r.Prefix,
+			Destination: &cos.ReplicationDestination{
+				Bucket:       qcsBucket(r.DestinationRegion, r.DestinationBucket),
+				StorageClass: r.StorageClass,
+			},
+		})
+	}
+	_, err = c.Bucket.PutBucketReplication(context.Background(), opt)
+	return err
+}
+
+// DeleteReplication 删除存储桶的跨地域复制规则
+func DeleteReplication(c *cos.Client) error {
+	_, err := c.Bucket.DeleteBucketReplication(context.Background())
+	return err
+}
+
+// ReplicationStatusObjects 并发获取前缀下符合过滤条件的对象的复制状态，返回按对象键排序的结果及按状态的统计
+func ReplicationStatusObjects(c *cos.Client, cosUrl StorageUrl, fo *FileOperations) (*ReplicationStatusSummary, error) {
+	summary := &ReplicationStatusSummary{}
+	var mu sync.Mutex
+	err := processObjects(c, cosUrl, fo, "get replication status", func(object objectInfoType, key string) error {
+		resp, err := c.Object.Head(context.Background(), key, nil)
+		if err != nil {
+			return err
+		}
+		status := resp.Header.Get(replicationStatusHeader)
+		if status == "" {
+			status = ReplicationStatusNone
+		}
+		mu.Lock()
+		summary.Objects = append(summary.Objects, ReplicationStatusEntry{Key: key, Size: object.size, Status: status})
+		mu.Unlock()
+		return nil
+	})
+	summary.count()
+	return summary, err
+}
+
+// 按对象键排序并统计各复制状态的对象数与大小
+func (r *ReplicationStatusSummary) count() {
+	sort.Slice(r.Objects, func(i, j int) bool { return r.Objects[i].Key < r.Objects[j].Key })
+	index := make(map[string]int)
+	r.Statuses = nil
+	for _, status := range replicationStatuses {
+		index[status] = len(r.Statuses)
+		r.Statuses = append(r.Statuses, ReplicationStatusStat{Status: status})
+	}
+	for _, object := range r.Objects {
+		i, ok := index[object.Status]
+		if !ok {
+			i = len(r.Statuses)
+			index[object.Status] = i
+			r.Statuses = append(r.Statuses, ReplicationStatusStat{Status: object.Status})
+		}
+		r.Statuses[i].Objects++
+		r.Statuses[i].Size += object.Size
+		r.TotalObjects++
+		r.TotalSize += object.Size
+	}
+}
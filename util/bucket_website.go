@@ -0,0 +1,136 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+const websiteMaxRoutingRules = 100
+
+// WebsiteConfig 静态网站配置文件
+type WebsiteConfig struct {
+	Index         string `yaml:"index" json:"index"`
+	ErrorDocument string `yaml:"error_document,omitempty" json:"error_document,omitempty"`
+	// 所有请求重定向的协议，http 或 https
+	RedirectProtocol string `yaml:"redirect_protocol,omitempty" json:"redirect_protocol,omitempty"`
+	// 为 true 时访问目录自动指向目录下的索引文档
+	AutoAddressing bool                 `yaml:"auto_addressing,omitempty" json:"auto_addressing,omitempty"`
+	RoutingRules   []WebsiteRoutingRule `yaml:"routing_rules,omitempty" json:"routing_rules,omitempty"`
+}
+
+// WebsiteRoutingRule 重定向规则，条件为错误码或前缀之一，替换对象键或前缀之一
+type WebsiteRoutingRule struct {
+	ConditionErrorCode       string `yaml:"condition_error_code,omitempty" json:"condition_error_code,omitempty"`
+	ConditionPrefix          string `yaml:"condition_prefix,omitempty" json:"condition_prefix,omitempty"`
+	RedirectProtocol         string `yaml:"redirect_protocol,omitempty" json:"redirect_protocol,omitempty"`
+	RedirectReplaceKey       string `yaml:"redirect_replace_key,omitempty" json:"redirect_replace_key,omitempty"`
+	RedirectReplaceKeyPrefix string `yaml:"redirect_replace_key_prefix,omitempty" json:"redirect_replace_key_prefix,omitempty"`
+}
+
+// Validate 校验静态网站配置
+func (wc *WebsiteConfig) Validate() error {
+	if wc.Index == "" {
+		return fmt.Errorf("index is required")
+	}
+	if err := validateRedirectProtocol(wc.RedirectProtocol); err != nil {
+		return err
+	}
+	if len(wc.RoutingRules) > websiteMaxRoutingRules {
+		return fmt.Errorf("a bucket can have at most %d routing rules", websiteMaxRoutingRules)
+	}
+	for i, rule := range wc.RoutingRules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("routing rule %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (rule WebsiteRoutingRule) validate() error {
+	if (rule.ConditionErrorCode == "") == (rule.ConditionPrefix == "") {
+		return fmt.Errorf("one of condition_error_code and condition_prefix is required")
+	}
+	if rule.ConditionErrorCode != "" {
+		if code, err := strconv.Atoi(rule.ConditionErrorCode); err != nil || code < 400 || code > 499 {
+			return fmt.Errorf("invalid condition_error_code %s, must be a 4xx http status code", rule.ConditionErrorCode)
+		}
+	}
+	if rule.RedirectReplaceKey != "" && rule.RedirectReplaceKeyPrefix != "" {
+		return fmt.Errorf("redirect_replace_key and redirect_replace_key_prefix can not be both set")
+	}
+	if rule.RedirectProtocol == "" && rule.RedirectReplaceKey == "" && rule.RedirectReplaceKeyPrefix == "" {
+		return fmt.Errorf("one of redirect_protocol, redirect_replace_key and redirect_replace_key_prefix is required")
+	}
+	return validateRedirectProtocol(rule.RedirectProtocol)
+}
+
+func validateRedirectProtocol(protocol string) error {
+	if protocol != "" && protocol != "http" && protocol != "https" {
+		return fmt.Errorf("invalid redirect_protocol %s, must be http or https", protocol)
+	}
+	return nil
+}
+
+// LoadWebsiteConfig 读取并校验 YAML 或 JSON 格式的静态网站配置文件
+func LoadWebsiteConfig(path string) (*WebsiteConfig, error) {
+	var wc WebsiteConfig
+	if err := LoadConfigFile(path, &wc); err != nil {
+		return nil, err
+	}
+	return &wc, nil
+}
+
+// GetWebsite 获取存储桶的静态网站配置
+func GetWebsite(c *cos.Client) (*WebsiteConfig, error) {
+	res, _, err := c.Bucket.GetWebsite(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	wc := &WebsiteConfig{Index: res.Index}
+	if res.Error != nil {
+		wc.ErrorDocument = res.Error.Key
+	}
+	if res.RedirectProtocol != nil {
+		wc.RedirectProtocol = res.RedirectProtocol.Protocol
+	}
+	if res.AutoAddressing != nil {
+		wc.AutoAddressing = res.AutoAddressing.Status == LifecycleStatusEnabled
+	}
+	if res.RoutingRules != nil {
+		for _, r := range res.RoutingRules.Rules {
+			wc.RoutingRules = append(wc.RoutingRules, WebsiteRoutingRule(r))
+		}
+	}
+	return wc, nil
+}
+
+// PutWebsite 设置存储桶的静态网站配置，覆盖已有的配置
+func PutWebsite(c *cos.Client, wc *WebsiteConfig) error {
+	opt := &cos.BucketPutWebsiteOptions{Index: wc.Index}
+	if wc.ErrorDocument != "" {
+		opt.Error = &cos.ErrorDocument{Key: wc.ErrorDocument}
+	}
+	if wc.RedirectProtocol != "" {
+		opt.RedirectProtocol = &cos.RedirectRequestsProtocol{Protocol: wc.RedirectProtocol}
+	}
+	if wc.AutoAddressing {
+		opt.AutoAddressing = &cos.AutoAddressing{Status: LifecycleStatusEnabled}
+	}
+	if len(wc.RoutingRules) > 0 {
+		opt.RoutingRules = &cos.WebsiteRoutingRules{}
+		for _, r := range wc.RoutingRules {
+			opt.RoutingRules.Rules = append(opt.RoutingRules.Rules, cos.WebsiteRoutingRule(r))
+		}
+	}
+	_, err := c.Bucket.PutWebsite(context.Background(), opt)
+	return err
+}
+
+// DeleteWebsite 删除存储桶的静态网站配置
+func DeleteWebsite(c *cos.Client) error {
+	_, err := c.Bucket.DeleteWebsite(context.Background())
+	return err
+}
@@ -6,12 +6,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sync"
 	"time"
 )
 
-const (
-	CamUrl = "http://metadata.tencentyun.com/meta-data/cam/security-credentials/"
-)
+// CamUrl 默认的 CVM 元数据服务地址，可在配置文件中通过 camurl 修改
+const CamUrl = "http://metadata.tencentyun.com/meta-data/cam/security-credentials/"
 
 type Data struct {
 	TmpSecretId  string `json:"TmpSecretId"`
@@ -22,29 +22,30 @@ type Data struct {
 	Code         string `json:"Code"`
 }
 
-var data Data
+// 元数据服务只能在本机访问，不经过代理
+var camClient = &http.Client{Transport: &http.Transport{Proxy: nil}}
 
-func CamAuth(roleName string) (data Data, err error) {
+func CamAuth(camUrl, roleName string) (data Data, err error) {
 	if roleName == "" {
 		return data, fmt.Errorf("Get cam auth error : roleName not set")
 	}
-
-	// 创建HTTP客户端
-	client := &http.Client{}
+	if camUrl == "" {
+		camUrl = CamUrl
+	}
 
 	// 创建一个5秒的超时上下文
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	// 创建一个HTTP GET请求并将上下文与其关联
-	req, err := http.NewRequest("GET", CamUrl+roleName, nil)
+	req, err := http.NewRequest("GET", camUrl+roleName, nil)
 	if err != nil {
 		return data, fmt.Errorf("Get cam auth error : create request error[%v]", err)
 	}
 	req = req.WithContext(ctx)
 
 	// 发起HTTP GET请求
-	res, err := client.Do(req)
+	res, err := camClient.Do(req)
 	if err != nil {
 		// 检查是否超时错误
 		if ctx.Err() == context.DeadlineExceeded {
@@ -67,8 +68,32 @@ func CamAuth(roleName string) (data Data, err error) {
 	}
 
 	if data.Code != "Success" {
-		return data, fmt.Errorf("Get cam auth error : response error[%v]", err)
+		return data, fmt.Errorf("Get cam auth error : response code[%s]", data.Code)
 	}
 
 	return data, nil
 }
+
+// 同一角色在进程内共用一个 provider，避免每个客户端各自请求元数据服务
+var (
+	camProvidersMu sync.Mutex
+	camProviders   = make(map[string]*TemporaryCredentialProvider)
+)
+
+// GetCamCredentialProvider 获取指定角色的临时密钥 provider
+func GetCamCredentialProvider(camUrl, roleName string) *TemporaryCredentialProvider {
+	if camUrl == "" {
+		camUrl = CamUrl
+	}
+	camProvidersMu.Lock()
+	defer camProvidersMu.Unlock()
+	key := camUrl + roleName
+	if p, ok := camProviders[key]; ok {
+		return p
+	}
+	p := NewTemporaryCredentialProvider(func() (Data, error) {
+		return CamAuth(camUrl, roleName)
+	})
+	camProviders[key] = p
+	return p
+}
@@ -0,0 +1,212 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// FollowOptions cat --follow 的轮询设置
+type FollowOptions struct {
+	// 首次轮询的间隔，没有新数据时间隔加倍，直到 MaxInterval
+	Interval    time.Duration
+	MaxInterval time.Duration
+}
+
+// FollowObject 持续输出可追加对象新增的数据，类似 tail -f。
+// 先输出当前内容（指定 --tail 时只输出末尾部分），之后轮询 HEAD 获取对象长度，按范围读取新增的数据。
+// ctx 取消时正常返回
+func FollowObject(ctx context.Context, c *cos.Client, cosUrl StorageUrl, catOpt *CatOptions, followOpt *FollowOptions, w io.Writer) error {
+	switch {
+	case catOpt.Range != "" || catOpt.Head != 0:
+		return fmt.Errorf("--follow can not be used with --range or --head")
+	case catOpt.VersionId != "":
+		return fmt.Errorf("--follow can not be used with --version-id")
+	case catOpt.Decompress:
+		return fmt.Errorf("--follow can not be used with --decompress")
+	case followOpt.Interval <= 0 || followOpt.MaxInterval < followOpt.Interval:
+		return fmt.Errorf("--poll-interval must be greater than 0 and not greater than --max-poll-interval")
+	}
+	if err := CheckCatOptions(catOpt); err != nil {
+		return err
+	}
+	object := cosUrl.(*CosUrl).Object
+
+	size, err := appendableSize(ctx, c, object)
+	if err != nil {
+		return stopFollow(ctx, err)
+	}
+	var offset int64
+	switch {
+	case catOpt.Tail > 0 && catOpt.Lines:
+		if offset, err = tailLinesOffset(ctx, c, object, size, catOpt.Tail); err != nil {
+			return stopFollow(ctx, err)
+		}
+	case catOpt.Tail > 0 && catOpt.Tail < size:
+		offset = size - catOpt.Tail
+	}
+
+	out := &followWriter{w: w}
+	interval := followOpt.Interval
+	for {
+		if offset < size {
+			n, err := copyObjectRange(ctx, c, object, offset, size-1, out)
+			offset += n
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				if out.err != nil {
+					return out.err
+				}
+				if isNotFound(err) {
+					return fmt.Errorf("the object %s is deleted", object)
+				}
+				// 网络等临时错误加倍间隔后从已输出的位置继续读取
+				interval = nextPollInterval(interval, followOpt.MaxInterval)
+				logger.Warningf("Get %s error, retry in %v: %v", object, interval, err)
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(interval):
+				}
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+		newSize, err := appendableSize(ctx, c, object)
+		switch err.(type) {
+		case nil:
+		case *notAppendableError:
+			return err
+		default:
+			if ctx.Err() != nil {
+				return nil
+			}
+			if isNotFound(err) {
+				return fmt.Errorf("the object %s is deleted", object)
+			}
+			// 网络等临时错误加倍间隔后重试
+			interval = nextPollInterval(interval, followOpt.MaxInterval)
+			logger.Warningf("Head %s error, retry in %v: %v", object, interval, err)
+			continue
+		}
+		if newSize < offset {
+			return fmt.Errorf("the object %s is overwritten, its length %d is less than the output length %d", object, newSize, offset)
+		}
+		// 有新数据时恢复初始间隔，否则加倍
+		if newSize == size {
+			interval = nextPollInterval(interval, followOpt.MaxInterval)
+		} else {
+			interval = followOpt.Interval
+		}
+		size = newSize
+	}
+}
+
+func nextPollInterval(interval, maxInterval time.Duration) time.Duration {
+	if interval *= 2; interval > maxInterval {
+		return maxInterval
+	}
+	return interval
+}
+
+func isNotFound(err error) bool {
+	cosErr, ok := err.(*cos.ErrorResponse)
+	return ok && cosErr.Response.StatusCode == http.StatusNotFound
+}
+
+// 记录输出时的错误，与读取对象的错误区分，输出失败时不再重试
+type followWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (fw *followWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if err != nil {
+		fw.err = err
+	}
+	return n, err
+}
+
+// ctx 取消导致的错误视为正常结束
+func stopFollow(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// 可追加对象当前的长度，优先使用 x-cos-next-append-position
+func appendableSize(ctx context.Context, c *cos.Client, object string) (int64, error) {
+	resp, err := c.Object.Head(ctx, object, nil)
+	if err != nil {
+		return 0, err
+	}
+	if resp.Header.Get("x-cos-object-type") != "appendable" {
+		return 0, &notAppendableError{object: object}
+	}
+	if v := resp.Header.Get("x-cos-next-append-position"); v != "" {
+		return strconv.ParseInt(v, 10, 64)
+	}
+	return resp.ContentLength, nil
+}
+
+// 对象不是可追加对象，或已被普通上传覆盖
+type notAppendableError struct {
+	object string
+}
+
+func (e *notAppendableError) Error() string {
+	return fmt.Sprintf("--follow only works with appendable objects, %s is not appendable", e.object)
+}
+
+// 输出对象 [start, end] 范围内的数据，返回输出的字节数
+func copyObjectRange(ctx context.Context, c *cos.Client, object string, start, end int64, w io.Writer) (int64, error) {
+	opt := &cos.ObjectGetOptions{
+		Range:         fmt.Sprintf("bytes=%d-%d", start, end),
+		XOptionHeader: &http.Header{"Accept-Encoding": []string{"identity"}},
+	}
+	resp, err := c.Object.Get(ctx, object, opt)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return io.Copy(w, resp.Body)
+}
+
+// 对象 [0, size) 中末尾 n 行的起始位置，从末尾按范围读取，行数不足时加倍读取的长度
+func tailLinesOffset(ctx context.Context, c *cos.Client, object string, size, n int64) (int64, error) {
+	if size == 0 {
+		return 0, nil
+	}
+	for chunk := int64(catTailChunkSize); ; chunk *= 2 {
+		start := size - chunk
+		if start < 0 {
+			start = 0
+		}
+		var buf bytes.Buffer
+		if _, err := copyObjectRange(ctx, c, object, start, size-1, &buf); err != nil {
+			return 0, err
+		}
+		if i := lastLinesIndex(buf.Bytes(), n); i >= 0 {
+			return start + int64(i), nil
+		}
+		if start == 0 {
+			return 0, nil
+		}
+	}
+}
@@ -1,23 +1,340 @@
 package util
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
-	"github.com/tencentyun/cos-go-sdk-v5"
 	"io"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/tencentyun/cos-go-sdk-v5"
 )
 
-func CatObject(c *cos.Client, cosUrl StorageUrl) error {
-	opt := &cos.ObjectGetOptions{
-		ResponseContentType: "text/html",
+// --tail 按行输出时，每次从末尾读取的长度，行数不足时加倍
+const catTailChunkSize = 64 * 1024
+
+// CatOptions cat 命令的可选设置
+type CatOptions struct {
+	// 解密客户端加密的对象
+	ClientEncryption *ClientEncryption
+	// 输出指定版本
+	VersionId string
+	// 输出的字节范围，格式为 start-end、start- 或 -N（末尾 N 个字节）
+	Range string
+	// 输出开头或末尾的 Head/Tail 个字节，Lines 为 true 时按行计算
+	Head  int64
+	Tail  int64
+	Lines bool
+	// 按对象的 Content-Encoding 解压 gzip 或 zstd 压缩的内容
+	Decompress bool
+}
+
+// 对象明文的字节范围。suffix 大于 0 时为末尾 suffix 个字节，否则为 [start, end]，end 为 -1 时到对象末尾
+type byteRange struct {
+	start  int64
+	end    int64
+	suffix int64
+}
+
+// 解析 --range 的 start-end、start- 或 -N
+func parseByteRange(s string) (*byteRange, error) {
+	parts := strings.SplitN(s, "-", 2)
+	if len(parts) != 2 || parts[0] == "" && parts[1] == "" {
+		return nil, fmt.Errorf("invalid range %s, the format is start-end, start- or -N", s)
+	}
+	if parts[0] == "" {
+		suffix, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || suffix <= 0 {
+			return nil, fmt.Errorf("invalid range %s, N of -N must be greater than 0", s)
+		}
+		return &byteRange{suffix: suffix}, nil
+	}
+	r := &byteRange{end: -1}
+	var err error
+	if r.start, err = strconv.ParseInt(parts[0], 10, 64); err != nil || r.start < 0 {
+		return nil, fmt.Errorf("invalid range %s, start must be a non-negative integer", s)
+	}
+	if parts[1] != "" {
+		if r.end, err = strconv.ParseInt(parts[1], 10, 64); err != nil || r.end < r.start {
+			return nil, fmt.Errorf("invalid range %s, end must be an integer not less than start", s)
+		}
+	}
+	return r, nil
+}
+
+// Range 请求头
+func (r *byteRange) header() string {
+	if r.suffix > 0 {
+		return fmt.Sprintf("bytes=-%d", r.suffix)
+	}
+	if r.end < 0 {
+		return fmt.Sprintf("bytes=%d-", r.start)
+	}
+	return fmt.Sprintf("bytes=%d-%d", r.start, r.end)
+}
+
+// 按对象长度计算实际的闭区间，范围为空时返回 false
+func (r *byteRange) resolve(size int64) (int64, int64, bool) {
+	start, end := r.start, r.end
+	if r.suffix > 0 {
+		start, end = size-r.suffix, size-1
+		if start < 0 {
+			start = 0
+		}
+	}
+	if end < 0 || end > size-1 {
+		end = size - 1
+	}
+	return start, end, start <= end
+}
+
+// CheckCatOptions 检查 cat 的输出范围设置
+func CheckCatOptions(catOpt *CatOptions) error {
+	set := 0
+	for _, v := range []bool{catOpt.Range != "", catOpt.Head != 0, catOpt.Tail != 0} {
+		if v {
+			set++
+		}
+	}
+	switch {
+	case set > 1:
+		return fmt.Errorf("--range, --head and --tail can not be used together")
+	case catOpt.Head < 0 || catOpt.Tail < 0:
+		return fmt.Errorf("--head and --tail must be greater than 0")
+	case catOpt.Lines && catOpt.Head == 0 && catOpt.Tail == 0:
+		return fmt.Errorf("--lines only works with --head or --tail")
+	case catOpt.Decompress && (catOpt.Range != "" || catOpt.Tail != 0):
+		return fmt.Errorf("--decompress only works with the whole object or --head")
+	}
+	if catOpt.Range != "" {
+		if _, err := parseByteRange(catOpt.Range); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CatObject 将对象的内容原样输出到标准输出，可只输出指定范围、开头或末尾的部分
+func CatObject(c *cos.Client, cosUrl StorageUrl, options ...*CatOptions) error {
+	catOpt := &CatOptions{}
+	if len(options) > 0 && options[0] != nil {
+		catOpt = options[0]
 	}
-	res, err := c.Object.Get(context.Background(), cosUrl.(*CosUrl).Object, opt)
+	if err := CheckCatOptions(catOpt); err != nil {
+		return err
+	}
+	object := cosUrl.(*CosUrl).Object
+	// 对空对象发送后缀范围请求会返回 416，空对象的末尾部分直接输出为空
+	if catOpt.Tail > 0 || strings.HasPrefix(catOpt.Range, "-") {
+		empty, err := isEmptyObject(c, object, catOpt)
+		if err != nil || empty {
+			return err
+		}
+	}
+	if catOpt.Tail > 0 && catOpt.Lines {
+		return catTailLines(c, object, catOpt, os.Stdout)
+	}
+
+	// 按行或解压后输出开头时需从头读取，读取足够的数据后停止
+	var r *byteRange
+	switch {
+	case catOpt.Range != "":
+		r, _ = parseByteRange(catOpt.Range)
+	case catOpt.Head > 0 && !catOpt.Lines && !catOpt.Decompress:
+		r = &byteRange{start: 0, end: catOpt.Head - 1}
+	case catOpt.Tail > 0:
+		r = &byteRange{suffix: catOpt.Tail}
+	}
+	body, _, header, err := getObjectReader(c, object, catOpt, r)
 	if err != nil {
 		return err
 	}
+	defer body.Close()
 
-	io.Copy(os.Stdout, res.Body)
-	fmt.Printf("\n")
+	var reader io.Reader = body
+	if catOpt.Decompress {
+		decompressed, err := decompressReader(body, header.Get("Content-Encoding"))
+		if err != nil {
+			return err
+		}
+		defer decompressed.Close()
+		reader = decompressed
+	}
+	if catOpt.Head > 0 && catOpt.Lines {
+		return copyHeadLines(os.Stdout, reader, catOpt.Head)
+	}
+	if catOpt.Head > 0 {
+		_, err = io.CopyN(os.Stdout, reader, catOpt.Head)
+		if err == io.EOF {
+			err = nil
+		}
+		return err
+	}
+	_, err = io.Copy(os.Stdout, reader)
+	return err
+}
+
+// 对象长度是否为 0
+func isEmptyObject(c *cos.Client, object string, catOpt *CatOptions) (bool, error) {
+	resp, err := c.Object.Head(context.Background(), object, nil, versionIds(catOpt.VersionId)...)
+	if err != nil {
+		return false, err
+	}
+	return resp.ContentLength == 0, nil
+}
+
+// 读取对象明文 r 范围内的数据，r 为 nil 时读取整个对象，返回数据在明文中的起始位置。
+// 客户端加密的对象需按明文长度换算密文范围，范围请求返回加密信息后再按密文范围读取
+func getObjectReader(c *cos.Client, object string, catOpt *CatOptions, r *byteRange) (io.ReadCloser, int64, http.Header, error) {
+	get := func(rangeHeader string) (*cos.Response, error) {
+		opt := &cos.ObjectGetOptions{
+			Range: rangeHeader,
+			// 明确不接受压缩，避免 Transport 自动解压 gzip 内容
+			XOptionHeader: &http.Header{"Accept-Encoding": []string{"identity"}},
+		}
+		return c.Object.Get(context.Background(), object, opt, versionIds(catOpt.VersionId)...)
+	}
+	rangeHeader := ""
+	if r != nil {
+		rangeHeader = r.header()
+	}
+	resp, err := get(rangeHeader)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if !isClientEncrypted(resp.Header) {
+		return resp.Body, contentRangeStart(resp), resp.Header, nil
+	}
+
+	cseCipher, err := catOpt.ClientEncryption.cipherFromHeader(resp.Header)
+	if err != nil {
+		resp.Body.Close()
+		return nil, 0, nil, err
+	}
+	if r == nil {
+		reader, err := cseCipher.newDecryptReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, 0, nil, err
+		}
+		return readCloser{reader, resp.Body}, 0, resp.Header, nil
+	}
+	resp.Body.Close()
+	start, end, ok := r.resolve(cseCipher.size)
+	if !ok {
+		if r.suffix == 0 {
+			return nil, 0, nil, fmt.Errorf("the range start %d exceeds the object size %d", start, cseCipher.size)
+		}
+		// 空对象的末尾部分为空
+		return ioutil.NopCloser(bytes.NewReader(nil)), 0, resp.Header, nil
+	}
+	encryptedStart, encryptedEnd := cseCipher.encryptedRange(start, end)
+	if resp, err = get(fmt.Sprintf("bytes=%d-%d", encryptedStart, encryptedEnd)); err != nil {
+		return nil, 0, nil, err
+	}
+	reader, err := cseCipher.newRangeDecryptReader(resp.Body, start, end)
+	if err != nil {
+		resp.Body.Close()
+		return nil, 0, nil, err
+	}
+	return readCloser{reader, resp.Body}, start, resp.Header, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// 范围请求返回的数据在对象中的起始位置，取自 Content-Range: bytes start-end/size
+func contentRangeStart(resp *cos.Response) int64 {
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0
+	}
+	v := strings.TrimPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	start, _ := strconv.ParseInt(strings.SplitN(v, "-", 2)[0], 10, 64)
+	return start
+}
+
+// 按 Content-Encoding 解压，没有压缩的内容原样返回。关闭返回值只释放解压用的资源，不关闭 r
+func decompressReader(r io.Reader, encoding string) (io.ReadCloser, error) {
+	switch strings.ToLower(encoding) {
+	case "", "identity":
+		return ioutil.NopCloser(r), nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "zstd":
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("--decompress does not support the content encoding %s", encoding)
+}
+
+// 输出开头的 n 行
+func copyHeadLines(w io.Writer, r io.Reader, n int64) error {
+	br := bufio.NewReader(r)
+	for n > 0 {
+		line, err := br.ReadSlice('\n')
+		if _, werr := w.Write(line); werr != nil {
+			return werr
+		}
+		switch err {
+		case nil:
+			n--
+		case bufio.ErrBufferFull:
+			// 行超过缓冲区长度，继续输出该行的剩余部分
+		case io.EOF:
+			return nil
+		default:
+			return err
+		}
+	}
 	return nil
 }
+
+// 输出末尾的 n 行，从末尾按后缀范围读取，行数不足时加倍读取的长度
+func catTailLines(c *cos.Client, object string, catOpt *CatOptions, w io.Writer) error {
+	for chunk := int64(catTailChunkSize); ; chunk *= 2 {
+		body, start, _, err := getObjectReader(c, object, catOpt, &byteRange{suffix: chunk})
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return err
+		}
+		if i := lastLinesIndex(data, catOpt.Tail); i >= 0 || start == 0 {
+			if i < 0 {
+				i = 0
+			}
+			_, err = w.Write(data[i:])
+			return err
+		}
+	}
+}
+
+// 末尾 n 行在 data 中的起始位置，行数不足时返回 -1。结尾的换行符属于最后一行
+func lastLinesIndex(data []byte, n int64) int {
+	end := len(data)
+	if end > 0 && data[end-1] == '\n' {
+		end--
+	}
+	for ; n > 0; n-- {
+		i := bytes.LastIndexByte(data[:end], '\n')
+		if i < 0 {
+			return -1
+		}
+		end = i
+	}
+	return end + 1
+}
@@ -0,0 +1,289 @@
+package util
+
+import (
+	"crypto/md5"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// Checkpoint 递归 cp/sync 的任务日志，记录已完成的文件，中断后重新执行相同命令可从断点继续
+type Checkpoint struct {
+	Path string
+	Db   *leveldb.DB
+	// 续传开始时日志中已完成的条数
+	DoneNum int64
+	// 本次执行因已完成而跳过的条数
+	ResumeNum int64
+
+	listing *listProgress
+}
+
+// listProgress 列举 cos 来源的进度。一页对象由多个协程并发处理，该页及之前各页的对象全部完成后，
+// 才将该页的 NextMarker 作为源路径的列举位置写入任务日志，续传时从这里继续列举
+type listProgress struct {
+	mu      sync.Mutex
+	prefix  string
+	pages   []*listPage
+	objects map[string]*listPage
+}
+
+// listPage 列举的一页对象
+type listPage struct {
+	nextMarker string
+	pending    int
+	listed     bool
+}
+
+// 任务日志中记录列举位置的键，对象键中不会出现 \x00，不会与已完成的记录冲突
+const listMarkerKeyPrefix = "\x00list-marker\x00"
+
+// checkpointItem 任务日志中的一条记录
+type checkpointItem struct {
+	key   string
+	value string
+	size  int64
+	isDir bool
+}
+
+// InitCheckpoint 打开 --checkpoint-dir 下当前任务的日志，未指定 --resume 时清空旧的日志重新开始。
+// 日志记录已完成的文件以及 cos 来源的列举位置，续传时从列举位置继续列举，列出的对象逐个与日志比对后跳过
+func InitCheckpoint(srcUrl, destUrl StorageUrl, fo *FileOperations) error {
+	if fo.Operation.CheckpointDir == "" {
+		if fo.Operation.Resume {
+			return fmt.Errorf("--resume must be used with --checkpoint-dir")
+		}
+		return nil
+	}
+	if !fo.Operation.Recursive {
+		return fmt.Errorf("--checkpoint-dir only works with --recursive")
+	}
+
+	var err error
+	if srcUrl.IsFileUrl() {
+		err = CheckPath(srcUrl, fo, TypeCheckpointDir)
+	} else if destUrl.IsFileUrl() {
+		err = CheckPath(destUrl, fo, TypeCheckpointDir)
+	}
+	if err != nil {
+		return err
+	}
+
+	// dry-run 不改动任务日志，仅在 --resume 时读取
+	if fo.Operation.DryRun && !fo.Operation.Resume {
+		return nil
+	}
+
+	jobPath, err := checkpointJobPath(srcUrl, destUrl, fo)
+	if err != nil {
+		return err
+	}
+
+	if !fo.Operation.Resume {
+		if err = os.RemoveAll(jobPath); err != nil {
+			return fmt.Errorf("clear checkpoint error, reason: %v", err)
+		}
+	}
+
+	db, err := leveldb.OpenFile(jobPath, nil)
+	if err != nil {
+		return fmt.Errorf("load checkpoint error, reason: %v", err)
+	}
+	fo.Checkpoint = &Checkpoint{Path: jobPath, Db: db}
+
+	if fo.Operation.Resume {
+		iter := db.NewIterator(nil, nil)
+		for iter.Next() {
+			if !strings.HasPrefix(string(iter.Key()), listMarkerKeyPrefix) {
+				fo.Checkpoint.DoneNum++
+			}
+		}
+		iter.Release()
+		logger.Infof("Resume from checkpoint %s, %d items already done", jobPath, fo.Checkpoint.DoneNum)
+	}
+	return nil
+}
+
+// FinishCheckpoint 输出续传跳过的数量，任务全部成功后删除任务日志
+func FinishCheckpoint(fo *FileOperations) {
+	if fo.Checkpoint == nil {
+		return
+	}
+	if fo.Checkpoint.ResumeNum > 0 {
+		logger.Infof("Skip %d items already done before resume", fo.Checkpoint.ResumeNum)
+	}
+	path := fo.Checkpoint.Path
+	CloseCheckpoint(fo)
+	if fo.Monitor.ErrNum == 0 && !fo.Operation.DryRun {
+		os.RemoveAll(path)
+	}
+}
+
+// CloseCheckpoint 关闭任务日志
+func CloseCheckpoint(fo *FileOperations) {
+	if fo.Checkpoint == nil {
+		return
+	}
+	fo.Checkpoint.Db.Close()
+	fo.Checkpoint = nil
+}
+
+// 同一命令、源路径和目标路径对应同一个任务日志
+func checkpointJobPath(srcUrl, destUrl StorageUrl, fo *FileOperations) (string, error) {
+	paths := make([]string, 0, 2)
+	for _, storageUrl := range []StorageUrl{srcUrl, destUrl} {
+		path := storageUrl.ToString()
+		if storageUrl.IsFileUrl() {
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				return "", err
+			}
+			path = absPath
+		}
+		paths = append(paths, path)
+	}
+	job := fmt.Sprintf("%s%s%s%s%s", fo.Command, SnapshotConnector, paths[0], SnapshotConnector, paths[1])
+	return filepath.Join(fo.Operation.CheckpointDir, fmt.Sprintf("%x", md5.Sum([]byte(job)))), nil
+}
+
+// 上传以目标对象为键，本地文件大小和修改时间为值，文件有变化时重新上传
+func uploadCheckpointItem(file fileInfoType, cosUrl StorageUrl) (checkpointItem, error) {
+	localFilePath, cosPath := UploadPathFixed(file, cosUrl.(*CosUrl).Object)
+	fileInfo, err := os.Stat(localFilePath)
+	if err != nil {
+		return checkpointItem{}, err
+	}
+	item := checkpointItem{
+		key:   cosPath,
+		value: strconv.FormatInt(fileInfo.Size(), 10) + SnapshotConnector + strconv.FormatInt(fileInfo.ModTime().Unix(), 10),
+		isDir: fileInfo.IsDir(),
+	}
+	if !item.isDir {
+		item.size = fileInfo.Size()
+	}
+	return item, nil
+}
+
+// 下载和拷贝以源对象（及版本）为键，对象大小和修改时间为值
+func objectCheckpointItem(object objectInfoType) checkpointItem {
+	key := object.prefix + object.relativeKey
+	itemKey := key
+	if object.versionId != "" {
+		itemKey += SnapshotConnector + object.versionId
+	}
+	return checkpointItem{
+		key:   itemKey,
+		value: strconv.FormatInt(object.size, 10) + SnapshotConnector + object.lastModified,
+		size:  object.size,
+		isDir: object.size == 0 && strings.HasSuffix(key, CosSeparator),
+	}
+}
+
+// 已在任务日志中完成的记录直接跳过
+func skipByCheckpoint(fo *FileOperations, item checkpointItem) bool {
+	if fo.Checkpoint == nil || item.key == "" {
+		return false
+	}
+	value, err := fo.Checkpoint.Db.Get([]byte(item.key), nil)
+	if err != nil || string(value) != item.value {
+		return false
+	}
+	atomic.AddInt64(&fo.Checkpoint.ResumeNum, 1)
+	fo.Monitor.updateMonitor(true, nil, item.isDir, item.size)
+	fo.Checkpoint.finishListObject(item.key)
+	return true
+}
+
+// 完成后写入任务日志
+func recordCheckpoint(fo *FileOperations, item checkpointItem) {
+	if fo.Checkpoint == nil || item.key == "" || fo.Operation.DryRun {
+		return
+	}
+	fo.Checkpoint.Db.Put([]byte(item.key), []byte(item.value), nil)
+	fo.Checkpoint.finishListObject(item.key)
+}
+
+// 源路径在任务日志中的列举位置，未记录时从头列举
+func (cp *Checkpoint) listMarker(prefix string) string {
+	if cp == nil {
+		return ""
+	}
+	value, err := cp.Db.Get([]byte(listMarkerKeyPrefix+prefix), nil)
+	if err != nil {
+		return ""
+	}
+	return string(value)
+}
+
+// 开始列举源路径的一页对象，dry-run 不记录列举位置
+func (cp *Checkpoint) beginListPage(fo *FileOperations, prefix string) *listPage {
+	if cp == nil || fo.Operation.DryRun {
+		return nil
+	}
+	if cp.listing == nil {
+		cp.listing = &listProgress{prefix: prefix, objects: make(map[string]*listPage)}
+	}
+	page := &listPage{}
+	cp.listing.mu.Lock()
+	cp.listing.pages = append(cp.listing.pages, page)
+	cp.listing.mu.Unlock()
+	return page
+}
+
+// 记录该页列出的对象，须在交给处理协程之前调用
+func (cp *Checkpoint) addListObject(page *listPage, key string) {
+	if page == nil {
+		return
+	}
+	cp.listing.mu.Lock()
+	defer cp.listing.mu.Unlock()
+	page.pending++
+	cp.listing.objects[key] = page
+}
+
+// 该页已列举完，nextMarker 为下一页的列举位置
+func (cp *Checkpoint) endListPage(page *listPage, nextMarker string) {
+	if page == nil {
+		return
+	}
+	cp.listing.mu.Lock()
+	defer cp.listing.mu.Unlock()
+	page.nextMarker = nextMarker
+	page.listed = true
+	cp.advanceListMarker()
+}
+
+// 对象已完成，所在页及之前各页全部完成时更新列举位置。失败的对象不调用，其所在页之后的位置不会写入
+func (cp *Checkpoint) finishListObject(key string) {
+	if cp.listing == nil {
+		return
+	}
+	cp.listing.mu.Lock()
+	defer cp.listing.mu.Unlock()
+	page, ok := cp.listing.objects[key]
+	if !ok {
+		return
+	}
+	delete(cp.listing.objects, key)
+	page.pending--
+	cp.advanceListMarker()
+}
+
+// 从第一页起依次写入已全部完成的页的列举位置，调用时须持有锁
+func (cp *Checkpoint) advanceListMarker() {
+	listing := cp.listing
+	for len(listing.pages) > 0 && listing.pages[0].listed && listing.pages[0].pending == 0 {
+		page := listing.pages[0]
+		listing.pages = listing.pages[1:]
+		if page.nextMarker != "" {
+			cp.Db.Put([]byte(listMarkerKeyPrefix+listing.prefix), []byte(page.nextMarker), nil)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package util
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc64"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// 校验算法
+const (
+	HashCrc64  = "crc64"
+	HashMd5    = "md5"
+	HashSha256 = "sha256"
+)
+
+// 上传时指定 --sha256 记录文件 SHA-256 的元数据
+const sha256Meta = "x-cos-meta-sha256"
+
+// 校验算法：本地文件用 newHash 计算，对象的值从 HEAD 响应头读取
+type checksumAlgorithm struct {
+	newHash func() hash.Hash
+	// 计算结果的文本形式
+	format func(sum []byte) string
+	// 是否同时输出计算结果的 base64 形式
+	base64 bool
+	// 对象的值，对象没有该值时返回空
+	object func(header http.Header) (string, error)
+}
+
+var checksumAlgorithms = map[string]*checksumAlgorithm{
+	HashCrc64: {
+		newHash: func() hash.Hash { return crc64.New(crc64.MakeTable(crc64.ECMA)) },
+		format: func(sum []byte) string {
+			return strconv.FormatUint(binary.BigEndian.Uint64(sum), 10)
+		},
+		object: func(header http.Header) (string, error) {
+			return objectCrc64(header), nil
+		},
+	},
+	HashMd5: {
+		newHash: md5.New,
+		format:  hex.EncodeToString,
+		base64:  true,
+		object: func(header http.Header) (string, error) {
+			// 分块上传的对象 ETag 为 <hash>-<分块数>，不是对象的 MD5
+			etag := strings.Trim(header.Get("ETag"), "\"")
+			if strings.Contains(etag, "-") {
+				return "", fmt.Errorf("the ETag %s of the multipart uploaded object is not MD5, use crc64 or sha256 instead", etag)
+			}
+			return etag, nil
+		},
+	},
+	HashSha256: {
+		newHash: sha256.New,
+		format:  hex.EncodeToString,
+		base64:  true,
+		object: func(header http.Header) (string, error) {
+			return header.Get(sha256Meta), nil
+		},
+	},
+}
+
+func getChecksumAlgorithm(hashType string) (*checksumAlgorithm, error) {
+	algorithm, ok := checksumAlgorithms[hashType]
+	if !ok {
+		return nil, fmt.Errorf("--type can only be selected between crc64, md5 and sha256")
+	}
+	return algorithm, nil
+}
+
+// CheckSha256Options 检查 --sha256，仅上传本地文件时计算 SHA-256 写入对象的元数据
+func CheckSha256Options(srcUrl, destUrl StorageUrl, fo *FileOperations) error {
+	if !fo.Operation.Sha256 {
+		return nil
+	}
+	switch {
+	case !(srcUrl.IsFileUrl() && destUrl.IsCosUrl()):
+		return fmt.Errorf("--sha256 only works with upload")
+	case fo.Operation.Append:
+		return fmt.Errorf("--sha256 can not be used with --append")
+	}
+	return nil
+}
+
+// 在元数据中加入 SHA-256，不修改原有的元数据
+func withSha256Meta(meta *http.Header, sum string) *http.Header {
+	header := http.Header{}
+	if meta != nil {
+		for name, values := range *meta {
+			header[name] = values
+		}
+	}
+	header.Set(sha256Meta, sum)
+	return &header
+}
+
+// 合并两段连续数据的 CRC64，crc2 为长度 len2 的后一段的 CRC64。
+// 与 zlib 的 crc32_combine 相同，用 GF(2) 矩阵计算 crc1 后接 len2 个零字节的结果
+func crc64Combine(crc1, crc2 uint64, len2 int64) uint64 {
+	if len2 <= 0 {
+		return crc1
+	}
+	var even, odd [64]uint64
+	// 一个零比特对应的矩阵
+	odd[0] = crc64.ECMA
+	row := uint64(1)
+	for n := 1; n < 64; n++ {
+		odd[n] = row
+		row <<= 1
+	}
+	gf2MatrixSquare(&even, &odd) // 两个零比特
+	gf2MatrixSquare(&odd, &even) // 四个零比特
+
+	// 按 len2 的二进制位依次叠加一个、两个、四个……零字节
+	for {
+		gf2MatrixSquare(&even, &odd)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(&even, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+		gf2MatrixSquare(&odd, &even)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(&odd, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+	}
+	return crc1 ^ crc2
+}
+
+func gf2MatrixTimes(mat *[64]uint64, vec uint64) uint64 {
+	var sum uint64
+	for i := 0; vec != 0; i, vec = i+1, vec>>1 {
+		if vec&1 != 0 {
+			sum ^= mat[i]
+		}
+	}
+	return sum
+}
+
+func gf2MatrixSquare(square, mat *[64]uint64) {
+	for n := 0; n < 64; n++ {
+		square[n] = gf2MatrixTimes(mat, mat[n])
+	}
+}
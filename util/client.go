@@ -7,26 +7,40 @@ import (
 	"github.com/tencentyun/cos-go-sdk-v5"
 )
 
-var secretID, secretKey, secretToken string
+// 根据配置和参数生成签名用的 Transport。
+// CvmRole、AssumeRole 及 WebIdentity 方式使用临时密钥 provider，每次请求前获取密钥，在密钥过期前自动刷新
+func newAuthTransport(config *Config, param *Param, transport http.RoundTripper) (http.RoundTripper, error) {
+	// 若参数中有传 SecretID 或 SecretKey ，则使用参数中的密钥，且不使用配置中的 SessionToken 及临时密钥
+	temporary := config.Base.Mode == "CvmRole" || config.Base.Mode == "AssumeRole" || config.Base.Mode == "WebIdentity"
+	if temporary && param.SecretID == "" && param.SecretKey == "" {
+		var provider *TemporaryCredentialProvider
+		if config.Base.Mode == "CvmRole" {
+			provider = GetCamCredentialProvider(config.Base.CamUrl, config.Base.CvmRoleName)
+		} else {
+			var err error
+			provider, err = GetStsCredentialProvider(config.Base, transport)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// 先获取一次临时密钥，尽早暴露角色配置错误
+		if _, _, _, err := provider.Credential(); err != nil {
+			return nil, err
+		}
+		return &temporaryCredentialTransport{
+			AuthorizationTransport: &cos.AuthorizationTransport{Transport: transport},
+			provider:               provider,
+		}, nil
+	}
 
-// 根据桶别名，从配置文件中加载信息，创建客户端
-func NewClient(config *Config, param *Param, bucketName string, options ...*FileOperations) (client *cos.Client, err error) {
+	// SecretKey 方式则直接获取用户配置文件中设置的密钥
+	secretID := config.Base.SecretID
+	secretKey := config.Base.SecretKey
+	secretToken := config.Base.SessionToken
 	if config.Base.Mode == "CvmRole" {
-		// 若使用 CvmRole 方式，则需请求请求CAM的服务，获取临时密钥
-		data, err = CamAuth(config.Base.CvmRoleName)
-		if err != nil {
-			return client, err
-		}
-		secretID = data.TmpSecretId
-		secretKey = data.TmpSecretKey
-		secretToken = data.Token
-	} else {
-		// SecretKey 方式则直接获取用户配置文件中设置的密钥
-		secretID = config.Base.SecretID
-		secretKey = config.Base.SecretKey
-		secretToken = config.Base.SessionToken
-	}
-	// 若参数中有传 SecretID 或 SecretKey ，需将之前赋值的SessionToken置为空，否则会出现使用参数的 SecretID 和 SecretKey ，却使用了CvmRole方式返回的token，导致鉴权失败
+		secretToken = ""
+	}
+	// 若参数中有传 SecretID 或 SecretKey ，需将之前赋值的SessionToken置为空，否则会出现使用参数的 SecretID 和 SecretKey ，却使用了配置中的token，导致鉴权失败
 	if param.SecretID != "" {
 		secretID = param.SecretID
 		secretToken = ""
@@ -38,111 +52,32 @@ func NewClient(config *Config, param *Param, bucketName string, options ...*File
 	if param.SessionToken != "" {
 		secretToken = param.SessionToken
 	}
+	return &cos.AuthorizationTransport{
+		SecretID:     secretID,
+		SecretKey:    secretKey,
+		SessionToken: secretToken,
+		Transport:    transport,
+	}, nil
+}
 
-	if bucketName == "" { // 不指定 bucket，则创建用于发送 Service 请求的客户端
-		client = cos.NewClient(GenBaseURL(config, param), &http.Client{
-			Transport: &cos.AuthorizationTransport{
-				SecretID:     secretID,
-				SecretKey:    secretKey,
-				SessionToken: secretToken,
-			},
-		})
-	} else {
-		url, err := GenURL(config, param, bucketName)
-		if err != nil {
-			return client, err
-		}
-
-		var httpClient *http.Client
-		// 如果使用长链接则调整连接池大小至并发数
-		if len(options) > 0 && options[0] != nil && !options[0].Operation.DisableLongLinks {
-			longLinksNums := 0
-			if options[0].Operation.LongLinksNums > 0 {
-				longLinksNums = options[0].Operation.LongLinksNums
-			} else {
-				longLinksNums = options[0].Operation.Routines
-			}
-			httpClient = &http.Client{
-				Transport: &cos.AuthorizationTransport{
-					SecretID:     secretID,
-					SecretKey:    secretKey,
-					SessionToken: secretToken,
-					Transport: &http.Transport{
-						MaxIdleConnsPerHost: longLinksNums,
-						MaxIdleConns:        longLinksNums,
-					},
-				},
-			}
-		} else {
-			// 若没有传递 options 或者没有设置 DisableLongLinks
-			httpClient = &http.Client{
-				Transport: &cos.AuthorizationTransport{
-					SecretID:     secretID,
-					SecretKey:    secretKey,
-					SessionToken: secretToken,
-				},
-			}
-		}
-
-		client = cos.NewClient(url, httpClient)
+// 根据桶别名，从配置文件中加载信息，创建客户端
+func NewClient(config *Config, param *Param, bucketName string, options ...*FileOperations) (client *cos.Client, err error) {
+	var fo *FileOperations
+	if len(options) > 0 {
+		fo = options[0]
 	}
-
-	// 切换备用域名开关
-	if config.Base.CloseAutoSwitchHost == "true" {
-		client.Conf.RetryOpt.AutoSwitchHost = false
+	if bucketName == "" { // 不指定 bucket，则创建用于发送 Service 请求的客户端
+		return newCosClient(config, param, GenBaseURL(config, param), fo)
 	}
-
-	// 服务端错误重试（默认10次，每次间隔1s）
-	if len(options) > 0 && options[0] != nil && options[0].Operation.ErrRetryNum > 0 {
-		client.Conf.RetryOpt.Count = options[0].Operation.ErrRetryNum
-		if options[0].Operation.ErrRetryInterval > 0 {
-			client.Conf.RetryOpt.Interval = time.Duration(options[0].Operation.ErrRetryInterval)
-		} else {
-			client.Conf.RetryOpt.Interval = time.Duration(1)
-		}
-	} else {
-		client.Conf.RetryOpt.Count = 10
-		client.Conf.RetryOpt.Interval = time.Duration(1)
+	url, err := GenURL(config, param, bucketName)
+	if err != nil {
+		return client, err
 	}
-
-	// 修改 UserAgent
-	client.UserAgent = Package + "-" + Version
-
-	return client, nil
+	return newCosClient(config, param, url, fo)
 }
 
 // 根据函数参数创建客户端
 func CreateClient(config *Config, param *Param, bucketIDName string) (client *cos.Client, err error) {
-	if config.Base.Mode == "CvmRole" {
-		// 若使用 CvmRole 方式，则需请求请求CAM的服务，获取临时密钥
-		data, err = CamAuth(config.Base.CvmRoleName)
-		if err != nil {
-			return client, err
-		}
-
-		secretID = data.TmpSecretId
-		secretKey = data.TmpSecretKey
-		secretToken = data.Token
-	} else {
-		// SecretKey 方式则直接获取用户配置文件中设置的密钥
-		secretID = config.Base.SecretID
-		secretKey = config.Base.SecretKey
-		secretToken = config.Base.SessionToken
-	}
-
-	// 若参数中有传 SecretID 或 SecretKey ，需将之前赋值的SessionToken置为空，否则会出现使用参数的 SecretID 和 SecretKey ，却使用了CvmRole方式返回的token，导致鉴权失败
-	if param.SecretID != "" {
-		secretID = param.SecretID
-		secretToken = ""
-	}
-	if param.SecretKey != "" {
-		secretKey = param.SecretKey
-		secretToken = ""
-	}
-	if param.SessionToken != "" {
-		secretToken = param.SessionToken
-	}
-
 	protocol := "https"
 	if config.Base.Protocol != "" {
 		protocol = config.Base.Protocol
@@ -150,26 +85,51 @@ func CreateClient(config *Config, param *Param, bucketIDName string) (client *co
 	if param.Protocol != "" {
 		protocol = param.Protocol
 	}
+	return newCosClient(config, param, CreateURL(bucketIDName, protocol, param.Endpoint, false), nil)
+}
 
-	client = cos.NewClient(CreateURL(bucketIDName, protocol, param.Endpoint, false), &http.Client{
-		Transport: &cos.AuthorizationTransport{
-			SecretID:     secretID,
-			SecretKey:    secretKey,
-			SessionToken: secretToken,
-		},
-	})
+// 所有客户端均由此创建，统一连接设置、重试及 UserAgent
+func newCosClient(config *Config, param *Param, baseURL *cos.BaseURL, fo *FileOperations) (client *cos.Client, err error) {
+	httpClient, err := NewHTTPClient(config, param, fo)
+	if err != nil {
+		return client, err
+	}
+	client = cos.NewClient(baseURL, httpClient)
 
 	// 切换备用域名开关
 	if config.Base.CloseAutoSwitchHost == "true" {
 		client.Conf.RetryOpt.AutoSwitchHost = false
 	}
 
-	// 错误重试
+	// 服务端错误重试（默认10次，每次间隔1s）
 	client.Conf.RetryOpt.Count = 10
-	client.Conf.RetryOpt.Interval = 2
+	client.Conf.RetryOpt.Interval = time.Second
+	if fo != nil && fo.Operation.ErrRetryNum > 0 {
+		client.Conf.RetryOpt.Count = fo.Operation.ErrRetryNum
+		if fo.Operation.ErrRetryInterval > 0 {
+			client.Conf.RetryOpt.Interval = time.Duration(fo.Operation.ErrRetryInterval) * time.Second
+		}
+	}
 
 	// 修改 UserAgent
 	client.UserAgent = Package + "-" + Version
 
 	return client, nil
 }
+
+// NewHTTPClient 按配置及参数创建带签名的 HTTP 客户端
+func NewHTTPClient(config *Config, param *Param, fo *FileOperations) (*http.Client, error) {
+	opt, err := GetTransportOptions(config, param, fo)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := NewTransport(opt)
+	if err != nil {
+		return nil, err
+	}
+	authTransport, err := newAuthTransport(config, param, transport)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: authTransport}, nil
+}
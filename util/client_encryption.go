@@ -0,0 +1,460 @@
+package util
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	CseAesCtr = "aes-ctr"
+	CseAesGcm = "aes-gcm"
+
+	// 加密信息保存在对象的自定义元数据中
+	cseMetaAlgorithm = "x-cos-meta-cse-algorithm"
+	cseMetaKey       = "x-cos-meta-cse-key"
+	cseMetaIV        = "x-cos-meta-cse-iv"
+	cseMetaLength    = "x-cos-meta-cse-unencrypted-content-length"
+	cseMetaCrc64     = "x-cos-meta-cse-unencrypted-crc64"
+
+	// AES-GCM 按段加密，每段附带 16 字节的校验值，可按段解密范围内的数据
+	cseGcmSegmentSize = 64 * 1024
+	cseGcmTagSize     = 16
+
+	// 下载客户端加密的对象时，密文先写入本地路径加该后缀的临时文件
+	cseDownloadSuffix = ".cse-download"
+)
+
+// ClientEncryption 客户端加密设置。每个对象使用随机生成的数据密钥加密，数据密钥由主密钥加密后与 IV 一起保存在对象的元数据中
+type ClientEncryption struct {
+	// 上传时使用的加密算法，为空时不加密，仅用于解密
+	Algorithm string
+	masterKey []byte
+}
+
+// NewClientEncryption 从主密钥文件读取主密钥，keyFile 为空时不使用客户端加密
+func NewClientEncryption(algorithm, keyFile string) (*ClientEncryption, error) {
+	switch algorithm {
+	case "", CseAesCtr, CseAesGcm:
+	default:
+		return nil, fmt.Errorf("--client-encryption must be %s or %s", CseAesCtr, CseAesGcm)
+	}
+	if keyFile == "" {
+		if algorithm != "" {
+			return nil, fmt.Errorf("--client-encryption requires the master key in --encryption-key-file")
+		}
+		return nil, nil
+	}
+	key, err := readAES256Key("--encryption-key", "", expandHome(keyFile))
+	if err != nil {
+		return nil, err
+	}
+	return &ClientEncryption{Algorithm: algorithm, masterKey: key}, nil
+}
+
+// 对象是否经过客户端加密
+func isClientEncrypted(header http.Header) bool {
+	return header.Get(cseMetaAlgorithm) != ""
+}
+
+// 对象明文的 crc64，客户端加密的对象为加密前的 crc64
+func objectCrc64(header http.Header) string {
+	if isClientEncrypted(header) {
+		return header.Get(cseMetaCrc64)
+	}
+	return header.Get("x-cos-hash-crc64ecma")
+}
+
+// 单个对象的加密参数
+type cseCipher struct {
+	algorithm string
+	block     cipher.Block
+	iv        []byte
+	// 明文长度
+	size int64
+}
+
+// 生成新的数据密钥及 IV，返回需写入对象元数据的加密信息
+func (e *ClientEncryption) newCipher(size int64) (*cseCipher, http.Header, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, nil, err
+	}
+	ivSize := aes.BlockSize
+	if e.Algorithm == CseAesGcm {
+		ivSize = 12
+	}
+	iv := make([]byte, ivSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, nil, err
+	}
+	wrappedKey, err := e.wrapKey(dataKey, e.Algorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := http.Header{}
+	header.Set(cseMetaAlgorithm, e.Algorithm)
+	header.Set(cseMetaKey, base64.StdEncoding.EncodeToString(wrappedKey))
+	header.Set(cseMetaIV, base64.StdEncoding.EncodeToString(iv))
+	header.Set(cseMetaLength, strconv.FormatInt(size, 10))
+	return &cseCipher{algorithm: e.Algorithm, block: block, iv: iv, size: size}, header, nil
+}
+
+// 从对象的元数据中读取加密参数，并用主密钥解密数据密钥
+func (e *ClientEncryption) cipherFromHeader(header http.Header) (*cseCipher, error) {
+	if e == nil {
+		return nil, fmt.Errorf("the object is encrypted on the client side, the master key is required in --encryption-key-file")
+	}
+	algorithm := header.Get(cseMetaAlgorithm)
+	if algorithm != CseAesCtr && algorithm != CseAesGcm {
+		return nil, fmt.Errorf("unsupported client-side encryption algorithm %s", algorithm)
+	}
+	wrappedKey, err := base64.StdEncoding.DecodeString(header.Get(cseMetaKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted data key: %v", err)
+	}
+	iv, err := base64.StdEncoding.DecodeString(header.Get(cseMetaIV))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption iv: %v", err)
+	}
+	size, err := strconv.ParseInt(header.Get(cseMetaLength), 10, 64)
+	if err != nil || size < 0 {
+		return nil, fmt.Errorf("invalid unencrypted content length %s", header.Get(cseMetaLength))
+	}
+	dataKey, err := e.unwrapKey(wrappedKey, algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if (algorithm == CseAesCtr && len(iv) != aes.BlockSize) || (algorithm == CseAesGcm && len(iv) != 12) {
+		return nil, fmt.Errorf("invalid encryption iv")
+	}
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &cseCipher{algorithm: algorithm, block: block, iv: iv, size: size}, nil
+}
+
+// 使用主密钥以 AES-GCM 加密数据密钥，结果为 nonce 与密文的拼接
+func (e *ClientEncryption) wrapKey(dataKey []byte, algorithm string) ([]byte, error) {
+	aead, err := newGCM(e.masterKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, dataKey, []byte(algorithm)), nil
+}
+
+func (e *ClientEncryption) unwrapKey(wrappedKey []byte, algorithm string) ([]byte, error) {
+	aead, err := newGCM(e.masterKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrappedKey) < aead.NonceSize() {
+		return nil, fmt.Errorf("invalid encrypted data key")
+	}
+	dataKey, err := aead.Open(nil, wrappedKey[:aead.NonceSize()], wrappedKey[aead.NonceSize():], []byte(algorithm))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt the data key error, the master key may be wrong")
+	}
+	return dataKey, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// 加密后的长度，AES-CTR 与明文相同，AES-GCM 每段增加校验值，空文件也有一段
+func (c *cseCipher) encryptedSize() int64 {
+	if c.algorithm == CseAesCtr {
+		return c.size
+	}
+	return c.size + c.segments()*cseGcmTagSize
+}
+
+func (c *cseCipher) segments() int64 {
+	n := (c.size + cseGcmSegmentSize - 1) / cseGcmSegmentSize
+	if n == 0 {
+		n = 1
+	}
+	return n
+}
+
+// 第 index 段的 nonce 为 IV 的后 8 字节与段序号异或，最后一段的附加数据不同，可发现被截断的密文
+func (c *cseCipher) segmentNonce(index int64) []byte {
+	nonce := append([]byte(nil), c.iv...)
+	tail := binary.BigEndian.Uint64(nonce[4:]) ^ uint64(index)
+	binary.BigEndian.PutUint64(nonce[4:], tail)
+	return nonce
+}
+
+func (c *cseCipher) segmentData(index int64) []byte {
+	if index == c.segments()-1 {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// 加密 src 中的全部明文写入 dst
+func (c *cseCipher) encrypt(dst io.Writer, src io.Reader) error {
+	if c.algorithm == CseAesCtr {
+		writer := &cipher.StreamWriter{S: cipher.NewCTR(c.block, c.iv), W: dst}
+		n, err := io.Copy(writer, src)
+		if err == nil && n != c.size {
+			err = fmt.Errorf("the file size changed during encryption")
+		}
+		return err
+	}
+
+	aead, err := cipher.NewGCM(c.block)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, cseGcmSegmentSize)
+	sealed := make([]byte, 0, cseGcmSegmentSize+cseGcmTagSize)
+	remaining := c.size
+	for index := int64(0); index < c.segments(); index++ {
+		n := int64(cseGcmSegmentSize)
+		if remaining < n {
+			n = remaining
+		}
+		if _, err = io.ReadFull(src, buf[:n]); err != nil {
+			return fmt.Errorf("the file size changed during encryption")
+		}
+		remaining -= n
+		sealed = aead.Seal(sealed[:0], c.segmentNonce(index), buf[:n], c.segmentData(index))
+		if _, err = dst.Write(sealed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 解密 src 中的全部密文
+func (c *cseCipher) newDecryptReader(src io.Reader) (io.Reader, error) {
+	if c.algorithm == CseAesCtr {
+		return &cipher.StreamReader{S: cipher.NewCTR(c.block, c.iv), R: src}, nil
+	}
+	aead, err := cipher.NewGCM(c.block)
+	if err != nil {
+		return nil, err
+	}
+	return &gcmDecryptReader{cipher: c, aead: aead, src: src, end: c.segments(), buf: make([]byte, cseGcmSegmentSize+cseGcmTagSize)}, nil
+}
+
+// 明文范围 [start, end] 所需的密文范围，AES-CTR 从 start 所在的块开始，AES-GCM 取覆盖范围的完整段
+func (c *cseCipher) encryptedRange(start, end int64) (int64, int64) {
+	if c.algorithm == CseAesCtr {
+		return start - start%aes.BlockSize, end
+	}
+	sealedSize := int64(cseGcmSegmentSize + cseGcmTagSize)
+	encryptedEnd := (end/cseGcmSegmentSize+1)*sealedSize - 1
+	if encryptedEnd > c.encryptedSize()-1 {
+		encryptedEnd = c.encryptedSize() - 1
+	}
+	return start / cseGcmSegmentSize * sealedSize, encryptedEnd
+}
+
+// 解密从 encryptedRange 起始位置开始的密文，只返回明文 [start, end]
+func (c *cseCipher) newRangeDecryptReader(src io.Reader, start, end int64) (io.Reader, error) {
+	var reader io.Reader
+	var skip int64
+	if c.algorithm == CseAesCtr {
+		// 计数器按块递增，IV 加上起始块序号即为该块的计数器
+		iv := append([]byte(nil), c.iv...)
+		carry := uint64(start / aes.BlockSize)
+		for i := len(iv) - 1; i >= 0 && carry > 0; i-- {
+			sum := uint64(iv[i]) + carry&0xff
+			iv[i] = byte(sum)
+			carry = carry>>8 + sum>>8
+		}
+		reader = &cipher.StreamReader{S: cipher.NewCTR(c.block, iv), R: src}
+		skip = start % aes.BlockSize
+	} else {
+		aead, err := cipher.NewGCM(c.block)
+		if err != nil {
+			return nil, err
+		}
+		reader = &gcmDecryptReader{cipher: c, aead: aead, src: src, index: start / cseGcmSegmentSize,
+			end: end/cseGcmSegmentSize + 1, buf: make([]byte, cseGcmSegmentSize+cseGcmTagSize)}
+		skip = start % cseGcmSegmentSize
+	}
+	if _, err := io.CopyN(ioutil.Discard, reader, skip); err != nil {
+		return nil, err
+	}
+	return io.LimitReader(reader, end-start+1), nil
+}
+
+// 按段解密 AES-GCM 密文的 Reader，每段校验通过后才返回数据
+type gcmDecryptReader struct {
+	cipher *cseCipher
+	aead   cipher.AEAD
+	src    io.Reader
+	index  int64
+	// 解密到第 end 段之前
+	end   int64
+	buf   []byte
+	plain []byte
+}
+
+func (r *gcmDecryptReader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.index == r.end {
+			return 0, io.EOF
+		}
+		n := int64(cseGcmSegmentSize)
+		if r.index == r.cipher.segments()-1 {
+			n = r.cipher.size - r.index*cseGcmSegmentSize
+		}
+		segment := r.buf[:n+cseGcmTagSize]
+		if _, err := io.ReadFull(r.src, segment); err != nil {
+			return 0, fmt.Errorf("the encrypted object is truncated: %v", err)
+		}
+		plain, err := r.aead.Open(segment[:0], r.cipher.segmentNonce(r.index), segment, r.cipher.segmentData(r.index))
+		if err != nil {
+			return 0, fmt.Errorf("decrypt segment %d error, the object may be corrupted", r.index)
+		}
+		r.plain = plain
+		r.index++
+	}
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+// 加密本地文件到临时文件，返回临时文件路径及需写入对象元数据的加密信息
+func (e *ClientEncryption) encryptFile(localPath string) (tmpPath string, header http.Header, err error) {
+	src, err := os.Open(localPath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return "", nil, err
+	}
+	c, header, err := e.newCipher(info.Size())
+	if err != nil {
+		return "", nil, err
+	}
+
+	tmp, err := ioutil.TempFile("", "coscli-cse-")
+	if err != nil {
+		return "", nil, err
+	}
+	tmpPath = tmp.Name()
+	hash := crc64.New(crc64.MakeTable(crc64.ECMA))
+	writer := bufio.NewWriter(tmp)
+	err = c.encrypt(writer, io.TeeReader(src, hash))
+	if err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", nil, fmt.Errorf("encrypt %s error: %v", localPath, err)
+	}
+	header.Set(cseMetaCrc64, strconv.FormatUint(hash.Sum64(), 10))
+	return tmpPath, header, nil
+}
+
+// 解密下载到 encryptedPath 的密文文件，先写入本地文件同目录的临时文件再替换本地文件。
+// 失败时不修改已有的本地文件，密文文件由调用方删除
+func (e *ClientEncryption) decryptFile(encryptedPath, localPath string, header http.Header) error {
+	c, err := e.cipherFromHeader(header)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(encryptedPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() != c.encryptedSize() {
+		return fmt.Errorf("the size of the encrypted object is %d, want %d", info.Size(), c.encryptedSize())
+	}
+	reader, err := c.newDecryptReader(bufio.NewReader(src))
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(localPath), filepath.Base(localPath)+".cse-tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	writer := bufio.NewWriter(tmp)
+	_, err = io.Copy(writer, reader)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	src.Close()
+	if err == nil {
+		err = os.Chmod(tmpPath, info.Mode())
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, localPath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("decrypt %s error: %v", localPath, err)
+	}
+	return nil
+}
+
+// 对象元数据中的加密信息
+func encryptionMeta(header http.Header) http.Header {
+	meta := http.Header{}
+	for _, name := range []string{cseMetaAlgorithm, cseMetaKey, cseMetaIV, cseMetaLength, cseMetaCrc64} {
+		if v := header.Get(name); v != "" {
+			meta.Set(name, v)
+		}
+	}
+	return meta
+}
+
+// 在上传的元数据中加入加密信息
+func withEncryptionMeta(meta *http.Header, encryption http.Header) *http.Header {
+	header := http.Header{}
+	if meta != nil {
+		for name, values := range *meta {
+			header[name] = values
+		}
+	}
+	for name, values := range encryption {
+		header[name] = values
+	}
+	return &header
+}
@@ -23,6 +23,7 @@ const (
 const (
 	TypeSnapshotPath   = "snapshotPath"
 	TypeFailOutputPath = "failOutputPath"
+	TypeCheckpointDir  = "checkpointDir"
 )
 
 const (
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/tencentyun/cos-go-sdk-v5"
 	"math/rand"
+	"net/http"
 	"strings"
 	"time"
 )
@@ -14,7 +15,9 @@ func CosCopy(srcClient, destClient *cos.Client, srcUrl, destUrl StorageUrl, fo *
 
 	fo.Monitor.init(fo.CpType)
 	chProgressSignal = make(chan chProgressSignalType, 10)
-	go progressBar(fo)
+	if !fo.Operation.DryRun {
+		go progressBar(fo)
+	}
 
 	if srcUrl.(*CosUrl).Object != "" && !strings.HasSuffix(srcUrl.(*CosUrl).Object, CosSeparator) {
 		// 单对象copy
@@ -26,7 +29,7 @@ func CosCopy(srcClient, destClient *cos.Client, srcUrl, destUrl StorageUrl, fo *
 			relativeKey = srcUrl.(*CosUrl).Object[index+1:]
 		}
 		// 获取文件信息
-		resp, err := GetHead(srcClient, srcUrl.(*CosUrl).Object, fo.Operation.VersionId)
+		resp, err := GetHeadWithKey(srcClient, srcUrl.(*CosUrl).Object, fo.Operation.SSE.SourceCustomerKey, fo.Operation.VersionId)
 		if err != nil {
 			if resp != nil && resp.StatusCode == 404 {
 				// 源文件不在cos上
@@ -36,7 +39,7 @@ func CosCopy(srcClient, destClient *cos.Client, srcUrl, destUrl StorageUrl, fo *
 		}
 
 		// copy文件
-		skip, err, isDir, size, msg := singleCopy(srcClient, destClient, fo, objectInfoType{prefix, relativeKey, resp.ContentLength, resp.Header.Get("Last-Modified")}, srcUrl, destUrl, fo.Operation.VersionId)
+		skip, err, isDir, size, msg := singleCopy(srcClient, destClient, fo, objectInfoType{prefix, relativeKey, resp.ContentLength, resp.Header.Get("Last-Modified"), fo.Operation.VersionId}, srcUrl, destUrl, fo.Operation.VersionId)
 
 		fo.Monitor.updateMonitor(skip, err, isDir, size)
 		if err != nil {
@@ -50,6 +53,9 @@ func CosCopy(srcClient, destClient *cos.Client, srcUrl, destUrl StorageUrl, fo *
 
 	CloseErrorOutputFile(fo)
 	closeProgress()
+	if fo.Operation.DryRun {
+		return nil
+	}
 	fmt.Printf(fo.Monitor.progressBar(true, normalExit))
 
 	endT := time.Now().UnixNano() / 1000 / 1000
@@ -63,16 +69,19 @@ func batchCopyFiles(srcClient, destClient *cos.Client, srcUrl, destUrl StorageUr
 	chError := make(chan error, fo.Operation.Routines)
 	chListError := make(chan error, 1)
 
-	if fo.BucketType == "OFS" {
+	if fo.FileList != nil {
+		// 按 --files-from 清单获取对象
+		go getObjectListFromFile(srcClient, srcUrl, chObjects, chListError, chError, fo)
+	} else if fo.BucketType == "OFS" {
 		// 扫描ofs对象大小及数量
 		go getOfsObjectList(srcClient, srcUrl, nil, nil, fo, true, false)
 		// 获取ofs对象列表
 		go getOfsObjectList(srcClient, srcUrl, chObjects, chListError, fo, false, true)
 	} else {
 		// 扫描cos对象大小及数量
-		go getCosObjectList(srcClient, srcUrl, nil, nil, fo, true, false)
+		go getCosObjectListForCheckpoint(srcClient, srcUrl, nil, nil, fo, true, false)
 		// 获取cos对象列表
-		go getCosObjectList(srcClient, srcUrl, chObjects, chListError, fo, false, true)
+		go getCosObjectListForCheckpoint(srcClient, srcUrl, chObjects, chListError, fo, false, true)
 	}
 
 	for i := 0; i < fo.Operation.Routines; i++ {
@@ -103,12 +112,17 @@ func batchCopyFiles(srcClient, destClient *cos.Client, srcUrl, destUrl StorageUr
 
 func copyFiles(srcClient, destClient *cos.Client, srcUrl, destUrl StorageUrl, fo *FileOperations, chObjects <-chan objectInfoType, chError chan<- error) {
 	for object := range chObjects {
+		item := objectCheckpointItem(object)
+		if skipByCheckpoint(fo, item) {
+			continue
+		}
+
 		var skip, isDir bool
 		var err error
 		var size int64
 		var msg string
 		for retry := 0; retry <= fo.Operation.ErrRetryNum; retry++ {
-			skip, err, isDir, size, msg = singleCopy(srcClient, destClient, fo, object, srcUrl, destUrl)
+			skip, err, isDir, size, msg = singleCopy(srcClient, destClient, fo, object, srcUrl, destUrl, versionIds(object.versionId)...)
 			if err == nil {
 				break // Copy succeeded, break the loop
 			} else {
@@ -128,6 +142,7 @@ func copyFiles(srcClient, destClient *cos.Client, srcUrl, destUrl StorageUrl, fo
 			chError <- fmt.Errorf("%s failed: %w", msg, err)
 			continue
 		}
+		recordCheckpoint(fo, item)
 	}
 
 	chError <- nil
@@ -151,13 +166,24 @@ func singleCopy(srcClient, destClient *cos.Client, fo *FileOperations, objectInf
 
 	// 仅sync命令执行skip
 	if fo.Command == CommandSync && !isDir {
-		skip, err = skipCopy(srcClient, destClient, object, destPath)
+		skip, err = skipCopy(srcClient, destClient, fo, object, destPath)
 		if err != nil {
 			rErr = err
 			return
 		}
 	}
 
+	if fo.Operation.DryRun {
+		action := PlanCopy
+		if skip {
+			action = PlanSkip
+		} else if fo.Operation.Move {
+			action = PlanMove
+		}
+		fo.Plan.Add(&PlanRecord{Action: action, Source: getCosUrl(srcUrl.(*CosUrl).Bucket, object), Destination: getCosUrl(destUrl.(*CosUrl).Bucket, destPath), Size: size, VersionId: objectInfo.versionId})
+		return
+	}
+
 	if skip {
 		return
 	}
@@ -165,13 +191,46 @@ func singleCopy(srcClient, destClient *cos.Client, fo *FileOperations, objectInf
 	// copy暂不支持监听进度
 	// size = 0
 
-	url, err := GenURL(fo.Config, fo.Param, srcUrl.(*CosUrl).Bucket)
+	if fo.SrcConfig != nil {
+		// 来源与目标使用不同的命名配置时，目标账号通常无权读取来源对象，改为读取来源对象后上传
+		err = streamCopy(srcClient, destClient, object, destPath, size, fo, VersionId...)
+	} else {
+		err = serverSideCopy(srcClient, destClient, object, destPath, size, srcUrl, fo, VersionId...)
+	}
+
+	if err != nil {
+		rErr = err
+		return
+	}
+
+	if fo.Operation.Move {
+		if err == nil {
+			var deleteOpt *cos.ObjectDeleteOptions
+			if objectInfo.versionId != "" && fo.FileList != nil {
+				// 清单中指定了版本时删除该版本
+				deleteOpt = &cos.ObjectDeleteOptions{VersionId: objectInfo.versionId}
+			}
+			_, err = srcClient.Object.Delete(context.Background(), object, deleteOpt)
+			rErr = err
+			return
+		}
+	}
+
+	return
+}
+
+// 由目标桶直接拷贝来源对象
+func serverSideCopy(srcClient, destClient *cos.Client, object, destPath string, size int64, srcUrl StorageUrl, fo *FileOperations, VersionId ...string) error {
+	url, err := GenURL(SrcConfig(fo), fo.Param, srcUrl.(*CosUrl).Bucket)
+	if err != nil {
+		return err
+	}
 
 	srcURL := fmt.Sprintf("%s/%s", url.BucketURL.Host, object)
 
 	opt := &cos.MultiCopyOptions{
 		OptCopy: &cos.ObjectCopyOptions{
-			&cos.ObjectCopyHeaderOptions{
+			ObjectCopyHeaderOptions: &cos.ObjectCopyHeaderOptions{
 				CacheControl:       fo.Operation.Meta.CacheControl,
 				ContentDisposition: fo.Operation.Meta.ContentDisposition,
 				ContentEncoding:    fo.Operation.Meta.ContentEncoding,
@@ -180,32 +239,209 @@ func singleCopy(srcClient, destClient *cos.Client, fo *FileOperations, objectInf
 				XCosStorageClass:   fo.Operation.StorageClass,
 				XCosMetaXXX:        fo.Operation.Meta.XCosMetaXXX,
 			},
-			nil,
+			ACLHeaderOptions: fo.Operation.ACL.headerOptions(),
 		},
 		PartSize:       fo.Operation.PartSize,
 		ThreadPoolSize: fo.Operation.ThreadNum,
 	}
 	if fo.Operation.Meta.CacheControl != "" || fo.Operation.Meta.ContentDisposition != "" || fo.Operation.Meta.ContentEncoding != "" ||
 		fo.Operation.Meta.ContentType != "" || fo.Operation.Meta.Expires != "" || fo.Operation.Meta.MetaChange {
-	}
-	{
 		opt.OptCopy.ObjectCopyHeaderOptions.XCosMetadataDirective = "Replaced"
+		// 替换元数据时保留来源对象的客户端加密信息，否则拷贝后的对象无法解密
+		resp, err := GetHeadWithKey(srcClient, object, fo.Operation.SSE.SourceCustomerKey, VersionId...)
+		if err != nil {
+			return err
+		}
+		if isClientEncrypted(resp.Header) {
+			opt.OptCopy.ObjectCopyHeaderOptions.XCosMetaXXX = withEncryptionMeta(fo.Operation.Meta.XCosMetaXXX, encryptionMeta(resp.Header))
+		}
+	}
+	fo.Operation.SSE.setCopyHeader(opt.OptCopy.ObjectCopyHeaderOptions)
+	if err = fo.Operation.Tagging.setCopyHeader(srcClient, object, size, opt.OptCopy.ObjectCopyHeaderOptions, VersionId...); err != nil {
+		return err
 	}
 
+	if fo.Operation.SSE.CustomerKey != nil || fo.Operation.SSE.SourceCustomerKey != nil {
+		return customerKeyCopy(destClient, destPath, srcURL, size, opt, fo.Operation.SSE.CustomerKey, VersionId...)
+	}
 	_, _, err = destClient.Object.MultiCopy(context.Background(), destPath, srcURL, opt, VersionId...)
+	return err
+}
 
+// 来源或目标对象使用 SSE-C 时的拷贝。MultiCopy 获取来源对象大小时不携带密钥，且分块拷贝时不携带目标对象的密钥，
+// 因此使用已知的对象大小，不大于 5GB 时直接拷贝，否则逐块拷贝
+func customerKeyCopy(destClient *cos.Client, destPath, srcURL string, size int64, opt *cos.MultiCopyOptions, customerKey []byte, VersionId ...string) error {
+	if size <= copyMaxSingleSize {
+		_, _, err := destClient.Object.Copy(context.Background(), destPath, srcURL, opt.OptCopy, VersionId...)
+		return err
+	}
+
+	chunks, _, err := cos.SplitSizeIntoChunks(size, opt.PartSize*1024*1024)
 	if err != nil {
-		rErr = err
-		return
+		return err
 	}
+	initResult, _, err := destClient.Object.InitiateMultipartUpload(context.Background(), destPath, cos.CopyOptionsToMulti(opt.OptCopy))
+	if err != nil {
+		return err
+	}
+	uploadId := initResult.UploadID
 
-	if fo.Operation.Move {
-		if err == nil {
-			_, err = srcClient.Object.Delete(context.Background(), object, nil)
-			rErr = err
-			return
+	if len(VersionId) > 0 && VersionId[0] != "" {
+		srcURL = fmt.Sprintf("%s?versionId=%s", srcURL, VersionId[0])
+	}
+	completeOpt := &cos.CompleteMultipartUploadOptions{}
+	for _, chunk := range chunks {
+		partOpt := &cos.ObjectCopyPartOptions{
+			XCosCopySourceRange:             fmt.Sprintf("bytes=%d-%d", chunk.OffSet, chunk.OffSet+chunk.Size-1),
+			XCosCopySourceSSECustomerAglo:   opt.OptCopy.XCosCopySourceSSECustomerAglo,
+			XCosCopySourceSSECustomerKey:    opt.OptCopy.XCosCopySourceSSECustomerKey,
+			XCosCopySourceSSECustomerKeyMD5: opt.OptCopy.XCosCopySourceSSECustomerKeyMD5,
+			XOptionHeader:                   sseCustomerOptionHeader(customerKey),
+		}
+		result, _, err := destClient.Object.CopyPart(context.Background(), destPath, uploadId, chunk.Number, srcURL, partOpt)
+		if err != nil {
+			destClient.Object.AbortMultipartUpload(context.Background(), destPath, uploadId)
+			return err
 		}
+		completeOpt.Parts = append(completeOpt.Parts, cos.Object{PartNumber: chunk.Number, ETag: result.ETag})
 	}
 
-	return
+	_, _, err = destClient.Object.CompleteMultipartUpload(context.Background(), destPath, uploadId, completeOpt)
+	if err != nil {
+		destClient.Object.AbortMultipartUpload(context.Background(), destPath, uploadId)
+	}
+	return err
+}
+
+// 读取来源对象并上传到目标，不大于分块大小时简单上传，否则按分块逐块读取并上传。
+// 未通过 --meta 指定的元数据沿用来源对象的元数据
+func streamCopy(srcClient, destClient *cos.Client, object, destPath string, size int64, fo *FileOperations, VersionId ...string) error {
+	partSize := fo.Operation.PartSize * 1024 * 1024
+	if partSize <= 0 {
+		partSize = 32 * 1024 * 1024
+	}
+
+	sourceKey := fo.Operation.SSE.SourceCustomerKey
+	resp, err := GetHeadWithKey(srcClient, object, sourceKey, VersionId...)
+	if err != nil {
+		return err
+	}
+	header := streamCopyHeader(resp.Header, fo)
+	fo.Operation.SSE.setPutHeader(header)
+	if err = fo.Operation.Tagging.setStreamCopyHeader(srcClient, object, resp.Header, header, VersionId...); err != nil {
+		return err
+	}
+
+	if size <= partSize {
+		getOpt := &cos.ObjectGetOptions{}
+		setGetCustomerKey(getOpt, sourceKey)
+		resp, err := srcClient.Object.Get(context.Background(), object, getOpt, VersionId...)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		header.ContentLength = size
+		_, err = destClient.Object.Put(context.Background(), destPath, resp.Body, &cos.ObjectPutOptions{ACLHeaderOptions: fo.Operation.ACL.headerOptions(), ObjectPutHeaderOptions: header})
+		return err
+	}
+
+	initResult, _, err := destClient.Object.InitiateMultipartUpload(context.Background(), destPath, &cos.InitiateMultipartUploadOptions{ACLHeaderOptions: fo.Operation.ACL.headerOptions(), ObjectPutHeaderOptions: header})
+	if err != nil {
+		return err
+	}
+	uploadId := initResult.UploadID
+
+	completeOpt := &cos.CompleteMultipartUploadOptions{}
+	for partNumber, offset := 1, int64(0); offset < size; partNumber, offset = partNumber+1, offset+partSize {
+		end := offset + partSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		etag, err := streamCopyPart(srcClient, destClient, object, destPath, uploadId, partNumber, offset, end, fo.Operation.SSE, VersionId...)
+		if err != nil {
+			destClient.Object.AbortMultipartUpload(context.Background(), destPath, uploadId)
+			return err
+		}
+		completeOpt.Parts = append(completeOpt.Parts, cos.Object{PartNumber: partNumber, ETag: etag})
+	}
+
+	_, _, err = destClient.Object.CompleteMultipartUpload(context.Background(), destPath, uploadId, completeOpt)
+	if err != nil {
+		destClient.Object.AbortMultipartUpload(context.Background(), destPath, uploadId)
+	}
+	return err
+}
+
+func streamCopyPart(srcClient, destClient *cos.Client, object, destPath, uploadId string, partNumber int, start, end int64, sse SSEOptions, VersionId ...string) (string, error) {
+	getOpt := &cos.ObjectGetOptions{Range: fmt.Sprintf("bytes=%d-%d", start, end)}
+	setGetCustomerKey(getOpt, sse.SourceCustomerKey)
+	resp, err := srcClient.Object.Get(context.Background(), object, getOpt, VersionId...)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	partOpt := &cos.ObjectUploadPartOptions{ContentLength: end - start + 1}
+	partOpt.XCosSSECustomerAglo, partOpt.XCosSSECustomerKey, partOpt.XCosSSECustomerKeyMD5 = sseCustomerHeaders(sse.CustomerKey)
+	resp, err = destClient.Object.UploadPart(context.Background(), destPath, uploadId, partNumber, resp.Body, partOpt)
+	if err != nil {
+		return "", err
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// 上传到目标时使用的元数据，--meta 与 --storage-class 指定的值优先
+func streamCopyHeader(src http.Header, fo *FileOperations) *cos.ObjectPutHeaderOptions {
+	meta := fo.Operation.Meta
+	header := &cos.ObjectPutHeaderOptions{
+		CacheControl:       src.Get("Cache-Control"),
+		ContentDisposition: src.Get("Content-Disposition"),
+		ContentEncoding:    src.Get("Content-Encoding"),
+		ContentType:        src.Get("Content-Type"),
+		Expires:            src.Get("Expires"),
+		XCosStorageClass:   src.Get("x-cos-storage-class"),
+	}
+	metaHeader := http.Header{}
+	for name, values := range src {
+		if strings.HasPrefix(strings.ToLower(name), "x-cos-meta-") {
+			metaHeader[name] = values
+		}
+	}
+	if len(metaHeader) > 0 {
+		header.XCosMetaXXX = &metaHeader
+	}
+
+	if meta.CacheControl != "" {
+		header.CacheControl = meta.CacheControl
+	}
+	if meta.ContentDisposition != "" {
+		header.ContentDisposition = meta.ContentDisposition
+	}
+	if meta.ContentEncoding != "" {
+		header.ContentEncoding = meta.ContentEncoding
+	}
+	if meta.ContentType != "" {
+		header.ContentType = meta.ContentType
+	}
+	if meta.Expires != "" {
+		header.Expires = meta.Expires
+	}
+	if meta.XCosMetaXXX != nil {
+		header.XCosMetaXXX = meta.XCosMetaXXX
+		if isClientEncrypted(src) {
+			header.XCosMetaXXX = withEncryptionMeta(meta.XCosMetaXXX, encryptionMeta(src))
+		}
+	}
+	if fo.Operation.StorageClass != "" {
+		header.XCosStorageClass = fo.Operation.StorageClass
+	}
+	return header
+}
+
+// SrcConfig 拷贝时来源桶使用的配置
+func SrcConfig(fo *FileOperations) *Config {
+	if fo.SrcConfig != nil {
+		return fo.SrcConfig
+	}
+	return fo.Config
 }
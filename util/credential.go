@@ -0,0 +1,362 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"golang.org/x/term"
+)
+
+// 密钥的存储方式
+const (
+	// 密钥保存在配置文件中，未关闭加密时使用旧的 AES-ECB 方式加密
+	CredentialBackendConfig = "config"
+	// 密钥保存在口令或环境变量中的密钥加密的文件中
+	CredentialBackendFile = "file"
+	// 密钥由外部命令输出的 JSON 提供
+	CredentialBackendProcess = "process"
+	// 密钥从环境变量读取
+	CredentialBackendEnv = "env"
+)
+
+// 加密凭证文件使用的环境变量
+const (
+	// 加密凭证文件的口令，对应 age 的 scrypt 接收方
+	CredentialPassphraseEnv = "COSCLI_CREDENTIAL_PASSPHRASE"
+	// age X25519 私钥(AGE-SECRET-KEY-1...)，设置后凭证文件加密给该私钥对应的公钥
+	CredentialKeyEnv = "COSCLI_CREDENTIAL_KEY"
+)
+
+// 加密凭证文件的默认路径，文件为 ASCII armor 编码的 age 格式，也可用 age 命令行工具解密
+const DefaultCredentialFile = "~/.cos.credentials"
+
+// 使用口令加密凭证文件时 scrypt 的工作因子，即 N 为 2^15
+const credentialScryptLogN = 15
+
+// 环境变量后端读取的变量，按顺序取第一个非空值
+var (
+	credentialSecretIDEnvs     = []string{"COS_SECRET_ID", "TENCENTCLOUD_SECRET_ID"}
+	credentialSecretKeyEnvs    = []string{"COS_SECRET_KEY", "TENCENTCLOUD_SECRET_KEY"}
+	credentialSessionTokenEnvs = []string{"COS_SESSION_TOKEN", "TENCENTCLOUD_SESSION_TOKEN"}
+)
+
+// Credential 密钥，也是 credential_process 命令输出的 JSON 格式
+type Credential struct {
+	SecretID     string `json:"SecretId"`
+	SecretKey    string `json:"SecretKey"`
+	SessionToken string `json:"SessionToken,omitempty"`
+}
+
+// CheckCredentialBackend 检查密钥存储方式是否合法，空值等同于 config
+func CheckCredentialBackend(backend string) error {
+	switch backend {
+	case "", CredentialBackendConfig, CredentialBackendFile, CredentialBackendProcess, CredentialBackendEnv:
+		return nil
+	}
+	return fmt.Errorf("credential backend can only be selected among config, file, process and env")
+}
+
+// LoadCredential 按配置的存储方式读取密钥，写入 base 的 SecretID、SecretKey 与 SessionToken
+func LoadCredential(base *BaseCfg) error {
+	if err := CheckCredentialBackend(base.CredentialBackend); err != nil {
+		return err
+	}
+
+	var cred Credential
+	var err error
+	switch base.CredentialBackend {
+	case CredentialBackendFile:
+		cred, err = readCredentialFile(credentialFilePath(base.CredentialFile))
+	case CredentialBackendProcess:
+		cred, err = runCredentialProcess(base.CredentialProcess)
+	case CredentialBackendEnv:
+		cred, err = readCredentialEnv()
+	default:
+		// 若未关闭秘钥加密，则先解密秘钥
+		if base.DisableEncryption != "true" {
+			if secretKey, err := DecryptSecret(base.SecretKey); err == nil {
+				base.SecretKey = secretKey
+			}
+			if secretId, err := DecryptSecret(base.SecretID); err == nil {
+				base.SecretID = secretId
+			}
+			if sessionToken, err := DecryptSecret(base.SessionToken); err == nil {
+				base.SessionToken = sessionToken
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	base.SecretID = cred.SecretID
+	base.SecretKey = cred.SecretKey
+	base.SessionToken = cred.SessionToken
+	return nil
+}
+
+// SaveCredential 按配置的存储方式保存密钥，返回写入配置文件的基础配置，file、process 与 env 方式下配置文件中不保存密钥。
+// storeSecrets 为 false 时只生成写入配置文件的内容，不改动加密凭证文件
+func SaveCredential(base BaseCfg, storeSecrets bool) (BaseCfg, error) {
+	if err := CheckCredentialBackend(base.CredentialBackend); err != nil {
+		return base, err
+	}
+
+	switch base.CredentialBackend {
+	case CredentialBackendFile:
+		if storeSecrets {
+			cred := Credential{SecretID: base.SecretID, SecretKey: base.SecretKey, SessionToken: base.SessionToken}
+			if err := writeCredentialFile(credentialFilePath(base.CredentialFile), cred); err != nil {
+				return base, err
+			}
+		}
+	case CredentialBackendProcess, CredentialBackendEnv:
+		// 密钥由外部提供，无需保存
+	default:
+		// 若未关闭秘钥加密，则先加密秘钥
+		if base.DisableEncryption != "true" {
+			base.SecretKey, _ = EncryptSecret(base.SecretKey)
+			base.SecretID, _ = EncryptSecret(base.SecretID)
+			base.SessionToken, _ = EncryptSecret(base.SessionToken)
+		}
+		return base, nil
+	}
+
+	// 密钥不再保存在配置文件中
+	base.SecretID = ""
+	base.SecretKey = ""
+	base.SessionToken = ""
+	return base, nil
+}
+
+func credentialFilePath(path string) string {
+	if path == "" {
+		path = DefaultCredentialFile
+	}
+	return expandHome(path)
+}
+
+// 读取并解密凭证文件。设置了密钥环境变量时先尝试密钥，再按需读取口令
+func readCredentialFile(path string) (Credential, error) {
+	var cred Credential
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cred, fmt.Errorf("read credential file error: %v", err)
+	}
+
+	var identities []age.Identity
+	if os.Getenv(CredentialKeyEnv) != "" {
+		identity, err := credentialKeyIdentity()
+		if err != nil {
+			return cred, err
+		}
+		identities = append(identities, identity)
+	}
+	identities = append(identities, passphraseIdentity{})
+
+	r, err := age.Decrypt(armor.NewReader(bytes.NewReader(data)), identities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return cred, fmt.Errorf("decrypt credential file %s failed, wrong passphrase or key", path)
+		}
+		return cred, fmt.Errorf("decrypt credential file %s failed: %v", path, err)
+	}
+	plaintext, err := ioutil.ReadAll(r)
+	if err != nil {
+		return cred, fmt.Errorf("decrypt credential file %s failed: %v", path, err)
+	}
+	if err = json.Unmarshal(plaintext, &cred); err != nil {
+		return cred, fmt.Errorf("invalid credential file %s: %v", path, err)
+	}
+	return cred, nil
+}
+
+// 加密并写入凭证文件，文件权限为 0600。设置了密钥环境变量时加密给该密钥，否则使用口令加密
+func writeCredentialFile(path string, cred Credential) error {
+	var recipient age.Recipient
+	if os.Getenv(CredentialKeyEnv) != "" {
+		identity, err := credentialKeyIdentity()
+		if err != nil {
+			return err
+		}
+		recipient = identity.Recipient()
+	} else {
+		passphrase, err := credentialPassphrase(true)
+		if err != nil {
+			return err
+		}
+		scryptRecipient, err := age.NewScryptRecipient(passphrase)
+		if err != nil {
+			return err
+		}
+		scryptRecipient.SetWorkFactor(credentialScryptLogN)
+		recipient = scryptRecipient
+	}
+
+	plaintext, err := json.Marshal(cred)
+	if err != nil {
+		return err
+	}
+	var data bytes.Buffer
+	armorWriter := armor.NewWriter(&data)
+	w, err := age.Encrypt(armorWriter, recipient)
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(plaintext); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	if err = armorWriter.Close(); err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("write credential file error: %v", err)
+	}
+	// 先写临时文件再重命名，避免写入失败时损坏原有的凭证文件
+	tmpPath := path + ".tmp"
+	if err = ioutil.WriteFile(tmpPath, data.Bytes(), 0600); err != nil {
+		return fmt.Errorf("write credential file error: %v", err)
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("write credential file error: %v", err)
+	}
+	return nil
+}
+
+// 密钥环境变量中的 age X25519 私钥
+func credentialKeyIdentity() (*age.X25519Identity, error) {
+	identity, err := age.ParseX25519Identity(strings.TrimSpace(os.Getenv(CredentialKeyEnv)))
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an age X25519 identity(AGE-SECRET-KEY-1...), which can be generated by age-keygen", CredentialKeyEnv)
+	}
+	return identity, nil
+}
+
+// 口令对应的 age scrypt 身份，仅在凭证文件使用口令加密时才读取口令
+type passphraseIdentity struct{}
+
+func (passphraseIdentity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	scrypt := false
+	for _, stanza := range stanzas {
+		if stanza.Type == "scrypt" {
+			scrypt = true
+		}
+	}
+	if !scrypt {
+		if os.Getenv(CredentialKeyEnv) == "" {
+			return nil, fmt.Errorf("the credential file is encrypted with a key, please set %s", CredentialKeyEnv)
+		}
+		return nil, age.ErrIncorrectIdentity
+	}
+
+	passphrase, err := credentialPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return identity.Unwrap(stanzas)
+}
+
+func credentialPassphrase(confirm bool) (string, error) {
+	if passphrase := os.Getenv(CredentialPassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("the credential file is encrypted with a passphrase, please set %s", CredentialPassphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, "Input Credential Passphrase:")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(passphrase) == 0 {
+		return "", fmt.Errorf("passphrase can not be empty")
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm Credential Passphrase:")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if !bytes.Equal(passphrase, again) {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+	return string(passphrase), nil
+}
+
+// 执行 credential_process 命令，标准输出须为 {"SecretId":"","SecretKey":"","SessionToken":""} 格式的 JSON。
+// 命令的标准错误直接输出，便于命令提示用户交互
+func runCredentialProcess(command string) (Credential, error) {
+	var cred Credential
+	if strings.TrimSpace(command) == "" {
+		return cred, fmt.Errorf("credential process is empty, please set it with config set --credential_process")
+	}
+
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.Command("cmd", "/C", command)
+	} else {
+		c = exec.Command("sh", "-c", command)
+	}
+	var stdout bytes.Buffer
+	c.Stdin = os.Stdin
+	c.Stdout = &stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return cred, fmt.Errorf("run credential process error: %v", err)
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &cred); err != nil {
+		return cred, fmt.Errorf("invalid credential process output: %v", err)
+	}
+	if cred.SecretID == "" || cred.SecretKey == "" {
+		return cred, fmt.Errorf("credential process output must contain SecretId and SecretKey")
+	}
+	return cred, nil
+}
+
+func readCredentialEnv() (Credential, error) {
+	cred := Credential{
+		SecretID:     firstEnv(credentialSecretIDEnvs),
+		SecretKey:    firstEnv(credentialSecretKeyEnvs),
+		SessionToken: firstEnv(credentialSessionTokenEnvs),
+	}
+	if cred.SecretID == "" || cred.SecretKey == "" {
+		return cred, fmt.Errorf("missing secret in environment variables, please set %s and %s",
+			strings.Join(credentialSecretIDEnvs, " or "), strings.Join(credentialSecretKeyEnvs, " or "))
+	}
+	return cred, nil
+}
+
+func firstEnv(names []string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -22,6 +22,8 @@ func getDeleteKeys(srcClient, destClient *cos.Client, srcUrl StorageUrl, destUrl
 	var err error
 	srcKeys := make(map[string]string)
 	destKeys := make(map[string]string)
+	// 两方只按名称过滤。大小、修改时间等条件在两方的取值不同，来源中未选中的文件不能视为已删除
+	fo = withNameFilters(fo)
 	if srcUrl.IsFileUrl() {
 		err = getLocalFileKeys(srcUrl, srcKeys, fo)
 	} else {
@@ -63,6 +65,10 @@ func getDeleteKeys(srcClient, destClient *cos.Client, srcUrl StorageUrl, destUrl
 		}
 	}
 
+	if fo.Operation.DryRun {
+		return destKeys, nil
+	}
+
 	if destUrl.IsFileUrl() {
 		fmt.Printf("\nfile(directory) will be removed count:%d\n", len(destKeys))
 	} else {
@@ -72,6 +78,13 @@ func getDeleteKeys(srcClient, destClient *cos.Client, srcUrl StorageUrl, destUrl
 	return destKeys, nil
 }
 
+// 过滤规则只保留按名称过滤的 fo 副本
+func withNameFilters(fo *FileOperations) *FileOperations {
+	nameFo := *fo
+	nameFo.Operation.Filters = nameFilters(fo.Operation.Filters)
+	return &nameFo
+}
+
 func deleteKeys(c *cos.Client, keysToDelete map[string]string, destUrl StorageUrl, fo *FileOperations) error {
 	// 根据类型区分删除cos上的对象还是本地文件
 	if fo.CpType == CpTypeCopy || fo.CpType == CpTypeUpload {
@@ -81,11 +94,20 @@ func deleteKeys(c *cos.Client, keysToDelete map[string]string, destUrl StorageUr
 		err := DeleteLocalFiles(keysToDelete, destUrl, fo)
 		return err
 	}
-
-	return nil
 }
 
 func DeleteCosObjects(c *cos.Client, keysToDelete map[string]string, cosUrl StorageUrl, fo *FileOperations) error {
+	if fo.Operation.DryRun {
+		keys := make([]string, 0, len(keysToDelete))
+		for k, v := range keysToDelete {
+			keys = append(keys, v+k)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fo.Plan.Add(&PlanRecord{Action: PlanDelete, Source: getCosUrl(cosUrl.(*CosUrl).Bucket, key)})
+		}
+		return nil
+	}
 
 	errCount := 0
 	objects := []cos.Object{}
@@ -157,6 +179,12 @@ func DeleteCosObjects(c *cos.Client, keysToDelete map[string]string, cosUrl Stor
 }
 
 func DeleteCosObjectVersions(c *cos.Client, keysToDelete []cos.Object, cosUrl StorageUrl, fo *FileOperations) error {
+	if fo.Operation.DryRun {
+		for _, v := range keysToDelete {
+			fo.Plan.Add(&PlanRecord{Action: PlanDelete, Source: getCosUrl(cosUrl.(*CosUrl).Bucket, v.Key), VersionId: v.VersionId})
+		}
+		return nil
+	}
 
 	errCount := 0
 	objects := []cos.Object{}
@@ -274,6 +302,18 @@ func DeleteLocalFiles(keysToDelete map[string]string, fileUrl StorageUrl, fo *Fi
 		return err
 	}
 
+	if fo.Operation.DryRun {
+		for _, key := range sortList {
+			record := &PlanRecord{Action: PlanDelete, Source: absDirName + key}
+			// 删除的本地文件会移动到备份目录
+			if fo.Operation.BackupDir != "" {
+				record.Destination = fo.Operation.BackupDir + key
+			}
+			fo.Plan.Add(record)
+		}
+		return nil
+	}
+
 	nowFatherDirName := ""
 	for _, key := range sortList {
 		if strings.HasSuffix(key, string(os.PathSeparator)) {
@@ -454,7 +494,9 @@ func RemoveObjects(args []string, fo *FileOperations) error {
 		// 打印一个空行
 		fmt.Println()
 
-		if s.Header.Get("X-Cos-Bucket-Arch") == "OFS" {
+		if fo.FileList != nil {
+			err = RemoveFileListObjects(c, cosUrl, fo)
+		} else if s.Header.Get("X-Cos-Bucket-Arch") == "OFS" {
 			prefix := cosUrl.(*CosUrl).Object
 			err = RemoveOfsObjects("", c, cosUrl, prefix, fo)
 		} else {
@@ -512,7 +554,7 @@ func RemoveOfsObjects(marker string, c *cos.Client, cosUrl StorageUrl, prefix st
 		keysToDelete = make(map[string]string)
 		for _, object := range objects {
 			key, _ := url.QueryUnescape(object.Key)
-			if cosObjectMatchPatterns(key, fo.Operation.Filters) {
+			if cosObjectMetaMatchPatterns(prefix, key, object.Size, object.LastModified, object.StorageClass, fo.Operation.Filters) {
 				objPrefix := ""
 				objKey := key
 				index := strings.LastIndex(cosUrl.(*CosUrl).Object, "/")
@@ -540,7 +582,7 @@ func RemoveOfsObjects(marker string, c *cos.Client, cosUrl StorageUrl, prefix st
 			keysToDelete = make(map[string]string)
 			for _, commonPrefix := range commonPrefixes {
 				key, _ := url.QueryUnescape(commonPrefix)
-				if cosObjectMatchPatterns(key, fo.Operation.Filters) {
+				if cosObjectMatchPatterns(prefix, key, fo.Operation.Filters) {
 					objPrefix := ""
 					objKey := key
 					index := strings.LastIndex(cosUrl.(*CosUrl).Object, "/")
@@ -574,7 +616,7 @@ func RemoveCosObjects(marker string, c *cos.Client, cosUrl StorageUrl, fo *FileO
 		keysToDelete := make(map[string]string)
 		for _, object := range objects {
 			object.Key, _ = url.QueryUnescape(object.Key)
-			if cosObjectMatchPatterns(object.Key, fo.Operation.Filters) {
+			if cosObjectMetaMatchPatterns(cosUrl.(*CosUrl).Object, object.Key, object.Size, object.LastModified, object.StorageClass, fo.Operation.Filters) {
 				objPrefix := ""
 				objKey := object.Key
 				index := strings.LastIndex(cosUrl.(*CosUrl).Object, "/")
@@ -612,14 +654,14 @@ func RemoveCosObjectVersions(c *cos.Client, cosUrl StorageUrl, fo *FileOperation
 		keysToDelete := []cos.Object{}
 		for _, object := range versions {
 			object.Key, _ = url.QueryUnescape(object.Key)
-			if cosObjectMatchPatterns(object.Key, fo.Operation.Filters) {
+			if cosObjectMetaMatchPatterns(cosUrl.(*CosUrl).Object, object.Key, object.Size, object.LastModified, object.StorageClass, fo.Operation.Filters) {
 				keysToDelete = append(keysToDelete, cos.Object{Key: object.Key, VersionId: object.VersionId})
 			}
 		}
 
 		for _, object := range deleteMarkers {
 			object.Key, _ = url.QueryUnescape(object.Key)
-			if cosObjectMatchPatterns(object.Key, fo.Operation.Filters) {
+			if cosDeleteMarkerMatchPatterns(cosUrl.(*CosUrl).Object, object.Key, object.LastModified, fo.Operation.Filters) {
 				keysToDelete = append(keysToDelete, cos.Object{Key: object.Key, VersionId: object.VersionId})
 			}
 		}
@@ -633,6 +675,53 @@ func RemoveCosObjectVersions(c *cos.Client, cosUrl StorageUrl, fo *FileOperation
 	return nil
 }
 
+// RemoveFileListObjects 删除 --files-from 清单中的对象，指定了版本 ID 的项删除对应版本。
+// 使用了大小、修改时间或存储类型过滤时逐个获取对象元数据，不存在的对象记为失败
+func RemoveFileListObjects(c *cos.Client, cosUrl StorageUrl, fo *FileOperations) error {
+	prefix := cosUrl.(*CosUrl).Object
+	index := strings.LastIndex(prefix, "/")
+	checkMeta := hasMetaFilters(fo.Operation.Filters)
+
+	keysToDelete := make(map[string]string)
+	versionsToDelete := []cos.Object{}
+	for _, entry := range fo.FileList {
+		key := fileListKey(prefix, entry.Key)
+		if checkMeta {
+			object, err := headFileListObject(c, key, entry.VersionId, nil)
+			if err != nil {
+				totalDeleteErrCount++
+				if fo.Operation.FailOutput {
+					writeError(fmt.Sprintf("delete %s failed , errMsg:%v\n", key, err), fo)
+				}
+				continue
+			}
+			if !cosObjectMetaMatchPatterns(prefix, key, object.Size, object.LastModified, object.StorageClass, fo.Operation.Filters) {
+				continue
+			}
+		} else if !cosObjectMatchPatterns(prefix, key, fo.Operation.Filters) {
+			continue
+		}
+
+		if entry.VersionId != "" {
+			versionsToDelete = append(versionsToDelete, cos.Object{Key: key, VersionId: entry.VersionId})
+			continue
+		}
+		objPrefix := ""
+		objKey := key
+		if index > 0 {
+			objPrefix = key[:index+1]
+			objKey = key[index+1:]
+		}
+		keysToDelete[objKey] = objPrefix
+	}
+
+	err := DeleteCosObjects(c, keysToDelete, cosUrl, fo)
+	if err != nil {
+		return err
+	}
+	return DeleteCosObjectVersions(c, versionsToDelete, cosUrl, fo)
+}
+
 func RemoveObject(args []string, fo *FileOperations) error {
 	for _, arg := range args {
 
@@ -706,6 +795,11 @@ func RemoveObjectOrVersion(c *cos.Client, cosUrl StorageUrl, fo *FileOperations)
 		VersionId:             fo.Operation.VersionId,
 	}
 
+	if fo.Operation.DryRun {
+		fo.Plan.Add(&PlanRecord{Action: PlanDelete, Source: cosPath, VersionId: fo.Operation.VersionId})
+		return nil
+	}
+
 	if !fo.Operation.Force {
 		if fo.Operation.VersionId == "" {
 			logger.Infof("Are you sure you want to Delete object %s? (y/n)", cosPath)
@@ -29,7 +29,9 @@ func Download(c *cos.Client, cosUrl StorageUrl, fileUrl StorageUrl, fo *FileOper
 
 	fo.Monitor.init(fo.CpType)
 	chProgressSignal = make(chan chProgressSignalType, 10)
-	go progressBar(fo)
+	if !fo.Operation.DryRun {
+		go progressBar(fo)
+	}
 
 	if cosUrl.(*CosUrl).Object != "" && !strings.HasSuffix(cosUrl.(*CosUrl).Object, CosSeparator) {
 		// 单对象下载
@@ -41,7 +43,7 @@ func Download(c *cos.Client, cosUrl StorageUrl, fileUrl StorageUrl, fo *FileOper
 			relativeKey = cosUrl.(*CosUrl).Object[index+1:]
 		}
 		// 获取文件信息
-		resp, err := GetHead(c, cosUrl.(*CosUrl).Object, fo.Operation.VersionId)
+		resp, err := GetHeadWithKey(c, cosUrl.(*CosUrl).Object, fo.Operation.SSE.CustomerKey, fo.Operation.VersionId)
 		if err != nil {
 			if resp != nil && resp.StatusCode == 404 {
 				// 文件不在cos上
@@ -55,7 +57,7 @@ func Download(c *cos.Client, cosUrl StorageUrl, fileUrl StorageUrl, fo *FileOper
 		freshProgress()
 
 		// 下载文件
-		skip, err, isDir, size, _, msg := singleDownload(c, fo, objectInfoType{prefix, relativeKey, resp.ContentLength, resp.Header.Get("Last-Modified")}, cosUrl, fileUrl, fo.Operation.VersionId)
+		skip, err, isDir, size, _, msg := singleDownload(c, fo, objectInfoType{prefix, relativeKey, resp.ContentLength, resp.Header.Get("Last-Modified"), fo.Operation.VersionId}, cosUrl, fileUrl, fo.Operation.VersionId)
 		fo.Monitor.updateMonitor(skip, err, isDir, size)
 		if err != nil {
 			return fmt.Errorf("%s failed: %v", msg, err)
@@ -66,6 +68,9 @@ func Download(c *cos.Client, cosUrl StorageUrl, fileUrl StorageUrl, fo *FileOper
 	}
 
 	closeProgress()
+	if fo.Operation.DryRun {
+		return nil
+	}
 	fmt.Printf(fo.Monitor.progressBar(true, normalExit))
 
 	endT := time.Now().UnixNano() / 1000 / 1000
@@ -79,16 +84,19 @@ func batchDownloadFiles(c *cos.Client, cosUrl StorageUrl, fileUrl StorageUrl, fo
 	chError := make(chan error, fo.Operation.Routines)
 	chListError := make(chan error, 1)
 
-	if fo.BucketType == "OFS" {
+	if fo.FileList != nil {
+		// 按 --files-from 清单获取对象
+		go getObjectListFromFile(c, cosUrl, chObjects, chListError, chError, fo)
+	} else if fo.BucketType == "OFS" {
 		// 扫描ofs对象大小及数量
 		go getOfsObjectList(c, cosUrl, nil, nil, fo, true, false)
 		// 获取ofs对象列表
 		go getOfsObjectList(c, cosUrl, chObjects, chListError, fo, false, true)
 	} else {
 		// 扫描cos对象大小及数量
-		go getCosObjectList(c, cosUrl, nil, nil, fo, true, false)
+		go getCosObjectListForCheckpoint(c, cosUrl, nil, nil, fo, true, false)
 		// 获取cos对象列表
-		go getCosObjectList(c, cosUrl, chObjects, chListError, fo, false, true)
+		go getCosObjectListForCheckpoint(c, cosUrl, chObjects, chListError, fo, false, true)
 	}
 
 	for i := 0; i < fo.Operation.Routines; i++ {
@@ -119,12 +127,17 @@ func batchDownloadFiles(c *cos.Client, cosUrl StorageUrl, fileUrl StorageUrl, fo
 
 func downloadFiles(c *cos.Client, cosUrl, fileUrl StorageUrl, fo *FileOperations, chObjects <-chan objectInfoType, chError chan<- error) {
 	for object := range chObjects {
+		item := objectCheckpointItem(object)
+		if skipByCheckpoint(fo, item) {
+			continue
+		}
+
 		var skip, isDir bool
 		var err error
 		var size, transferSize int64
 		var msg string
 		for retry := 0; retry <= fo.Operation.ErrRetryNum; retry++ {
-			skip, err, isDir, size, transferSize, msg = singleDownload(c, fo, object, cosUrl, fileUrl)
+			skip, err, isDir, size, transferSize, msg = singleDownload(c, fo, object, cosUrl, fileUrl, versionIds(object.versionId)...)
 			if err == nil {
 				break // Download succeeded, break the loop
 			} else {
@@ -147,6 +160,7 @@ func downloadFiles(c *cos.Client, cosUrl, fileUrl StorageUrl, fo *FileOperations
 			chError <- fmt.Errorf("%s failed: %w", msg, err)
 			continue
 		}
+		recordCheckpoint(fo, item)
 	}
 
 	chError <- nil
@@ -167,8 +181,12 @@ func singleDownload(c *cos.Client, fo *FileOperations, objectInfo objectInfoType
 
 	// 是文件夹则直接创建并退出
 	if size == 0 && strings.HasSuffix(object, "/") {
-		rErr = os.MkdirAll(localFilePath, 0755)
 		isDir = true
+		if fo.Operation.DryRun {
+			fo.Plan.Add(&PlanRecord{Action: PlanDownload, Source: getCosUrl(cosUrl.(*CosUrl).Bucket, object), Destination: localFilePath, VersionId: objectInfo.versionId})
+			return
+		}
+		rErr = os.MkdirAll(localFilePath, 0755)
 		return
 	}
 
@@ -184,12 +202,20 @@ func singleDownload(c *cos.Client, fo *FileOperations, objectInfo objectInfoType
 			}
 
 			if skip {
+				if fo.Operation.DryRun {
+					fo.Plan.Add(&PlanRecord{Action: PlanSkip, Source: getCosUrl(cosUrl.(*CosUrl).Bucket, object), Destination: localFilePath, Size: size})
+				}
 				return
 			}
 
 		}
 	}
 
+	if fo.Operation.DryRun {
+		fo.Plan.Add(&PlanRecord{Action: PlanDownload, Source: getCosUrl(cosUrl.(*CosUrl).Bucket, object), Destination: localFilePath, Size: size, VersionId: objectInfo.versionId})
+		return
+	}
+
 	// 不是文件夹则创建父目录
 	err = createParentDirectory(localFilePath)
 	if err != nil {
@@ -197,6 +223,22 @@ func singleDownload(c *cos.Client, fo *FileOperations, objectInfo objectInfoType
 		return
 	}
 
+	// 客户端加密的对象先下载到临时文件，解密成功后再替换本地文件，缺少主密钥或解密失败时不破坏已有的文件
+	headResp, err := GetHeadWithKey(c, object, fo.Operation.SSE.CustomerKey, VersionId...)
+	if err != nil {
+		rErr = err
+		return
+	}
+	downloadPath := localFilePath
+	if isClientEncrypted(headResp.Header) {
+		if _, err = fo.Operation.ClientEncryption.cipherFromHeader(headResp.Header); err != nil {
+			rErr = err
+			return
+		}
+		downloadPath = localFilePath + cseDownloadSuffix
+		defer os.Remove(downloadPath)
+	}
+
 	// 开始下载文件
 	opt := &cos.MultiDownloadOptions{
 		Opt: &cos.ObjectGetOptions{
@@ -208,9 +250,6 @@ func singleDownload(c *cos.Client, fo *FileOperations, objectInfo objectInfoType
 			ResponseContentEncoding:    "",
 			Range:                      "",
 			IfModifiedSince:            "",
-			XCosSSECustomerAglo:        "",
-			XCosSSECustomerKey:         "",
-			XCosSSECustomerKeyMD5:      "",
 			XOptionHeader:              nil,
 			XCosTrafficLimit:           (int)(fo.Operation.RateLimiting * 1024 * 1024 * 8),
 		},
@@ -220,6 +259,7 @@ func singleDownload(c *cos.Client, fo *FileOperations, objectInfo objectInfoType
 		CheckPointFile:  "",
 		DisableChecksum: fo.Operation.DisableChecksum,
 	}
+	setGetCustomerKey(opt.Opt, fo.Operation.SSE.CustomerKey)
 	counter := &Counter{TransferSize: 0}
 	// 未跳过则通过监听更新size(仅需要分块文件的通过sdk监听进度)
 	if size > fo.Operation.PartSize*1024*1024 {
@@ -229,7 +269,7 @@ func singleDownload(c *cos.Client, fo *FileOperations, objectInfo objectInfoType
 
 	var resp *cos.Response
 
-	resp, err = c.Object.Download(context.Background(), object, localFilePath, opt, VersionId...)
+	resp, err = c.Object.Download(context.Background(), object, downloadPath, opt, VersionId...)
 
 	if err != nil {
 		if strings.HasPrefix(err.Error(), "verification failed, want:") {
@@ -239,6 +279,14 @@ func singleDownload(c *cos.Client, fo *FileOperations, objectInfo objectInfoType
 		return
 	}
 
+	// 客户端加密的对象下载后解密
+	if isClientEncrypted(resp.Header) {
+		if err = fo.Operation.ClientEncryption.decryptFile(downloadPath, localFilePath, resp.Header); err != nil {
+			rErr = err
+			return
+		}
+	}
+
 	// 下载完成记录快照信息
 	if fo.Operation.SnapshotPath != "" {
 		lastModified := resp.Header.Get("Last-Modified")
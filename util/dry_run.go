@@ -0,0 +1,109 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// dry-run 中记录的操作类型
+const (
+	PlanUpload   = "upload"
+	PlanDownload = "download"
+	PlanCopy     = "copy"
+	PlanMove     = "move"
+	PlanSkip     = "skip"
+	PlanDelete   = "delete"
+	PlanRestore  = "restore"
+	PlanAbort    = "abort"
+)
+
+// PlanRecord dry-run 中将要执行的一项操作
+type PlanRecord struct {
+	Action      string `json:"action"`
+	Source      string `json:"source"`
+	Destination string `json:"destination,omitempty"`
+	Size        int64  `json:"size"`
+	VersionId   string `json:"version_id,omitempty"`
+	UploadId    string `json:"upload_id,omitempty"`
+}
+
+func (r *PlanRecord) CsvHeader() []string {
+	return []string{"action", "source", "destination", "size", "version_id", "upload_id"}
+}
+
+func (r *PlanRecord) CsvRows() [][]string {
+	return [][]string{{r.Action, r.Source, r.Destination, strconv.FormatInt(r.Size, 10), r.VersionId, r.UploadId}}
+}
+
+// DryRunPlan 汇总 dry-run 中的操作，不发起任何修改请求
+type DryRunPlan struct {
+	mu     sync.Mutex
+	writer *RecordWriter
+	counts map[string]int
+	sizes  map[string]int64
+}
+
+// NewDryRunPlan 按 --output 指定的格式输出操作
+func NewDryRunPlan() *DryRunPlan {
+	plan := &DryRunPlan{
+		counts: make(map[string]int),
+		sizes:  make(map[string]int64),
+	}
+	if !IsTableOutput() {
+		plan.writer = NewRecordWriter(os.Stdout)
+	}
+	return plan
+}
+
+// Add 记录一项操作
+func (p *DryRunPlan) Add(record *PlanRecord) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.counts[record.Action]++
+	p.sizes[record.Action] += record.Size
+
+	if p.writer != nil {
+		p.writer.Write(record)
+		return
+	}
+	line := fmt.Sprintf("(dryrun) %s: %s", record.Action, record.Source)
+	if record.VersionId != "" {
+		line += fmt.Sprintf(" (version %s)", record.VersionId)
+	}
+	if record.UploadId != "" {
+		line += fmt.Sprintf(" (upload id %s)", record.UploadId)
+	}
+	if record.Destination != "" {
+		line += " to " + record.Destination
+	}
+	fmt.Println(line)
+}
+
+// Finish 输出各类操作的合计
+func (p *DryRunPlan) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.writer != nil {
+		p.writer.Close()
+	}
+
+	actions := make([]string, 0, len(p.counts))
+	for action := range p.counts {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	totals := make([]string, 0, len(actions))
+	for _, action := range actions {
+		totals = append(totals, fmt.Sprintf("%s %d, size: %s", action, p.counts[action], getSizeString(p.sizes[action])))
+	}
+	if len(totals) == 0 {
+		totals = append(totals, "nothing to do")
+	}
+	logger.Infof("Dry run completed, no changes were made. %s", strings.Join(totals, "; "))
+}
@@ -0,0 +1,225 @@
+package util
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// FileListEntry --files-from 清单中的一项，Key 为相对源路径的本地文件路径或对象键
+type FileListEntry struct {
+	Key       string
+	VersionId string
+}
+
+// ReadFileList 读取 --files-from 清单，path 为 - 时从标准输入读取。
+// 每行一个路径；以双引号开头或含逗号的行按 CSV 解析，第一列为路径，第二列为可选的版本 ID，
+// 路径本身含逗号时需用双引号括起。空行、# 开头的行以及 key,version_id 表头被忽略
+func ReadFileList(path string) ([]FileListEntry, error) {
+	var reader io.Reader
+	if path == "-" {
+		reader = os.Stdin
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("read files-from error: %v", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	entries := make([]FileListEntry, 0)
+	lineNum := 0
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry := FileListEntry{Key: line}
+		if strings.HasPrefix(line, "\"") || strings.Contains(line, ",") {
+			record, err := csv.NewReader(strings.NewReader(line)).Read()
+			if err != nil {
+				return nil, fmt.Errorf("invalid files-from line %d: %v", lineNum, err)
+			}
+			if len(record) > 2 {
+				return nil, fmt.Errorf("invalid files-from line %d: expect key[,version_id], got %d columns", lineNum, len(record))
+			}
+			if len(entries) == 0 && strings.EqualFold(record[0], "key") {
+				continue
+			}
+			entry.Key = record[0]
+			if len(record) == 2 {
+				entry.VersionId = strings.TrimSpace(record[1])
+			}
+		}
+		if entry.Key == "" {
+			return nil, fmt.Errorf("invalid files-from line %d: empty key", lineNum)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read files-from error: %v", err)
+	}
+	return entries, nil
+}
+
+// InitFileList 读取 --files-from 清单，清单代替列出源路径作为递归操作的文件列表。srcUrl 为本地路径时清单中不能指定版本 ID
+func InitFileList(path string, srcUrl StorageUrl, fo *FileOperations) error {
+	if path == "" {
+		return nil
+	}
+	if !fo.Operation.Recursive {
+		return fmt.Errorf("--files-from only works with --recursive")
+	}
+
+	entries, err := ReadFileList(path)
+	if err != nil {
+		return err
+	}
+	if srcUrl != nil && srcUrl.IsFileUrl() {
+		for _, entry := range entries {
+			if entry.VersionId != "" {
+				return fmt.Errorf("version id is not supported for local file %s in files-from", entry.Key)
+			}
+		}
+	}
+	fo.FileList = entries
+	return nil
+}
+
+// 清单中的对象键相对于源路径，源路径不以 / 结尾时按目录处理
+func fileListKey(prefix, key string) string {
+	if prefix != "" && !strings.HasSuffix(prefix, CosSeparator) {
+		prefix += CosSeparator
+	}
+	return prefix + strings.TrimPrefix(key, CosSeparator)
+}
+
+// 按清单生成待上传的本地文件，清单中的路径相对于源目录
+func generateFileListFromFile(localPath string, chFiles chan<- fileInfoType, chListError, chError chan<- error, fo *FileOperations) {
+	defer close(chFiles)
+	f, err := os.Stat(localPath)
+	if err == nil && !f.IsDir() {
+		err = fmt.Errorf("--files-from requires %s to be a directory", localPath)
+	}
+	if err != nil {
+		fo.Monitor.setScanError(err)
+		chListError <- err
+		return
+	}
+	if !strings.HasSuffix(localPath, string(os.PathSeparator)) {
+		localPath += string(os.PathSeparator)
+	}
+
+	for _, entry := range fo.FileList {
+		fileName := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(entry.Key, "/")))
+		filePath := filepath.Join(localPath, fileName)
+		info, err := os.Stat(filePath)
+		if err == nil && (fileName == ".." || strings.HasPrefix(fileName, ".."+string(os.PathSeparator))) {
+			err = fmt.Errorf("path is outside of %s", localPath)
+		}
+		if err != nil {
+			fo.Monitor.updateScanNum(1)
+			fo.Monitor.updateMonitor(false, err, false, 0)
+			chError <- fmt.Errorf("\nUpload %s failed: %w", filePath, err)
+			continue
+		}
+
+		if info.IsDir() {
+			if matchPatterns(fileName+string(os.PathSeparator), fo.Operation.Filters) {
+				fo.Monitor.updateScanNum(1)
+				chFiles <- fileInfoType{fileName + string(os.PathSeparator), localPath}
+			}
+			continue
+		}
+		if fileMatchPatterns(filePath, fileName, info, fo.Operation.Filters) {
+			fo.Monitor.updateScanSizeNum(info.Size(), 1)
+			chFiles <- fileInfoType{fileName, localPath}
+		}
+	}
+
+	fo.Monitor.setScanEnd()
+	freshProgress()
+	chListError <- nil
+}
+
+// 按清单生成待下载或拷贝的对象，逐个获取对象元数据，不存在的对象记为失败
+func getObjectListFromFile(c *cos.Client, cosUrl StorageUrl, chObjects chan<- objectInfoType, chListError, chError chan<- error, fo *FileOperations) {
+	defer close(chObjects)
+
+	prefix := cosUrl.(*CosUrl).Object
+	index := strings.LastIndex(prefix, "/")
+	// 下载时读取对象使用 --sse-c-key，拷贝时读取来源对象使用 --source-sse-c-key
+	customerKey := fo.Operation.SSE.CustomerKey
+	if fo.CpType == CpTypeCopy {
+		customerKey = fo.Operation.SSE.SourceCustomerKey
+	}
+	for _, entry := range fo.FileList {
+		key := fileListKey(prefix, entry.Key)
+		object, err := headFileListObject(c, key, entry.VersionId, customerKey)
+		if err != nil {
+			fo.Monitor.updateScanNum(1)
+			fo.Monitor.updateMonitor(false, err, false, 0)
+			chError <- fmt.Errorf("\nHead %s failed: %w", getCosUrl(cosUrl.(*CosUrl).Bucket, key), err)
+			continue
+		}
+		if !cosObjectMetaMatchPatterns(prefix, key, object.Size, object.LastModified, object.StorageClass, fo.Operation.Filters) {
+			continue
+		}
+
+		fo.Monitor.updateScanSizeNum(object.Size, 1)
+		objPrefix := ""
+		objKey := key
+		if index > 0 {
+			objPrefix = key[:index+1]
+			objKey = key[index+1:]
+		}
+		chObjects <- objectInfoType{objPrefix, objKey, object.Size, object.LastModified, entry.VersionId}
+	}
+
+	fo.Monitor.setScanEnd()
+	freshProgress()
+	chListError <- nil
+}
+
+// 获取清单中对象的大小、修改时间、存储类型与恢复状态
+func headFileListObject(c *cos.Client, key, versionId string, customerKey []byte) (cos.Object, error) {
+	resp, err := GetHeadWithKey(c, key, customerKey, versionIds(versionId)...)
+	if err != nil {
+		return cos.Object{}, err
+	}
+
+	object := cos.Object{
+		Key:          key,
+		Size:         resp.ContentLength,
+		StorageClass: resp.Header.Get("x-cos-storage-class"),
+		VersionId:    versionId,
+	}
+	if modTime, err := time.Parse(http.TimeFormat, resp.Header.Get("Last-Modified")); err == nil {
+		object.LastModified = modTime.UTC().Format(time.RFC3339)
+	}
+	if strings.Contains(resp.Header.Get("x-cos-restore"), "ongoing-request=\"true\"") {
+		object.RestoreStatus = "ONGOING"
+	}
+	return object, nil
+}
+
+// 版本 ID 为空时不指定版本
+func versionIds(versionId string) []string {
+	if versionId == "" {
+		return nil
+	}
+	return []string{versionId}
+}
@@ -1,10 +1,25 @@
 package util
 
 import (
+	"bufio"
+	"fmt"
+	"os"
 	"regexp"
 	"strings"
 )
 
+// 过滤规则语法
+const (
+	FilterSyntaxRegex = "regex"
+	FilterSyntaxGlob  = "glob"
+)
+
+// FilterRule 命令行中按顺序给出的一条 --include 或 --exclude 规则
+type FilterRule struct {
+	Name    string
+	Pattern string
+}
+
 func matchPatterns(filename string, filters []FilterOptionType) bool {
 	if len(filters) == 0 {
 		return true
@@ -35,76 +50,215 @@ func matchFiltersForStrs(strs []string, filters []FilterOptionType) []string {
 	return vsf
 }
 
+// 规则按顺序匹配，第一条匹配的规则决定是否包含；都不匹配时包含
 func matchFiltersForStr(str string, filters []FilterOptionType) bool {
 	if len(filters) == 0 {
 		return true
 	}
 
-	var res bool
-	if filters[0].name == IncludePrompt {
-		res = filterSingleStr(str, filters[0].pattern, true)
-	} else {
-		res = filterSingleStr(str, filters[0].pattern, false)
+	// 兼容windows路径
+	str = strings.Replace(str, "\\", "/", -1)
+	for _, filter := range filters {
+		if filter.meta != nil {
+			continue
+		}
+		if filter.re.MatchString(str) {
+			return filter.name == IncludePrompt
+		}
+	}
+	return true
+}
+
+// GetFilter 按顺序编译 --include/--exclude 规则与 --filter-from 文件中的规则。
+// syntax 为未带 glob:/regex: 前缀的规则使用的语法；只要给出了 --include，未匹配任何规则的路径即被排除
+func GetFilter(rules []FilterRule, syntax, filterFrom string) ([]FilterOptionType, error) {
+	filters := make([]FilterOptionType, 0)
+
+	if syntax == "" {
+		syntax = FilterSyntaxRegex
 	}
-	for _, filter := range filters[1:] {
-		if filter.name == IncludePrompt {
-			res = res || filterSingleStr(str, filter.pattern, true)
-		} else {
-			res = res && filterSingleStr(str, filter.pattern, false)
+	if syntax != FilterSyntaxRegex && syntax != FilterSyntaxGlob {
+		return nil, fmt.Errorf("--filter-syntax can only be selected between glob and regex")
+	}
+
+	hasInclude := false
+	for _, rule := range rules {
+		filter, err := createFilter(rule.Name, rule.Pattern, syntax)
+		if err != nil {
+			return nil, err
+		}
+		if rule.Name == IncludePrompt {
+			hasInclude = true
+		}
+		filters = append(filters, filter)
+	}
+
+	if filterFrom != "" {
+		fromFilters, err := readFilterFile(filterFrom)
+		if err != nil {
+			return nil, err
 		}
+		filters = append(filters, fromFilters...)
+	}
+
+	if hasInclude {
+		filters = append(filters, FilterOptionType{name: ExcludePrompt, pattern: "", re: regexp.MustCompile("")})
 	}
 
-	return res
+	return filters, nil
 }
 
-func filterSingleStr(v, p string, include bool) bool {
-	//_, name := filepath.Split(v)
-	res, _ := regexp.MatchString(p, v)
+func createFilter(name, pattern, syntax string) (FilterOptionType, error) {
+	var filter FilterOptionType
+	filter.name = name
+	filter.pattern = pattern
+
+	if strings.HasPrefix(pattern, FilterSyntaxGlob+":") {
+		syntax = FilterSyntaxGlob
+		pattern = strings.TrimPrefix(pattern, FilterSyntaxGlob+":")
+	} else if strings.HasPrefix(pattern, FilterSyntaxRegex+":") {
+		syntax = FilterSyntaxRegex
+		pattern = strings.TrimPrefix(pattern, FilterSyntaxRegex+":")
+	}
 
-	if include {
-		return res
+	var expr string
+	if syntax == FilterSyntaxGlob {
+		expr = globPathRegexp(pattern, false)
 	} else {
-		return !res
+		expr = strings.Replace(pattern, "[!", "[^", -1)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return filter, fmt.Errorf("invalid filter %s %s: %v", name, pattern, err)
 	}
+	filter.re = re
+	return filter, nil
 }
 
-func GetFilter(include, exclude string) (bool, []FilterOptionType) {
-	filters := make([]FilterOptionType, 0)
+// 读取 .gitignore 语法的规则文件。gitignore 中后面的规则优先，因此倒序加入以符合第一条匹配生效的顺序
+func readFilterFile(path string) ([]FilterOptionType, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read filter file error: %v", err)
+	}
+	defer file.Close()
 
-	if include != "" {
-		ok, filter := createFilter(IncludePrompt, include)
-		if !ok {
-			return false, filters
+	var filters []FilterOptionType
+	lineNum := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
 		}
-		filters = append(filters, filter)
-	}
 
-	if exclude != "" {
-		ok, filter := createFilter(ExcludePrompt, exclude)
-		if !ok {
-			return false, filters
+		name := ExcludePrompt
+		if strings.HasPrefix(line, "!") {
+			name = IncludePrompt
+			line = line[1:]
+		} else if strings.HasPrefix(line, "\\") {
+			// \# 与 \! 转义开头的字符
+			line = line[1:]
 		}
-		filters = append(filters, filter)
+
+		re, err := regexp.Compile(globPathRegexp(line, true))
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter in %s line %d: %v", path, lineNum, err)
+		}
+		filters = append([]FilterOptionType{{name: name, pattern: line, re: re}}, filters...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read filter file error: %v", err)
+	}
+	return filters, nil
+}
+
+// 将路径 glob 转换为正则，路径为相对源路径的路径：不含 / 的规则可匹配任意一级目录下的路径，
+// 以 / 开头或中间含 / 的规则从源路径开头匹配（结尾的 / 不算）。
+// gitignore 为 true 时，规则匹配目录时同样匹配目录下的所有文件，以 / 结尾的规则只匹配目录
+func globPathRegexp(pattern string, gitignore bool) string {
+	dirOnly := false
+	if gitignore && strings.HasSuffix(pattern, "/") {
+		dirOnly = true
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+
+	prefix := "^(?:.*/)?"
+	if strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		prefix = "^"
+		pattern = strings.TrimPrefix(pattern, "/")
 	}
 
-	return true, filters
+	suffix := "/?$"
+	if dirOnly {
+		suffix = "/.*$"
+	} else if gitignore {
+		suffix = "(?:/.*)?$"
+	}
+	return prefix + globToRegexp(pattern) + suffix
 }
 
-func createFilter(name, pattern string) (bool, FilterOptionType) {
-	var filter FilterOptionType
-	filter.name = name
-	filter.pattern = strings.Replace(pattern, "[!", "[^", -1)
-	//dir, _ := filepath.Split(filter.pattern)
-	//if dir != "" {
-	//	return false, filter
-	//}
-	return true, filter
+// 将 glob 转换为正则，* 与 ? 不匹配 /，** 匹配任意层目录
+func globToRegexp(pattern string) string {
+	var buf strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// **/ 匹配零或多级目录
+					i++
+					buf.WriteString("(?:.*/)?")
+				} else {
+					buf.WriteString(".*")
+				}
+			} else {
+				buf.WriteString("[^/]*")
+			}
+		case '?':
+			buf.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				buf.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			buf.WriteString("[" + strings.Replace(class, "\\", "\\\\", -1) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				buf.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return buf.String()
 }
 
-func cosObjectMatchPatterns(object string, filters []FilterOptionType) bool {
+// 对象按相对源路径的键匹配规则，prefix 为源路径
+func cosObjectMatchPatterns(prefix, object string, filters []FilterOptionType) bool {
 	if len(filters) == 0 {
 		return true
 	}
 
-	return matchPatterns(object, filters)
+	return matchPatterns(relativeFilterKey(prefix, object), filters)
+}
+
+// 去掉对象键中源路径最后一个 / 及之前的部分，与拷贝时计算目标路径的方式一致
+func relativeFilterKey(prefix, key string) string {
+	index := strings.LastIndex(prefix, CosSeparator)
+	if index < 0 || !strings.HasPrefix(key, prefix[:index+1]) {
+		return key
+	}
+	return key[index+1:]
 }
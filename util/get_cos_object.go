@@ -26,18 +26,18 @@ func GetCosKeys(c *cos.Client, cosUrl StorageUrl, keys map[string]string, fo *Fi
 
 func ReadCosKeys(keys map[string]string, cosUrl StorageUrl, chObjects <-chan objectInfoType, chFinish chan<- error) {
 	totalCount := 0
-	fmt.Printf("\n")
+	fmt.Fprintf(progressWriter(), "\n")
 	for objectInfo := range chObjects {
 		totalCount++
 		keys[objectInfo.relativeKey] = objectInfo.prefix
 		if len(keys) > MaxSyncNumbers {
-			fmt.Printf("\n")
+			fmt.Fprintf(progressWriter(), "\n")
 			chFinish <- fmt.Errorf("over max sync numbers %d", MaxSyncNumbers)
 			break
 		}
 	}
 
-	fmt.Printf("\r%s,total cos object count:%d", cosUrl.ToString(), totalCount)
+	fmt.Fprintf(progressWriter(), "\r%s,total cos object count:%d", cosUrl.ToString(), totalCount)
 	chFinish <- nil
 }
 
@@ -132,12 +132,21 @@ func CheckDeleteMarkerExist(c *cos.Client, cosUrl StorageUrl, versionId string)
 }
 
 func getCosObjectList(c *cos.Client, cosUrl StorageUrl, chObjects chan<- objectInfoType, chError chan<- error, fo *FileOperations, scanSizeNum bool, withFinishSignal bool) {
+	listCosObjects(c, cosUrl, chObjects, chError, fo, scanSizeNum, withFinishSignal, nil)
+}
+
+// 递归下载和拷贝按任务日志列举来源：续传时从日志中的列举位置继续，并在对象完成后更新列举位置
+func getCosObjectListForCheckpoint(c *cos.Client, cosUrl StorageUrl, chObjects chan<- objectInfoType, chError chan<- error, fo *FileOperations, scanSizeNum bool, withFinishSignal bool) {
+	listCosObjects(c, cosUrl, chObjects, chError, fo, scanSizeNum, withFinishSignal, fo.Checkpoint)
+}
+
+func listCosObjects(c *cos.Client, cosUrl StorageUrl, chObjects chan<- objectInfoType, chError chan<- error, fo *FileOperations, scanSizeNum bool, withFinishSignal bool, checkpoint *Checkpoint) {
 	if chObjects != nil {
 		defer close(chObjects)
 	}
 
 	prefix := cosUrl.(*CosUrl).Object
-	marker := ""
+	marker := checkpoint.listMarker(prefix)
 	limit := 0
 	delimiter := ""
 	if fo.Operation.OnlyCurrentDir {
@@ -165,9 +174,13 @@ func getCosObjectList(c *cos.Client, cosUrl StorageUrl, chObjects chan<- objectI
 			}
 
 		}
+		var page *listPage
+		if !scanSizeNum {
+			page = checkpoint.beginListPage(fo, prefix)
+		}
 		for _, object := range res.Contents {
 			object.Key, _ = url.QueryUnescape(object.Key)
-			if cosObjectMatchPatterns(object.Key, fo.Operation.Filters) {
+			if cosObjectMetaMatchPatterns(cosUrl.(*CosUrl).Object, object.Key, object.Size, object.LastModified, object.StorageClass, fo.Operation.Filters) {
 				if scanSizeNum {
 					fo.Monitor.updateScanSizeNum(object.Size, 1)
 				} else {
@@ -178,13 +191,15 @@ func getCosObjectList(c *cos.Client, cosUrl StorageUrl, chObjects chan<- objectI
 						objPrefix = object.Key[:index+1]
 						objKey = object.Key[index+1:]
 					}
-					chObjects <- objectInfoType{objPrefix, objKey, int64(object.Size), object.LastModified}
+					checkpoint.addListObject(page, object.Key)
+					chObjects <- objectInfoType{objPrefix, objKey, int64(object.Size), object.LastModified, ""}
 				}
 			}
 		}
 
 		isTruncated = res.IsTruncated
 		marker, _ = url.QueryUnescape(res.NextMarker)
+		checkpoint.endListPage(page, marker)
 	}
 
 	if scanSizeNum {
@@ -57,7 +57,7 @@ func getFileListStatistic(dpath string, fo *FileOperations) error {
 
 		if f.IsDir() {
 			if fpath != dpath {
-				if matchPatterns(filepath.Join(dpath, fileName), fo.Operation.Filters) {
+				if matchPatterns(fileName+string(os.PathSeparator), fo.Operation.Filters) {
 					fo.Monitor.updateScanNum(1)
 				}
 			}
@@ -92,7 +92,7 @@ func getFileListStatistic(dpath string, fo *FileOperations) error {
 				return nil
 			}
 		}
-		if matchPatterns(filepath.Join(dpath, fileName), fo.Operation.Filters) {
+		if fileMatchPatterns(fpath, fileName, f, fo.Operation.Filters) {
 			fo.Monitor.updateScanSizeNum(realFileSize, 1)
 		}
 		return nil
@@ -132,7 +132,7 @@ func getCurrentDirFilesStatistic(dpath string, fo *FileOperations) error {
 				// for symlink
 				continue
 			}
-			if matchPatterns(filepath.Join(dpath, fileInfo.Name()), fo.Operation.Filters) {
+			if fileMatchPatterns(filepath.Join(dpath, fileInfo.Name()), fileInfo.Name(), fileInfo, fo.Operation.Filters) {
 				fo.Monitor.updateScanSizeNum(fileInfo.Size(), 1)
 			}
 		}
@@ -186,7 +186,7 @@ func getFileList(dpath string, chFiles chan<- fileInfoType, fo *FileOperations)
 
 		if f.IsDir() {
 			if fpath != dpath {
-				if matchPatterns(filepath.Join(dpath, fileName), fo.Operation.Filters) {
+				if matchPatterns(fileName+string(os.PathSeparator), fo.Operation.Filters) {
 					if strings.HasSuffix(fileName, "\\") || strings.HasSuffix(fileName, "/") {
 						chFiles <- fileInfoType{fileName, name}
 					} else {
@@ -217,7 +217,7 @@ func getFileList(dpath string, chFiles chan<- fileInfoType, fo *FileOperations)
 			}
 		}
 
-		if matchPatterns(filepath.Join(dpath, fileName), fo.Operation.Filters) {
+		if fileMatchPatterns(fpath, fileName, f, fo.Operation.Filters) {
 			chFiles <- fileInfoType{fileName, name}
 		}
 		return nil
@@ -258,7 +258,7 @@ func getCurrentDirFileList(dpath string, chFiles chan<- fileInfoType, fo *FileOp
 				continue
 			}
 
-			if matchPatterns(filepath.Join(dpath, fileInfo.Name()), fo.Operation.Filters) {
+			if fileMatchPatterns(filepath.Join(dpath, fileInfo.Name()), fileInfo.Name(), fileInfo, fo.Operation.Filters) {
 				chFiles <- fileInfoType{fileInfo.Name(), dpath}
 			}
 		}
@@ -287,18 +287,18 @@ func getLocalFileKeys(fileUrl StorageUrl, keys map[string]string, fo *FileOperat
 
 func ReadLocalFileKeys(chFiles <-chan fileInfoType, chFinish chan<- error, keys map[string]string, fo *FileOperations) {
 	totalCount := 0
-	fmt.Printf("\n")
+	fmt.Fprintf(progressWriter(), "\n")
 	for fileInfo := range chFiles {
 		totalCount++
-		fmt.Printf("\rtotal file(directory) count:%d", totalCount)
+		fmt.Fprintf(progressWriter(), "\rtotal file(directory) count:%d", totalCount)
 		keys[fileInfo.filePath] = ""
 		if len(keys) > MaxSyncNumbers {
-			fmt.Printf("\n")
+			fmt.Fprintf(progressWriter(), "\n")
 			chFinish <- fmt.Errorf("over max sync numbers %d", MaxSyncNumbers)
 			break
 		}
 	}
-	fmt.Printf("\rtotal file(directory) count:%d", totalCount)
+	fmt.Fprintf(progressWriter(), "\rtotal file(directory) count:%d", totalCount)
 	chFinish <- nil
 }
 
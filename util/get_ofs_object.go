@@ -54,7 +54,7 @@ func getOfsObjectListRecursion(c *cos.Client, cosUrl StorageUrl, chObjects chan<
 
 		for _, object := range res.Contents {
 			object.Key, _ = url.QueryUnescape(object.Key)
-			if cosObjectMatchPatterns(object.Key, fo.Operation.Filters) {
+			if cosObjectMetaMatchPatterns(cosUrl.(*CosUrl).Object, object.Key, object.Size, object.LastModified, object.StorageClass, fo.Operation.Filters) {
 				if scanSizeNum {
 					fo.Monitor.updateScanSizeNum(object.Size, 1)
 				} else {
@@ -65,7 +65,7 @@ func getOfsObjectListRecursion(c *cos.Client, cosUrl StorageUrl, chObjects chan<
 						objPrefix = object.Key[:index+1]
 						objKey = object.Key[index+1:]
 					}
-					chObjects <- objectInfoType{objPrefix, objKey, int64(object.Size), object.LastModified}
+					chObjects <- objectInfoType{objPrefix, objKey, int64(object.Size), object.LastModified, ""}
 				}
 			}
 		}
@@ -74,7 +74,7 @@ func getOfsObjectListRecursion(c *cos.Client, cosUrl StorageUrl, chObjects chan<
 			for _, commonPrefix := range res.CommonPrefixes {
 				commonPrefix, _ = url.QueryUnescape(commonPrefix)
 
-				if cosObjectMatchPatterns(commonPrefix, fo.Operation.Filters) {
+				if cosObjectMatchPatterns(cosUrl.(*CosUrl).Object, commonPrefix, fo.Operation.Filters) {
 					if scanSizeNum {
 						fo.Monitor.updateScanSizeNum(0, 1)
 					} else {
@@ -85,7 +85,7 @@ func getOfsObjectListRecursion(c *cos.Client, cosUrl StorageUrl, chObjects chan<
 							objPrefix = commonPrefix[:index+1]
 							objKey = commonPrefix[index+1:]
 						}
-						chObjects <- objectInfoType{objPrefix, objKey, int64(0), ""}
+						chObjects <- objectInfoType{objPrefix, objKey, int64(0), "", ""}
 					}
 				}
 
@@ -2,12 +2,9 @@ package util
 
 import (
 	"context"
-	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
-	"hash"
-	"hash/crc64"
 	"io"
 	"os"
 
@@ -15,6 +12,11 @@ import (
 )
 
 func ShowHash(c *cos.Client, path string, hashType string) (h string, b string, resp *cos.Response, err error) {
+	return ShowObjectHash(c, path, hashType, "")
+}
+
+// ShowObjectHash 获取对象指定版本的哈希值，versionId 为空时获取最新版本
+func ShowObjectHash(c *cos.Client, path string, hashType string, versionId string) (h string, b string, resp *cos.Response, err error) {
 	opt := &cos.ObjectHeadOptions{
 		IfModifiedSince:       "",
 		XCosSSECustomerAglo:   "",
@@ -23,56 +25,48 @@ func ShowHash(c *cos.Client, path string, hashType string) (h string, b string,
 		XOptionHeader:         nil,
 	}
 
-	resp, err = c.Object.Head(context.Background(), path, opt)
+	algorithm, err := getChecksumAlgorithm(hashType)
+	if err != nil {
+		return "", "", nil, err
+	}
+	resp, err = c.Object.Head(context.Background(), path, opt, versionIds(versionId)...)
 	if err != nil {
 		return "", "", nil, err
 	}
 
-	switch hashType {
-	case "crc64":
-		h = resp.Header.Get("x-cos-hash-crc64ecma")
-	case "md5":
-		m := resp.Header.Get("etag")
-		h = m[1 : len(m)-1]
-
+	h, err = algorithm.object(resp.Header)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if hashType == HashSha256 && h == "" {
+		return "", "", nil, fmt.Errorf("%s has no SHA-256, upload it with --sha256 to record its SHA-256", path)
+	}
+	if algorithm.base64 {
 		encode, _ := hex.DecodeString(h)
 		b = base64.StdEncoding.EncodeToString(encode)
-	default:
-		return "", "", nil, fmt.Errorf("--type can only be selected between MD5 and CRC64")
 	}
 	return h, b, resp, nil
 }
 
 func CalculateHash(path string, hashType string) (h string, b string, err error) {
+	algorithm, err := getChecksumAlgorithm(hashType)
+	if err != nil {
+		return "", "", err
+	}
 	f, err := os.Open(path)
 	if err != nil {
 		return "", "", err
 	}
 	defer f.Close()
-	_, _ = f.Seek(0, 0)
 
-	switch hashType {
-	case "crc64":
-		ecma := crc64.New(crc64.MakeTable(crc64.ECMA))
-		w, _ := ecma.(hash.Hash)
-		if _, err := io.Copy(w, f); err != nil {
-			return "", "", err
-		}
-
-		res := ecma.Sum64()
-		h = fmt.Sprintf("%d", res)
-	case "md5":
-		m := md5.New()
-		w, _ := m.(hash.Hash)
-		if _, err := io.Copy(w, f); err != nil {
-			return "", "", err
-		}
-
-		res := m.Sum(nil)
-		h = fmt.Sprintf("%x", res)
+	w := algorithm.newHash()
+	if _, err := io.Copy(w, f); err != nil {
+		return "", "", err
+	}
+	res := w.Sum(nil)
+	h = algorithm.format(res)
+	if algorithm.base64 {
 		b = base64.StdEncoding.EncodeToString(res)
-	default:
-		return "", "", fmt.Errorf("Wrong args!")
 	}
 	return h, b, nil
 }
@@ -0,0 +1,255 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+const (
+	LifecycleStatusEnabled  = "Enabled"
+	LifecycleStatusDisabled = "Disabled"
+
+	lifecycleMaxRules    = 1000
+	lifecycleIDMaxLength = 255
+)
+
+// 生命周期可沉降到的存储类型，不能沉降为标准存储
+var lifecycleTransitionClasses = []string{
+	StandardIA, IntelligentTiering, Archive, DeepArchive,
+	MAZStandardIA, MAZIntelligentTiering, MAZArchive,
+}
+
+// LifecycleConfig 生命周期规则文件，YAML 与 JSON 使用相同的字段名
+type LifecycleConfig struct {
+	Rules []LifecycleRule `yaml:"rules" json:"rules"`
+}
+
+// LifecycleRule 一条生命周期规则，前缀与标签同时指定时需同时满足
+type LifecycleRule struct {
+	ID                                 string                `yaml:"id,omitempty" json:"id,omitempty"`
+	Status                             string                `yaml:"status,omitempty" json:"status,omitempty"`
+	Prefix                             string                `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	Tags                               []LifecycleTag        `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Transitions                        []LifecycleTransition `yaml:"transitions,omitempty" json:"transitions,omitempty"`
+	Expiration                         *LifecycleExpiration  `yaml:"expiration,omitempty" json:"expiration,omitempty"`
+	NoncurrentVersionExpirationDays    int                   `yaml:"noncurrent_version_expiration_days,omitempty" json:"noncurrent_version_expiration_days,omitempty"`
+	AbortIncompleteMultipartUploadDays int                   `yaml:"abort_incomplete_multipart_upload_days,omitempty" json:"abort_incomplete_multipart_upload_days,omitempty"`
+}
+
+type LifecycleTag struct {
+	Key   string `yaml:"key" json:"key"`
+	Value string `yaml:"value" json:"value"`
+}
+
+// LifecycleTransition 上传后经过 Days 天或到达 Date 时沉降为 StorageClass
+type LifecycleTransition struct {
+	Days         int    `yaml:"days,omitempty" json:"days,omitempty"`
+	Date         string `yaml:"date,omitempty" json:"date,omitempty"`
+	StorageClass string `yaml:"storage_class" json:"storage_class"`
+}
+
+// LifecycleExpiration 上传后经过 Days 天或到达 Date 时删除，ExpiredObjectDeleteMarker 删除过期的删除标记
+type LifecycleExpiration struct {
+	Days                      int    `yaml:"days,omitempty" json:"days,omitempty"`
+	Date                      string `yaml:"date,omitempty" json:"date,omitempty"`
+	ExpiredObjectDeleteMarker bool   `yaml:"expired_object_delete_marker,omitempty" json:"expired_object_delete_marker,omitempty"`
+}
+
+// LoadLifecycleConfig 读取并校验 YAML 或 JSON 格式的生命周期规则文件
+func LoadLifecycleConfig(path string) (*LifecycleConfig, error) {
+	var lc LifecycleConfig
+	if err := LoadConfigFile(path, &lc); err != nil {
+		return nil, err
+	}
+	return &lc, nil
+}
+
+// Validate 校验规则，未指定状态的规则默认启用
+func (lc *LifecycleConfig) Validate() error {
+	if len(lc.Rules) == 0 {
+		return fmt.Errorf("no rules")
+	}
+	if len(lc.Rules) > lifecycleMaxRules {
+		return fmt.Errorf("a bucket can have at most %d lifecycle rules", lifecycleMaxRules)
+	}
+	ids := make(map[string]bool)
+	for i := range lc.Rules {
+		rule := &lc.Rules[i]
+		name := fmt.Sprintf("rule %d", i+1)
+		if rule.ID != "" {
+			name = fmt.Sprintf("rule %s", rule.ID)
+			if len(rule.ID) > lifecycleIDMaxLength {
+				return fmt.Errorf("%s: the id must be at most %d characters", name, lifecycleIDMaxLength)
+			}
+			if ids[rule.ID] {
+				return fmt.Errorf("%s: duplicate id", name)
+			}
+			ids[rule.ID] = true
+		}
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func (rule *LifecycleRule) validate() error {
+	switch rule.Status {
+	case "":
+		rule.Status = LifecycleStatusEnabled
+	case LifecycleStatusEnabled, LifecycleStatusDisabled:
+	default:
+		return fmt.Errorf("status must be %s or %s", LifecycleStatusEnabled, LifecycleStatusDisabled)
+	}
+	tagKeys := make(map[string]bool)
+	for _, tag := range rule.Tags {
+		if tag.Key == "" {
+			return fmt.Errorf("the tag key is empty")
+		}
+		if tagKeys[tag.Key] {
+			return fmt.Errorf("duplicate tag key %s", tag.Key)
+		}
+		tagKeys[tag.Key] = true
+	}
+	if len(rule.Transitions) == 0 && rule.Expiration == nil && rule.NoncurrentVersionExpirationDays == 0 &&
+		rule.AbortIncompleteMultipartUploadDays == 0 {
+		return fmt.Errorf("at least one of transitions, expiration, noncurrent_version_expiration_days and abort_incomplete_multipart_upload_days is required")
+	}
+	for _, t := range rule.Transitions {
+		if !containsString(lifecycleTransitionClasses, t.StorageClass) {
+			return fmt.Errorf("invalid transition storage_class %s, must be one of %s", t.StorageClass, strings.Join(lifecycleTransitionClasses, ", "))
+		}
+		if err := validateLifecycleTime(t.Days, t.Date, false); err != nil {
+			return fmt.Errorf("transition to %s: %v", t.StorageClass, err)
+		}
+	}
+	if e := rule.Expiration; e != nil {
+		if err := validateLifecycleTime(e.Days, e.Date, e.ExpiredObjectDeleteMarker); err != nil {
+			return fmt.Errorf("expiration: %v", err)
+		}
+	}
+	if rule.NoncurrentVersionExpirationDays < 0 || rule.AbortIncompleteMultipartUploadDays < 0 {
+		return fmt.Errorf("days must be greater than 0")
+	}
+	return nil
+}
+
+// 天数与日期只能指定其一，optional 为 true 时可都不指定
+func validateLifecycleTime(days int, date string, optional bool) error {
+	if days < 0 {
+		return fmt.Errorf("days must be greater than 0")
+	}
+	if days > 0 && date != "" {
+		return fmt.Errorf("days and date can not be both set")
+	}
+	if days == 0 && date == "" {
+		if optional {
+			return nil
+		}
+		return fmt.Errorf("days or date is required")
+	}
+	if date != "" {
+		if _, err := time.Parse(time.RFC3339, date); err != nil {
+			return fmt.Errorf("invalid date %s, the format is like 2006-01-02T00:00:00+08:00", date)
+		}
+	}
+	return nil
+}
+
+// 转换为 SDK 的规则，只有前缀或只有一个标签时直接作为过滤条件，否则使用 And 组合
+func (rule LifecycleRule) toCos() cos.BucketLifecycleRule {
+	r := cos.BucketLifecycleRule{
+		ID:     rule.ID,
+		Status: rule.Status,
+		Filter: &cos.BucketLifecycleFilter{},
+	}
+	switch {
+	case len(rule.Tags) == 0:
+		r.Filter.Prefix = rule.Prefix
+	case len(rule.Tags) == 1 && rule.Prefix == "":
+		r.Filter.Tag = &cos.BucketTaggingTag{Key: rule.Tags[0].Key, Value: rule.Tags[0].Value}
+	default:
+		r.Filter.And = &cos.BucketLifecycleAndOperator{Prefix: rule.Prefix}
+		for _, tag := range rule.Tags {
+			r.Filter.And.Tag = append(r.Filter.And.Tag, cos.BucketTaggingTag{Key: tag.Key, Value: tag.Value})
+		}
+	}
+	for _, t := range rule.Transitions {
+		r.Transition = append(r.Transition, cos.BucketLifecycleTransition{Days: t.Days, Date: t.Date, StorageClass: t.StorageClass})
+	}
+	if e := rule.Expiration; e != nil {
+		r.Expiration = &cos.BucketLifecycleExpiration{Days: e.Days, Date: e.Date, ExpiredObjectDeleteMarker: e.ExpiredObjectDeleteMarker}
+	}
+	if rule.NoncurrentVersionExpirationDays > 0 {
+		r.NoncurrentVersionExpiration = &cos.BucketLifecycleNoncurrentVersion{NoncurrentDays: rule.NoncurrentVersionExpirationDays}
+	}
+	if rule.AbortIncompleteMultipartUploadDays > 0 {
+		r.AbortIncompleteMultipartUpload = &cos.BucketLifecycleAbortIncompleteMultipartUpload{DaysAfterInitiation: rule.AbortIncompleteMultipartUploadDays}
+	}
+	return r
+}
+
+// 从 SDK 的规则转换，文件格式不支持的字段（如按访问频率沉降）会被忽略
+func lifecycleRuleFromCos(r cos.BucketLifecycleRule) LifecycleRule {
+	rule := LifecycleRule{ID: r.ID, Status: r.Status}
+	if f := r.Filter; f != nil {
+		rule.Prefix = f.Prefix
+		if f.Tag != nil {
+			rule.Tags = append(rule.Tags, LifecycleTag{Key: f.Tag.Key, Value: f.Tag.Value})
+		}
+		if f.And != nil {
+			if f.And.Prefix != "" {
+				rule.Prefix = f.And.Prefix
+			}
+			for _, tag := range f.And.Tag {
+				rule.Tags = append(rule.Tags, LifecycleTag{Key: tag.Key, Value: tag.Value})
+			}
+		}
+	}
+	for _, t := range r.Transition {
+		rule.Transitions = append(rule.Transitions, LifecycleTransition{Days: t.Days, Date: t.Date, StorageClass: t.StorageClass})
+	}
+	if e := r.Expiration; e != nil {
+		rule.Expiration = &LifecycleExpiration{Days: e.Days, Date: e.Date, ExpiredObjectDeleteMarker: e.ExpiredObjectDeleteMarker}
+	}
+	if r.NoncurrentVersionExpiration != nil {
+		rule.NoncurrentVersionExpirationDays = r.NoncurrentVersionExpiration.NoncurrentDays
+	}
+	if r.AbortIncompleteMultipartUpload != nil {
+		rule.AbortIncompleteMultipartUploadDays = r.AbortIncompleteMultipartUpload.DaysAfterInitiation
+	}
+	return rule
+}
+
+// GetLifecycle 获取存储桶的生命周期规则
+func GetLifecycle(c *cos.Client) (*LifecycleConfig, error) {
+	res, _, err := c.Bucket.GetLifecycle(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	lc := &LifecycleConfig{}
+	for _, r := range res.Rules {
+		lc.Rules = append(lc.Rules, lifecycleRuleFromCos(r))
+	}
+	return lc, nil
+}
+
+// PutLifecycle 设置存储桶的生命周期规则，覆盖已有的规则
+func PutLifecycle(c *cos.Client, lc *LifecycleConfig) error {
+	opt := &cos.BucketPutLifecycleOptions{}
+	for _, rule := range lc.Rules {
+		opt.Rules = append(opt.Rules, rule.toCos())
+	}
+	_, err := c.Bucket.PutLifecycle(context.Background(), opt)
+	return err
+}
+
+// DeleteLifecycle 删除存储桶的所有生命周期规则
+func DeleteLifecycle(c *cos.Client) error {
+	_, err := c.Bucket.DeleteLifecycle(context.Background())
+	return err
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/olekukonko/tablewriter"
@@ -192,8 +193,7 @@ func tryGetParts(c *cos.Client, prefix, uploadId string, opt *cos.ObjectListPart
 
 // =====new
 
-func ListObjects(c *cos.Client, cosUrl StorageUrl, limit int, recursive bool, filters []FilterOptionType) error {
-	var err error
+func ListObjects(c *cos.Client, cosUrl StorageUrl, limit int, recursive bool, filters []FilterOptionType) (err error) {
 	var objects []cos.Object
 	var commonPrefixes []string
 	total := 0
@@ -206,6 +206,13 @@ func ListObjects(c *cos.Client, cosUrl StorageUrl, limit int, recursive bool, fi
 	table.SetAlignment(tablewriter.ALIGN_LEFT)
 	table.SetAutoWrapText(false)
 
+	// 结构化输出时不渲染表格
+	var rw *RecordWriter
+	if !IsTableOutput() {
+		rw = NewRecordWriter(os.Stdout)
+		defer closeRecordWriter(rw, &err)
+	}
+
 	for isTruncated && total < limit {
 		table.ClearRows()
 		queryLimit := 1000
@@ -221,8 +228,15 @@ func ListObjects(c *cos.Client, cosUrl StorageUrl, limit int, recursive bool, fi
 
 		if len(commonPrefixes) > 0 {
 			for _, commonPrefix := range commonPrefixes {
-				if cosObjectMatchPatterns(commonPrefix, filters) {
-					table.Append([]string{commonPrefix, "DIR", "", "", "", ""})
+				commonPrefix, _ = url.QueryUnescape(commonPrefix)
+				if cosObjectMatchPatterns(cosUrl.(*CosUrl).Object, commonPrefix, filters) {
+					if rw != nil {
+						if err = rw.Write(newDirRecord(commonPrefix)); err != nil {
+							return err
+						}
+					} else {
+						table.Append([]string{commonPrefix, "DIR", "", "", "", ""})
+					}
 					total++
 				}
 			}
@@ -230,7 +244,14 @@ func ListObjects(c *cos.Client, cosUrl StorageUrl, limit int, recursive bool, fi
 
 		for _, object := range objects {
 			object.Key, _ = url.QueryUnescape(object.Key)
-			if cosObjectMatchPatterns(object.Key, filters) {
+			if cosObjectMetaMatchPatterns(cosUrl.(*CosUrl).Object, object.Key, object.Size, object.LastModified, object.StorageClass, filters) {
+				if rw != nil {
+					if err = rw.Write(newObjectRecord(object)); err != nil {
+						return err
+					}
+					total++
+					continue
+				}
 				utcTime, err := time.Parse(time.RFC3339, object.LastModified)
 				if err != nil {
 					return fmt.Errorf("Error parsing time:%v", err)
@@ -240,6 +261,10 @@ func ListObjects(c *cos.Client, cosUrl StorageUrl, limit int, recursive bool, fi
 			}
 		}
 
+		if rw != nil {
+			continue
+		}
+
 		if !isTruncated || total >= limit {
 			table.SetFooter([]string{"", "", "", "", "Total Objects: ", fmt.Sprintf("%d", total)})
 			table.Render()
@@ -257,8 +282,7 @@ func ListObjects(c *cos.Client, cosUrl StorageUrl, limit int, recursive bool, fi
 	return nil
 }
 
-func ListObjectVersions(c *cos.Client, cosUrl StorageUrl, limit int, recursive bool, filters []FilterOptionType) error {
-	var err error
+func ListObjectVersions(c *cos.Client, cosUrl StorageUrl, limit int, recursive bool, filters []FilterOptionType) (err error) {
 	var versions []cos.ListVersionsResultVersion
 	var deleteMarkers []cos.ListVersionsResultDeleteMarker
 	var commonPrefixes []string
@@ -273,6 +297,12 @@ func ListObjectVersions(c *cos.Client, cosUrl StorageUrl, limit int, recursive b
 	table.SetAlignment(tablewriter.ALIGN_LEFT)
 	table.SetAutoWrapText(false)
 
+	var rw *RecordWriter
+	if !IsTableOutput() {
+		rw = NewRecordWriter(os.Stdout)
+		defer closeRecordWriter(rw, &err)
+	}
+
 	for isTruncated && total < limit {
 		table.ClearRows()
 		queryLimit := 1000
@@ -288,8 +318,15 @@ func ListObjectVersions(c *cos.Client, cosUrl StorageUrl, limit int, recursive b
 
 		if len(commonPrefixes) > 0 {
 			for _, commonPrefix := range commonPrefixes {
-				if cosObjectMatchPatterns(commonPrefix, filters) {
-					table.Append([]string{commonPrefix, "DIR", "", "", "", "", "", ""})
+				commonPrefix, _ = url.QueryUnescape(commonPrefix)
+				if cosObjectMatchPatterns(cosUrl.(*CosUrl).Object, commonPrefix, filters) {
+					if rw != nil {
+						if err = rw.Write(newDirRecord(commonPrefix)); err != nil {
+							return err
+						}
+					} else {
+						table.Append([]string{commonPrefix, "DIR", "", "", "", "", "", ""})
+					}
 					total++
 				}
 			}
@@ -297,7 +334,25 @@ func ListObjectVersions(c *cos.Client, cosUrl StorageUrl, limit int, recursive b
 
 		for _, object := range versions {
 			object.Key, _ = url.QueryUnescape(object.Key)
-			if cosObjectMatchPatterns(object.Key, filters) {
+			if cosObjectMetaMatchPatterns(cosUrl.(*CosUrl).Object, object.Key, object.Size, object.LastModified, object.StorageClass, filters) {
+				if rw != nil {
+					isLatest := object.IsLatest
+					err = rw.Write(&ObjectRecord{
+						Key:          object.Key,
+						Type:         "object",
+						Size:         object.Size,
+						ETag:         strings.Trim(object.ETag, "\""),
+						StorageClass: object.StorageClass,
+						LastModified: object.LastModified,
+						VersionId:    object.VersionId,
+						IsLatest:     &isLatest,
+					})
+					if err != nil {
+						return err
+					}
+					total++
+					continue
+				}
 				utcTime, err := time.Parse(time.RFC3339, object.LastModified)
 				if err != nil {
 					return fmt.Errorf("Error parsing time:%v", err)
@@ -310,7 +365,23 @@ func ListObjectVersions(c *cos.Client, cosUrl StorageUrl, limit int, recursive b
 
 		for _, object := range deleteMarkers {
 			object.Key, _ = url.QueryUnescape(object.Key)
-			if cosObjectMatchPatterns(object.Key, filters) {
+			if cosDeleteMarkerMatchPatterns(cosUrl.(*CosUrl).Object, object.Key, object.LastModified, filters) {
+				if rw != nil {
+					isLatest := object.IsLatest
+					err = rw.Write(&ObjectRecord{
+						Key:          object.Key,
+						Type:         "object",
+						LastModified: object.LastModified,
+						VersionId:    object.VersionId,
+						IsLatest:     &isLatest,
+						DeleteMarker: true,
+					})
+					if err != nil {
+						return err
+					}
+					total++
+					continue
+				}
 				utcTime, err := time.Parse(time.RFC3339, object.LastModified)
 				if err != nil {
 					return fmt.Errorf("Error parsing time:%v", err)
@@ -320,6 +391,10 @@ func ListObjectVersions(c *cos.Client, cosUrl StorageUrl, limit int, recursive b
 			}
 		}
 
+		if rw != nil {
+			continue
+		}
+
 		if !isTruncated || total >= limit {
 			table.SetFooter([]string{"", "", "", "", "", "", "Total Objects: ", fmt.Sprintf("%d", total)})
 			table.Render()
@@ -337,7 +412,7 @@ func ListObjectVersions(c *cos.Client, cosUrl StorageUrl, limit int, recursive b
 	return nil
 }
 
-func ListOfsObjects(c *cos.Client, cosUrl StorageUrl, limit int, recursive bool, filters []FilterOptionType) error {
+func ListOfsObjects(c *cos.Client, cosUrl StorageUrl, limit int, recursive bool, filters []FilterOptionType) (err error) {
 	lsCounter := &LsCounter{}
 	prefix := cosUrl.(*CosUrl).Object
 
@@ -346,18 +421,27 @@ func ListOfsObjects(c *cos.Client, cosUrl StorageUrl, limit int, recursive bool,
 	lsCounter.Table.SetBorder(false)
 	lsCounter.Table.SetAlignment(tablewriter.ALIGN_LEFT)
 	lsCounter.Table.SetAutoWrapText(false)
+	if !IsTableOutput() {
+		lsCounter.Writer = NewRecordWriter(os.Stdout)
+		defer closeRecordWriter(lsCounter.Writer, &err)
+	}
 
-	err := getOfsObjects(c, prefix, limit, recursive, filters, "", lsCounter)
+	err = getOfsObjects(c, prefix, prefix, limit, recursive, filters, "", lsCounter)
 	if err != nil {
 		return err
 	}
 
+	if lsCounter.Writer != nil {
+		return nil
+	}
+
 	lsCounter.Table.SetFooter([]string{"", "", "", "", "Total Objects: ", fmt.Sprintf("%d", lsCounter.TotalLimit)})
 	lsCounter.Table.Render()
 	return nil
 }
 
-func getOfsObjects(c *cos.Client, prefix string, limit int, recursive bool, filters []FilterOptionType, marker string, lsCounter *LsCounter) error {
+// root 为列出的源路径，过滤规则按相对它的路径匹配
+func getOfsObjects(c *cos.Client, root, prefix string, limit int, recursive bool, filters []FilterOptionType, marker string, lsCounter *LsCounter) error {
 	var err error
 	var objects []cos.Object
 	var commonPrefixes []string
@@ -382,7 +466,7 @@ func getOfsObjects(c *cos.Client, prefix string, limit int, recursive bool, filt
 
 		for _, object := range objects {
 			object.Key, _ = url.QueryUnescape(object.Key)
-			if cosObjectMatchPatterns(object.Key, filters) {
+			if cosObjectMetaMatchPatterns(root, object.Key, object.Size, object.LastModified, object.StorageClass, filters) {
 				utcTime, err := time.Parse(time.RFC3339, object.LastModified)
 				if err != nil {
 					return fmt.Errorf("Error parsing time:%v", err)
@@ -391,6 +475,12 @@ func getOfsObjects(c *cos.Client, prefix string, limit int, recursive bool, filt
 					break
 				}
 				lsCounter.TotalLimit++
+				if lsCounter.Writer != nil {
+					if err = lsCounter.Writer.Write(newObjectRecord(object)); err != nil {
+						return err
+					}
+					continue
+				}
 				lsCounter.RenderNum++
 				lsCounter.Table.Append([]string{object.Key, object.StorageClass, utcTime.Local().Format(time.RFC3339), object.ETag, formatBytes(float64(object.Size)), object.RestoreStatus})
 				tableRender(lsCounter)
@@ -403,15 +493,21 @@ func getOfsObjects(c *cos.Client, prefix string, limit int, recursive bool, filt
 				if lsCounter.TotalLimit >= limit {
 					break
 				}
-				if cosObjectMatchPatterns(commonPrefix, filters) {
+				if cosObjectMatchPatterns(root, commonPrefix, filters) {
 					lsCounter.TotalLimit++
-					lsCounter.RenderNum++
-					lsCounter.Table.Append([]string{commonPrefix, "DIR", "", "", "", ""})
-					tableRender(lsCounter)
+					if lsCounter.Writer != nil {
+						if err = lsCounter.Writer.Write(newDirRecord(commonPrefix)); err != nil {
+							return err
+						}
+					} else {
+						lsCounter.RenderNum++
+						lsCounter.Table.Append([]string{commonPrefix, "DIR", "", "", "", ""})
+						tableRender(lsCounter)
+					}
 				}
 				if recursive {
 					// 递归目录
-					err = getOfsObjects(c, commonPrefix, limit, recursive, filters, "", lsCounter)
+					err = getOfsObjects(c, root, commonPrefix, limit, recursive, filters, "", lsCounter)
 					if err != nil {
 						return err
 					}
@@ -435,22 +531,32 @@ func tableRender(lsCounter *LsCounter) {
 	}
 }
 
-func ListBuckets(c *cos.Client, limit int) error {
+func ListBuckets(c *cos.Client, limit int) (err error) {
 	var buckets []cos.Bucket
 	marker := ""
 	isTruncated := true
 	totalNum := 0
-	var err error
 
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader([]string{"Bucket Name", "Region", "Create Date"})
+	var rw *RecordWriter
+	if !IsTableOutput() {
+		rw = NewRecordWriter(os.Stdout)
+		defer closeRecordWriter(rw, &err)
+	}
 	for isTruncated {
 		buckets, marker, isTruncated, err = GetBucketsList(c, limit, marker)
 		if err != nil {
 			return err
 		}
 		for _, b := range buckets {
-			table.Append([]string{b.Name, b.Region, b.CreationDate})
+			if rw != nil {
+				if err = rw.Write(&BucketRecord{Name: b.Name, Region: b.Region, CreationDate: b.CreationDate}); err != nil {
+					return err
+				}
+			} else {
+				table.Append([]string{b.Name, b.Region, b.CreationDate})
+			}
 			totalNum++
 		}
 		if limit > 0 {
@@ -458,6 +564,10 @@ func ListBuckets(c *cos.Client, limit int) error {
 		}
 	}
 
+	if rw != nil {
+		return nil
+	}
+
 	table.SetFooter([]string{"", "Total Buckets: ", fmt.Sprintf("%d", totalNum)})
 	table.SetBorder(false)
 	table.Render()
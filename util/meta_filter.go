@@ -0,0 +1,278 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetaFilterOptions --min-size、--max-size、--newer-than、--older-than 与 --storage-class-filter 的取值
+type MetaFilterOptions struct {
+	MinSize        string
+	MaxSize        string
+	NewerThan      string
+	OlderThan      string
+	StorageClasses []string
+}
+
+// metaFilter 按大小、修改时间与存储类型过滤，各条件同时满足才包含
+type metaFilter struct {
+	minSize        int64
+	maxSize        int64
+	newerThan      time.Time
+	olderThan      time.Time
+	storageClasses map[string]bool
+}
+
+// GetMetaFilter 解析元数据过滤条件，未给出任何条件时返回空列表
+func GetMetaFilter(opt MetaFilterOptions) ([]FilterOptionType, error) {
+	meta := &metaFilter{minSize: -1, maxSize: -1}
+	now := time.Now()
+	var err error
+	hasMeta := false
+
+	if opt.MinSize != "" {
+		if meta.minSize, err = parseSizeFilter(opt.MinSize); err != nil {
+			return nil, fmt.Errorf("invalid --min-size %s: %v", opt.MinSize, err)
+		}
+		hasMeta = true
+	}
+	if opt.MaxSize != "" {
+		if meta.maxSize, err = parseSizeFilter(opt.MaxSize); err != nil {
+			return nil, fmt.Errorf("invalid --max-size %s: %v", opt.MaxSize, err)
+		}
+		hasMeta = true
+	}
+	if meta.minSize >= 0 && meta.maxSize >= 0 && meta.minSize > meta.maxSize {
+		return nil, fmt.Errorf("--min-size can not be greater than --max-size")
+	}
+
+	if opt.NewerThan != "" {
+		if meta.newerThan, err = parseTimeFilter(opt.NewerThan, now); err != nil {
+			return nil, fmt.Errorf("invalid --newer-than %s: %v", opt.NewerThan, err)
+		}
+		hasMeta = true
+	}
+	if opt.OlderThan != "" {
+		if meta.olderThan, err = parseTimeFilter(opt.OlderThan, now); err != nil {
+			return nil, fmt.Errorf("invalid --older-than %s: %v", opt.OlderThan, err)
+		}
+		hasMeta = true
+	}
+	if !meta.newerThan.IsZero() && !meta.olderThan.IsZero() && !meta.newerThan.Before(meta.olderThan) {
+		return nil, fmt.Errorf("--newer-than must be earlier than --older-than")
+	}
+
+	for _, class := range opt.StorageClasses {
+		class = strings.ToUpper(strings.TrimSpace(class))
+		if class == "" {
+			continue
+		}
+		if meta.storageClasses == nil {
+			meta.storageClasses = make(map[string]bool)
+		}
+		meta.storageClasses[class] = true
+		hasMeta = true
+	}
+
+	if !hasMeta {
+		return nil, nil
+	}
+	return []FilterOptionType{{name: ExcludePrompt, meta: meta}}, nil
+}
+
+// 去掉按大小、修改时间和存储类型的过滤条件，只保留按名称的过滤规则
+func nameFilters(filters []FilterOptionType) []FilterOptionType {
+	result := make([]FilterOptionType, 0, len(filters))
+	for _, filter := range filters {
+		if filter.meta == nil {
+			result = append(result, filter)
+		}
+	}
+	return result
+}
+
+// 解析大小，支持 B、K/KB/KiB、M/MB/MiB、G/GB/GiB、T/TB/TiB 后缀，单位均按 1024 进制计算
+func parseSizeFilter(str string) (int64, error) {
+	str = strings.TrimSpace(str)
+	units := []struct {
+		suffixes []string
+		multiple float64
+	}{
+		{[]string{"TIB", "TB", "T"}, 1024 * 1024 * 1024 * 1024},
+		{[]string{"GIB", "GB", "G"}, 1024 * 1024 * 1024},
+		{[]string{"MIB", "MB", "M"}, 1024 * 1024},
+		{[]string{"KIB", "KB", "K"}, 1024},
+		{[]string{"B"}, 1},
+	}
+
+	upper := strings.ToUpper(str)
+	multiple := float64(1)
+	number := upper
+	for _, unit := range units {
+		found := false
+		for _, suffix := range unit.suffixes {
+			if strings.HasSuffix(upper, suffix) {
+				number = strings.TrimSpace(strings.TrimSuffix(upper, suffix))
+				multiple = unit.multiple
+				found = true
+				break
+			}
+		}
+		if found {
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("size must be a non-negative number with an optional unit, such as 100, 10KB, 1.5GiB")
+	}
+	return int64(value * multiple), nil
+}
+
+// 解析时间，支持相对当前时间的时长（如 30m、12h、7d、2w）和时间戳（RFC3339、2006-01-02T15:04:05、2006-01-02）
+func parseTimeFilter(str string, now time.Time) (time.Time, error) {
+	str = strings.TrimSpace(str)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, str, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	duration, err := parseDurationFilter(str)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("time must be a duration such as 30m, 12h, 7d, 2w or a timestamp such as 2006-01-02T15:04:05Z")
+	}
+	return now.Add(-duration), nil
+}
+
+// 在 time.ParseDuration 的基础上支持 d（天）与 w（周）
+func parseDurationFilter(str string) (time.Duration, error) {
+	days := float64(0)
+	if strings.HasSuffix(str, "d") || strings.HasSuffix(str, "w") {
+		value, err := strconv.ParseFloat(str[:len(str)-1], 64)
+		if err != nil {
+			return 0, err
+		}
+		days = value
+		if strings.HasSuffix(str, "w") {
+			days = value * 7
+		}
+		str = ""
+	}
+
+	var duration time.Duration
+	if str != "" {
+		var err error
+		if duration, err = time.ParseDuration(str); err != nil {
+			return 0, err
+		}
+	}
+	duration += time.Duration(days * float64(24*time.Hour))
+	if duration < 0 {
+		return 0, fmt.Errorf("duration can not be negative")
+	}
+	return duration, nil
+}
+
+// modTime 为零值、storageClass 为空时不判断对应条件
+func (m *metaFilter) match(size int64, modTime time.Time, storageClass string) bool {
+	if m.minSize >= 0 && size < m.minSize {
+		return false
+	}
+	if m.maxSize >= 0 && size > m.maxSize {
+		return false
+	}
+	if !modTime.IsZero() {
+		if !m.newerThan.IsZero() && !modTime.After(m.newerThan) {
+			return false
+		}
+		if !m.olderThan.IsZero() && !modTime.Before(m.olderThan) {
+			return false
+		}
+	}
+	if storageClass != "" && m.storageClasses != nil && !m.storageClasses[strings.ToUpper(storageClass)] {
+		return false
+	}
+	return true
+}
+
+// 是否给出了元数据过滤条件
+func hasMetaFilters(filters []FilterOptionType) bool {
+	for _, filter := range filters {
+		if filter.meta != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func matchMetaFilters(size int64, modTime time.Time, storageClass string, filters []FilterOptionType) bool {
+	for _, filter := range filters {
+		if filter.meta != nil && !filter.meta.match(size, modTime, storageClass) {
+			return false
+		}
+	}
+	return true
+}
+
+// 按名称与元数据过滤对象，prefix 为源路径，lastModified 为列出对象时返回的时间，storageClass 为空时按标准存储处理
+func cosObjectMetaMatchPatterns(prefix, key string, size int64, lastModified, storageClass string, filters []FilterOptionType) bool {
+	if !cosObjectMatchPatterns(prefix, key, filters) {
+		return false
+	}
+	modTime := parseLastModified(lastModified)
+	if storageClass == "" {
+		storageClass = Standard
+	}
+	return matchMetaFilters(size, modTime, storageClass, filters)
+}
+
+// 解析对象的修改时间，兼容列出对象时的 RFC3339 格式与 HEAD 响应头中的格式，无法解析时返回零值
+func parseLastModified(lastModified string) time.Time {
+	modTime, err := time.Parse(time.RFC3339, lastModified)
+	if err != nil {
+		modTime, err = time.Parse(http.TimeFormat, lastModified)
+		if err != nil {
+			return time.Time{}
+		}
+	}
+	return modTime
+}
+
+// 删除标记没有大小和存储类型，给出了大小或存储类型条件时不包含删除标记
+func cosDeleteMarkerMatchPatterns(prefix, key string, lastModified string, filters []FilterOptionType) bool {
+	if !cosObjectMatchPatterns(prefix, key, filters) {
+		return false
+	}
+	modTime := parseLastModified(lastModified)
+	for _, filter := range filters {
+		if filter.meta == nil {
+			continue
+		}
+		if filter.meta.minSize >= 0 || filter.meta.maxSize >= 0 || filter.meta.storageClasses != nil {
+			return false
+		}
+		if !filter.meta.match(0, modTime, "") {
+			return false
+		}
+	}
+	return true
+}
+
+// 按名称与元数据过滤本地文件，name 为相对源目录的路径，软链文件按其指向的文件判断，本地文件不判断存储类型
+func fileMatchPatterns(path, name string, info os.FileInfo, filters []FilterOptionType) bool {
+	if !matchPatterns(name, filters) {
+		return false
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		if realInfo, err := os.Stat(path); err == nil {
+			info = realInfo
+		}
+	}
+	return matchMetaFilters(info.Size(), info.ModTime(), "", filters)
+}
@@ -0,0 +1,189 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+const (
+	TaggingDirectiveCopy    = "copy"
+	TaggingDirectiveReplace = "replace"
+
+	ObjectTaggingPut    = "put"
+	ObjectTaggingGet    = "get"
+	ObjectTaggingDelete = "delete"
+
+	objectTagMaxNum         = 10
+	objectTagKeyMaxLength   = 128
+	objectTagValueMaxLength = 256
+	taggingHeader           = "x-cos-tagging"
+	taggingDirectiveHeader  = "x-cos-tagging-directive"
+	taggingCountHeader      = "x-cos-tagging-count"
+)
+
+// TaggingOptions 上传或拷贝时设置的对象标签，Directive 为 replace 时拷贝使用 Tags 替换来源对象的标签
+type TaggingOptions struct {
+	Tags      []cos.ObjectTaggingTag
+	Directive string
+}
+
+// ParseObjectTags 解析 k=v&k2=v2 格式的对象标签，键和值可使用 URL 编码
+func ParseObjectTags(tags string) ([]cos.ObjectTaggingTag, error) {
+	values, err := url.ParseQuery(tags)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tags %s: %v", tags, err)
+	}
+	if len(values) > objectTagMaxNum {
+		return nil, fmt.Errorf("an object can have at most %d tags", objectTagMaxNum)
+	}
+	var result []cos.ObjectTaggingTag
+	for key, value := range values {
+		if key == "" {
+			return nil, fmt.Errorf("invalid tags %s: the tag key is empty", tags)
+		}
+		if len(value) > 1 {
+			return nil, fmt.Errorf("invalid tags %s: duplicate tag key %s", tags, key)
+		}
+		if len(key) > objectTagKeyMaxLength || len(value[0]) > objectTagValueMaxLength {
+			return nil, fmt.Errorf("invalid tags %s: the tag key must be at most %d characters and the value at most %d characters",
+				tags, objectTagKeyMaxLength, objectTagValueMaxLength)
+		}
+		result = append(result, cos.ObjectTaggingTag{Key: key, Value: value[0]})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+// 生成 x-cos-tagging 请求头的值
+func encodeObjectTags(tags []cos.ObjectTaggingTag) string {
+	values := url.Values{}
+	for _, tag := range tags {
+		values.Set(tag.Key, tag.Value)
+	}
+	return values.Encode()
+}
+
+// 在请求头中设置对象标签，header 为空时新建
+func setTaggingHeader(header *http.Header, tags []cos.ObjectTaggingTag) *http.Header {
+	if len(tags) == 0 {
+		return header
+	}
+	if header == nil {
+		header = &http.Header{}
+	}
+	header.Set(taggingHeader, encodeObjectTags(tags))
+	return header
+}
+
+// 设置上传对象的标签
+func (t TaggingOptions) setPutHeader(header *cos.ObjectPutHeaderOptions) {
+	header.XOptionHeader = setTaggingHeader(header.XOptionHeader, t.Tags)
+}
+
+// 设置服务端拷贝目标对象的标签。单次拷贝通过 x-cos-tagging-directive 保留或替换来源对象的标签，
+// 分块拷贝不会保留来源对象的标签，需读取后重新设置
+func (t TaggingOptions) setCopyHeader(srcClient *cos.Client, object string, size int64, header *cos.ObjectCopyHeaderOptions, VersionId ...string) error {
+	if size <= copyMaxSingleSize {
+		if t.Directive == TaggingDirectiveReplace {
+			if header.XOptionHeader == nil {
+				header.XOptionHeader = &http.Header{}
+			}
+			header.XOptionHeader.Set(taggingDirectiveHeader, "Replaced")
+			header.XOptionHeader = setTaggingHeader(header.XOptionHeader, t.Tags)
+		}
+		return nil
+	}
+
+	tags := t.Tags
+	if t.Directive != TaggingDirectiveReplace {
+		var err error
+		if tags, err = GetObjectTags(srcClient, object, VersionId...); err != nil {
+			return err
+		}
+	}
+	header.XOptionHeader = setTaggingHeader(header.XOptionHeader, tags)
+	return nil
+}
+
+// 设置读取后上传的拷贝目标对象的标签，来源对象有标签时读取后设置
+func (t TaggingOptions) setStreamCopyHeader(srcClient *cos.Client, object string, src http.Header, header *cos.ObjectPutHeaderOptions, VersionId ...string) error {
+	tags := t.Tags
+	if t.Directive != TaggingDirectiveReplace {
+		if count, _ := strconv.Atoi(src.Get(taggingCountHeader)); count == 0 {
+			return nil
+		}
+		var err error
+		if tags, err = GetObjectTags(srcClient, object, VersionId...); err != nil {
+			return err
+		}
+	}
+	header.XOptionHeader = setTaggingHeader(header.XOptionHeader, tags)
+	return nil
+}
+
+// GetObjectTags 获取对象的标签
+func GetObjectTags(c *cos.Client, object string, id ...string) ([]cos.ObjectTaggingTag, error) {
+	var opt []interface{}
+	for _, versionId := range id {
+		opt = append(opt, versionId)
+	}
+	res, _, err := c.Object.GetTagging(context.Background(), object, opt...)
+	if err != nil {
+		return nil, err
+	}
+	return res.TagSet, nil
+}
+
+// PutObjectTags 设置对象的标签，覆盖已有的标签
+func PutObjectTags(c *cos.Client, object string, tags []cos.ObjectTaggingTag, id ...string) error {
+	_, err := c.Object.PutTagging(context.Background(), object, &cos.ObjectPutTaggingOptions{TagSet: tags}, id...)
+	return err
+}
+
+// DeleteObjectTags 删除对象的所有标签
+func DeleteObjectTags(c *cos.Client, object string, id ...string) error {
+	var opt []interface{}
+	for _, versionId := range id {
+		opt = append(opt, versionId)
+	}
+	_, err := c.Object.DeleteTagging(context.Background(), object, opt...)
+	return err
+}
+
+// ObjectTagsResult 批量获取的对象标签
+type ObjectTagsResult struct {
+	Key  string
+	Tags []cos.ObjectTaggingTag
+}
+
+// ObjectTaggingObjects 对前缀下符合过滤条件的对象并发执行标签操作，method 为 put、get 或 delete，
+// get 时返回按对象键排序的标签
+func ObjectTaggingObjects(c *cos.Client, cosUrl StorageUrl, method string, tags []cos.ObjectTaggingTag, fo *FileOperations) ([]ObjectTagsResult, error) {
+	var results []ObjectTagsResult
+	var mu sync.Mutex
+	err := processObjects(c, cosUrl, fo, method+" tagging", func(object objectInfoType, key string) error {
+		switch method {
+		case ObjectTaggingPut:
+			return PutObjectTags(c, key, tags)
+		case ObjectTaggingDelete:
+			return DeleteObjectTags(c, key)
+		}
+		objectTags, err := GetObjectTags(c, key)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		results = append(results, ObjectTagsResult{Key: key, Tags: objectTags})
+		mu.Unlock()
+		return nil
+	})
+	sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+	return results, err
+}
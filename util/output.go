@@ -0,0 +1,412 @@
+package util
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// 输出格式
+const (
+	OutputTable = "table"
+	OutputJson  = "json"
+	OutputJsonl = "jsonl"
+	OutputCsv   = "csv"
+)
+
+var outputFormat = OutputTable
+
+// SetOutputFormat 设置 ls、du 等命令的输出格式
+func SetOutputFormat(format string) error {
+	format = strings.ToLower(format)
+	switch format {
+	case "":
+		format = OutputTable
+	case OutputTable, OutputJson, OutputJsonl, OutputCsv:
+	default:
+		return fmt.Errorf("--output can only be selected between json, jsonl, csv and table")
+	}
+	outputFormat = format
+	return nil
+}
+
+// IsTableOutput 是否以表格形式输出
+func IsTableOutput() bool {
+	return outputFormat == OutputTable
+}
+
+// 进度等提示信息的输出位置，结构化输出时写到 stderr，避免与结果混在一起
+func progressWriter() io.Writer {
+	if IsTableOutput() {
+		return os.Stdout
+	}
+	return os.Stderr
+}
+
+// OutputRecord 结构化输出的记录，json/jsonl 直接序列化，csv 使用表头与行
+type OutputRecord interface {
+	CsvHeader() []string
+	CsvRows() [][]string
+}
+
+// ObjectRecord 对象、对象版本或目录，IsLatest 只在列出对象版本时设置
+type ObjectRecord struct {
+	Key           string `json:"key"`
+	Type          string `json:"type"`
+	Size          int64  `json:"size"`
+	ETag          string `json:"etag"`
+	StorageClass  string `json:"storage_class"`
+	LastModified  string `json:"last_modified"`
+	VersionId     string `json:"version_id"`
+	IsLatest      *bool  `json:"is_latest,omitempty"`
+	DeleteMarker  bool   `json:"delete_marker"`
+	RestoreStatus string `json:"restore_status"`
+}
+
+func (r *ObjectRecord) CsvHeader() []string {
+	return []string{"key", "type", "size", "etag", "storage_class", "last_modified", "version_id", "is_latest", "delete_marker", "restore_status"}
+}
+
+func (r *ObjectRecord) CsvRows() [][]string {
+	return [][]string{{r.Key, r.Type, strconv.FormatInt(r.Size, 10), r.ETag, r.StorageClass, r.LastModified, r.VersionId,
+		formatOptionalBool(r.IsLatest), strconv.FormatBool(r.DeleteMarker), r.RestoreStatus}}
+}
+
+// 未设置时输出空值
+func formatOptionalBool(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
+
+// UploadRecord 分块上传任务
+type UploadRecord struct {
+	Key          string `json:"key"`
+	UploadId     string `json:"upload_id"`
+	StorageClass string `json:"storage_class"`
+	Initiated    string `json:"initiated"`
+}
+
+func (r *UploadRecord) CsvHeader() []string {
+	return []string{"key", "upload_id", "storage_class", "initiated"}
+}
+
+func (r *UploadRecord) CsvRows() [][]string {
+	return [][]string{{r.Key, r.UploadId, r.StorageClass, r.Initiated}}
+}
+
+// PartRecord 已上传的分块
+type PartRecord struct {
+	PartNumber   int    `json:"part_number"`
+	Size         int64  `json:"size"`
+	ETag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+}
+
+func (r *PartRecord) CsvHeader() []string {
+	return []string{"part_number", "size", "etag", "last_modified"}
+}
+
+func (r *PartRecord) CsvRows() [][]string {
+	return [][]string{{strconv.Itoa(r.PartNumber), strconv.FormatInt(r.Size, 10), r.ETag, r.LastModified}}
+}
+
+// BucketRecord 存储桶
+type BucketRecord struct {
+	Name         string `json:"name"`
+	Region       string `json:"region"`
+	CreationDate string `json:"creation_date"`
+}
+
+func (r *BucketRecord) CsvHeader() []string {
+	return []string{"name", "region", "creation_date"}
+}
+
+func (r *BucketRecord) CsvRows() [][]string {
+	return [][]string{{r.Name, r.Region, r.CreationDate}}
+}
+
+// StorageClassStat 按存储类型统计的对象数与大小
+type StorageClassStat struct {
+	StorageClass string `json:"storage_class"`
+	Objects      int    `json:"objects"`
+	Size         int64  `json:"size"`
+}
+
+// DuSummary du 命令的统计结果
+type DuSummary struct {
+	StorageClasses []StorageClassStat `json:"storage_classes"`
+	TotalObjects   int                `json:"total_objects"`
+	TotalSize      int64              `json:"total_size"`
+	DeleteMarkers  int                `json:"delete_markers"`
+}
+
+func (r *DuSummary) CsvHeader() []string {
+	return []string{"storage_class", "objects", "size"}
+}
+
+func (r *DuSummary) CsvRows() [][]string {
+	rows := make([][]string, 0, len(r.StorageClasses)+2)
+	for _, stat := range r.StorageClasses {
+		rows = append(rows, []string{stat.StorageClass, strconv.Itoa(stat.Objects), strconv.FormatInt(stat.Size, 10)})
+	}
+	rows = append(rows, []string{"TOTAL", strconv.Itoa(r.TotalObjects), strconv.FormatInt(r.TotalSize, 10)})
+	rows = append(rows, []string{"DELETE_MARKER", strconv.Itoa(r.DeleteMarkers), "0"})
+	return rows
+}
+
+// LsduEntry lsdu 命令中单个目录或文件的统计
+type LsduEntry struct {
+	Key     string `json:"key"`
+	Type    string `json:"type"`
+	Objects int    `json:"objects"`
+	Size    int64  `json:"size"`
+}
+
+// LsduSummary lsdu 命令的统计结果
+type LsduSummary struct {
+	Entries      []LsduEntry `json:"entries"`
+	TotalObjects int         `json:"total_objects"`
+	TotalSize    int64       `json:"total_size"`
+}
+
+func (r *LsduSummary) CsvHeader() []string {
+	return []string{"key", "type", "objects", "size"}
+}
+
+// csv 只输出各目录与文件的行，合计不与它们混在一起，可由各行相加得到
+func (r *LsduSummary) CsvRows() [][]string {
+	rows := make([][]string, 0, len(r.Entries))
+	for _, entry := range r.Entries {
+		rows = append(rows, []string{entry.Key, entry.Type, strconv.Itoa(entry.Objects), strconv.FormatInt(entry.Size, 10)})
+	}
+	return rows
+}
+
+// ReplicationStatusEntry 单个对象的跨地域复制状态
+type ReplicationStatusEntry struct {
+	Key    string `json:"key"`
+	Size   int64  `json:"size"`
+	Status string `json:"replication_status"`
+}
+
+// ReplicationStatusStat 按复制状态统计的对象数与大小
+type ReplicationStatusStat struct {
+	Status  string `json:"replication_status"`
+	Objects int    `json:"objects"`
+	Size    int64  `json:"size"`
+}
+
+// ReplicationStatusSummary bucket-replication --method status 的结果
+type ReplicationStatusSummary struct {
+	Objects      []ReplicationStatusEntry `json:"objects"`
+	Statuses     []ReplicationStatusStat  `json:"statuses"`
+	TotalObjects int                      `json:"total_objects"`
+	TotalSize    int64                    `json:"total_size"`
+}
+
+func (r *ReplicationStatusSummary) CsvHeader() []string {
+	return []string{"key", "replication_status", "objects", "size"}
+}
+
+func (r *ReplicationStatusSummary) CsvRows() [][]string {
+	rows := make([][]string, 0, len(r.Objects)+len(r.Statuses)+1)
+	for _, object := range r.Objects {
+		rows = append(rows, []string{object.Key, object.Status, "1", strconv.FormatInt(object.Size, 10)})
+	}
+	for _, stat := range r.Statuses {
+		rows = append(rows, []string{"", stat.Status, strconv.Itoa(stat.Objects), strconv.FormatInt(stat.Size, 10)})
+	}
+	rows = append(rows, []string{"", "TOTAL", strconv.Itoa(r.TotalObjects), strconv.FormatInt(r.TotalSize, 10)})
+	return rows
+}
+
+// HashSummary hash 命令的结果
+type HashSummary struct {
+	Path      string `json:"path"`
+	VersionId string `json:"version_id,omitempty"`
+	Source    string `json:"source"`
+	HashType  string `json:"hash_type"`
+	Hash      string `json:"hash"`
+	Base64    string `json:"base64,omitempty"`
+}
+
+func (r *HashSummary) CsvHeader() []string {
+	return []string{"path", "version_id", "source", "hash_type", "hash", "base64"}
+}
+
+func (r *HashSummary) CsvRows() [][]string {
+	return [][]string{{r.Path, r.VersionId, r.Source, r.HashType, r.Hash, r.Base64}}
+}
+
+// RecordWriter 逐条输出结构化记录，json 格式输出为数组，jsonl 每行一条，csv 首行为表头
+type RecordWriter struct {
+	w      io.Writer
+	csv    *csv.Writer
+	format string
+	count  int
+}
+
+func NewRecordWriter(w io.Writer) *RecordWriter {
+	return &RecordWriter{
+		w:      w,
+		csv:    csv.NewWriter(w),
+		format: outputFormat,
+	}
+}
+
+func (rw *RecordWriter) Write(record OutputRecord) error {
+	switch rw.format {
+	case OutputJson:
+		prefix := ",\n  "
+		if rw.count == 0 {
+			prefix = "[\n  "
+		}
+		data, err := json.MarshalIndent(record, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(rw.w, "%s%s", prefix, data); err != nil {
+			return err
+		}
+	case OutputJsonl:
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(rw.w, "%s\n", data); err != nil {
+			return err
+		}
+	case OutputCsv:
+		if rw.count == 0 {
+			rw.csv.Write(record.CsvHeader())
+		}
+		rw.csv.WriteAll(record.CsvRows())
+		if err := rw.csv.Error(); err != nil {
+			return err
+		}
+	}
+	rw.count++
+	return nil
+}
+
+// Close 结束输出，json 格式需补全数组
+func (rw *RecordWriter) Close() error {
+	switch rw.format {
+	case OutputJson:
+		if rw.count == 0 {
+			_, err := fmt.Fprintln(rw.w, "[]")
+			return err
+		}
+		_, err := fmt.Fprintln(rw.w, "\n]")
+		return err
+	case OutputCsv:
+		rw.csv.Flush()
+		return rw.csv.Error()
+	}
+	return nil
+}
+
+// 在 defer 中结束结构化输出，调用方没有返回其他错误时返回结束输出的错误
+func closeRecordWriter(rw *RecordWriter, err *error) {
+	if closeErr := rw.Close(); *err == nil {
+		*err = closeErr
+	}
+}
+
+// PrintSummary 输出单个统计结果
+func PrintSummary(record OutputRecord) error {
+	switch outputFormat {
+	case OutputJson:
+		data, err := json.MarshalIndent(record, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(os.Stdout, "%s\n", data)
+		return err
+	case OutputJsonl:
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(os.Stdout, "%s\n", data)
+		return err
+	case OutputCsv:
+		w := csv.NewWriter(os.Stdout)
+		w.Write(record.CsvHeader())
+		w.WriteAll(record.CsvRows())
+		return w.Error()
+	}
+	return nil
+}
+
+func newObjectRecord(object cos.Object) *ObjectRecord {
+	return &ObjectRecord{
+		Key:           object.Key,
+		Type:          "object",
+		Size:          object.Size,
+		ETag:          strings.Trim(object.ETag, "\""),
+		StorageClass:  object.StorageClass,
+		LastModified:  object.LastModified,
+		VersionId:     object.VersionId,
+		RestoreStatus: object.RestoreStatus,
+	}
+}
+
+func newDirRecord(prefix string) *ObjectRecord {
+	return &ObjectRecord{Key: prefix, Type: "dir"}
+}
+
+// VerifyEntry verify 命令发现的一处不一致
+type VerifyEntry struct {
+	Key          string `json:"key"`
+	Status       string `json:"status"`
+	SourceSize   int64  `json:"source_size"`
+	TargetSize   int64  `json:"target_size"`
+	SourceCrc64  string `json:"source_crc64,omitempty"`
+	TargetCrc64  string `json:"target_crc64,omitempty"`
+	SourceSha256 string `json:"source_sha256,omitempty"`
+	TargetSha256 string `json:"target_sha256,omitempty"`
+	Detail       string `json:"detail,omitempty"`
+}
+
+// VerifySummary verify 命令的结果，Entries 只包含不一致与失败的项
+type VerifySummary struct {
+	Source     string        `json:"source"`
+	Target     string        `json:"target"`
+	Entries    []VerifyEntry `json:"entries"`
+	Matched    int           `json:"matched"`
+	Missing    int           `json:"missing"`
+	Extra      int           `json:"extra"`
+	Mismatched int           `json:"mismatched"`
+	Failed     int           `json:"failed"`
+}
+
+func (r *VerifySummary) CsvHeader() []string {
+	return []string{"key", "status", "objects", "source_size", "target_size", "source_crc64", "target_crc64", "detail"}
+}
+
+func (r *VerifySummary) CsvRows() [][]string {
+	rows := make([][]string, 0, len(r.Entries)+5)
+	for _, entry := range r.Entries {
+		rows = append(rows, []string{entry.Key, entry.Status, "1", strconv.FormatInt(entry.SourceSize, 10),
+			strconv.FormatInt(entry.TargetSize, 10), entry.SourceCrc64, entry.TargetCrc64, entry.Detail})
+	}
+	for _, stat := range []struct {
+		status string
+		count  int
+	}{
+		{"matched", r.Matched}, {VerifyStatusMissing, r.Missing}, {VerifyStatusExtra, r.Extra},
+		{VerifyStatusMismatch, r.Mismatched}, {VerifyStatusFailed, r.Failed},
+	} {
+		rows = append(rows, []string{"", stat.status, strconv.Itoa(stat.count), "", "", "", "", ""})
+	}
+	return rows
+}
@@ -105,6 +105,8 @@ func CheckPath(fileUrl StorageUrl, fo *FileOperations, pathType string) error {
 	var path string
 	if pathType == TypeSnapshotPath {
 		path = fo.Operation.SnapshotPath
+	} else if pathType == TypeCheckpointDir {
+		path = fo.Operation.CheckpointDir
 	} else if pathType == TypeFailOutputPath {
 		if fo.Operation.FailOutput {
 			path = fo.Operation.FailOutputPath
@@ -122,7 +124,7 @@ func CheckPath(fileUrl StorageUrl, fo *FileOperations, pathType string) error {
 	}
 
 	if strings.Index(absPath, absFileDir) >= 0 {
-		return fmt.Errorf("%s %s is subdirectory of %s", pathType, fo.Operation.SnapshotPath, fileUrl.ToString())
+		return fmt.Errorf("%s %s is subdirectory of %s", pathType, path, fileUrl.ToString())
 	}
 	return nil
 }
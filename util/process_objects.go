@@ -0,0 +1,78 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// processObjects 列出前缀下符合过滤条件的对象，由 fo.Operation.Routines 个协程并发调用 process 处理每个对象。
+// operation 为错误信息及日志中的操作名称，如 put tagging。process 返回错误的对象计为失败，
+// 指定 --fail-output 时错误写入错误输出文件。列举出错或有对象失败时返回错误
+func processObjects(c *cos.Client, cosUrl StorageUrl, fo *FileOperations, operation string, process func(object objectInfoType, key string) error) error {
+	s, err := c.Bucket.Head(context.Background())
+	if err != nil {
+		return err
+	}
+
+	chObjects := make(chan objectInfoType, ChannelSize)
+	chError := make(chan error, fo.Operation.Routines)
+	chListError := make(chan error, 1)
+	if s.Header.Get("X-Cos-Bucket-Arch") == "OFS" {
+		go getOfsObjectList(c, cosUrl, chObjects, chListError, fo, false, true)
+	} else {
+		go getCosObjectList(c, cosUrl, chObjects, chListError, fo, false, true)
+	}
+
+	var succeed, failed int64
+	for i := 0; i < fo.Operation.Routines; i++ {
+		go func() {
+			for object := range chObjects {
+				key := object.prefix + object.relativeKey
+				if err := process(object, key); err != nil {
+					atomic.AddInt64(&failed, 1)
+					chError <- fmt.Errorf("%s of %s failed, errMsg:%v\n", operation, getCosUrl(cosUrl.(*CosUrl).Bucket, key), err)
+					continue
+				}
+				atomic.AddInt64(&succeed, 1)
+			}
+			chError <- nil
+		}()
+	}
+
+	var listErr error
+	completed := 0
+	for completed <= fo.Operation.Routines {
+		select {
+		case err := <-chListError:
+			if err != nil {
+				listErr = fmt.Errorf("list objects error : %v", err)
+			}
+			completed++
+		case err := <-chError:
+			if err == nil {
+				completed++
+			} else if fo.Operation.FailOutput {
+				writeError(err.Error(), fo)
+			}
+		}
+	}
+	CloseErrorOutputFile(fo)
+
+	if listErr != nil {
+		return listErr
+	}
+	if failed > 0 {
+		if fo.Operation.FailOutput {
+			absErrOutputPath, _ := filepath.Abs(fo.ErrOutput.Path)
+			return fmt.Errorf("%s of %d objects failed, please check the detailed information in dir %s", operation, failed, absErrOutputPath)
+		}
+		return fmt.Errorf("%s of %d objects failed", operation, failed)
+	}
+	logger.Infof("%s of %s completed, total num: %d", operation, cosUrl.ToString(), succeed)
+	return nil
+}
@@ -0,0 +1,57 @@
+package util
+
+import "fmt"
+
+// DefaultProfile 表示配置文件顶层的 base 与 buckets
+const DefaultProfile = "default"
+
+// ProfileEnv 未指定 --profile 时读取的环境变量
+const ProfileEnv = "COSCLI_PROFILE"
+
+// FindProfile 按名称查找命名配置，不存在时返回 -1
+func FindProfile(config *Config, name string) (*Profile, int) {
+	for i := range config.Profiles {
+		if config.Profiles[i].Name == name {
+			return &config.Profiles[i], i
+		}
+	}
+	return nil, -1
+}
+
+// UseProfile 返回使用指定命名配置的配置，name 为空或 default 时使用顶层配置。
+// 返回的配置中的密钥未解密，需再调用 LoadCredential
+func UseProfile(config Config, name string) (Config, error) {
+	if name == "" || name == DefaultProfile {
+		return config, nil
+	}
+	profile, _ := FindProfile(&config, name)
+	if profile == nil {
+		return Config{Profiles: config.Profiles}, fmt.Errorf("profile %s does not exist in the config file", name)
+	}
+	return Config{Base: profile.Base, Buckets: profile.Buckets, Profiles: config.Profiles}, nil
+}
+
+// LoadProfile 读取指定命名配置并按其存储方式读取密钥
+func LoadProfile(config Config, name string) (*Config, error) {
+	profileConfig, err := UseProfile(config, name)
+	if err != nil {
+		return nil, err
+	}
+	if profileConfig.Base.Protocol == "" {
+		profileConfig.Base.Protocol = "https"
+	}
+	if err = LoadCredential(&profileConfig.Base); err != nil {
+		return nil, fmt.Errorf("profile %s: %v", name, err)
+	}
+	return &profileConfig, nil
+}
+
+// SetProfile 修改命名配置，不存在时新建
+func SetProfile(config *Config, name string, base BaseCfg, buckets []Bucket) {
+	if profile, _ := FindProfile(config, name); profile != nil {
+		profile.Base = base
+		profile.Buckets = buckets
+		return
+	}
+	config.Profiles = append(config.Profiles, Profile{Name: name, Base: base, Buckets: buckets})
+}
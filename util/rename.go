@@ -71,24 +71,9 @@ func PutRename(ctx context.Context, config *Config, param *Param, c *cosgo.Clien
 	if c.Conf.RequestBodyClose {
 		req.Close = true
 	}
-	secretID := config.Base.SecretID
-	secretKey := config.Base.SecretKey
-	secretToken := config.Base.SessionToken
-	if param.SecretID != "" {
-		secretID = param.SecretID
-	}
-	if param.SecretKey != "" {
-		secretKey = param.SecretKey
-	}
-	if param.SessionToken != "" {
-		secretToken = param.SessionToken
-	}
-	client := &http.Client{
-		Transport: &cosgo.AuthorizationTransport{
-			SecretID:     secretID,
-			SecretKey:    secretKey,
-			SessionToken: secretToken,
-		},
+	client, err := NewHTTPClient(config, param, nil)
+	if err != nil {
+		return nil, err
 	}
 	req = req.WithContext(ctx)
 	resp, err = client.Do(req)
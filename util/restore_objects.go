@@ -21,10 +21,12 @@ func RestoreObjects(c *cos.Client, cosUrl StorageUrl, fo *FileOperations) error
 		return err
 	}
 	logger.Infof("Start Restore %s", cosUrl.(*CosUrl).Bucket+cosUrl.(*CosUrl).Object)
-	if s.Header.Get("X-Cos-Bucket-Arch") == "OFS" {
+	if fo.FileList != nil {
+		err = restoreFileListObjects(c, cosUrl, fo)
+	} else if s.Header.Get("X-Cos-Bucket-Arch") == "OFS" {
 		bucketName := cosUrl.(*CosUrl).Bucket
 		prefix := cosUrl.(*CosUrl).Object
-		err = restoreOfsObjects(c, bucketName, prefix, fo, "")
+		err = restoreOfsObjects(c, bucketName, prefix, prefix, fo, "")
 	} else {
 		err = restoreCosObjects(c, cosUrl, fo)
 	}
@@ -55,23 +57,8 @@ func restoreCosObjects(c *cos.Client, cosUrl StorageUrl, fo *FileOperations) err
 		for _, object := range objects {
 			if object.StorageClass == Archive || object.StorageClass == MAZArchive || object.StorageClass == DeepArchive {
 				object.Key, _ = url.QueryUnescape(object.Key)
-				if cosObjectMatchPatterns(object.Key, fo.Operation.Filters) {
-					if object.RestoreStatus == "ONGOING" || object.RestoreStatus == "ONGING" {
-						succeedNum += 1
-					} else {
-						resp, err := TryRestoreObject(c, cosUrl.(*CosUrl).Bucket, object.Key, fo.Operation.Days, fo.Operation.RestoreMode)
-						if err != nil {
-							if resp != nil && resp.StatusCode == 409 {
-								succeedNum += 1
-							} else {
-								failedNum += 1
-								writeError(fmt.Sprintf("restore %s failed , errMsg:%v\n", object.Key, err), fo)
-							}
-						} else {
-							succeedNum += 1
-						}
-					}
-
+				if cosObjectMetaMatchPatterns(cosUrl.(*CosUrl).Object, object.Key, object.Size, object.LastModified, object.StorageClass, fo.Operation.Filters) {
+					restoreObject(c, cosUrl.(*CosUrl).Bucket, object, fo)
 				}
 			} else {
 				errTypeNum += 1
@@ -83,7 +70,54 @@ func restoreCosObjects(c *cos.Client, cosUrl StorageUrl, fo *FileOperations) err
 	return nil
 }
 
-func TryRestoreObject(c *cos.Client, bucketName, objectKey string, days int, mode string) (resp *cos.Response, err error) {
+// 恢复单个归档对象，恢复中或已恢复的对象视为成功
+func restoreObject(c *cos.Client, bucketName string, object cos.Object, fo *FileOperations) {
+	if object.RestoreStatus == "ONGOING" || object.RestoreStatus == "ONGING" {
+		if fo.Operation.DryRun {
+			fo.Plan.Add(&PlanRecord{Action: PlanSkip, Source: getCosUrl(bucketName, object.Key), Size: object.Size, VersionId: object.VersionId})
+		}
+		succeedNum += 1
+	} else if fo.Operation.DryRun {
+		fo.Plan.Add(&PlanRecord{Action: PlanRestore, Source: getCosUrl(bucketName, object.Key), Size: object.Size, VersionId: object.VersionId})
+		succeedNum += 1
+	} else {
+		resp, err := TryRestoreObject(c, bucketName, object.Key, fo.Operation.Days, fo.Operation.RestoreMode, versionIds(object.VersionId)...)
+		if err != nil {
+			if resp != nil && resp.StatusCode == 409 {
+				succeedNum += 1
+			} else {
+				failedNum += 1
+				writeError(fmt.Sprintf("restore %s failed , errMsg:%v\n", object.Key, err), fo)
+			}
+		} else {
+			succeedNum += 1
+		}
+	}
+}
+
+// 恢复 --files-from 清单中的对象，逐个获取对象的存储类型与恢复状态
+func restoreFileListObjects(c *cos.Client, cosUrl StorageUrl, fo *FileOperations) error {
+	bucketName := cosUrl.(*CosUrl).Bucket
+	for _, entry := range fo.FileList {
+		key := fileListKey(cosUrl.(*CosUrl).Object, entry.Key)
+		object, err := headFileListObject(c, key, entry.VersionId, nil)
+		if err != nil {
+			failedNum += 1
+			writeError(fmt.Sprintf("restore %s failed , errMsg:%v\n", key, err), fo)
+			continue
+		}
+		if object.StorageClass != Archive && object.StorageClass != MAZArchive && object.StorageClass != DeepArchive {
+			errTypeNum += 1
+			continue
+		}
+		if cosObjectMetaMatchPatterns(cosUrl.(*CosUrl).Object, key, object.Size, object.LastModified, object.StorageClass, fo.Operation.Filters) {
+			restoreObject(c, bucketName, object, fo)
+		}
+	}
+	return nil
+}
+
+func TryRestoreObject(c *cos.Client, bucketName, objectKey string, days int, mode string, id ...string) (resp *cos.Response, err error) {
 
 	logger.Infof("Restore cos://%s/%s\n", bucketName, objectKey)
 	opt := &cos.ObjectRestoreOptions{
@@ -94,7 +128,7 @@ func TryRestoreObject(c *cos.Client, bucketName, objectKey string, days int, mod
 	}
 
 	for i := 0; i <= 10; i++ {
-		resp, err = c.Object.PostRestore(context.Background(), objectKey, opt)
+		resp, err = c.Object.PostRestore(context.Background(), objectKey, opt, id...)
 		if err != nil {
 			if resp != nil && resp.StatusCode == 503 {
 				if i == 10 {
@@ -115,7 +149,8 @@ func TryRestoreObject(c *cos.Client, bucketName, objectKey string, days int, mod
 	return resp, err
 }
 
-func restoreOfsObjects(c *cos.Client, bucketName, prefix string, fo *FileOperations, marker string) error {
+// root 为恢复的源路径，过滤规则按相对它的路径匹配
+func restoreOfsObjects(c *cos.Client, bucketName, root, prefix string, fo *FileOperations, marker string) error {
 	var err error
 	var objects []cos.Object
 	var commonPrefixes []string
@@ -130,23 +165,8 @@ func restoreOfsObjects(c *cos.Client, bucketName, prefix string, fo *FileOperati
 		for _, object := range objects {
 			if object.StorageClass == Archive || object.StorageClass == MAZArchive || object.StorageClass == DeepArchive {
 				object.Key, _ = url.QueryUnescape(object.Key)
-				if cosObjectMatchPatterns(object.Key, fo.Operation.Filters) {
-					if object.RestoreStatus == "ONGOING" || object.RestoreStatus == "ONGING" {
-						succeedNum += 1
-					} else {
-						resp, err := TryRestoreObject(c, bucketName, object.Key, fo.Operation.Days, fo.Operation.RestoreMode)
-						if err != nil {
-							if resp != nil && resp.StatusCode == 409 {
-								succeedNum += 1
-							} else {
-								failedNum += 1
-								writeError(fmt.Sprintf("restore %s failed , errMsg:%v\n", object.Key, err), fo)
-							}
-						} else {
-							succeedNum += 1
-						}
-					}
-
+				if cosObjectMetaMatchPatterns(root, object.Key, object.Size, object.LastModified, object.StorageClass, fo.Operation.Filters) {
+					restoreObject(c, bucketName, object, fo)
 				}
 			} else {
 				errTypeNum += 1
@@ -157,7 +177,7 @@ func restoreOfsObjects(c *cos.Client, bucketName, prefix string, fo *FileOperati
 			for _, commonPrefix := range commonPrefixes {
 				commonPrefix, _ = url.QueryUnescape(commonPrefix)
 				// 递归目录
-				err = restoreOfsObjects(c, bucketName, commonPrefix, fo, "")
+				err = restoreOfsObjects(c, bucketName, root, commonPrefix, fo, "")
 				if err != nil {
 					return err
 				}
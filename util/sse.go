@@ -0,0 +1,170 @@
+package util
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+const (
+	SSECos = "cos"
+	SSEKms = "kms"
+
+	sseAlgorithmAES256     = "AES256"
+	sseAlgorithmKms        = "cos/kms"
+	sseKmsKeyIdHeader      = "x-cos-server-side-encryption-cos-kms-key-id"
+	sseCustomerKeyLength   = 32
+	sseCustomerKeyMaxBytes = 1024
+	// 单次拷贝请求支持的最大对象
+	copyMaxSingleSize = 5 * 1024 * 1024 * 1024
+)
+
+// SSEOptions 服务端加密设置。Algorithm 与 CustomerKey 用于写入的对象，
+// CustomerKey 同时用于读取下载的对象，SourceCustomerKey 用于读取拷贝的来源对象
+type SSEOptions struct {
+	Algorithm         string
+	KmsKeyId          string
+	CustomerKey       []byte
+	SourceCustomerKey []byte
+}
+
+// NewSSEOptions 校验并生成服务端加密设置，sse 为 cos 或 kms，SSE-C 密钥可直接指定或从文件读取
+func NewSSEOptions(sse, kmsKeyId, customerKey, customerKeyFile, sourceKey, sourceKeyFile string) (opt SSEOptions, err error) {
+	switch sse {
+	case "":
+	case SSECos:
+		opt.Algorithm = sseAlgorithmAES256
+	case SSEKms:
+		opt.Algorithm = sseAlgorithmKms
+	default:
+		return opt, fmt.Errorf("--sse must be cos or kms")
+	}
+	if kmsKeyId != "" {
+		if sse != SSEKms {
+			return opt, fmt.Errorf("--sse-kms-key-id only works with --sse kms")
+		}
+		opt.KmsKeyId = kmsKeyId
+	}
+
+	if opt.CustomerKey, err = readAES256Key("--sse-c-key", customerKey, customerKeyFile); err != nil {
+		return opt, err
+	}
+	if opt.SourceCustomerKey, err = readAES256Key("--source-sse-c-key", sourceKey, sourceKeyFile); err != nil {
+		return opt, err
+	}
+	if opt.Algorithm != "" && opt.CustomerKey != nil {
+		return opt, fmt.Errorf("--sse and --sse-c-key can not be used together")
+	}
+	return opt, nil
+}
+
+// 读取 AES-256 密钥，可为 32 字节的原始密钥或其 base64 编码
+func readAES256Key(name, value, file string) ([]byte, error) {
+	if value != "" && file != "" {
+		return nil, fmt.Errorf("%s and %s-file can not be used together", name, name)
+	}
+	if file != "" {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read %s-file error: %v", name, err)
+		}
+		if len(content) > sseCustomerKeyMaxBytes {
+			return nil, fmt.Errorf("%s-file %s is not a key file", name, file)
+		}
+		value = string(content)
+		// 文本文件末尾的换行不属于密钥
+		if len(value) != sseCustomerKeyLength {
+			value = strings.TrimSpace(value)
+		}
+	}
+	if value == "" {
+		return nil, nil
+	}
+	if len(value) == sseCustomerKeyLength {
+		return []byte(value), nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(value); err == nil && len(key) == sseCustomerKeyLength {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s must be 32 bytes, or 32 bytes encoded in base64", name)
+}
+
+// 生成 SSE-C 请求头的算法、密钥及密钥 MD5
+func sseCustomerHeaders(key []byte) (algorithm, encodedKey, keyMD5 string) {
+	if key == nil {
+		return "", "", ""
+	}
+	sum := md5.Sum(key)
+	return sseAlgorithmAES256, base64.StdEncoding.EncodeToString(key), base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// 设置上传对象的加密方式
+func (opt SSEOptions) setPutHeader(header *cos.ObjectPutHeaderOptions) {
+	header.XCosServerSideEncryption = opt.Algorithm
+	if opt.KmsKeyId != "" {
+		if header.XOptionHeader == nil {
+			header.XOptionHeader = &http.Header{}
+		}
+		header.XOptionHeader.Set(sseKmsKeyIdHeader, opt.KmsKeyId)
+	}
+	header.XCosSSECustomerAglo, header.XCosSSECustomerKey, header.XCosSSECustomerKeyMD5 = sseCustomerHeaders(opt.CustomerKey)
+}
+
+// 设置拷贝目标对象的加密方式及读取来源对象的 SSE-C 密钥
+func (opt SSEOptions) setCopyHeader(header *cos.ObjectCopyHeaderOptions) {
+	header.XCosServerSideEncryption = opt.Algorithm
+	if opt.KmsKeyId != "" {
+		if header.XOptionHeader == nil {
+			header.XOptionHeader = &http.Header{}
+		}
+		header.XOptionHeader.Set(sseKmsKeyIdHeader, opt.KmsKeyId)
+	}
+	header.XCosSSECustomerAglo, header.XCosSSECustomerKey, header.XCosSSECustomerKeyMD5 = sseCustomerHeaders(opt.CustomerKey)
+	header.XCosCopySourceSSECustomerAglo, header.XCosCopySourceSSECustomerKey, header.XCosCopySourceSSECustomerKeyMD5 = sseCustomerHeaders(opt.SourceCustomerKey)
+}
+
+// 设置读取对象的 SSE-C 密钥
+func setGetCustomerKey(getOpt *cos.ObjectGetOptions, key []byte) {
+	getOpt.XCosSSECustomerAglo, getOpt.XCosSSECustomerKey, getOpt.XCosSSECustomerKeyMD5 = sseCustomerHeaders(key)
+}
+
+// 分块上传或拷贝时，使用 SSE-C 的每个分块请求也需携带密钥
+func sseCustomerOptionHeader(key []byte) *http.Header {
+	if key == nil {
+		return nil
+	}
+	algorithm, encodedKey, keyMD5 := sseCustomerHeaders(key)
+	header := &http.Header{}
+	header.Set("x-cos-server-side-encryption-customer-algorithm", algorithm)
+	header.Set("x-cos-server-side-encryption-customer-key", encodedKey)
+	header.Set("x-cos-server-side-encryption-customer-key-MD5", keyMD5)
+	return header
+}
+
+// GetHeadWithKey 获取使用 SSE-C 加密的对象的信息，key 为空时与 GetHead 相同
+func GetHeadWithKey(c *cos.Client, cosPath string, key []byte, id ...string) (*cos.Response, error) {
+	headOpt := &cos.ObjectHeadOptions{}
+	headOpt.XCosSSECustomerAglo, headOpt.XCosSSECustomerKey, headOpt.XCosSSECustomerKeyMD5 = sseCustomerHeaders(key)
+	return c.Object.Head(context.Background(), cosPath, headOpt, id...)
+}
+
+// 判断对象是否存在，使用 SSE-C 加密的对象需携带密钥才能获取其信息
+func checkCosObjectExistWithKey(c *cos.Client, cosPath string, key []byte, id ...string) (bool, error) {
+	if key == nil {
+		return CheckCosObjectExist(c, cosPath, id...)
+	}
+	_, err := GetHeadWithKey(c, cosPath, key, id...)
+	if err != nil {
+		if cos.IsNotFoundError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -21,6 +21,11 @@ var totalCnt int
 var totalSize int64
 
 func DuObjects(c *cos.Client, cosUrl StorageUrl, filters []FilterOptionType, duType int, allVersions bool) error {
+	return duObjects(c, cosUrl, cosUrl.(*CosUrl).Object, filters, duType, allVersions)
+}
+
+// root 为统计的源路径，过滤规则按相对它的路径匹配；lsdu 统计子目录时仍按 lsdu 的源路径匹配
+func duObjects(c *cos.Client, cosUrl StorageUrl, root string, filters []FilterOptionType, duType int, allVersions bool) error {
 	// 根据s.Header判断是否是融合桶或者普通桶
 	s, err := c.Bucket.Head(context.Background())
 	if err != nil {
@@ -29,12 +34,12 @@ func DuObjects(c *cos.Client, cosUrl StorageUrl, filters []FilterOptionType, duT
 
 	if s.Header.Get("X-Cos-Bucket-Arch") == "OFS" {
 		prefix := cosUrl.(*CosUrl).Object
-		err = countOfsObjects(c, prefix, filters, "", duType)
+		err = countOfsObjects(c, root, prefix, filters, "", duType)
 	} else {
 		if allVersions {
-			err = countCosObjectVersions(c, cosUrl, filters, duType)
+			err = countCosObjectVersions(c, cosUrl, root, filters, duType)
 		} else {
-			err = countCosObjects(c, cosUrl, filters, duType)
+			err = countCosObjects(c, cosUrl, root, filters, duType)
 		}
 
 	}
@@ -51,7 +56,7 @@ func DuObjects(c *cos.Client, cosUrl StorageUrl, filters []FilterOptionType, duT
 	return nil
 }
 
-func countCosObjects(c *cos.Client, cosUrl StorageUrl, filters []FilterOptionType, duType int) error {
+func countCosObjects(c *cos.Client, cosUrl StorageUrl, root string, filters []FilterOptionType, duType int) error {
 	var err error
 	var objects []cos.Object
 	marker := ""
@@ -67,7 +72,7 @@ func countCosObjects(c *cos.Client, cosUrl StorageUrl, filters []FilterOptionTyp
 			if strings.HasSuffix(object.Key, "/") {
 				continue
 			}
-			if cosObjectMatchPatterns(object.Key, filters) {
+			if cosObjectMetaMatchPatterns(root, object.Key, object.Size, object.LastModified, object.StorageClass, filters) {
 				statisticObjects(object, duType)
 			}
 
@@ -77,7 +82,7 @@ func countCosObjects(c *cos.Client, cosUrl StorageUrl, filters []FilterOptionTyp
 	return nil
 }
 
-func countCosObjectVersions(c *cos.Client, cosUrl StorageUrl, filters []FilterOptionType, duType int) error {
+func countCosObjectVersions(c *cos.Client, cosUrl StorageUrl, root string, filters []FilterOptionType, duType int) error {
 	var err error
 	var versions []cos.ListVersionsResultVersion
 	var deleteMarkers []cos.ListVersionsResultDeleteMarker
@@ -91,14 +96,14 @@ func countCosObjectVersions(c *cos.Client, cosUrl StorageUrl, filters []FilterOp
 		}
 		for _, object := range versions {
 			object.Key, _ = url.QueryUnescape(object.Key)
-			if cosObjectMatchPatterns(object.Key, filters) {
+			if cosObjectMetaMatchPatterns(root, object.Key, object.Size, object.LastModified, object.StorageClass, filters) {
 				statisticObjectVersions(object, duType)
 			}
 		}
 
 		for _, object := range deleteMarkers {
 			object.Key, _ = url.QueryUnescape(object.Key)
-			if cosObjectMatchPatterns(object.Key, filters) {
+			if cosDeleteMarkerMatchPatterns(root, object.Key, object.LastModified, filters) {
 				deleteMarkerCnt += 1
 			}
 		}
@@ -107,7 +112,7 @@ func countCosObjectVersions(c *cos.Client, cosUrl StorageUrl, filters []FilterOp
 	return nil
 }
 
-func countOfsObjects(c *cos.Client, prefix string, filters []FilterOptionType, marker string, duType int) error {
+func countOfsObjects(c *cos.Client, root, prefix string, filters []FilterOptionType, marker string, duType int) error {
 	var err error
 	var objects []cos.Object
 	var commonPrefixes []string
@@ -121,7 +126,7 @@ func countOfsObjects(c *cos.Client, prefix string, filters []FilterOptionType, m
 
 		for _, object := range objects {
 			object.Key, _ = url.QueryUnescape(object.Key)
-			if cosObjectMatchPatterns(object.Key, filters) {
+			if cosObjectMetaMatchPatterns(root, object.Key, object.Size, object.LastModified, object.StorageClass, filters) {
 				statisticObjects(object, duType)
 			}
 		}
@@ -130,7 +135,7 @@ func countOfsObjects(c *cos.Client, prefix string, filters []FilterOptionType, m
 			for _, commonPrefix := range commonPrefixes {
 				commonPrefix, _ = url.QueryUnescape(commonPrefix)
 				// 递归目录
-				err = countOfsObjects(c, commonPrefix, filters, "", duType)
+				err = countOfsObjects(c, root, commonPrefix, filters, "", duType)
 				if err != nil {
 					return err
 				}
@@ -215,6 +220,27 @@ func statisticObjectVersions(object cos.ListVersionsResultVersion, duType int) {
 }
 
 func printStatistic(allVersions bool) {
+	if !IsTableOutput() {
+		summary := &DuSummary{
+			StorageClasses: []StorageClassStat{
+				{Standard, standardCnt, standardSize},
+				{StandardIA, standardIACnt, standardIASize},
+				{IntelligentTiering, intelligentTieringCnt, intelligentTieringSize},
+				{Archive, archiveCnt, archiveSize},
+				{DeepArchive, deepArchiveCnt, deepArchiveSize},
+				{MAZStandard, mazStandardCnt, mazStandardSize},
+				{MAZStandardIA, mazStandardIACnt, mazStandardIASize},
+				{MAZIntelligentTiering, mazIntelligentTieringCnt, mazIntelligentTieringSize},
+				{MAZArchive, mazArchiveCnt, mazArchiveSize},
+			},
+			TotalObjects:  totalCnt,
+			TotalSize:     totalSize,
+			DeleteMarkers: deleteMarkerCnt,
+		}
+		PrintSummary(summary)
+		return
+	}
+
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader([]string{"Storage Class", "Objects Count", "Total Size"})
 	table.Append([]string{Standard, fmt.Sprintf("%d", standardCnt), FormatSize(standardSize)})
@@ -267,13 +293,13 @@ func LsAndDuObjects(c *cos.Client, cosUrl StorageUrl, filters []FilterOptionType
 
 		if len(commonPrefixes) > 0 {
 			for _, commonPrefix := range commonPrefixes {
-				if cosObjectMatchPatterns(commonPrefix, filters) {
+				if cosObjectMatchPatterns(cosUrl.(*CosUrl).Object, commonPrefix, filters) {
 					commonPrefix, _ = url.QueryUnescape(commonPrefix)
 					cosDirUrl, err := FormatUrl(SchemePrefix + cosUrl.(*CosUrl).Bucket + "/" + commonPrefix)
 					if err != nil {
 						return fmt.Errorf("cos url format error:%v", err)
 					}
-					DuObjects(c, cosDirUrl, filters, DU_TYPE_TOTAL, false)
+					duObjects(c, cosDirUrl, cosUrl.(*CosUrl).Object, filters, DU_TYPE_TOTAL, false)
 					// 记录统计数据
 					dirs = append(dirs, CosInfo{
 						Name:       commonPrefix,
@@ -289,7 +315,7 @@ func LsAndDuObjects(c *cos.Client, cosUrl StorageUrl, filters []FilterOptionType
 
 		for _, object := range objects {
 			object.Key, _ = url.QueryUnescape(object.Key)
-			if cosObjectMatchPatterns(object.Key, filters) {
+			if cosObjectMetaMatchPatterns(cosUrl.(*CosUrl).Object, object.Key, object.Size, object.LastModified, object.StorageClass, filters) {
 				files = append(files, CosInfo{
 					Name:       object.Key,
 					Size:       object.Size,
@@ -305,6 +331,23 @@ func LsAndDuObjects(c *cos.Client, cosUrl StorageUrl, filters []FilterOptionType
 
 	var printTotalSize int64
 	var printTotalCnt int
+	if !IsTableOutput() {
+		summary := &LsduSummary{Entries: []LsduEntry{}}
+		for _, dir := range dirs {
+			summary.Entries = append(summary.Entries, LsduEntry{Key: dir.Name, Type: "dir", Objects: dir.TotalFiles, Size: dir.Size})
+			printTotalSize += dir.Size
+			printTotalCnt += dir.TotalFiles
+		}
+		for _, file := range files {
+			summary.Entries = append(summary.Entries, LsduEntry{Key: file.Name, Type: "object", Objects: file.TotalFiles, Size: file.Size})
+			printTotalSize += file.Size
+			printTotalCnt += file.TotalFiles
+		}
+		summary.TotalObjects = printTotalCnt
+		summary.TotalSize = printTotalSize
+		return PrintSummary(summary)
+	}
+
 	// 输出结果
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader([]string{"name", "Objects Count", "Total Size"})
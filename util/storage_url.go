@@ -223,7 +223,7 @@ func FormatDownloadPath(cosUrl StorageUrl, fileUrl StorageUrl, fo *FileOperation
 	}
 
 	if !isDir {
-		fileExist, err := CheckCosObjectExist(c, cosPath, fo.Operation.VersionId)
+		fileExist, err := checkCosObjectExistWithKey(c, cosPath, fo.Operation.SSE.CustomerKey, fo.Operation.VersionId)
 		if err != nil {
 			return err
 		}
@@ -294,7 +294,7 @@ func FormatCopyPath(srcUrl StorageUrl, destUrl StorageUrl, fo *FileOperations, s
 	}
 
 	if !isDir {
-		fileExist, err := CheckCosObjectExist(srcClient, srcPath, fo.Operation.VersionId)
+		fileExist, err := checkCosObjectExistWithKey(srcClient, srcPath, fo.Operation.SSE.SourceCustomerKey, fo.Operation.VersionId)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,215 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// StreamPath cp 中表示标准输入或标准输出的路径
+const StreamPath = "-"
+
+// 分块上传的最大分块数
+const maxPartNumber = 10000
+
+// 流式分块上传中待上传的分块
+type streamPart struct {
+	number int
+	data   []byte
+}
+
+// StreamUpload 从 r 读取数据上传到 cosUrl，数据长度未知，按 --part-size 分块并发上传，
+// 同时最多缓存 ThreadNum+1 个分块。数据不足一个分块时使用简单上传。
+// 上传完成后校验对象的 CRC64，失败时中止分块上传。返回上传的字节数
+func StreamUpload(c *cos.Client, r io.Reader, cosUrl StorageUrl, fo *FileOperations) (int64, error) {
+	object := cosUrl.(*CosUrl).Object
+	partSize := fo.Operation.PartSize * 1024 * 1024
+	threadNum := fo.Operation.ThreadNum
+	if threadNum < 1 {
+		threadNum = 1
+	}
+	header := &cos.ObjectPutHeaderOptions{
+		CacheControl:       fo.Operation.Meta.CacheControl,
+		ContentDisposition: fo.Operation.Meta.ContentDisposition,
+		ContentEncoding:    fo.Operation.Meta.ContentEncoding,
+		ContentType:        fo.Operation.Meta.ContentType,
+		ContentLanguage:    fo.Operation.Meta.ContentLanguage,
+		Expires:            fo.Operation.Meta.Expires,
+		XCosMetaXXX:        fo.Operation.Meta.XCosMetaXXX,
+		XCosStorageClass:   fo.Operation.StorageClass,
+		XCosTrafficLimit:   (int)(fo.Operation.RateLimiting * 1024 * 1024 * 8),
+	}
+	fo.Operation.SSE.setPutHeader(header)
+	fo.Operation.Tagging.setPutHeader(header)
+
+	hash := crc64.New(crc64.MakeTable(crc64.ECMA))
+	first := make([]byte, partSize)
+	n, err := io.ReadFull(r, first)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		// 数据不足一个分块，简单上传时由 SDK 校验 CRC64
+		header.ContentLength = int64(n)
+		opt := &cos.ObjectPutOptions{ACLHeaderOptions: fo.Operation.ACL.headerOptions(), ObjectPutHeaderOptions: header}
+		_, err = c.Object.Put(context.Background(), object, bytes.NewReader(first[:n]), opt)
+		return int64(n), err
+	}
+	if err != nil {
+		return 0, err
+	}
+	hash.Write(first)
+
+	res, _, err := c.Object.InitiateMultipartUpload(context.Background(), object, &cos.InitiateMultipartUploadOptions{
+		ACLHeaderOptions:       fo.Operation.ACL.headerOptions(),
+		ObjectPutHeaderOptions: header,
+	})
+	if err != nil {
+		return 0, err
+	}
+	uploadId := res.UploadID
+
+	// 缓存池限制内存占用，分块上传完成后归还
+	buffers := make(chan []byte, threadNum+1)
+	for i := 0; i < threadNum; i++ {
+		buffers <- make([]byte, partSize)
+	}
+	chParts := make(chan streamPart, threadNum)
+	var mu sync.Mutex
+	var uploadErr error
+	var parts []cos.Object
+	var wg sync.WaitGroup
+	for i := 0; i < threadNum; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for part := range chParts {
+				mu.Lock()
+				failed := uploadErr != nil
+				mu.Unlock()
+				if !failed {
+					opt := &cos.ObjectUploadPartOptions{
+						ContentLength:    int64(len(part.data)),
+						XCosTrafficLimit: header.XCosTrafficLimit,
+					}
+					opt.XCosSSECustomerAglo, opt.XCosSSECustomerKey, opt.XCosSSECustomerKeyMD5 = sseCustomerHeaders(fo.Operation.SSE.CustomerKey)
+					resp, err := c.Object.UploadPart(context.Background(), object, uploadId, part.number, bytes.NewReader(part.data), opt)
+					mu.Lock()
+					if err != nil {
+						if uploadErr == nil {
+							uploadErr = fmt.Errorf("upload part %d failed: %v", part.number, err)
+						}
+					} else {
+						parts = append(parts, cos.Object{PartNumber: part.number, ETag: resp.Header.Get("ETag")})
+					}
+					mu.Unlock()
+				}
+				buffers <- part.data[:cap(part.data)]
+			}
+		}()
+	}
+
+	size := int64(n)
+	chParts <- streamPart{number: 1, data: first}
+	var readErr error
+	for number := 2; ; number++ {
+		buf := <-buffers
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			if number > maxPartNumber {
+				readErr = fmt.Errorf("the data exceeds %d parts, please increase --part-size", maxPartNumber)
+				break
+			}
+			hash.Write(buf[:n])
+			size += int64(n)
+			chParts <- streamPart{number: number, data: buf[:n]}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			readErr = err
+			break
+		}
+		mu.Lock()
+		failed := uploadErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+	}
+	close(chParts)
+	wg.Wait()
+
+	if readErr == nil {
+		readErr = uploadErr
+	}
+	if readErr == nil {
+		sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+		var resp *cos.Response
+		_, resp, readErr = c.Object.CompleteMultipartUpload(context.Background(), object, uploadId, &cos.CompleteMultipartUploadOptions{Parts: parts})
+		if readErr == nil && c.Conf.EnableCRC && !fo.Operation.DisableChecksum {
+			if err := checkStreamCrc64(hash.Sum64(), resp.Header.Get("x-cos-hash-crc64ecma")); err != nil {
+				// 对象已生成，不再中止分块上传
+				return size, err
+			}
+		}
+	}
+	if readErr != nil {
+		c.Object.AbortMultipartUpload(context.Background(), object, uploadId)
+		return size, readErr
+	}
+	return size, nil
+}
+
+// StreamDownload 将对象写入 w，写入完成后校验 CRC64，客户端加密的对象解密后写入并校验明文的 CRC64。返回写入的字节数
+func StreamDownload(c *cos.Client, cosUrl StorageUrl, w io.Writer, fo *FileOperations) (int64, error) {
+	opt := &cos.ObjectGetOptions{
+		XCosTrafficLimit: (int)(fo.Operation.RateLimiting * 1024 * 1024 * 8),
+		// 明确不接受压缩，避免 Transport 自动解压 gzip 内容导致 CRC64 校验失败
+		XOptionHeader: &http.Header{"Accept-Encoding": []string{"identity"}},
+	}
+	setGetCustomerKey(opt, fo.Operation.SSE.CustomerKey)
+	resp, err := c.Object.Get(context.Background(), cosUrl.(*CosUrl).Object, opt, versionIds(fo.Operation.VersionId)...)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var body io.Reader = resp.Body
+	if isClientEncrypted(resp.Header) {
+		cseCipher, err := fo.Operation.ClientEncryption.cipherFromHeader(resp.Header)
+		if err != nil {
+			return 0, err
+		}
+		if body, err = cseCipher.newDecryptReader(resp.Body); err != nil {
+			return 0, err
+		}
+	}
+
+	hash := crc64.New(crc64.MakeTable(crc64.ECMA))
+	size, err := io.Copy(io.MultiWriter(w, hash), body)
+	if err != nil {
+		return size, err
+	}
+	if c.Conf.EnableCRC && !fo.Operation.DisableChecksum {
+		return size, checkStreamCrc64(hash.Sum64(), objectCrc64(resp.Header))
+	}
+	return size, nil
+}
+
+// 比较本地计算的 CRC64 与服务端返回的 CRC64，服务端未返回时不校验
+func checkStreamCrc64(local uint64, cosCrc string) error {
+	if cosCrc == "" {
+		return nil
+	}
+	if cosCrc != strconv.FormatUint(local, 10) {
+		return fmt.Errorf("verification failed, want:%d, x-cos-hash-crc64ecma:%s", local, cosCrc)
+	}
+	return nil
+}
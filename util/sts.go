@@ -0,0 +1,315 @@
+package util
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultStsEndpoint 默认的 STS 服务地址，可在配置文件中通过 stsendpoint 修改
+	DefaultStsEndpoint = "https://sts.tencentcloudapi.com/"
+	// DefaultStsRegion 默认的 STS 服务地域
+	DefaultStsRegion = "ap-guangzhou"
+	// DefaultRoleSessionName 未设置会话名称时使用的名称
+	DefaultRoleSessionName = "coscli"
+	// DefaultProviderId 未设置身份提供商时使用的名称
+	DefaultProviderId = "OIDC"
+	// StsCacheDir 临时密钥的缓存目录
+	StsCacheDir = "~/.cos.sts-cache"
+
+	stsVersion = "2018-08-13"
+	stsService = "sts"
+)
+
+// STS 请求的整体超时，连接与读取超时由 Transport 的设置决定
+const stsTimeout = 10 * time.Second
+
+type stsCredentials struct {
+	Token        string `json:"Token"`
+	TmpSecretId  string `json:"TmpSecretId"`
+	TmpSecretKey string `json:"TmpSecretKey"`
+}
+
+type stsResponse struct {
+	Response struct {
+		Credentials stsCredentials `json:"Credentials"`
+		ExpiredTime int            `json:"ExpiredTime"`
+		Expiration  string         `json:"Expiration"`
+		Error       *struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+		RequestId string `json:"RequestId"`
+	} `json:"Response"`
+}
+
+// GetStsCredentialProvider 获取 AssumeRole 或 WebIdentity 方式的临时密钥 provider。
+// transport 为 COS 请求使用的未签名 Transport，访问 STS 时同样使用其中的代理、证书及超时设置。
+// 临时密钥缓存在 StsCacheDir 中，过期前其他客户端及进程可直接使用
+func GetStsCredentialProvider(base BaseCfg, transport http.RoundTripper) (*TemporaryCredentialProvider, error) {
+	if base.RoleArn == "" {
+		return nil, fmt.Errorf("the role arn is required in %s mode", base.Mode)
+	}
+	var fetch func(*http.Client, BaseCfg) (Data, error)
+	var key string
+	switch base.Mode {
+	case "AssumeRole":
+		if base.SecretID == "" || base.SecretKey == "" {
+			return nil, fmt.Errorf("the secret id and secret key are required to assume role")
+		}
+		fetch = AssumeRole
+		key = base.SecretID
+	case "WebIdentity":
+		if base.WebIdentityTokenFile == "" {
+			return nil, fmt.Errorf("the web identity token file is required in WebIdentity mode")
+		}
+		fetch = AssumeRoleWithWebIdentity
+		key = base.WebIdentityTokenFile + "\n" + base.ProviderId
+	default:
+		return nil, fmt.Errorf("mode %s does not use sts", base.Mode)
+	}
+	key = strings.Join([]string{base.Mode, stsEndpoint(base), stsRegion(base), base.RoleArn, roleSessionName(base),
+		base.DurationSeconds, base.ExternalId, key}, "\n")
+	sum := sha256.Sum256([]byte(key))
+	cachePath := filepath.Join(stsCacheDir(), hex.EncodeToString(sum[:16])+".json")
+	client := &http.Client{Transport: transport, Timeout: stsTimeout}
+
+	return NewTemporaryCredentialProvider(func() (Data, error) {
+		if data, err := readStsCache(cachePath); err == nil && !expiringSoon(data, time.Now()) {
+			return data, nil
+		}
+		data, err := fetch(client, base)
+		if err != nil {
+			return data, err
+		}
+		if err := writeStsCache(cachePath, data); err != nil {
+			logger.Warningf("cache temporary credential error: %v", err)
+		}
+		return data, nil
+	}), nil
+}
+
+// AssumeRole 使用配置中的密钥调用 STS AssumeRole 接口申请角色的临时密钥
+func AssumeRole(client *http.Client, base BaseCfg) (data Data, err error) {
+	params := map[string]interface{}{
+		"RoleArn":         base.RoleArn,
+		"RoleSessionName": roleSessionName(base),
+	}
+	if base.ExternalId != "" {
+		params["ExternalId"] = base.ExternalId
+	}
+	if err = setDurationSeconds(params, base.DurationSeconds); err != nil {
+		return data, err
+	}
+	return callSts(client, base, "AssumeRole", params, true)
+}
+
+// AssumeRoleWithWebIdentity 使用 OIDC 令牌文件调用 STS AssumeRoleWithWebIdentity 接口申请角色的临时密钥，无需密钥
+func AssumeRoleWithWebIdentity(client *http.Client, base BaseCfg) (data Data, err error) {
+	// 令牌可能被定期轮换，每次申请时重新读取
+	token, err := ioutil.ReadFile(expandHome(base.WebIdentityTokenFile))
+	if err != nil {
+		return data, fmt.Errorf("read web identity token file error: %v", err)
+	}
+	providerId := base.ProviderId
+	if providerId == "" {
+		providerId = DefaultProviderId
+	}
+	params := map[string]interface{}{
+		"ProviderId":       providerId,
+		"WebIdentityToken": strings.TrimSpace(string(token)),
+		"RoleArn":          base.RoleArn,
+		"RoleSessionName":  roleSessionName(base),
+	}
+	if err = setDurationSeconds(params, base.DurationSeconds); err != nil {
+		return data, err
+	}
+	return callSts(client, base, "AssumeRoleWithWebIdentity", params, false)
+}
+
+// 调用 STS 接口，sign 为 false 时不签名
+func callSts(client *http.Client, base BaseCfg, action string, params map[string]interface{}, sign bool) (data Data, err error) {
+	endpoint := stsEndpoint(base)
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return data, fmt.Errorf("invalid sts endpoint %s", endpoint)
+	}
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return data, err
+	}
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return data, fmt.Errorf("%s error : create request error[%v]", action, err)
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("X-TC-Action", action)
+	req.Header.Set("X-TC-Version", stsVersion)
+	req.Header.Set("X-TC-Region", stsRegion(base))
+	req.Header.Set("X-TC-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	if sign {
+		if base.SessionToken != "" {
+			req.Header.Set("X-TC-Token", base.SessionToken)
+		}
+		req.Header.Set("Authorization", tc3Authorization(base.SecretID, base.SecretKey, u.Host, payload, now))
+	} else {
+		req.Header.Set("Authorization", "SKIP")
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return data, fmt.Errorf("%s error : request error[%v]", action, err)
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return data, fmt.Errorf("%s error : get response error[%v]", action, err)
+	}
+	var resp stsResponse
+	if err = json.Unmarshal(body, &resp); err != nil {
+		return data, fmt.Errorf("%s error : status[%d] response error[%v]", action, res.StatusCode, err)
+	}
+	if resp.Response.Error != nil {
+		return data, fmt.Errorf("%s error : %s %s, request id: %s", action, resp.Response.Error.Code,
+			resp.Response.Error.Message, resp.Response.RequestId)
+	}
+	if resp.Response.Credentials.TmpSecretId == "" {
+		return data, fmt.Errorf("%s error : no credentials in response, request id: %s", action, resp.Response.RequestId)
+	}
+
+	data.TmpSecretId = resp.Response.Credentials.TmpSecretId
+	data.TmpSecretKey = resp.Response.Credentials.TmpSecretKey
+	data.Token = resp.Response.Credentials.Token
+	data.ExpiredTime = resp.Response.ExpiredTime
+	data.Expiration = resp.Response.Expiration
+	data.Code = "Success"
+	return data, nil
+}
+
+// TC3-HMAC-SHA256 签名，签名的请求头为 content-type 和 host
+func tc3Authorization(secretID, secretKey, host string, payload []byte, now time.Time) string {
+	date := now.UTC().Format("2006-01-02")
+	payloadHash := sha256.Sum256(payload)
+	canonicalRequest := "POST\n/\n\ncontent-type:application/json; charset=utf-8\nhost:" + host +
+		"\n\ncontent-type;host\n" + hex.EncodeToString(payloadHash[:])
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := date + "/" + stsService + "/tc3_request"
+	stringToSign := "TC3-HMAC-SHA256\n" + strconv.FormatInt(now.Unix(), 10) + "\n" + scope + "\n" +
+		hex.EncodeToString(requestHash[:])
+
+	secretDate := hmacSha256([]byte("TC3"+secretKey), date)
+	secretService := hmacSha256(secretDate, stsService)
+	secretSigning := hmacSha256(secretService, "tc3_request")
+	signature := hex.EncodeToString(hmacSha256(secretSigning, stringToSign))
+	return "TC3-HMAC-SHA256 Credential=" + secretID + "/" + scope + ", SignedHeaders=content-type;host, Signature=" + signature
+}
+
+func hmacSha256(key []byte, s string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
+
+func setDurationSeconds(params map[string]interface{}, durationSeconds string) error {
+	if durationSeconds == "" {
+		return nil
+	}
+	duration, err := strconv.Atoi(durationSeconds)
+	if err != nil || duration <= 0 {
+		return fmt.Errorf("invalid duration seconds %s", durationSeconds)
+	}
+	params["DurationSeconds"] = duration
+	return nil
+}
+
+func stsEndpoint(base BaseCfg) string {
+	if base.StsEndpoint != "" {
+		return base.StsEndpoint
+	}
+	return DefaultStsEndpoint
+}
+
+func stsRegion(base BaseCfg) string {
+	if base.StsRegion != "" {
+		return base.StsRegion
+	}
+	return DefaultStsRegion
+}
+
+func roleSessionName(base BaseCfg) string {
+	if base.RoleSessionName != "" {
+		return base.RoleSessionName
+	}
+	return DefaultRoleSessionName
+}
+
+func stsCacheDir() string {
+	return expandHome(StsCacheDir)
+}
+
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~") {
+		home, err := homedir.Dir()
+		if err == nil {
+			path = home + path[1:]
+		}
+	}
+	return path
+}
+
+func readStsCache(path string) (data Data, err error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return data, err
+	}
+	if err = json.Unmarshal(content, &data); err != nil {
+		return data, err
+	}
+	if data.TmpSecretId == "" || data.ExpiredTime == 0 {
+		return data, fmt.Errorf("invalid sts cache %s", path)
+	}
+	return data, nil
+}
+
+// 缓存文件权限为 0600，先写临时文件再重命名，避免并发读取到不完整的文件
+func writeStsCache(path string, data Data) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
+}
@@ -42,7 +42,7 @@ func skipUpload(snapshotKey string, c *cos.Client, fo *FileOperations, localFile
 		}
 	}
 
-	resp, err := GetHead(c, cosPath)
+	resp, err := GetHeadWithKey(c, cosPath, fo.Operation.SSE.CustomerKey)
 	if err != nil {
 		if resp != nil && resp.StatusCode == 404 {
 			// 文件不在cos上，上传
@@ -52,7 +52,7 @@ func skipUpload(snapshotKey string, c *cos.Client, fo *FileOperations, localFile
 		}
 	} else {
 		if resp.StatusCode != 404 {
-			cosCrc := resp.Header.Get("x-cos-hash-crc64ecma")
+			cosCrc := objectCrc64(resp.Header)
 			localCrc, _, err := CalculateHash(localPath, "crc64")
 			if err != nil {
 				return false, err
@@ -70,7 +70,6 @@ func skipUpload(snapshotKey string, c *cos.Client, fo *FileOperations, localFile
 			return false, nil
 		}
 	}
-	return false, nil
 }
 
 func getUploadSnapshotKey(absLocalFilePath string, bucket string, object string) string {
@@ -106,7 +105,7 @@ func skipDownload(snapshotKey string, c *cos.Client, fo *FileOperations, localPa
 	if err != nil {
 		return false, err
 	}
-	resp, err := GetHead(c, object)
+	resp, err := GetHeadWithKey(c, object, fo.Operation.SSE.CustomerKey)
 	if err != nil {
 		if resp != nil && resp.StatusCode == 404 {
 			// 文件不在cos上
@@ -115,7 +114,7 @@ func skipDownload(snapshotKey string, c *cos.Client, fo *FileOperations, localPa
 			return false, err
 		}
 	} else {
-		cosCrc := resp.Header.Get("x-cos-hash-crc64ecma")
+		cosCrc := objectCrc64(resp.Header)
 		if cosCrc == localCrc {
 			// 本地校验通过后，添加快照记录
 			if fo.Operation.SnapshotPath != "" {
@@ -126,13 +125,11 @@ func skipDownload(snapshotKey string, c *cos.Client, fo *FileOperations, localPa
 			return false, nil
 		}
 	}
-
-	return false, nil
 }
 
-func skipCopy(srcClient, destClient *cos.Client, object, destPath string) (bool, error) {
+func skipCopy(srcClient, destClient *cos.Client, fo *FileOperations, object, destPath string) (bool, error) {
 	// 获取目标对象的crc64
-	resp, err := GetHead(destClient, destPath)
+	resp, err := GetHeadWithKey(destClient, destPath, fo.Operation.SSE.CustomerKey)
 	if err != nil {
 		if resp != nil && resp.StatusCode == 404 {
 			// 文件不在目标cos上，直接copy
@@ -145,7 +142,7 @@ func skipCopy(srcClient, destClient *cos.Client, object, destPath string) (bool,
 			destCrc := resp.Header.Get("x-cos-hash-crc64ecma")
 
 			// 获取来源对象的crc64
-			resp, err = GetHead(srcClient, object)
+			resp, err = GetHeadWithKey(srcClient, object, fo.Operation.SSE.SourceCustomerKey)
 			srcCrc := ""
 			if err != nil {
 				if resp != nil && resp.StatusCode == 404 {
@@ -168,8 +165,6 @@ func skipCopy(srcClient, destClient *cos.Client, object, destPath string) (bool,
 			return false, nil
 		}
 	}
-
-	return false, nil
 }
 
 func InitSnapshotDb(srcUrl, destUrl StorageUrl, fo *FileOperations) error {
@@ -195,6 +190,15 @@ func InitSnapshotDb(srcUrl, destUrl StorageUrl, fo *FileOperations) error {
 	return nil
 }
 
+// CloseSnapshotDb 关闭快照db，释放文件锁
+func CloseSnapshotDb(fo *FileOperations) {
+	if fo.SnapshotDb == nil {
+		return
+	}
+	fo.SnapshotDb.Close()
+	fo.SnapshotDb = nil
+}
+
 func SyncDownload(c *cos.Client, cosUrl StorageUrl, fileUrl StorageUrl, fo *FileOperations) error {
 	var err error
 	keysToDelete := make(map[string]string)
@@ -228,7 +232,7 @@ func SyncCosCopy(srcClient, destClient *cos.Client, srcUrl, destUrl StorageUrl,
 	if fo.Operation.Delete {
 		keysToDelete, err = getDeleteKeys(srcClient, destClient, srcUrl, destUrl, fo)
 		if err != nil {
-			fmt.Errorf("get delete keys error : %v", err)
+			return fmt.Errorf("get delete keys error : %v", err)
 		}
 	}
 
@@ -244,7 +248,7 @@ func SyncCosCopy(srcClient, destClient *cos.Client, srcUrl, destUrl StorageUrl,
 	}
 
 	if err != nil {
-		fmt.Errorf("delete keys error : %v", err)
+		return fmt.Errorf("delete keys error : %v", err)
 	}
 	return nil
 }
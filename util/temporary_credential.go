@@ -0,0 +1,75 @@
+package util
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+const (
+	// 临时密钥在过期前多久刷新
+	temporaryCredentialRefreshAhead = 5 * time.Minute
+	// 刷新失败后至少间隔多久再重试
+	temporaryCredentialRetryInterval = 10 * time.Second
+)
+
+// TemporaryCredentialProvider 缓存临时密钥，在 ExpiredTime 前 temporaryCredentialRefreshAhead 自动刷新，可被多个协程共用
+type TemporaryCredentialProvider struct {
+	fetch func() (Data, error)
+
+	mu        sync.Mutex
+	data      Data
+	nextRetry time.Time
+}
+
+// NewTemporaryCredentialProvider 创建临时密钥 provider，fetch 用于获取新的临时密钥
+func NewTemporaryCredentialProvider(fetch func() (Data, error)) *TemporaryCredentialProvider {
+	return &TemporaryCredentialProvider{fetch: fetch}
+}
+
+// Credential 返回当前有效的临时密钥，即将过期时先刷新。
+// 刷新失败但原有密钥尚未过期时继续使用原有密钥，间隔 temporaryCredentialRetryInterval 后再刷新
+func (p *TemporaryCredentialProvider) Credential() (secretID, secretKey, token string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	expiredTime := time.Unix(int64(p.data.ExpiredTime), 0)
+	valid := p.data.TmpSecretId != "" && (p.data.ExpiredTime == 0 || now.Before(expiredTime))
+	if !valid || (p.data.ExpiredTime > 0 && expiringSoon(p.data, now) && !now.Before(p.nextRetry)) {
+		data, err := p.fetch()
+		if err != nil {
+			if !valid {
+				return "", "", "", err
+			}
+			p.nextRetry = now.Add(temporaryCredentialRetryInterval)
+			logger.Warningf("refresh temporary credential failed, the current credential expires at %s: %v", expiredTime.Format(time.RFC3339), err)
+		} else {
+			p.data = data
+		}
+	}
+	return p.data.TmpSecretId, p.data.TmpSecretKey, p.data.Token, nil
+}
+
+// 临时密钥是否已进入刷新窗口
+func expiringSoon(data Data, now time.Time) bool {
+	return now.Add(temporaryCredentialRefreshAhead).After(time.Unix(int64(data.ExpiredTime), 0))
+}
+
+// temporaryCredentialTransport 每次请求前从 provider 获取临时密钥，更新到签名使用的 AuthorizationTransport
+type temporaryCredentialTransport struct {
+	*cos.AuthorizationTransport
+	provider *TemporaryCredentialProvider
+}
+
+func (t *temporaryCredentialTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	secretID, secretKey, token, err := t.provider.Credential()
+	if err != nil {
+		return nil, err
+	}
+	t.SetCredential(secretID, secretKey, token)
+	return t.AuthorizationTransport.RoundTrip(req)
+}
@@ -0,0 +1,182 @@
+package util
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultConnectTimeout = 30 * time.Second
+	defaultKeepAlive      = 30 * time.Second
+)
+
+// TransportOptions 客户端的连接设置，命令行参数优先于配置文件
+type TransportOptions struct {
+	ConnectTimeout     time.Duration
+	ReadTimeout        time.Duration
+	Proxy              string
+	CaFile             string
+	InsecureSkipVerify bool
+	MaxConns           int
+	KeepAlive          time.Duration
+	// 以下由传输操作的参数决定
+	DisableKeepAlives bool
+	MaxIdleConns      int
+}
+
+// TransportHook 不为空时，在创建每个 Transport 后调用，可统一调整所有客户端的 Transport
+var TransportHook func(transport *http.Transport)
+
+// 相同设置的客户端共用一个 Transport，以复用连接
+var (
+	transportsMu sync.Mutex
+	transports   = make(map[TransportOptions]*http.Transport)
+)
+
+// GetTransportOptions 根据配置文件、命令行参数及传输操作的参数生成连接设置
+func GetTransportOptions(config *Config, param *Param, fo *FileOperations) (opt TransportOptions, err error) {
+	opt.ConnectTimeout = defaultConnectTimeout
+	opt.KeepAlive = defaultKeepAlive
+
+	if config.Base.ConnectTimeout != "" {
+		if opt.ConnectTimeout, err = parseSeconds("connecttimeout", config.Base.ConnectTimeout); err != nil {
+			return opt, err
+		}
+	}
+	if param.ConnectTimeout > 0 {
+		opt.ConnectTimeout = time.Duration(param.ConnectTimeout) * time.Second
+	}
+	if config.Base.ReadTimeout != "" {
+		if opt.ReadTimeout, err = parseSeconds("readtimeout", config.Base.ReadTimeout); err != nil {
+			return opt, err
+		}
+	}
+	if param.ReadTimeout > 0 {
+		opt.ReadTimeout = time.Duration(param.ReadTimeout) * time.Second
+	}
+	if config.Base.KeepAlive != "" {
+		if opt.KeepAlive, err = parseSeconds("keepalive", config.Base.KeepAlive); err != nil {
+			return opt, err
+		}
+	}
+	if param.KeepAlive != 0 {
+		opt.KeepAlive = time.Duration(param.KeepAlive) * time.Second
+	}
+	if config.Base.MaxConns != "" {
+		if opt.MaxConns, err = strconv.Atoi(config.Base.MaxConns); err != nil || opt.MaxConns < 0 {
+			return opt, fmt.Errorf("invalid maxconns %s", config.Base.MaxConns)
+		}
+	}
+	if param.MaxConns > 0 {
+		opt.MaxConns = param.MaxConns
+	}
+
+	opt.Proxy = config.Base.Proxy
+	if param.Proxy != "" {
+		opt.Proxy = param.Proxy
+	}
+	opt.CaFile = config.Base.CaFile
+	if param.CaFile != "" {
+		opt.CaFile = param.CaFile
+	}
+	opt.InsecureSkipVerify = config.Base.InsecureSkipVerify == "true" || param.InsecureSkipVerify
+
+	// 如果使用长链接则调整连接池大小至并发数，否则使用短链接
+	if fo != nil {
+		if fo.Operation.DisableLongLinks {
+			opt.DisableKeepAlives = true
+		} else if fo.Operation.LongLinksNums > 0 {
+			opt.MaxIdleConns = fo.Operation.LongLinksNums
+		} else {
+			opt.MaxIdleConns = fo.Operation.Routines
+		}
+	}
+	if opt.MaxConns > 0 && opt.MaxIdleConns > opt.MaxConns {
+		opt.MaxIdleConns = opt.MaxConns
+	}
+	return opt, nil
+}
+
+// 解析以秒为单位的配置项，keepalive 可为负数，表示关闭 TCP keepalive
+func parseSeconds(name, value string) (time.Duration, error) {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || (seconds < 0 && name != "keepalive") {
+		return 0, fmt.Errorf("invalid %s %s", name, value)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// NewTransport 按连接设置获取 Transport，相同设置的客户端共用同一个 Transport
+func NewTransport(opt TransportOptions) (*http.Transport, error) {
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	if t, ok := transports[opt]; ok {
+		return t, nil
+	}
+
+	// 与 http.DefaultTransport 的默认设置相同，但不依赖全局的 DefaultTransport
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	dialer := &net.Dialer{Timeout: opt.ConnectTimeout, KeepAlive: opt.KeepAlive}
+	transport.DialContext = dialer.DialContext
+	transport.ResponseHeaderTimeout = opt.ReadTimeout
+	transport.DisableKeepAlives = opt.DisableKeepAlives
+	transport.MaxConnsPerHost = opt.MaxConns
+	if opt.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opt.MaxIdleConns
+		transport.MaxIdleConnsPerHost = opt.MaxIdleConns
+	}
+
+	if opt.Proxy != "" {
+		proxyURL, err := url.Parse(opt.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %s", opt.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if opt.CaFile != "" || opt.InsecureSkipVerify {
+		tlsConfig := &tls.Config{}
+		if opt.CaFile != "" {
+			pem, err := ioutil.ReadFile(opt.CaFile)
+			if err != nil {
+				return nil, fmt.Errorf("read ca file error: %v", err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificate found in ca file %s", opt.CaFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if opt.InsecureSkipVerify {
+			logger.Warningln("TLS certificate verification is disabled, use it only for private endpoints")
+			tlsConfig.InsecureSkipVerify = true
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	if TransportHook != nil {
+		TransportHook(transport)
+	}
+	transports[opt] = transport
+	return transport, nil
+}
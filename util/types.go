@@ -5,22 +5,51 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"net/http"
 	"os"
+	"regexp"
 )
 
 type Config struct {
+	Base     BaseCfg   `yaml:"base"`
+	Buckets  []Bucket  `yaml:"buckets"`
+	Profiles []Profile `yaml:"profiles"`
+}
+
+// Profile 命名配置，拥有独立的密钥、模式、协议与桶列表
+type Profile struct {
+	Name    string   `yaml:"name"`
 	Base    BaseCfg  `yaml:"base"`
 	Buckets []Bucket `yaml:"buckets"`
 }
 
 type BaseCfg struct {
-	SecretID            string `yaml:"secretid"`
-	SecretKey           string `yaml:"secretkey"`
-	SessionToken        string `yaml:"sessiontoken"`
-	Protocol            string `yaml:"protocol"`
-	Mode                string `yaml:"mode"`
-	CvmRoleName         string `yaml:"cvmrolename"`
-	CloseAutoSwitchHost string `yaml:"closeautoswitchhost"`
-	DisableEncryption   string `yaml:"disableencryption"`
+	SecretID             string `yaml:"secretid"`
+	SecretKey            string `yaml:"secretkey"`
+	SessionToken         string `yaml:"sessiontoken"`
+	Protocol             string `yaml:"protocol"`
+	Mode                 string `yaml:"mode"`
+	CvmRoleName          string `yaml:"cvmrolename"`
+	CamUrl               string `yaml:"camurl"`
+	RoleArn              string `yaml:"rolearn"`
+	RoleSessionName      string `yaml:"rolesessionname"`
+	DurationSeconds      string `yaml:"durationseconds"`
+	ExternalId           string `yaml:"externalid"`
+	WebIdentityTokenFile string `yaml:"webidentitytokenfile"`
+	ProviderId           string `yaml:"providerid"`
+	StsEndpoint          string `yaml:"stsendpoint"`
+	StsRegion            string `yaml:"stsregion"`
+	ConnectTimeout       string `yaml:"connecttimeout"`
+	ReadTimeout          string `yaml:"readtimeout"`
+	Proxy                string `yaml:"proxy"`
+	CaFile               string `yaml:"cafile"`
+	InsecureSkipVerify   string `yaml:"insecureskipverify"`
+	MaxConns             string `yaml:"maxconns"`
+	KeepAlive            string `yaml:"keepalive"`
+	EncryptionKeyFile    string `yaml:"encryptionkeyfile"`
+	CloseAutoSwitchHost  string `yaml:"closeautoswitchhost"`
+	DisableEncryption    string `yaml:"disableencryption"`
+	CredentialBackend    string `yaml:"credentialbackend"`
+	CredentialFile       string `yaml:"credentialfile"`
+	CredentialProcess    string `yaml:"credentialprocess"`
 }
 
 type Bucket struct {
@@ -37,6 +66,14 @@ type Param struct {
 	Endpoint     string
 	Customized   bool
 	Protocol     string
+	// 连接设置，优先于配置文件
+	ConnectTimeout     int
+	ReadTimeout        int
+	Proxy              string
+	CaFile             string
+	InsecureSkipVerify bool
+	MaxConns           int
+	KeepAlive          int
 }
 
 type UploadInfo struct {
@@ -55,6 +92,7 @@ type objectInfoType struct {
 	relativeKey  string
 	size         int64
 	lastModified string
+	versionId    string
 }
 
 type CpType int
@@ -64,8 +102,12 @@ type FileOperations struct {
 	Monitor     *FileProcessMonitor
 	ErrOutput   *ErrOutput
 	Config      *Config
+	SrcConfig   *Config // 拷贝时来源桶使用不同的命名配置，为空时与 Config 相同
 	Param       *Param
 	SnapshotDb  *leveldb.DB
+	Checkpoint  *Checkpoint
+	Plan        *DryRunPlan
+	FileList    []FileListEntry
 	CpType      CpType
 	Command     string
 	DeleteCount int
@@ -96,12 +138,21 @@ type Operation struct {
 	VersionId         string
 	AllVersions       bool
 	SnapshotPath      string
+	CheckpointDir     string
+	Resume            bool
+	DryRun            bool
 	Delete            bool
 	BackupDir         string
 	Force             bool
 	Days              int
 	RestoreMode       string
 	Move              bool
+	Append            bool
+	Sha256            bool
+	SSE               SSEOptions
+	ACL               ACLOptions
+	Tagging           TaggingOptions
+	ClientEncryption  *ClientEncryption
 }
 
 type ErrOutput struct {
@@ -112,6 +163,9 @@ type ErrOutput struct {
 type FilterOptionType struct {
 	name    string
 	pattern string
+	re      *regexp.Regexp
+	// 按元数据过滤，不为空时该项不参与名称匹配
+	meta *metaFilter
 }
 
 type Meta struct {
@@ -132,4 +186,5 @@ type LsCounter struct {
 	TotalLimit int
 	RenderNum  int
 	Table      *tablewriter.Table
+	Writer     *RecordWriter
 }
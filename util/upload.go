@@ -28,15 +28,22 @@ func Upload(c *cos.Client, fileUrl StorageUrl, cosUrl StorageUrl, fo *FileOperat
 
 	fo.Monitor.init(fo.CpType)
 	chProgressSignal = make(chan chProgressSignalType, 10)
-	go progressBar(fo)
+	if !fo.Operation.DryRun {
+		go progressBar(fo)
+	}
 
 	chFiles := make(chan fileInfoType, ChannelSize)
 	chError := make(chan error, fo.Operation.Routines)
 	chListError := make(chan error, 1)
-	// 统计文件数量及大小数据
-	go fileStatistic(localPath, fo)
-	// 生成文件列表
-	go generateFileList(localPath, chFiles, chListError, fo)
+	if fo.FileList != nil {
+		// 按 --files-from 清单生成文件列表并统计
+		go generateFileListFromFile(localPath, chFiles, chListError, chError, fo)
+	} else {
+		// 统计文件数量及大小数据
+		go fileStatistic(localPath, fo)
+		// 生成文件列表
+		go generateFileList(localPath, chFiles, chListError, fo)
+	}
 
 	for i := 0; i < fo.Operation.Routines; i++ {
 		go uploadFiles(c, cosUrl, fo, chFiles, chError)
@@ -64,6 +71,9 @@ func Upload(c *cos.Client, fileUrl StorageUrl, cosUrl StorageUrl, fo *FileOperat
 	}
 
 	closeProgress()
+	if fo.Operation.DryRun {
+		return
+	}
 	fmt.Printf(fo.Monitor.progressBar(true, normalExit))
 
 	endT := time.Now().UnixNano() / 1000 / 1000
@@ -72,6 +82,14 @@ func Upload(c *cos.Client, fileUrl StorageUrl, cosUrl StorageUrl, fo *FileOperat
 
 func uploadFiles(c *cos.Client, cosUrl StorageUrl, fo *FileOperations, chFiles <-chan fileInfoType, chError chan<- error) {
 	for file := range chFiles {
+		var item checkpointItem
+		if fo.Checkpoint != nil {
+			item, _ = uploadCheckpointItem(file, cosUrl)
+			if skipByCheckpoint(fo, item) {
+				continue
+			}
+		}
+
 		var skip, isDir bool
 		var err error
 		var size, transferSize int64
@@ -100,6 +118,7 @@ func uploadFiles(c *cos.Client, cosUrl StorageUrl, fo *FileOperations, chFiles <
 			chError <- fmt.Errorf("%s failed: %w", msg, err)
 			continue
 		}
+		recordCheckpoint(fo, item)
 	}
 
 	chError <- nil
@@ -125,6 +144,10 @@ func SingleUpload(c *cos.Client, fo *FileOperations, file fileInfoType, cosUrl S
 	msg = fmt.Sprintf("\nUpload %s to %s", localFilePath, getCosUrl(cosUrl.(*CosUrl).Bucket, cosPath))
 	if fileInfo.IsDir() {
 		isDir = true
+		if fo.Operation.DryRun {
+			fo.Plan.Add(&PlanRecord{Action: PlanUpload, Source: localFilePath, Destination: getCosUrl(cosUrl.(*CosUrl).Bucket, cosPath)})
+			return
+		}
 		// 在cos创建文件夹
 		_, err = c.Object.Put(context.Background(), cosPath, strings.NewReader(""), nil)
 		if err != nil {
@@ -134,6 +157,30 @@ func SingleUpload(c *cos.Client, fo *FileOperations, file fileInfoType, cosUrl S
 	} else {
 		size = fileInfo.Size()
 
+		// 追加上传只上传本地文件在上次追加位置之后新增的数据
+		if fo.Operation.Append {
+			absLocalFilePath, _ := filepath.Abs(localFilePath)
+			appendKey := getAppendSnapshotKey(absLocalFilePath, cosUrl.(*CosUrl).Bucket, cosPath)
+			start, startCrc, err := appendStart(c, fo, localFilePath, cosPath, appendKey, size)
+			if err != nil {
+				rErr = err
+				return
+			}
+			skip = start == size
+			if fo.Operation.DryRun {
+				action := PlanUpload
+				if skip {
+					action = PlanSkip
+				}
+				fo.Plan.Add(&PlanRecord{Action: action, Source: localFilePath, Destination: getCosUrl(cosUrl.(*CosUrl).Bucket, cosPath), Size: size - start})
+				return
+			}
+			if !skip {
+				size, rErr = appendUpload(c, fo, localFilePath, cosPath, appendKey, start, startCrc, size)
+			}
+			return
+		}
+
 		// 仅sync命令执行skip
 		if fo.Command == CommandSync {
 			absLocalFilePath, _ := filepath.Abs(localFilePath)
@@ -145,39 +192,60 @@ func SingleUpload(c *cos.Client, fo *FileOperations, file fileInfoType, cosUrl S
 			}
 		}
 
+		if fo.Operation.DryRun {
+			action := PlanUpload
+			if skip {
+				action = PlanSkip
+			}
+			fo.Plan.Add(&PlanRecord{Action: action, Source: localFilePath, Destination: getCosUrl(cosUrl.(*CosUrl).Bucket, cosPath), Size: size})
+			return
+		}
+
 		if skip {
 			return
 		}
 
+		// 客户端加密时上传加密后的临时文件，加密信息写入对象的元数据
+		uploadPath := localFilePath
+		metaHeader := fo.Operation.Meta.XCosMetaXXX
+		// 记录明文的 SHA-256，供 verify --sha256 校验
+		if fo.Operation.Sha256 {
+			sum, _, err := CalculateHash(localFilePath, HashSha256)
+			if err != nil {
+				rErr = err
+				return
+			}
+			metaHeader = withSha256Meta(metaHeader, sum)
+		}
+		if fo.Operation.ClientEncryption != nil && fo.Operation.ClientEncryption.Algorithm != "" {
+			tmpPath, encryptionHeader, err := fo.Operation.ClientEncryption.encryptFile(localFilePath)
+			if err != nil {
+				rErr = err
+				return
+			}
+			defer os.Remove(tmpPath)
+			uploadPath = tmpPath
+			metaHeader = withEncryptionMeta(metaHeader, encryptionHeader)
+		}
+
 		opt := &cos.MultiUploadOptions{
 			OptIni: &cos.InitiateMultipartUploadOptions{
-				ACLHeaderOptions: &cos.ACLHeaderOptions{
-					XCosACL:              "",
-					XCosGrantRead:        "",
-					XCosGrantWrite:       "",
-					XCosGrantFullControl: "",
-					XCosGrantReadACP:     "",
-					XCosGrantWriteACP:    "",
-				},
+				ACLHeaderOptions: fo.Operation.ACL.headerOptions(),
 				ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
-					CacheControl:             fo.Operation.Meta.CacheControl,
-					ContentDisposition:       fo.Operation.Meta.ContentDisposition,
-					ContentEncoding:          fo.Operation.Meta.ContentEncoding,
-					ContentType:              fo.Operation.Meta.ContentType,
-					ContentMD5:               fo.Operation.Meta.ContentMD5,
-					ContentLength:            fo.Operation.Meta.ContentLength,
-					ContentLanguage:          fo.Operation.Meta.ContentLanguage,
-					Expect:                   "",
-					Expires:                  fo.Operation.Meta.Expires,
-					XCosContentSHA1:          "",
-					XCosMetaXXX:              fo.Operation.Meta.XCosMetaXXX,
-					XCosStorageClass:         fo.Operation.StorageClass,
-					XCosServerSideEncryption: "",
-					XCosSSECustomerAglo:      "",
-					XCosSSECustomerKey:       "",
-					XCosSSECustomerKeyMD5:    "",
-					XOptionHeader:            nil,
-					XCosTrafficLimit:         (int)(fo.Operation.RateLimiting * 1024 * 1024 * 8),
+					CacheControl:       fo.Operation.Meta.CacheControl,
+					ContentDisposition: fo.Operation.Meta.ContentDisposition,
+					ContentEncoding:    fo.Operation.Meta.ContentEncoding,
+					ContentType:        fo.Operation.Meta.ContentType,
+					ContentMD5:         fo.Operation.Meta.ContentMD5,
+					ContentLength:      fo.Operation.Meta.ContentLength,
+					ContentLanguage:    fo.Operation.Meta.ContentLanguage,
+					Expect:             "",
+					Expires:            fo.Operation.Meta.Expires,
+					XCosContentSHA1:    "",
+					XCosMetaXXX:        metaHeader,
+					XCosStorageClass:   fo.Operation.StorageClass,
+					XOptionHeader:      nil,
+					XCosTrafficLimit:   (int)(fo.Operation.RateLimiting * 1024 * 1024 * 8),
 				},
 			},
 			PartSize:        fo.Operation.PartSize,
@@ -186,6 +254,9 @@ func SingleUpload(c *cos.Client, fo *FileOperations, file fileInfoType, cosUrl S
 			DisableChecksum: fo.Operation.DisableChecksum,
 		}
 
+		fo.Operation.SSE.setPutHeader(opt.OptIni.ObjectPutHeaderOptions)
+		fo.Operation.Tagging.setPutHeader(opt.OptIni.ObjectPutHeaderOptions)
+
 		counter := &Counter{TransferSize: 0}
 		// 未跳过则通过监听更新size(仅需要分块文件的通过sdk监听进度)
 		if size > fo.Operation.PartSize*1024*1024 {
@@ -193,7 +264,7 @@ func SingleUpload(c *cos.Client, fo *FileOperations, file fileInfoType, cosUrl S
 			size = 0
 		}
 
-		_, _, err = c.Object.Upload(context.Background(), cosPath, localFilePath, opt)
+		_, _, err = c.Object.Upload(context.Background(), cosPath, uploadPath, opt)
 
 		if err != nil {
 			if strings.HasPrefix(err.Error(), "verification failed, want:") {
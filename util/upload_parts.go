@@ -10,10 +10,11 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
-func ListParts(c *cos.Client, cosUrl StorageUrl, limit int, uploadId string) error {
+func ListParts(c *cos.Client, cosUrl StorageUrl, limit int, uploadId string) (err error) {
 	// 查询上传中的分块任务是否存在
 	uploadExist, err := CheckUploadExist(c, cosUrl, uploadId)
 	if err != nil {
@@ -35,6 +36,12 @@ func ListParts(c *cos.Client, cosUrl StorageUrl, limit int, uploadId string) err
 	table.SetAlignment(tablewriter.ALIGN_LEFT)
 	table.SetAutoWrapText(false)
 
+	var rw *RecordWriter
+	if !IsTableOutput() {
+		rw = NewRecordWriter(os.Stdout)
+		defer closeRecordWriter(rw, &err)
+	}
+
 	for isTruncated && total < limit {
 		table.ClearRows()
 		queryLimit := 1000
@@ -49,6 +56,19 @@ func ListParts(c *cos.Client, cosUrl StorageUrl, limit int, uploadId string) err
 		}
 
 		for _, part := range parts {
+			if rw != nil {
+				err = rw.Write(&PartRecord{
+					PartNumber:   part.PartNumber,
+					Size:         part.Size,
+					ETag:         strings.Trim(part.ETag, "\""),
+					LastModified: part.LastModified,
+				})
+				if err != nil {
+					return err
+				}
+				total++
+				continue
+			}
 			utcTime, err := time.Parse(time.RFC3339, part.LastModified)
 			if err != nil {
 				return fmt.Errorf("Error parsing time:%v", err)
@@ -57,6 +77,10 @@ func ListParts(c *cos.Client, cosUrl StorageUrl, limit int, uploadId string) err
 			total++
 		}
 
+		if rw != nil {
+			continue
+		}
+
 		if !isTruncated || total >= limit {
 			table.SetFooter([]string{"", "", "", fmt.Sprintf("Total: %d", total)})
 			table.Render()
@@ -74,8 +98,7 @@ func ListParts(c *cos.Client, cosUrl StorageUrl, limit int, uploadId string) err
 	return nil
 }
 
-func ListUploads(c *cos.Client, cosUrl StorageUrl, limit int, filters []FilterOptionType) error {
-	var err error
+func ListUploads(c *cos.Client, cosUrl StorageUrl, limit int, filters []FilterOptionType) (err error) {
 	var uploads []struct {
 		Key          string
 		UploadID     string `xml:"UploadId"`
@@ -95,6 +118,12 @@ func ListUploads(c *cos.Client, cosUrl StorageUrl, limit int, filters []FilterOp
 	table.SetAlignment(tablewriter.ALIGN_LEFT)
 	table.SetAutoWrapText(false)
 
+	var rw *RecordWriter
+	if !IsTableOutput() {
+		rw = NewRecordWriter(os.Stdout)
+		defer closeRecordWriter(rw, &err)
+	}
+
 	for isTruncated && total < limit {
 		table.ClearRows()
 		queryLimit := 1000
@@ -110,12 +139,22 @@ func ListUploads(c *cos.Client, cosUrl StorageUrl, limit int, filters []FilterOp
 
 		for _, upload := range uploads {
 			upload.Key, _ = url.QueryUnescape(upload.Key)
-			if cosObjectMatchPatterns(upload.Key, filters) {
-				table.Append([]string{upload.Key, upload.UploadID, upload.StorageClass, upload.Initiated})
+			if cosObjectMatchPatterns(cosUrl.(*CosUrl).Object, upload.Key, filters) {
+				if rw != nil {
+					if err = rw.Write(&UploadRecord{Key: upload.Key, UploadId: upload.UploadID, StorageClass: upload.StorageClass, Initiated: upload.Initiated}); err != nil {
+						return err
+					}
+				} else {
+					table.Append([]string{upload.Key, upload.UploadID, upload.StorageClass, upload.Initiated})
+				}
 				total++
 			}
 		}
 
+		if rw != nil {
+			continue
+		}
+
 		if !isTruncated || total >= limit {
 			table.SetFooter([]string{"", "", "", fmt.Sprintf("Total: %d", total)})
 			table.Render()
@@ -219,12 +258,21 @@ func AbortUploads(args []string, fo *FileOperations) error {
 			}
 			for _, upload := range uploads {
 				upload.Key, _ = url.QueryUnescape(upload.Key)
+				if !cosObjectMatchPatterns(cosUrl.(*CosUrl).Object, upload.Key, fo.Operation.Filters) {
+					continue
+				}
+				if fo.Operation.DryRun {
+					fo.Plan.Add(&PlanRecord{Action: PlanAbort, Source: getCosUrl(cosUrl.(*CosUrl).Bucket, upload.Key), UploadId: upload.UploadID})
+					successCnt++
+					total++
+					continue
+				}
 				_, err := c.Object.AbortMultipartUpload(context.Background(), upload.Key, upload.UploadID)
 				if err != nil {
 					logger.Infof("Abort fail! UploadID: %s,Key: %s", upload.UploadID, upload.Key)
 					// 记录错误日志
 					if fo.Operation.FailOutput {
-						writeError(fmt.Sprintln("Abort fail! UploadID: %s,Key: %s,err: %v", upload.UploadID, upload.Key, err), fo)
+						writeError(fmt.Sprintf("Abort fail! UploadID: %s,Key: %s,err: %v\n", upload.UploadID, upload.Key, err), fo)
 					}
 					failCnt++
 				} else {
@@ -0,0 +1,340 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"hash"
+	"hash/crc64"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// verify 发现的不一致类型
+const (
+	// 来源中有、目标中没有
+	VerifyStatusMissing = "missing"
+	// 目标中有、来源中没有
+	VerifyStatusExtra = "extra"
+	// 大小或校验值不一致
+	VerifyStatusMismatch = "mismatch"
+	// 获取对象信息或读取本地文件失败
+	VerifyStatusFailed = "failed"
+)
+
+// VerifySide verify 的一方，本地目录或 COS 前缀，Client 为 nil 时为本地目录
+type VerifySide struct {
+	Url    StorageUrl
+	Client *cos.Client
+
+	files map[string]*verifyFile
+}
+
+// 一方中的一个文件或对象，以相对于目录或前缀的路径为 key
+type verifyFile struct {
+	// 本地文件路径或对象键
+	path   string
+	size   int64
+	crc64  string
+	sha256 string
+	err    error
+
+	// 本地大文件按分块并发计算 CRC64 后合并
+	partCrcs []uint64
+	partErrs []error
+}
+
+// VerifyObjects 按大小与 CRC64 比较来源与目标中的文件或对象，--sha256 时同时比较 SHA-256。
+// 先列出两方，再并发获取两方都有的对象的信息，最后并发计算大小一致的本地文件的校验值
+func VerifyObjects(source, target *VerifySide, fo *FileOperations) (*VerifySummary, error) {
+	for _, side := range []*VerifySide{source, target} {
+		if err := side.list(fo); err != nil {
+			return nil, err
+		}
+	}
+
+	var keys []string
+	for key := range source.files {
+		if _, ok := target.files[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+
+	var tasks []func()
+	for _, side := range []*VerifySide{source, target} {
+		if side.Client == nil {
+			continue
+		}
+		for _, key := range keys {
+			c, f := side.Client, side.files[key]
+			tasks = append(tasks, func() { f.head(c) })
+		}
+	}
+	runVerifyTasks(fo.Operation.Routines, tasks)
+
+	tasks = nil
+	var hashed []*verifyFile
+	for _, side := range []*VerifySide{source, target} {
+		if side.Client != nil {
+			continue
+		}
+		for _, key := range keys {
+			src, dest := source.files[key], target.files[key]
+			if src.err != nil || dest.err != nil || src.size != dest.size {
+				continue
+			}
+			f := side.files[key]
+			tasks = append(tasks, f.hashTasks(fo.Operation.PartSize*1024*1024, fo.Operation.Sha256)...)
+			hashed = append(hashed, f)
+		}
+	}
+	runVerifyTasks(fo.Operation.Routines, tasks)
+	for _, f := range hashed {
+		f.combinePartCrcs(fo.Operation.PartSize * 1024 * 1024)
+	}
+
+	summary := compareVerifySides(source, target, fo.Operation.Sha256)
+	logger.Infof("verify %s and %s completed, matched: %d, missing: %d, extra: %d, mismatched: %d, failed: %d",
+		source.Url.ToString(), target.Url.ToString(), summary.Matched, summary.Missing, summary.Extra, summary.Mismatched, summary.Failed)
+	return summary, nil
+}
+
+// 列出本地目录中的文件或 COS 前缀下的对象，过滤规则对两方都生效
+func (side *VerifySide) list(fo *FileOperations) error {
+	side.files = make(map[string]*verifyFile)
+	chListError := make(chan error, 1)
+	if side.Client == nil {
+		localPath := side.Url.ToString()
+		info, err := os.Stat(localPath)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory, verify compares a directory with a cos prefix", localPath)
+		}
+		chFiles := make(chan fileInfoType, ChannelSize)
+		go generateFileList(localPath, chFiles, chListError, fo)
+		for file := range chFiles {
+			if strings.HasSuffix(file.filePath, string(os.PathSeparator)) {
+				continue
+			}
+			f := &verifyFile{path: filepath.Join(file.dir, file.filePath)}
+			if info, err := os.Stat(f.path); err != nil {
+				f.err = err
+			} else {
+				f.size = info.Size()
+			}
+			side.files[filepath.ToSlash(file.filePath)] = f
+		}
+		return <-chListError
+	}
+
+	// 前缀按目录处理，对象以去掉前缀后的路径比较
+	cosUrl := side.Url.(*CosUrl)
+	prefix := cosUrl.Object
+	if prefix != "" && !strings.HasSuffix(prefix, CosSeparator) {
+		prefix += CosSeparator
+	}
+	chObjects := make(chan objectInfoType, ChannelSize)
+	go getCosObjectList(side.Client, &CosUrl{Bucket: cosUrl.Bucket, Object: prefix}, chObjects, chListError, fo, false, true)
+	for object := range chObjects {
+		key := object.prefix + object.relativeKey
+		relativeKey := strings.TrimPrefix(key, prefix)
+		// 跳过目录对象
+		if relativeKey == "" || strings.HasSuffix(relativeKey, CosSeparator) {
+			continue
+		}
+		side.files[relativeKey] = &verifyFile{path: key, size: object.size}
+	}
+	if err := <-chListError; err != nil {
+		return fmt.Errorf("list objects error : %v", err)
+	}
+	return nil
+}
+
+// 获取对象的 CRC64 与 SHA-256，客户端加密的对象使用明文的长度与 CRC64
+func (f *verifyFile) head(c *cos.Client) {
+	resp, err := c.Object.Head(context.Background(), f.path, nil)
+	if err != nil {
+		f.err = err
+		return
+	}
+	if isClientEncrypted(resp.Header) {
+		if f.size, err = strconv.ParseInt(resp.Header.Get(cseMetaLength), 10, 64); err != nil {
+			f.err = fmt.Errorf("invalid unencrypted content length %s", resp.Header.Get(cseMetaLength))
+			return
+		}
+	}
+	f.crc64, _ = checksumAlgorithms[HashCrc64].object(resp.Header)
+	f.sha256, _ = checksumAlgorithms[HashSha256].object(resp.Header)
+}
+
+// 计算本地文件校验值的任务。SHA-256 只能顺序计算，与 CRC64 一起读取一遍文件；
+// 只计算 CRC64 时大于 partSize 的文件按分块并发计算，之后由 combinePartCrcs 合并
+func (f *verifyFile) hashTasks(partSize int64, withSha256 bool) []func() {
+	if withSha256 || f.size <= partSize {
+		return []func(){func() {
+			crcHash := checksumAlgorithms[HashCrc64].newHash()
+			hashes := []hash.Hash{crcHash}
+			if withSha256 {
+				hashes = append(hashes, checksumAlgorithms[HashSha256].newHash())
+			}
+			if f.err = hashFileSection(f.path, 0, f.size, hashes...); f.err != nil {
+				return
+			}
+			f.crc64 = checksumAlgorithms[HashCrc64].format(crcHash.Sum(nil))
+			if withSha256 {
+				f.sha256 = checksumAlgorithms[HashSha256].format(hashes[1].Sum(nil))
+			}
+		}}
+	}
+
+	n := int((f.size + partSize - 1) / partSize)
+	f.partCrcs = make([]uint64, n)
+	f.partErrs = make([]error, n)
+	tasks := make([]func(), n)
+	for i := range tasks {
+		i := i
+		tasks[i] = func() {
+			offset := int64(i) * partSize
+			crcHash := crc64.New(crc64.MakeTable(crc64.ECMA))
+			f.partErrs[i] = hashFileSection(f.path, offset, partLength(f.size, partSize, i), crcHash)
+			f.partCrcs[i] = crcHash.Sum64()
+		}
+	}
+	return tasks
+}
+
+// 合并分块的 CRC64
+func (f *verifyFile) combinePartCrcs(partSize int64) {
+	if f.partCrcs == nil {
+		return
+	}
+	var crc uint64
+	for i, partCrc := range f.partCrcs {
+		if f.partErrs[i] != nil {
+			f.err = f.partErrs[i]
+			return
+		}
+		crc = crc64Combine(crc, partCrc, partLength(f.size, partSize, i))
+	}
+	f.crc64 = strconv.FormatUint(crc, 10)
+}
+
+// 第 i 个分块的长度
+func partLength(size, partSize int64, i int) int64 {
+	if n := size - int64(i)*partSize; n < partSize {
+		return n
+	}
+	return partSize
+}
+
+// 读取本地文件 [offset, offset+n) 的数据写入 hashes
+func hashFileSection(path string, offset, n int64, hashes ...hash.Hash) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writers := make([]io.Writer, len(hashes))
+	for i, h := range hashes {
+		writers[i] = h
+	}
+	if _, err = io.Copy(io.MultiWriter(writers...), io.NewSectionReader(file, offset, n)); err != nil {
+		return fmt.Errorf("read %s error: %v", path, err)
+	}
+	return nil
+}
+
+// 用 routines 个协程并发执行任务
+func runVerifyTasks(routines int, tasks []func()) {
+	chTasks := make(chan func(), len(tasks))
+	for _, task := range tasks {
+		chTasks <- task
+	}
+	close(chTasks)
+	var wg sync.WaitGroup
+	for i := 0; i < routines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range chTasks {
+				task()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// 比较两方的文件，按 key 排序输出不一致的项
+func compareVerifySides(source, target *VerifySide, withSha256 bool) *VerifySummary {
+	summary := &VerifySummary{Source: source.Url.ToString(), Target: target.Url.ToString()}
+	for key, src := range source.files {
+		entry := VerifyEntry{Key: key, SourceSize: src.size}
+		dest, ok := target.files[key]
+		if !ok {
+			entry.Status = VerifyStatusMissing
+			summary.add(entry)
+			continue
+		}
+		entry.TargetSize = dest.size
+		entry.SourceCrc64, entry.TargetCrc64 = src.crc64, dest.crc64
+		if withSha256 {
+			entry.SourceSha256, entry.TargetSha256 = src.sha256, dest.sha256
+		}
+		switch {
+		case src.err != nil:
+			entry.Status, entry.Detail = VerifyStatusFailed, src.err.Error()
+		case dest.err != nil:
+			entry.Status, entry.Detail = VerifyStatusFailed, dest.err.Error()
+		case src.size != dest.size:
+			entry.Status, entry.Detail = VerifyStatusMismatch, "size"
+		case src.crc64 == "" || dest.crc64 == "":
+			entry.Status, entry.Detail = VerifyStatusMismatch, "crc64 not available"
+		case src.crc64 != dest.crc64:
+			entry.Status, entry.Detail = VerifyStatusMismatch, "crc64"
+		case withSha256 && (src.sha256 == "" || dest.sha256 == ""):
+			entry.Status, entry.Detail = VerifyStatusMismatch, "sha256 not available"
+		case withSha256 && src.sha256 != dest.sha256:
+			entry.Status, entry.Detail = VerifyStatusMismatch, "sha256"
+		}
+		summary.add(entry)
+	}
+	for key, dest := range target.files {
+		if _, ok := source.files[key]; !ok {
+			summary.add(VerifyEntry{Key: key, Status: VerifyStatusExtra, TargetSize: dest.size})
+		}
+	}
+	sort.Slice(summary.Entries, func(i, j int) bool { return summary.Entries[i].Key < summary.Entries[j].Key })
+	return summary
+}
+
+// 统计一项比较结果，一致的项只计数
+func (r *VerifySummary) add(entry VerifyEntry) {
+	switch entry.Status {
+	case "":
+		r.Matched++
+		return
+	case VerifyStatusMissing:
+		r.Missing++
+	case VerifyStatusExtra:
+		r.Extra++
+	case VerifyStatusMismatch:
+		r.Mismatched++
+	case VerifyStatusFailed:
+		r.Failed++
+	}
+	r.Entries = append(r.Entries, entry)
+}
+
+// Differences 不一致与失败的项数
+func (r *VerifySummary) Differences() int {
+	return r.Missing + r.Extra + r.Mismatched + r.Failed
+}